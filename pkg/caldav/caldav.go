@@ -38,26 +38,33 @@ type Todo struct {
 	UID       string
 
 	// Optional
-	Summary      string
-	Description  string
-	Completed    time.Time
-	Organizer    *user.User
-	Priority     int64 // 0-9, 1 is highest
-	RelatedToUID string
-	Color        string
-	Categories   []string
-	Start        time.Time
-	End          time.Time
-	DueDate      time.Time
-	Duration     time.Duration
-	RepeatAfter  int64
-	RepeatMode   models.TaskRepeatMode
-	Alarms       []Alarm
+	Summary     string
+	Description string
+	Completed   time.Time
+	Organizer   *user.User
+	Priority    int64 // 0-9, 1 is highest
+	RelatedTo   []RelatedTo
+	Color       string
+	Categories  []string
+	Start       time.Time
+	End         time.Time
+	DueDate     time.Time
+	Duration    time.Duration
+	RepeatAfter int64
+	RepeatMode  models.TaskRepeatMode
+	RepeatRule  string
+	Alarms      []Alarm
 
 	Created time.Time
 	Updated time.Time // last-mod
 }
 
+// RelatedTo holds a relation of a VTODO to another one
+type RelatedTo struct {
+	UID     string
+	RelType string // PARENT, CHILD or SIBLING
+}
+
 // Alarm holds infos about an alarm from a caldav event
 type Alarm struct {
 	Time        time.Time
@@ -99,15 +106,112 @@ func formatDuration(duration time.Duration) string {
 		strconv.FormatFloat(seconds, 'f', 0, 64) + `S`
 }
 
-// ParseTodos returns a caldav vcalendar string with todos
-func ParseTodos(config *Config, todos []*Todo) (caldavtodos string) {
-	caldavtodos = `BEGIN:VCALENDAR
+func makeCalendar(config *Config, components string) string {
+	return `BEGIN:VCALENDAR
 VERSION:2.0
 METHOD:PUBLISH
 X-PUBLISHED-TTL:PT4H
 X-WR-CALNAME:` + config.Name + `
-PRODID:-//` + config.ProdID + `//EN` + getCaldavColor(config.Color)
+PRODID:-//` + config.ProdID + `//EN` + getCaldavColor(config.Color) +
+		components + `
+END:VCALENDAR` // Need a line break
+}
+
+// ParseTodos returns a caldav vcalendar string with todos
+func ParseTodos(config *Config, todos []*Todo) (caldavtodos string) {
+	return makeCalendar(config, makeVTodos(todos))
+}
+
+// ParseEvents returns a vcalendar string with an event for every todo which has a start or due date.
+// If withTodos is true, all todos are included as VTODO as well.
+func ParseEvents(config *Config, todos []*Todo, withTodos bool) string {
+	components := makeVEvents(todos)
+	if withTodos {
+		components += makeVTodos(todos)
+	}
+	return makeCalendar(config, components)
+}
+
+func makeVEvents(todos []*Todo) (caldavevents string) {
+	for _, t := range todos {
+		if t.Start.Unix() <= 0 && t.DueDate.Unix() <= 0 {
+			continue
+		}
+
+		if t.UID == "" {
+			t.UID = makeCalDavTimeFromTimeStamp(t.Timestamp) + utils.Sha256(t.Summary)
+		}
+
+		// Tasks only having a due date become an event without duration at their due date.
+		start := t.DueDate
+		var end time.Time
+		if t.Start.Unix() > 0 {
+			start = t.Start
+			switch {
+			case t.End.After(start):
+				end = t.End
+			case t.DueDate.After(start):
+				end = t.DueDate
+			}
+		}
+
+		// The uid needs to be different from the one of the VTODO because both can be part of the same calendar.
+		caldavevents += `
+BEGIN:VEVENT
+UID:event-` + t.UID + `
+DTSTAMP:` + makeCalDavTimeFromTimeStamp(t.Timestamp) + `
+SUMMARY:` + t.Summary + getCaldavColor(t.Color) + `
+DTSTART:` + makeCalDavTimeFromTimeStamp(start)
+		if !end.IsZero() {
+			caldavevents += `
+DTEND:` + makeCalDavTimeFromTimeStamp(end)
+		}
+		if t.Description != "" {
+			caldavevents += `
+DESCRIPTION:` + formatDescription(t.Description)
+		}
+		if t.Created.Unix() > 0 {
+			caldavevents += `
+CREATED:` + makeCalDavTimeFromTimeStamp(t.Created)
+		}
+		caldavevents += makeRepeatRule(t)
+		if len(t.Categories) > 0 {
+			caldavevents += `
+CATEGORIES:` + strings.Join(t.Categories, ",")
+		}
+
+		caldavevents += `
+LAST-MODIFIED:` + makeCalDavTimeFromTimeStamp(t.Updated)
+		caldavevents += ParseAlarms(t.Alarms, t.Summary)
+		caldavevents += `
+END:VEVENT`
+	}
+
+	return
+}
 
+func formatDescription(description string) string {
+	re := regexp.MustCompile(`\r?\n`)
+	return re.ReplaceAllString(description, "\\n")
+}
+
+func makeRepeatRule(t *Todo) string {
+	if t.RepeatRule != "" {
+		return `
+RRULE:` + t.RepeatRule
+	}
+	if t.RepeatMode == models.TaskRepeatModeMonth {
+		return `
+RRULE:FREQ=MONTHLY;BYMONTHDAY=` + t.DueDate.Format("02") // Day of the month
+	}
+	if t.RepeatAfter > 0 {
+		return `
+RRULE:FREQ=SECONDLY;INTERVAL=` + strconv.FormatInt(t.RepeatAfter, 10)
+	}
+	return ""
+}
+
+func makeVTodos(todos []*Todo) (caldavtodos string) {
 	for _, t := range todos {
 		if t.UID == "" {
 			t.UID = makeCalDavTimeFromTimeStamp(t.Timestamp) + utils.Sha256(t.Summary)
@@ -132,10 +236,8 @@ DURATION:PT` + formatDuration(t.Duration)
 DTEND:` + makeCalDavTimeFromTimeStamp(t.End)
 		}
 		if t.Description != "" {
-			re := regexp.MustCompile(`\r?\n`)
-			formattedDescription := re.ReplaceAllString(t.Description, "\\n")
 			caldavtodos += `
-DESCRIPTION:` + formattedDescription
+DESCRIPTION:` + formatDescription(t.Description)
 		}
 		if t.Completed.Unix() > 0 {
 			caldavtodos += `
@@ -147,9 +249,14 @@ STATUS:COMPLETED`
 ORGANIZER;CN=:` + t.Organizer.Username
 		}
 
-		if t.RelatedToUID != "" {
+		for _, related := range t.RelatedTo {
+			if related.RelType == "PARENT" {
+				caldavtodos += `
+RELATED-TO:` + related.UID
+				continue
+			}
 			caldavtodos += `
-RELATED-TO:` + t.RelatedToUID
+RELATED-TO;RELTYPE=` + related.RelType + `:` + related.UID
 		}
 
 		if t.DueDate.Unix() > 0 {
@@ -167,15 +274,7 @@ CREATED:` + makeCalDavTimeFromTimeStamp(t.Created)
 PRIORITY:` + strconv.Itoa(mapPriorityToCaldav(t.Priority))
 		}
 
-		if t.RepeatAfter > 0 || t.RepeatMode == models.TaskRepeatModeMonth {
-			if t.RepeatMode == models.TaskRepeatModeMonth {
-				caldavtodos += `
-RRULE:FREQ=MONTHLY;BYMONTHDAY=` + t.DueDate.Format("02") // Day of the month
-			} else {
-				caldavtodos += `
-RRULE:FREQ=SECONDLY;INTERVAL=` + strconv.FormatInt(t.RepeatAfter, 10)
-			}
-		}
+		caldavtodos += makeRepeatRule(t)
 
 		if len(t.Categories) > 0 {
 			caldavtodos += `
@@ -189,9 +288,6 @@ LAST-MODIFIED:` + makeCalDavTimeFromTimeStamp(t.Updated)
 END:VTODO`
 	}
 
-	caldavtodos += `
-END:VCALENDAR` // Need a line break
-
 	return
 }
 
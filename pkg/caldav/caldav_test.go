@@ -251,6 +251,43 @@ X-FUNAMBOL-COLOR:#affffeFF
 CATEGORIES:label1,label2
 LAST-MODIFIED:00010101T000000Z
 END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "with relations",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary:   "Todo #1",
+						UID:       "randommduid",
+						Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						RelatedTo: []RelatedTo{
+							{UID: "parentuid", RelType: "PARENT"},
+							{UID: "childuid", RelType: "CHILD"},
+							{UID: "siblinguid", RelType: "SIBLING"},
+						},
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+RELATED-TO:parentuid
+RELATED-TO;RELTYPE=CHILD:childuid
+RELATED-TO;RELTYPE=SIBLING:siblinguid
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
 END:VCALENDAR`,
 		},
 		{
@@ -336,3 +373,93 @@ END:VCALENDAR`,
 		})
 	}
 }
+
+func TestParseEvents(t *testing.T) {
+	caldavConfig := &Config{
+		Name:   "test",
+		ProdID: "RandomProdID which is not random",
+	}
+
+	t.Run("task with start and due date", func(t *testing.T) {
+		todos := []*Todo{
+			{
+				Summary:   "Todo #1",
+				UID:       "randomuid",
+				Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				Start:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				DueDate:   time.Unix(1543630324, 0).In(config.GetTimeZone()),
+				Updated:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		}
+		assert.Equal(t, `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VEVENT
+UID:event-randomuid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DTSTART:20181201T011204Z
+DTEND:20181201T021204Z
+LAST-MODIFIED:20181201T011204Z
+END:VEVENT
+END:VCALENDAR`, ParseEvents(caldavConfig, todos, false))
+	})
+	t.Run("task with only a due date", func(t *testing.T) {
+		todos := []*Todo{
+			{
+				Summary:   "Todo #1",
+				UID:       "randomuid",
+				Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				DueDate:   time.Unix(1543630324, 0).In(config.GetTimeZone()),
+				Updated:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		}
+		assert.Equal(t, `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VEVENT
+UID:event-randomuid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DTSTART:20181201T021204Z
+LAST-MODIFIED:20181201T011204Z
+END:VEVENT
+END:VCALENDAR`, ParseEvents(caldavConfig, todos, false))
+	})
+	t.Run("tasks without dates are only included as todos", func(t *testing.T) {
+		todos := []*Todo{
+			{
+				Summary:   "Todo #1",
+				UID:       "randomuid",
+				Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				Updated:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		}
+		assert.Equal(t, `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+END:VCALENDAR`, ParseEvents(caldavConfig, todos, false))
+		assert.Equal(t, `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+LAST-MODIFIED:20181201T011204Z
+END:VTODO
+END:VCALENDAR`, ParseEvents(caldavConfig, todos, true))
+	})
+}
@@ -17,23 +17,44 @@
 package caldav
 
 import (
+	"encoding/base64"
 	"errors"
 	"strconv"
 	"strings"
 	"time"
 
 	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/rrule"
 	"code.vikunja.io/api/pkg/utils"
 
 	ics "github.com/arran4/golang-ical"
 )
 
 func GetCaldavTodosForTasks(project *models.ProjectWithTasksAndBuckets, projectTasks []*models.TaskWithComments) string {
+	caldavConfig := &Config{
+		Name:   project.Title,
+		ProdID: "Vikunja Todo App",
+	}
 
-	// Make caldav todos from Vikunja todos
-	var caldavtodos []*Todo
+	return ParseTodos(caldavConfig, getCaldavTodosFromTasks(projectTasks))
+}
+
+// GetCaldavEventsForTasks returns a calendar with an event for every task which has a start or due date.
+// If withTodos is true, all tasks are included as todos as well.
+func GetCaldavEventsForTasks(name string, tasks []*models.TaskWithComments, withTodos bool) string {
+	caldavConfig := &Config{
+		Name:   name,
+		ProdID: "Vikunja Todo App",
+	}
+
+	return ParseEvents(caldavConfig, getCaldavTodosFromTasks(tasks), withTodos)
+}
+
+// Make caldav todos from Vikunja todos
+func getCaldavTodosFromTasks(projectTasks []*models.TaskWithComments) (caldavtodos []*Todo) {
 	for _, t := range projectTasks {
 
 		duration := t.EndDate.Sub(t.StartDate)
@@ -50,6 +71,16 @@ func GetCaldavTodosForTasks(project *models.ProjectWithTasksAndBuckets, projectT
 			})
 		}
 
+		var relatedTo []RelatedTo
+		for _, kind := range []models.RelationKind{models.RelationKindParenttask, models.RelationKindSubtask, models.RelationKindRelated} {
+			for _, related := range t.RelatedTasks[kind] {
+				if related.UID == "" {
+					continue
+				}
+				relatedTo = append(relatedTo, RelatedTo{UID: related.UID, RelType: relTypes[kind]})
+			}
+		}
+
 		caldavtodos = append(caldavtodos, &Todo{
 			Timestamp:   t.Updated,
 			UID:         t.UID,
@@ -67,16 +98,14 @@ func GetCaldavTodosForTasks(project *models.ProjectWithTasksAndBuckets, projectT
 			Duration:    duration,
 			RepeatAfter: t.RepeatAfter,
 			RepeatMode:  t.RepeatMode,
+			RepeatRule:  t.RepeatRule,
 			Alarms:      alarms,
-		})
-	}
 
-	caldavConfig := &Config{
-		Name:   project.Title,
-		ProdID: "Vikunja Todo App",
+			RelatedTo: relatedTo,
+		})
 	}
 
-	return ParseTodos(caldavConfig, caldavtodos)
+	return
 }
 
 func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
@@ -142,6 +171,20 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 		vTask.EndDate = vTask.StartDate.Add(duration)
 	}
 
+	if val, ok := task["RRULE"]; ok {
+		vTask = parseRRule(val.Value, vTask)
+	}
+
+	// A task can have multiple relations and attachments, which is why these can't be taken from the map
+	for _, property := range vTodo.UnknownPropertiesIANAProperties() {
+		switch property.IANAToken {
+		case "RELATED-TO":
+			vTask = parseRelatedTo(property, vTask)
+		case "ATTACH":
+			vTask = parseAttach(property, vTask)
+		}
+	}
+
 	for _, vAlarm := range vTodo.SubComponents() {
 		if vAlarm, ok := vAlarm.(*ics.VAlarm); ok {
 			vTask = parseVAlarm(vAlarm, vTask)
@@ -151,6 +194,99 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 	return
 }
 
+func parseRRule(value string, vTask *models.Task) *models.Task {
+	rule, err := rrule.Parse(value, config.GetTimeZone())
+	if err != nil {
+		log.Warningf("Error while parsing caldav repeat rule %s: %s", value, err)
+		return vTask
+	}
+
+	// Older versions of Vikunja exported repeat_after as a secondly rule, we map these back to repeat_after
+	// to keep the task unchanged when it is synced back.
+	if rule.Freq == rrule.FrequencySecondly && rule.Count == 0 && rule.Until.IsZero() &&
+		value == "FREQ=SECONDLY;INTERVAL="+strconv.Itoa(rule.Interval) {
+		vTask.RepeatAfter = int64(rule.Interval)
+		return vTask
+	}
+
+	vTask.RepeatRule = value
+	return vTask
+}
+
+// relTypes maps the relation kinds which can be represented in caldav to their RELTYPE
+var relTypes = map[models.RelationKind]string{
+	models.RelationKindParenttask: "PARENT",
+	models.RelationKindSubtask:    "CHILD",
+	models.RelationKindRelated:    "SIBLING",
+}
+
+// https://tools.ietf.org/html/rfc5545#section-3.8.4.5
+// The related task is only referenced by its uid, it is up to the caller to resolve it.
+func parseRelatedTo(property ics.IANAProperty, vTask *models.Task) *models.Task {
+	if property.Value == "" {
+		return vTask
+	}
+
+	// PARENT is the default if no relation type is given
+	kind := models.RelationKindParenttask
+	if relType := property.ICalParameters["RELTYPE"]; len(relType) > 0 {
+		switch strings.ToUpper(relType[0]) {
+		case "PARENT":
+			kind = models.RelationKindParenttask
+		case "CHILD":
+			kind = models.RelationKindSubtask
+		case "SIBLING":
+			kind = models.RelationKindRelated
+		default:
+			log.Warningf("Unknown caldav relation type %s, ignoring relation to %s", relType[0], property.Value)
+			return vTask
+		}
+	}
+
+	if vTask.RelatedTasks == nil {
+		vTask.RelatedTasks = make(models.RelatedTaskMap)
+	}
+	vTask.RelatedTasks[kind] = append(vTask.RelatedTasks[kind], &models.Task{UID: property.Value})
+	return vTask
+}
+
+// https://tools.ietf.org/html/rfc5545#section-3.8.1.1
+// Only inline attachments are imported, attachments referenced by an uri are ignored.
+func parseAttach(property ics.IANAProperty, vTask *models.Task) *models.Task {
+	if !contains(property.ICalParameters["ENCODING"], "BASE64") {
+		return vTask
+	}
+
+	content, err := base64.StdEncoding.DecodeString(property.Value)
+	if err != nil {
+		log.Warningf("Error while decoding caldav attachment: %s", err)
+		return vTask
+	}
+
+	name := "attachment"
+	for _, param := range []string{"FILENAME", "X-FILENAME", "X-APPLE-FILENAME"} {
+		if filename := property.ICalParameters[param]; len(filename) > 0 && filename[0] != "" {
+			name = filename[0]
+			break
+		}
+	}
+
+	var mime string
+	if fmtType := property.ICalParameters["FMTTYPE"]; len(fmtType) > 0 {
+		mime = fmtType[0]
+	}
+
+	vTask.Attachments = append(vTask.Attachments, &models.TaskAttachment{
+		File: &files.File{
+			Name:        name,
+			Mime:        mime,
+			Size:        uint64(len(content)),
+			FileContent: content,
+		},
+	})
+	return vTask
+}
+
 func parseVAlarm(vAlarm *ics.VAlarm, vTask *models.Task) *models.Task {
 	for _, property := range vAlarm.UnknownPropertiesIANAProperties() {
 		if property.IANAToken != "TRIGGER" {
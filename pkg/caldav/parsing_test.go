@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/models"
 	"gopkg.in/d4l3k/messagediff.v1"
 )
@@ -289,6 +290,161 @@ END:VCALENDAR`,
 				},
 			},
 		},
+		{
+			name: "With repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=10
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				RepeatRule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=10",
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		},
+		{
+			name: "With secondly repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+RRULE:FREQ=SECONDLY;INTERVAL=86400
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				RepeatAfter: 86400,
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		},
+		{
+			name: "With invalid repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+RRULE:FREQ=FORTNIGHTLY
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		},
+		{
+			name: "With related tasks",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+RELATED-TO:parentuid
+RELATED-TO;RELTYPE=CHILD:childuid1
+RELATED-TO;RELTYPE=CHILD:childuid2
+RELATED-TO;RELTYPE=SIBLING:siblinguid
+RELATED-TO;RELTYPE=X-UNKNOWN:unknownuid
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				RelatedTasks: models.RelatedTaskMap{
+					models.RelationKindParenttask: {{UID: "parentuid"}},
+					models.RelationKindSubtask:    {{UID: "childuid1"}, {UID: "childuid2"}},
+					models.RelationKindRelated:    {{UID: "siblinguid"}},
+				},
+			},
+		},
+		{
+			name: "With attachments",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+ATTACH;ENCODING=BASE64;VALUE=BINARY;FMTTYPE=text/plain;FILENAME=hello.txt:SGVsbG8gVmlrdW5qYQ==
+ATTACH;ENCODING=BASE64;VALUE=BINARY:SGVsbG8gVmlrdW5qYQ==
+ATTACH:https://example.com/remote.pdf
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				Attachments: []*models.TaskAttachment{
+					{
+						File: &files.File{
+							Name:        "hello.txt",
+							Mime:        "text/plain",
+							Size:        13,
+							FileContent: []byte("Hello Vikunja"),
+						},
+					},
+					{
+						File: &files.File{
+							Name:        "attachment",
+							Size:        13,
+							FileContent: []byte("Hello Vikunja"),
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -392,6 +548,45 @@ ACTION:DISPLAY
 DESCRIPTION:Task 1
 END:VALARM
 END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "Format Task with repeat rule as CalDAV",
+			args: args{
+				list: &models.ProjectWithTasksAndBuckets{
+					Project: models.Project{
+						Title: "List title",
+					},
+				},
+				tasks: []*models.TaskWithComments{
+					{
+						Task: models.Task{
+							Title:       "Task 1",
+							UID:         "randomuid",
+							Created:     time.Unix(1543626721, 0).In(config.GetTimeZone()),
+							DueDate:     time.Unix(1543626722, 0).In(config.GetTimeZone()),
+							Updated:     time.Unix(1543626725, 0).In(config.GetTimeZone()),
+							RepeatAfter: 86400,
+							RepeatRule:  "FREQ=MONTHLY;BYDAY=-1FR;COUNT=6",
+						},
+					},
+				},
+			},
+			wantCaldav: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:List title
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011205Z
+SUMMARY:Task 1
+DUE:20181201T011202Z
+CREATED:20181201T011201Z
+RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=6
+LAST-MODIFIED:20181201T011205Z
+END:VTODO
 END:VCALENDAR`,
 		},
 	}
@@ -404,3 +599,44 @@ END:VCALENDAR`,
 		})
 	}
 }
+
+func TestCaldavRoundTrip(t *testing.T) {
+	project := &models.ProjectWithTasksAndBuckets{
+		Project: models.Project{
+			Title: "List title",
+		},
+	}
+	tasks := []*models.TaskWithComments{
+		{
+			Task: models.Task{
+				Title:      "Task 1",
+				UID:        "randomuid",
+				Updated:    time.Unix(1543626725, 0).In(config.GetTimeZone()),
+				DueDate:    time.Unix(1543626722, 0).In(config.GetTimeZone()),
+				RepeatRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+				RelatedTasks: models.RelatedTaskMap{
+					models.RelationKindParenttask: {{UID: "parentuid"}},
+				},
+			},
+		},
+	}
+
+	vTask, err := ParseTaskFromVTODO(GetCaldavTodosForTasks(project, tasks))
+	if err != nil {
+		t.Fatalf("ParseTaskFromVTODO() error = %v", err)
+	}
+
+	want := &models.Task{
+		Title:      "Task 1",
+		UID:        "randomuid",
+		Updated:    time.Unix(1543626725, 0).In(config.GetTimeZone()),
+		DueDate:    time.Unix(1543626722, 0).In(config.GetTimeZone()),
+		RepeatRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+		RelatedTasks: models.RelatedTaskMap{
+			models.RelationKindParenttask: {{UID: "parentuid"}},
+		},
+	}
+	if diff, equal := messagediff.PrettyDiff(vTask, want); !equal {
+		t.Errorf("Round trip through caldav changed the task\n got = %v\n want %v\n diff = %s", vTask, want, diff)
+	}
+}
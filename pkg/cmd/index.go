@@ -0,0 +1,59 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/initialize"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/search"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	indexCmd.AddCommand(indexRebuildCmd)
+	rootCmd.AddCommand(indexCmd)
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the search index.",
+}
+
+var indexRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Removes all tasks from the search index and adds them again. Vikunja must not be running while the index is rebuilt.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		if err := models.RebuildSearchIndex(s); err != nil {
+			log.Fatalf("Error rebuilding the search index: %s", err)
+		}
+
+		if err := search.Close(); err != nil {
+			log.Fatalf("Error closing the search index: %s", err)
+		}
+
+		log.Info("The search index was rebuilt successfully.")
+	},
+}
@@ -46,6 +46,8 @@ var (
 	userFlagEnableUser            bool
 	userFlagDisableUser           bool
 	userFlagDeleteNow             bool
+	userFlagGrantAdmin            bool
+	userFlagRevokeAdmin           bool
 )
 
 func init() {
@@ -70,10 +72,14 @@ func init() {
 	userChangeEnabledCmd.Flags().BoolVarP(&userFlagDisableUser, "disable", "d", false, "Disable the user.")
 	userChangeEnabledCmd.Flags().BoolVarP(&userFlagEnableUser, "enable", "e", false, "Enable the user.")
 
+	// Change admin flags
+	userChangeAdminCmd.Flags().BoolVarP(&userFlagGrantAdmin, "grant", "g", false, "Make the user an instance admin.")
+	userChangeAdminCmd.Flags().BoolVarP(&userFlagRevokeAdmin, "revoke", "r", false, "Revoke the instance admin rights of the user.")
+
 	// User deletion flags
 	userDeleteCmd.Flags().BoolVarP(&userFlagDeleteNow, "now", "n", false, "If provided, deletes the user immediately instead of sending them an email first.")
 
-	userCmd.AddCommand(userListCmd, userCreateCmd, userUpdateCmd, userResetPasswordCmd, userChangeEnabledCmd, userDeleteCmd)
+	userCmd.AddCommand(userListCmd, userCreateCmd, userUpdateCmd, userResetPasswordCmd, userChangeEnabledCmd, userChangeAdminCmd, userDeleteCmd)
 	rootCmd.AddCommand(userCmd)
 }
 
@@ -143,6 +149,7 @@ var userListCmd = &cobra.Command{
 			"Username",
 			"Email",
 			"Status",
+			"Admin",
 			"Created",
 			"Updated",
 		})
@@ -153,6 +160,7 @@ var userListCmd = &cobra.Command{
 				u.Username,
 				u.Email,
 				u.Status.String(),
+				strconv.FormatBool(u.IsInstanceAdmin()),
 				u.Created.Format(time.RFC3339),
 				u.Updated.Format(time.RFC3339),
 			})
@@ -288,18 +296,11 @@ var userChangeEnabledCmd = &cobra.Command{
 
 		u := getUserFromArg(s, args[0])
 
-		if userFlagEnableUser {
-			u.Status = user.StatusActive
-		} else if userFlagDisableUser {
-			u.Status = user.StatusDisabled
-		} else {
-			if u.Status == user.StatusActive {
-				u.Status = user.StatusDisabled
-			} else {
-				u.Status = user.StatusActive
-			}
+		var status user.Status = user.StatusActive
+		if userFlagDisableUser || (!userFlagEnableUser && u.Status == user.StatusActive) {
+			status = user.StatusDisabled
 		}
-		_, err := user.UpdateUser(s, u, false)
+		err := u.SetStatus(s, status)
 		if err != nil {
 			_ = s.Rollback()
 			log.Fatalf("Could not enable the user")
@@ -313,6 +314,38 @@ var userChangeEnabledCmd = &cobra.Command{
 	},
 }
 
+var userChangeAdminCmd = &cobra.Command{
+	Use:   "change-admin [user id]",
+	Short: "Grant or revoke instance admin rights. Will toggle the current state if no flag (--grant or --revoke) is provided.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		u := getUserFromArg(s, args[0])
+
+		isAdmin := userFlagGrantAdmin || (!userFlagRevokeAdmin && !u.IsAdmin)
+		err := u.SetIsAdmin(s, isAdmin)
+		if err != nil {
+			_ = s.Rollback()
+			log.Fatalf("Could not change the admin rights of the user: %s", err)
+		}
+
+		if err := s.Commit(); err != nil {
+			log.Fatalf("Error saving everything: %s", err)
+		}
+
+		if u.IsAdmin {
+			fmt.Println("The user is now an instance admin.")
+			return
+		}
+		fmt.Println("The user is no longer an instance admin.")
+	},
+}
+
 var userDeleteCmd = &cobra.Command{
 	Use:   "delete [user id]",
 	Short: "Delete an existing user.",
@@ -41,6 +41,7 @@ const (
 	ServiceJWTSecret       Key = `service.JWTSecret`
 	ServiceJWTTTL          Key = `service.jwtttl`
 	ServiceJWTTTLLong      Key = `service.jwtttllong`
+	ServiceJWTTTLShort     Key = `service.jwtttlshort`
 	ServiceInterface       Key = `service.interface`
 	ServiceUnixSocket      Key = `service.unixsocket`
 	ServiceUnixSocketMode  Key = `service.unixsocketmode`
@@ -63,11 +64,29 @@ const (
 	ServiceEnableEmailReminders  Key = `service.enableemailreminders`
 	ServiceEnableUserDeletion    Key = `service.enableuserdeletion`
 	ServiceMaxAvatarSize         Key = `service.maxavatarsize`
-
-	AuthLocalEnabled      Key = `auth.local.enabled`
-	AuthOpenIDEnabled     Key = `auth.openid.enabled`
-	AuthOpenIDRedirectURL Key = `auth.openid.redirecturl`
-	AuthOpenIDProviders   Key = `auth.openid.providers`
+	ServiceAdmins                Key = `service.admins`
+	ServiceTrashRetentionDays    Key = `service.trashretentiondays`
+
+	AuthLocalEnabled             Key = `auth.local.enabled`
+	AuthOpenIDEnabled            Key = `auth.openid.enabled`
+	AuthOpenIDRedirectURL        Key = `auth.openid.redirecturl`
+	AuthOpenIDProviders          Key = `auth.openid.providers`
+	AuthLdapEnabled              Key = `auth.ldap.enabled`
+	AuthLdapHost                 Key = `auth.ldap.host`
+	AuthLdapPort                 Key = `auth.ldap.port`
+	AuthLdapUseTLS               Key = `auth.ldap.usetls`
+	AuthLdapVerifyTLS            Key = `auth.ldap.verifytls`
+	AuthLdapBaseDN               Key = `auth.ldap.basedn`
+	AuthLdapBindDN               Key = `auth.ldap.binddn`
+	AuthLdapBindPassword         Key = `auth.ldap.bindpassword`
+	AuthLdapUserFilter           Key = `auth.ldap.userfilter`
+	AuthLdapAttributeID          Key = `auth.ldap.attribute.id`
+	AuthLdapAttributeUsername    Key = `auth.ldap.attribute.username`
+	AuthLdapAttributeEmail       Key = `auth.ldap.attribute.email`
+	AuthLdapAttributeDisplayname Key = `auth.ldap.attribute.displayname`
+	AuthLdapGroupSyncEnabled     Key = `auth.ldap.groupsyncenabled`
+	AuthLdapGroupFilter          Key = `auth.ldap.groupfilter`
+	AuthLdapAttributeGroupName   Key = `auth.ldap.attribute.groupname`
 
 	LegalImprintURL Key = `legal.imprinturl`
 	LegalPrivacyURL Key = `legal.privacyurl`
@@ -171,6 +190,13 @@ const (
 	DefaultSettingsLanguage                    Key = `defaultsettings.language`
 	DefaultSettingsTimezone                    Key = `defaultsettings.timezone`
 	DefaultSettingsOverdueTaskRemindersTime    Key = `defaultsettings.overdue_tasks_reminders_time`
+
+	WebhooksEnabled        Key = `webhooks.enabled`
+	WebhooksTimeoutSeconds Key = `webhooks.timeoutseconds`
+	WebhooksMaxRetries     Key = `webhooks.maxretries`
+
+	SearchType         Key = `search.type`
+	SearchEmbeddedPath Key = `search.embeddedpath`
 )
 
 // GetString returns a string config value
@@ -281,6 +307,7 @@ func InitDefaultConfig() {
 	ServiceJWTSecret.setDefault(random)
 	ServiceJWTTTL.setDefault(259200)      // 72 hours
 	ServiceJWTTTLLong.setDefault(2592000) // 30 days
+	ServiceJWTTTLShort.setDefault(600)    // 10 minutes
 	ServiceInterface.setDefault(":3456")
 	ServiceUnixSocket.setDefault("")
 	ServiceFrontendurl.setDefault("")
@@ -300,10 +327,24 @@ func InitDefaultConfig() {
 	ServiceEnableEmailReminders.setDefault(true)
 	ServiceEnableUserDeletion.setDefault(true)
 	ServiceMaxAvatarSize.setDefault(1024)
+	ServiceAdmins.setDefault([]string{})
+	ServiceTrashRetentionDays.setDefault(30)
 
 	// Auth
 	AuthLocalEnabled.setDefault(true)
 	AuthOpenIDEnabled.setDefault(false)
+	AuthLdapEnabled.setDefault(false)
+	AuthLdapPort.setDefault(389)
+	AuthLdapUseTLS.setDefault(false)
+	AuthLdapVerifyTLS.setDefault(true)
+	AuthLdapUserFilter.setDefault("(&(objectclass=inetOrgPerson)(uid=%[1]s))")
+	AuthLdapAttributeID.setDefault("entryUUID")
+	AuthLdapAttributeUsername.setDefault("uid")
+	AuthLdapAttributeEmail.setDefault("mail")
+	AuthLdapAttributeDisplayname.setDefault("displayName")
+	AuthLdapGroupSyncEnabled.setDefault(false)
+	AuthLdapGroupFilter.setDefault("(&(objectclass=groupOfNames)(member=%[1]s))")
+	AuthLdapAttributeGroupName.setDefault("cn")
 
 	// Database
 	DatabaseType.setDefault("sqlite")
@@ -386,6 +427,13 @@ func InitDefaultConfig() {
 	DefaultSettingsAvatarProvider.setDefault("initials")
 	DefaultSettingsOverdueTaskRemindersEnabled.setDefault(true)
 	DefaultSettingsOverdueTaskRemindersTime.setDefault("9:00")
+	// Webhook
+	WebhooksEnabled.setDefault(false)
+	WebhooksTimeoutSeconds.setDefault(30)
+	WebhooksMaxRetries.setDefault(3)
+	// Search
+	SearchType.setDefault("database")
+	SearchEmbeddedPath.setDefault(ServiceRootpath.GetString() + "/search-index")
 }
 
 // InitConfig initializes the config, sets defaults etc.
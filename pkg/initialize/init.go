@@ -30,6 +30,7 @@ import (
 	"code.vikunja.io/api/pkg/modules/auth/openid"
 	"code.vikunja.io/api/pkg/modules/keyvalue"
 	migrator "code.vikunja.io/api/pkg/modules/migration"
+	"code.vikunja.io/api/pkg/modules/search"
 	"code.vikunja.io/api/pkg/notifications"
 	"code.vikunja.io/api/pkg/red"
 	"code.vikunja.io/api/pkg/user"
@@ -88,6 +89,9 @@ func FullInit() {
 	// Set Engine
 	InitEngines()
 
+	// Open the search index
+	search.InitBackend()
+
 	// Start the mail daemon
 	mail.StartMailDaemon()
 
@@ -95,7 +99,11 @@ func FullInit() {
 	cron.Init()
 	models.RegisterReminderCron()
 	models.RegisterOverdueReminderCron()
+	models.RegisterProjectTemplateCron()
+	models.RegisterTrashPurgeCron()
+	models.RegisterWebhookRetryCron()
 	user.RegisterTokenCleanupCron()
+	user.RegisterSessionCleanupCron()
 	user.RegisterDeletionNotificationCron()
 	models.RegisterUserDeletionCron()
 	models.RegisterOldExportCleanupCron()
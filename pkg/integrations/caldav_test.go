@@ -18,10 +18,13 @@ package integrations
 
 import (
 	"net/http"
+	"regexp"
 	"testing"
 
+	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/routes/caldav"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const vtodo = `BEGIN:VCALENDAR
@@ -44,6 +47,39 @@ END:VALARM
 END:VTODO
 END:VCALENDAR`
 
+const vtodoWithRelationAndAttachment = `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:List 36 for Caldav tests
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:uid-subtask
+DTSTAMP:20230301T073337Z
+SUMMARY:Caldav Subtask
+RELATED-TO:uid-caldav-test
+RELATED-TO:uid-does-not-exist
+ATTACH;ENCODING=BASE64;VALUE=BINARY;FMTTYPE=text/plain;FILENAME=hello.txt:SGVsbG8gVmlrdW5qYQ==
+CREATED:20230301T073337Z
+LAST-MODIFIED:20230301T073337Z
+END:VTODO
+END:VCALENDAR`
+
+const vtodoWithoutRelation = `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:List 36 for Caldav tests
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:uid-caldav-subtask
+DTSTAMP:20230301T073337Z
+SUMMARY:Subtask Caldav Test
+CREATED:20230301T073337Z
+LAST-MODIFIED:20230301T073337Z
+END:VTODO
+END:VCALENDAR`
+
 func TestCaldav(t *testing.T) {
 	t.Run("Delivers VTODO for project", func(t *testing.T) {
 		rec, err := newCaldavTestRequestWithUser(t, http.MethodGet, caldav.ProjectHandler, &testuser15, ``, nil, map[string]string{"project": "36"})
@@ -60,8 +96,38 @@ func TestCaldav(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, 201, rec.Result().StatusCode)
 	})
+	t.Run("Import VTODO with relation and attachment", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodPut, caldav.TaskHandler, &testuser15, vtodoWithRelationAndAttachment, nil, map[string]string{"project": "36", "task": "uid-subtask"})
+		assert.NoError(t, err)
+		assert.Equal(t, 201, rec.Result().StatusCode)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"other_task_id": 40,
+			"relation_kind": "parenttask",
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       40,
+			"relation_kind": "subtask",
+		}, false)
+		db.AssertExists(t, "files", map[string]interface{}{
+			"name": "hello.txt",
+			"size": 13,
+		}, false)
+	})
+	t.Run("Update VTODO removes relations", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodPut, caldav.TaskHandler, &testuser15, vtodoWithoutRelation, nil, map[string]string{"project": "36", "task": "uid-caldav-subtask"})
+		assert.NoError(t, err)
+		assert.Equal(t, 201, rec.Result().StatusCode)
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"task_id":       41,
+			"other_task_id": 40,
+		})
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"task_id":       40,
+			"other_task_id": 41,
+		})
+	})
 	t.Run("Export VTODO", func(t *testing.T) {
-		rec, err := newCaldavTestRequestWithUser(t, http.MethodGet, caldav.TaskHandler, &testuser15, ``, nil, map[string]string{"project": "36", "task": "uid-caldav-test"})
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodGet, caldav.TaskHandler, &testuser15, ``, nil, map[string]string{"project": "-3", "task": "uid-caldav-test"})
 		assert.NoError(t, err)
 		assert.Contains(t, rec.Body.String(), "BEGIN:VCALENDAR")
 		assert.Contains(t, rec.Body.String(), "SUMMARY:Title Caldav Test")
@@ -73,5 +139,146 @@ func TestCaldav(t *testing.T) {
 		assert.Contains(t, rec.Body.String(), "TRIGGER;VALUE=DATE-TIME:20230304T150000Z")
 		assert.Contains(t, rec.Body.String(), "ACTION:DISPLAY")
 		assert.Contains(t, rec.Body.String(), "END:VALARM")
+		assert.Contains(t, rec.Body.String(), "RELATED-TO;RELTYPE=CHILD:uid-caldav-subtask")
+	})
+}
+
+const propfindSyncToken = `<?xml version="1.0" encoding="UTF-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/">
+	<d:prop>
+		<d:displayname/>
+		<cs:getctag/>
+		<d:sync-token/>
+		<d:supported-report-set/>
+	</d:prop>
+</d:propfind>`
+
+func syncCollectionReport(token string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<d:sync-collection xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+	<d:sync-token>` + token + `</d:sync-token>
+	<d:sync-level>1</d:sync-level>
+	<d:prop>
+		<d:getetag/>
+		<c:calendar-data/>
+	</d:prop>
+</d:sync-collection>`
+}
+
+func TestCaldavSync(t *testing.T) {
+	t.Run("PROPFIND returns sync token and ctag", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "PROPFIND", caldav.ProjectHandler, &testuser15, propfindSyncToken, nil, map[string]string{"project": "36"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<D:sync-token>http://vikunja.io/ns/sync/36-2</D:sync-token>`)
+		assert.Contains(t, rec.Body.String(), `<CS:getctag>"36-2"</CS:getctag>`)
+		assert.Contains(t, rec.Body.String(), `<D:report><D:sync-collection/></D:report>`)
+		assert.NotContains(t, rec.Body.String(), `<D:sync-token/>`)
+	})
+	t.Run("Initial sync returns all tasks", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, syncCollectionReport(""), nil, map[string]string{"project": "36"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<D:href>/dav/projects/36/uid-caldav-test.ics</D:href>`)
+		assert.Contains(t, rec.Body.String(), `<D:getetag>`)
+		assert.Contains(t, rec.Body.String(), `SUMMARY:Title Caldav Test`)
+		assert.NotContains(t, rec.Body.String(), `uid-caldav-deleted`)
+		assert.Contains(t, rec.Body.String(), `<D:sync-token>http://vikunja.io/ns/sync/36-2</D:sync-token>`)
+	})
+	t.Run("Sync with token returns changes and deletions", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, syncCollectionReport("http://vikunja.io/ns/sync/36-1"), nil, map[string]string{"project": "36"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+		assert.NotContains(t, rec.Body.String(), `uid-caldav-test`)
+		assert.Contains(t, rec.Body.String(), `<D:response><D:href>/dav/projects/36/uid-caldav-deleted.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status></D:response>`)
+		assert.Contains(t, rec.Body.String(), `<D:sync-token>http://vikunja.io/ns/sync/36-2</D:sync-token>`)
+	})
+	t.Run("Invalid sync token", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, syncCollectionReport("http://vikunja.io/ns/sync/1-2"), nil, map[string]string{"project": "36"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<D:valid-sync-token/>`)
+	})
+	t.Run("Sync token from the future", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, syncCollectionReport("http://vikunja.io/ns/sync/36-9999"), nil, map[string]string{"project": "36"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<D:valid-sync-token/>`)
+	})
+	t.Run("Forbidden project", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, syncCollectionReport(""), nil, map[string]string{"project": "1"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+	})
+}
+
+const calendarQueryTodos = `<?xml version="1.0" encoding="UTF-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+	<d:prop>
+		<d:getetag/>
+	</d:prop>
+	<c:filter>
+		<c:comp-filter name="VCALENDAR">
+			<c:comp-filter name="VTODO"/>
+		</c:comp-filter>
+	</c:filter>
+</c:calendar-query>`
+
+func TestCaldavSavedFilters(t *testing.T) {
+	// Saved filter 2 of user 15 is available as pseudo project -3
+	t.Run("Lists saved filters as calendars", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "PROPFIND", caldav.ProjectHandler, &testuser15, propfindSyncToken, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<D:href>/dav/projects/-3</D:href>`)
+		assert.Contains(t, rec.Body.String(), `<D:displayname>Caldav filter</D:displayname>`)
+		assert.NotContains(t, rec.Body.String(), `<D:displayname>Filters</D:displayname>`)
+	})
+	t.Run("Delivers the tasks of a saved filter", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodGet, caldav.ProjectHandler, &testuser15, ``, nil, map[string]string{"project": "-3"})
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), "X-WR-CALNAME:Caldav filter")
+		assert.Contains(t, rec.Body.String(), "SUMMARY:Title Caldav Test")
+	})
+	t.Run("Task urls are inside the saved filter", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, calendarQueryTodos, nil, map[string]string{"project": "-3"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<D:href>/dav/projects/-3/uid-caldav-test.ics</D:href>`)
+	})
+	t.Run("PROPFIND returns a ctag but no sync token", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "PROPFIND", caldav.ProjectHandler, &testuser15, propfindSyncToken, nil, map[string]string{"project": "-3"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<CS:getctag>"-3-`)
+		assert.NotContains(t, rec.Body.String(), `http://vikunja.io/ns/sync/`)
+		assert.NotContains(t, rec.Body.String(), `<D:sync-collection/>`)
+	})
+	t.Run("Listing and PROPFIND return the same ctag", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "PROPFIND", caldav.ProjectHandler, &testuser15, propfindSyncToken, nil, map[string]string{"project": "-3"})
+		assert.NoError(t, err)
+		ctag := regexp.MustCompile(`<CS:getctag>("-3-\d+")</CS:getctag>`).FindStringSubmatch(rec.Body.String())
+		require.Len(t, ctag, 2)
+
+		rec, err = newCaldavTestRequestWithUser(t, "PROPFIND", caldav.ProjectHandler, &testuser15, propfindSyncToken, nil, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), `<CS:getctag>`+ctag[1]+`</CS:getctag>`)
+		assert.Contains(t, rec.Body.String(), `<CS:getctag>"36-2"</CS:getctag>`)
+	})
+	t.Run("Sync collection is not supported", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, syncCollectionReport(""), nil, map[string]string{"project": "-3"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<D:supported-report/>`)
+	})
+	t.Run("Creating a task without a default project", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodPut, caldav.TaskHandler, &testuser15, vtodo, nil, map[string]string{"project": "-3", "task": "uid"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), `<D:need-privileges>`)
+		assert.Contains(t, rec.Body.String(), `<D:bind/>`)
+		db.AssertMissing(t, "tasks", map[string]interface{}{
+			"uid": "uid",
+		})
 	})
 }
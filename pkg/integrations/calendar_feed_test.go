@@ -0,0 +1,54 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.vikunja.io/api/pkg/models"
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	t.Run("Project", func(t *testing.T) {
+		rec, err := newTestRequest(t, http.MethodGet, apiv1.GetCalendarFeed, ``, nil, map[string]string{"token": "calendarfeedtoken1.ics"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), "X-WR-CALNAME:Project feed")
+		assert.Contains(t, rec.Body.String(), "BEGIN:VEVENT")
+		assert.NotContains(t, rec.Body.String(), "BEGIN:VTODO")
+	})
+	t.Run("Assigned tasks with todos", func(t *testing.T) {
+		rec, err := newTestRequest(t, http.MethodGet, apiv1.GetCalendarFeed, ``, nil, map[string]string{"token": "calendarfeedtoken2"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "X-WR-CALNAME:Assigned to user1")
+		assert.Contains(t, rec.Body.String(), "BEGIN:VTODO")
+	})
+	t.Run("Invalid token", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodGet, apiv1.GetCalendarFeed, ``, nil, map[string]string{"token": "invalidtoken"})
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, models.ErrCodeCalendarFeedDoesNotExist)
+	})
+	t.Run("No access to the project anymore", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodGet, apiv1.GetCalendarFeed, ``, nil, map[string]string{"token": "calendarfeedtoken4"})
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, models.ErrCodeUserDoesNotHaveAccessToProject)
+	})
+}
@@ -57,4 +57,14 @@ func TestLinkSharingAuth(t *testing.T) {
 		assert.Error(t, err)
 		assertHandlerErrorCode(t, err, models.ErrCodeLinkSharePasswordInvalid)
 	})
+	t.Run("Expired", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.AuthenticateLinkShare, ``, nil, map[string]string{"share": "testExpired"})
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, models.ErrCodeLinkShareExpired)
+	})
+	t.Run("Usage Limit Reached", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.AuthenticateLinkShare, ``, nil, map[string]string{"share": "testUsedUp"})
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, models.ErrCodeLinkShareUsageLimitReached)
+	})
 }
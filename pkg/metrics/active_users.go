@@ -62,19 +62,10 @@ func setupActiveUsersMetric() {
 		Name: "vikunja_active_users",
 		Help: "The number of users active within the last 30 seconds on this node",
 	}, func() float64 {
-		allActiveUsers, err := getActiveUsers()
+		activeUsersCount, err := GetActiveUsersCount()
 		if err != nil {
 			log.Error(err.Error())
 		}
-		if allActiveUsers == nil {
-			return 0
-		}
-		activeUsersCount := 0
-		for _, u := range allActiveUsers {
-			if time.Since(u.LastSeen) < SecondsUntilInactive*time.Second {
-				activeUsersCount++
-			}
-		}
 		return float64(activeUsersCount)
 	}))
 	if err != nil {
@@ -93,6 +84,20 @@ func SetUserActive(a web.Auth) (err error) {
 	return PushActiveUsers()
 }
 
+// GetActiveUsersCount returns the number of users active within the last SecondsUntilInactive seconds
+func GetActiveUsersCount() (count int64, err error) {
+	allActiveUsers, err := getActiveUsers()
+	if allActiveUsers == nil {
+		return 0, err
+	}
+	for _, u := range allActiveUsers {
+		if time.Since(u.LastSeen) < SecondsUntilInactive*time.Second {
+			count++
+		}
+	}
+	return count, err
+}
+
 // getActiveUsers returns the active users from redis
 func getActiveUsers() (users activeUsersMap, err error) {
 	users = activeUsersMap{}
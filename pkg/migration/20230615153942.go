@@ -0,0 +1,149 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type projectViews20230615153942 struct {
+	ID        int64                  `xorm:"autoincr not null unique pk" json:"id" param:"view"`
+	Title     string                 `xorm:"varchar(255) not null" json:"title"`
+	ProjectID int64                  `xorm:"not null index" json:"project_id" param:"project"`
+	ViewKind  int                    `xorm:"not null default 0" json:"view_kind"`
+	Filter    map[string]interface{} `xorm:"JSON null default null" json:"filter"`
+	Position  float64                `xorm:"double null" json:"position"`
+	IsDefault bool                   `xorm:"not null default false" json:"is_default"`
+	Created   time.Time              `xorm:"created not null" json:"created"`
+	Updated   time.Time              `xorm:"updated not null" json:"updated"`
+}
+
+func (projectViews20230615153942) TableName() string {
+	return "project_views"
+}
+
+type taskBuckets20230615153942 struct {
+	ID            int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	BucketID      int64 `xorm:"bigint not null index" json:"bucket_id"`
+	TaskID        int64 `xorm:"bigint not null index" json:"task_id"`
+	ProjectViewID int64 `xorm:"bigint not null index" json:"project_view_id"`
+}
+
+func (taskBuckets20230615153942) TableName() string {
+	return "task_buckets"
+}
+
+type taskPositions20230615153942 struct {
+	ID            int64   `xorm:"bigint autoincr not null unique pk" json:"-"`
+	TaskID        int64   `xorm:"bigint not null index" json:"task_id"`
+	ProjectViewID int64   `xorm:"bigint not null index" json:"project_view_id"`
+	Position      float64 `xorm:"double not null" json:"position"`
+}
+
+func (taskPositions20230615153942) TableName() string {
+	return "task_positions"
+}
+
+type buckets20230615153942 struct {
+	ProjectViewID int64 `xorm:"bigint not null default 0 index" json:"project_view_id"`
+}
+
+func (buckets20230615153942) TableName() string {
+	return "buckets"
+}
+
+type projects20230615153942 struct {
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+}
+
+func (projects20230615153942) TableName() string {
+	return "projects"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230615153942",
+		Description: "Add project views and move kanban buckets into the default kanban view",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(
+				projectViews20230615153942{},
+				taskBuckets20230615153942{},
+				taskPositions20230615153942{},
+				buckets20230615153942{},
+			)
+			if err != nil {
+				return err
+			}
+
+			projects := []*projects20230615153942{}
+			err = tx.Find(&projects)
+			if err != nil {
+				return err
+			}
+
+			defaultViews := []struct {
+				title string
+				kind  int
+			}{
+				{title: "List", kind: 0},
+				{title: "Gantt", kind: 1},
+				{title: "Table", kind: 2},
+				{title: "Kanban", kind: 3},
+			}
+
+			for _, p := range projects {
+				for _, dv := range defaultViews {
+					view := &projectViews20230615153942{
+						Title:     dv.title,
+						ProjectID: p.ID,
+						ViewKind:  dv.kind,
+						IsDefault: true,
+					}
+					_, err = tx.Insert(view)
+					if err != nil {
+						return err
+					}
+
+					view.Position = float64(view.ID) * 65536
+					_, err = tx.Where("id = ?", view.ID).Cols("position").Update(view)
+					if err != nil {
+						return err
+					}
+
+					if dv.kind != 3 {
+						continue
+					}
+
+					_, err = tx.Where("project_id = ?", p.ID).
+						Cols("project_view_id").
+						Update(&buckets20230615153942{ProjectViewID: view.ID})
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,68 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type projectCustomFields20230619103012 struct {
+	ID        int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	Title     string    `xorm:"varchar(250) not null" json:"title"`
+	ProjectID int64     `xorm:"bigint not null index" json:"project_id"`
+	FieldType int       `xorm:"not null default 0" json:"field_type"`
+	Options   []string  `xorm:"JSON null" json:"options"`
+	Position  float64   `xorm:"double null" json:"position"`
+	Created   time.Time `xorm:"created not null" json:"created"`
+	Updated   time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (projectCustomFields20230619103012) TableName() string {
+	return "project_custom_fields"
+}
+
+type taskCustomFieldValues20230619103012 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk" json:"-"`
+	TaskID      int64     `xorm:"bigint not null index" json:"task_id"`
+	FieldID     int64     `xorm:"bigint not null index" json:"field_id"`
+	ValueText   string    `xorm:"text null" json:"-"`
+	ValueNumber float64   `xorm:"double null" json:"-"`
+	ValueDate   time.Time `xorm:"DATETIME null" json:"-"`
+	ValueUserID int64     `xorm:"bigint null" json:"-"`
+	Created     time.Time `xorm:"created not null" json:"created"`
+	Updated     time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (taskCustomFieldValues20230619103012) TableName() string {
+	return "task_custom_field_values"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230619103012",
+		Description: "Add custom fields tables",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(projectCustomFields20230619103012{}, taskCustomFieldValues20230619103012{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(projectCustomFields20230619103012{}, taskCustomFieldValues20230619103012{})
+		},
+	})
+}
@@ -0,0 +1,52 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskActivities20230620143012 struct {
+	ID       int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	TaskID   int64     `xorm:"bigint not null index" json:"task_id"`
+	DoerID   int64     `xorm:"bigint not null" json:"-"`
+	Kind     string    `xorm:"varchar(50) not null" json:"kind"`
+	Field    string    `xorm:"varchar(250) not null" json:"field"`
+	OldValue string    `xorm:"text null" json:"old_value"`
+	NewValue string    `xorm:"text null" json:"new_value"`
+	Created  time.Time `xorm:"created not null" json:"created"`
+}
+
+func (taskActivities20230620143012) TableName() string {
+	return "task_activities"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230620143012",
+		Description: "Add task activities table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(taskActivities20230620143012{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(taskActivities20230620143012{})
+		},
+	})
+}
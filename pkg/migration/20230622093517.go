@@ -0,0 +1,54 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type projectTemplates20230622093517 struct {
+	ID                      int64             `xorm:"bigint autoincr not null unique pk" json:"id"`
+	ProjectID               int64             `xorm:"bigint not null unique" json:"project_id"`
+	BaseDate                time.Time         `xorm:"DATETIME not null" json:"base_date"`
+	Schedule                string            `xorm:"varchar(250) null" json:"schedule"`
+	ScheduleParentProjectID int64             `xorm:"bigint null" json:"schedule_parent_project_id"`
+	ScheduleVariables       map[string]string `xorm:"json null" json:"schedule_variables"`
+	LastInstantiated        time.Time         `xorm:"DATETIME null" json:"last_instantiated"`
+	CreatedByID             int64             `xorm:"bigint not null" json:"-"`
+	Created                 time.Time         `xorm:"created not null" json:"created"`
+	Updated                 time.Time         `xorm:"updated not null" json:"updated"`
+}
+
+func (projectTemplates20230622093517) TableName() string {
+	return "project_templates"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230622093517",
+		Description: "Add project templates table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(projectTemplates20230622093517{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(projectTemplates20230622093517{})
+		},
+	})
+}
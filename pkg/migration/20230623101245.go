@@ -0,0 +1,74 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type tasks20230623101245 struct {
+	Deleted time.Time `xorm:"deleted INDEX null" json:"-"`
+}
+
+func (tasks20230623101245) TableName() string {
+	return "tasks"
+}
+
+type projects20230623101245 struct {
+	Deleted time.Time `xorm:"deleted INDEX null" json:"-"`
+}
+
+func (projects20230623101245) TableName() string {
+	return "projects"
+}
+
+type taskComments20230623101245 struct {
+	Deleted time.Time `xorm:"deleted INDEX null" json:"-"`
+}
+
+func (taskComments20230623101245) TableName() string {
+	return "task_comments"
+}
+
+type buckets20230623101245 struct {
+	Deleted time.Time `xorm:"deleted INDEX null" json:"-"`
+}
+
+func (buckets20230623101245) TableName() string {
+	return "buckets"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230623101245",
+		Description: "Add deleted timestamp to tasks, projects, comments and buckets",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(
+				tasks20230623101245{},
+				projects20230623101245{},
+				taskComments20230623101245{},
+				buckets20230623101245{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
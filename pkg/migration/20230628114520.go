@@ -0,0 +1,49 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type linkShares20230628114520 struct {
+	ExpiresAt            time.Time `xorm:"datetime null" json:"expires_at"`
+	MaxUses              int64     `xorm:"bigint not null default 0" json:"max_uses"`
+	UseCount             int64     `xorm:"bigint not null default 0" json:"use_count"`
+	IncludeChildProjects bool      `xorm:"bool not null default false" json:"include_child_projects"`
+	LastUsed             time.Time `xorm:"datetime null" json:"last_used"`
+	LastUsedIP           string    `xorm:"varchar(50) null" json:"last_used_ip"`
+}
+
+func (linkShares20230628114520) TableName() string {
+	return "link_shares"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230628114520",
+		Description: "Add expiry, usage limits, child project scope and last usage to link shares",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(linkShares20230628114520{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,52 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type calendarFeeds20230629101045 struct {
+	ID           int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	Title        string    `xorm:"varchar(250) not null" json:"title"`
+	Kind         string    `xorm:"varchar(20) not null" json:"kind"`
+	ProjectID    int64     `xorm:"bigint null" json:"project_id"`
+	FilterID     int64     `xorm:"bigint null" json:"filter_id"`
+	IncludeTodos bool      `xorm:"bool not null default false" json:"include_todos"`
+	TokenHash    string    `xorm:"varchar(64) not null unique" json:"-"`
+	Created      time.Time `xorm:"created not null" json:"created"`
+	UserID       int64     `xorm:"bigint not null INDEX" json:"-"`
+}
+
+func (calendarFeeds20230629101045) TableName() string {
+	return "calendar_feeds"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230629101045",
+		Description: "Add calendar feeds table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(calendarFeeds20230629101045{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(calendarFeeds20230629101045{})
+		},
+	})
+}
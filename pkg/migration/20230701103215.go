@@ -0,0 +1,65 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskSyncChanges20230701103215 struct {
+	Sequence int64 `xorm:"bigint not null default 0 index" json:"-"`
+}
+
+func (taskSyncChanges20230701103215) TableName() string {
+	return "task_sync_changes"
+}
+
+type projectSyncSequences20230701103215 struct {
+	ProjectID int64 `xorm:"bigint not null pk" json:"-"`
+	Sequence  int64 `xorm:"bigint not null default 0" json:"-"`
+}
+
+func (projectSyncSequences20230701103215) TableName() string {
+	return "project_sync_sequences"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230701103215",
+		Description: "Add per project sync sequences for caldav sync tokens",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(taskSyncChanges20230701103215{}, projectSyncSequences20230701103215{})
+			if err != nil {
+				return err
+			}
+
+			// The ids of existing changes were used as sequence so far, keeping them keeps existing sync tokens valid
+			_, err = tx.Exec("UPDATE task_sync_changes SET sequence = id")
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`INSERT INTO project_sync_sequences (project_id, sequence)
+SELECT p.id, COALESCE((SELECT MAX(c.sequence) FROM task_sync_changes c WHERE c.project_id = p.id), 0)
+FROM projects p`)
+			return err
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(projectSyncSequences20230701103215{})
+		},
+	})
+}
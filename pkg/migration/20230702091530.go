@@ -0,0 +1,49 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type webhookRetries20230702091530 struct {
+	ID        int64                  `xorm:"bigint autoincr not null unique pk" json:"-"`
+	WebhookID int64                  `xorm:"bigint not null index" json:"-"`
+	Payload   map[string]interface{} `xorm:"JSON not null" json:"-"`
+	Attempt   int                    `xorm:"int not null" json:"-"`
+	RetryAt   time.Time              `xorm:"DATETIME not null index" json:"-"`
+	Created   time.Time              `xorm:"created not null" json:"-"`
+}
+
+func (webhookRetries20230702091530) TableName() string {
+	return "webhook_retries"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230702091530",
+		Description: "Add webhook retries table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(webhookRetries20230702091530{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(webhookRetries20230702091530{})
+		},
+	})
+}
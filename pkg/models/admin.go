@@ -0,0 +1,415 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/metrics"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// AdminUser is a user as instance admins see and manage it.
+type AdminUser struct {
+	// The unique, numeric id of this user.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"user"`
+	// The full name of the user.
+	Name string `xorm:"text null" json:"name"`
+	// The username of the user. Is always unique.
+	Username string `xorm:"varchar(250) not null unique" json:"username" valid:"length(1|250)" minLength:"1" maxLength:"250"`
+	// The user's email address.
+	Email string `xorm:"varchar(250) null" json:"email" valid:"email,length(0|250)" maxLength:"250"`
+	// The password of a new user. Only used when creating a user, use the password endpoint to change it afterwards.
+	Password string `xorm:"-" json:"password,omitempty"`
+	// The status of the user. 0 = active, 1 = email confirmation required, 2 = disabled.
+	// Left unchanged when updating a user without it.
+	Status *user.Status `xorm:"default 0" json:"status"`
+	// Whether this user is an instance admin. Users listed as admins in the config are admins regardless of this flag.
+	// Left unchanged when updating a user without it.
+	IsAdmin *bool `xorm:"bool not null default false" json:"is_admin"`
+	// The authentication provider this user came from, `local` for users with a password in Vikunja.
+	Issuer string `xorm:"text null" json:"issuer"`
+
+	// A timestamp when this user was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this user was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for admin users
+func (*AdminUser) TableName() string {
+	return "users"
+}
+
+// IsInstanceAdmin checks if the auth object belongs to an instance admin.
+// The admin flag is always read from the database so revoking it takes effect immediately.
+func IsInstanceAdmin(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	u, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return false, err
+	}
+
+	return u.IsInstanceAdmin(), nil
+}
+
+func checkIsInstanceAdmin(s *xorm.Session, a web.Auth) error {
+	is, err := IsInstanceAdmin(s, a)
+	if err != nil {
+		return err
+	}
+	if !is {
+		return &user.ErrUserIsNotAdmin{UserID: a.GetID()}
+	}
+	return nil
+}
+
+// ReadAll returns all users of the instance
+// @Summary Get all users
+// @Description Returns all users of this instance. Only available to instance admins.
+// @tags admin
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search users by their username, name or email."
+// @Success 200 {array} models.AdminUser "The users"
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /admin/users [get]
+func (au *AdminUser) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if err := checkIsInstanceAdmin(s, a); err != nil {
+		return nil, 0, 0, err
+	}
+
+	cond := builder.Or(
+		db.ILIKE("username", search),
+		db.ILIKE("name", search),
+		db.ILIKE("email", search),
+	)
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	users := []*AdminUser{}
+	query := s.Where(cond).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&users)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.Where(cond).Count(&AdminUser{})
+	return users, len(users), numberOfTotalItems, err
+}
+
+// ReadOne returns one user
+// @Summary Get one user
+// @Description Returns one user of this instance. Only available to instance admins.
+// @tags admin
+// @Produce json
+// @Security JWTKeyAuth
+// @Param user path int true "User ID"
+// @Success 200 {object} models.AdminUser "The user"
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 404 {object} web.HTTPError "The user does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /admin/users/{user} [get]
+func (au *AdminUser) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	exists, err := s.Where("id = ?", au.ID).Get(au)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return user.ErrUserDoesNotExist{UserID: au.ID}
+	}
+	return nil
+}
+
+// Create creates a new user
+// @Summary Create a user
+// @Description Creates a new user with a default project, just like the user create cli command. Only available to instance admins.
+// @tags admin
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param user body models.AdminUser true "The user to create."
+// @Success 201 {object} models.AdminUser "The created user."
+// @Failure 400 {object} web.HTTPError "Invalid user object provided."
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /admin/users [put]
+func (au *AdminUser) Create(s *xorm.Session, a web.Auth) (err error) {
+	newUser, err := user.CreateUser(s, &user.User{
+		Name:     au.Name,
+		Username: au.Username,
+		Email:    au.Email,
+		Password: au.Password,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = CreateNewProjectForUser(s, newUser)
+	if err != nil {
+		return err
+	}
+
+	if au.IsAdmin != nil && *au.IsAdmin {
+		err = newUser.SetIsAdmin(s, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	*au = AdminUser{ID: newUser.ID}
+	return au.ReadOne(s, a)
+}
+
+// Update updates a user
+// @Summary Update a user
+// @Description Updates the name, username, email, status and admin flag of a user. Set the status to 2 to disable a user. The status and admin flag are only changed if they are part of the request. Only available to instance admins.
+// @tags admin
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param user path int true "User ID"
+// @Param body body models.AdminUser true "The user with updated values."
+// @Success 200 {object} models.AdminUser "The updated user."
+// @Failure 400 {object} web.HTTPError "Invalid user object provided."
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 404 {object} web.HTTPError "The user does not exist."
+// @Failure 412 {object} web.HTTPError "Admins cannot disable or demote themselves."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /admin/users/{user} [post]
+func (au *AdminUser) Update(s *xorm.Session, a web.Auth) (err error) {
+	u, err := user.GetUserWithEmail(s, &user.User{ID: au.ID})
+	if err != nil {
+		return err
+	}
+
+	statusChanged := au.Status != nil && *au.Status != u.Status
+	isAdminChanged := au.IsAdmin != nil && *au.IsAdmin != u.IsAdmin
+
+	if u.ID == a.GetID() && (statusChanged || isAdminChanged) {
+		return &user.ErrAdminCannotChangeOwnAccount{UserID: u.ID}
+	}
+
+	u.Name = au.Name
+	u.Username = au.Username
+	u.Email = au.Email
+	_, err = user.UpdateUser(s, u, true)
+	if err != nil {
+		return err
+	}
+
+	if statusChanged {
+		err = u.SetStatus(s, *au.Status)
+		if err != nil {
+			return err
+		}
+	}
+
+	if isAdminChanged {
+		err = u.SetIsAdmin(s, *au.IsAdmin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return au.ReadOne(s, a)
+}
+
+// Delete deletes a user
+// @Summary Delete a user
+// @Description Deletes a user and all their data immediately, just like the user delete cli command with --now. Only available to instance admins.
+// @tags admin
+// @Produce json
+// @Security JWTKeyAuth
+// @Param user path int true "User ID"
+// @Success 200 {object} models.Message "The user was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 404 {object} web.HTTPError "The user does not exist."
+// @Failure 412 {object} web.HTTPError "Admins cannot delete themselves."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /admin/users/{user} [delete]
+func (au *AdminUser) Delete(s *xorm.Session, a web.Auth) (err error) {
+	if au.ID == a.GetID() {
+		return &user.ErrAdminCannotChangeOwnAccount{UserID: au.ID}
+	}
+
+	u, err := user.GetUserByID(s, au.ID)
+	if err != nil {
+		return err
+	}
+
+	return DeleteUser(s, u)
+}
+
+// AdminProject is used to list all projects of the instance.
+type AdminProject struct {
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// ReadAll returns all projects of the instance
+// @Summary Get all projects
+// @Description Returns all projects of this instance with their owners, regardless of who has access to them. Only available to instance admins.
+// @tags admin
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search projects by their title."
+// @Success 200 {array} models.Project "The projects"
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /admin/projects [get]
+func (ap *AdminProject) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if err := checkIsInstanceAdmin(s, a); err != nil {
+		return nil, 0, 0, err
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	projects := []*Project{}
+	query := s.Where(db.ILIKE("title", search)).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&projects)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	ownerIDs := make([]int64, 0, len(projects))
+	for _, p := range projects {
+		ownerIDs = append(ownerIDs, p.OwnerID)
+	}
+	owners, err := user.GetUsersByIDs(s, ownerIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, p := range projects {
+		p.Owner = owners[p.OwnerID]
+	}
+
+	numberOfTotalItems, err = s.Where(db.ILIKE("title", search)).Count(&Project{})
+	return projects, len(projects), numberOfTotalItems, err
+}
+
+// AdminTeam is used to list all teams of the instance.
+type AdminTeam struct {
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// ReadAll returns all teams of the instance
+// @Summary Get all teams
+// @Description Returns all teams of this instance with their members, regardless of who is a member. Only available to instance admins.
+// @tags admin
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search teams by their name."
+// @Success 200 {array} models.Team "The teams"
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /admin/teams [get]
+func (at *AdminTeam) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if err := checkIsInstanceAdmin(s, a); err != nil {
+		return nil, 0, 0, err
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	teams := []*Team{}
+	query := s.Where(db.ILIKE("name", search)).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&teams)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addMoreInfoToTeams(s, teams)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.Where(db.ILIKE("name", search)).Count(&Team{})
+	return teams, len(teams), numberOfTotalItems, err
+}
+
+// InstanceStats holds a few numbers about the instance
+type InstanceStats struct {
+	// The total number of users.
+	Users int64 `json:"users"`
+	// The total number of projects.
+	Projects int64 `json:"projects"`
+	// The total number of tasks.
+	Tasks int64 `json:"tasks"`
+	// The total number of teams.
+	Teams int64 `json:"teams"`
+	// The number of users active within the last 30 seconds. Only available when metrics are enabled.
+	ActiveUsers int64 `json:"active_users"`
+}
+
+// GetInstanceStats returns the counts tracked for the metrics endpoint.
+// When metrics are disabled, the counts are not tracked and are taken from the database instead.
+func GetInstanceStats(s *xorm.Session) (stats *InstanceStats, err error) {
+	stats = &InstanceStats{}
+
+	counts := []struct {
+		key      string
+		counting interface{}
+		target   *int64
+	}{
+		{metrics.UserCountKey, &user.User{}, &stats.Users},
+		{metrics.ProjectCountKey, &Project{}, &stats.Projects},
+		{metrics.TaskCountKey, &Task{}, &stats.Tasks},
+		{metrics.TeamCountKey, &Team{}, &stats.Teams},
+	}
+
+	for _, c := range counts {
+		if config.MetricsEnabled.GetBool() {
+			*c.target, err = metrics.GetCount(c.key)
+		} else {
+			*c.target, err = s.Count(c.counting)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.MetricsEnabled.GetBool() {
+		stats.ActiveUsers, err = metrics.GetActiveUsersCount()
+	}
+
+	return stats, err
+}
@@ -0,0 +1,227 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminUser_ReadAll(t *testing.T) {
+	t.Run("admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		au := &AdminUser{}
+		res, count, total, err := au.ReadAll(s, &user.User{ID: 15}, "", 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 15, count)
+		assert.Equal(t, int64(15), total)
+		users := res.([]*AdminUser)
+		assert.Equal(t, "user1", users[0].Username)
+		assert.False(t, *users[0].IsAdmin)
+		assert.True(t, *users[14].IsAdmin)
+	})
+	t.Run("search", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		au := &AdminUser{}
+		res, count, _, err := au.ReadAll(s, &user.User{ID: 15}, "user1@example", 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, int64(1), res.([]*AdminUser)[0].ID)
+	})
+	t.Run("no admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		au := &AdminUser{}
+		_, _, _, err := au.ReadAll(s, &user.User{ID: 2}, "", 0, 0)
+		require.Error(t, err)
+		assert.True(t, user.IsErrUserIsNotAdmin(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		au := &AdminUser{}
+		_, _, _, err := au.ReadAll(s, &LinkSharing{ID: 1}, "", 0, 0)
+		require.Error(t, err)
+		assert.True(t, user.IsErrUserIsNotAdmin(err))
+	})
+}
+
+func TestAdminUser_Create(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	isAdmin := true
+	au := &AdminUser{
+		Username: "newadmin",
+		Email:    "newadmin@example.com",
+		Password: "12345678",
+		IsAdmin:  &isAdmin,
+	}
+	err := au.Create(s, &user.User{ID: 15})
+	require.NoError(t, err)
+	assert.NotZero(t, au.ID)
+	assert.Empty(t, au.Password)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertExists(t, "users", map[string]interface{}{
+		"id":       au.ID,
+		"username": "newadmin",
+		"is_admin": true,
+	}, false)
+	db.AssertExists(t, "projects", map[string]interface{}{
+		"owner_id": au.ID,
+	}, false)
+}
+
+func TestAdminUser_Update(t *testing.T) {
+	t.Run("disable and promote", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		var status user.Status = user.StatusDisabled
+		isAdmin := true
+		au := &AdminUser{
+			ID:       2,
+			Username: "user2",
+			Email:    "user2@example.com",
+			Name:     "User Two",
+			Status:   &status,
+			IsAdmin:  &isAdmin,
+		}
+		err := au.Update(s, &user.User{ID: 15})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":       2,
+			"name":     "User Two",
+			"status":   user.StatusDisabled,
+			"is_admin": true,
+		}, false)
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"user_id": 2,
+		})
+	})
+	t.Run("demote yourself", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		isAdmin := false
+		au := &AdminUser{
+			ID:       15,
+			Username: "user15",
+			Email:    "user15@example.com",
+			IsAdmin:  &isAdmin,
+		}
+		err := au.Update(s, &user.User{ID: 15})
+		require.Error(t, err)
+		assert.True(t, user.IsErrAdminCannotChangeOwnAccount(err))
+	})
+	t.Run("without status and admin flag", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		au := &AdminUser{
+			ID:       15,
+			Username: "user15",
+			Email:    "user15@example.com",
+			Name:     "Admin",
+		}
+		err := au.Update(s, &user.User{ID: 15})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":       15,
+			"name":     "Admin",
+			"is_admin": true,
+		}, false)
+	})
+}
+
+func TestAdminUser_Delete(t *testing.T) {
+	t.Run("yourself", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		au := &AdminUser{ID: 15}
+		err := au.Delete(s, &user.User{ID: 15})
+		require.Error(t, err)
+		assert.True(t, user.IsErrAdminCannotChangeOwnAccount(err))
+	})
+	t.Run("no admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		au := &AdminUser{ID: 1}
+		can, err := au.CanDelete(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestAdminProject_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	ap := &AdminProject{}
+	res, _, total, err := ap.ReadAll(s, &user.User{ID: 15}, "", 0, 0)
+	require.NoError(t, err)
+	projects := res.([]*Project)
+	assert.Len(t, projects, int(total))
+	for _, p := range projects {
+		require.NotNil(t, p.Owner)
+		assert.Equal(t, p.OwnerID, p.Owner.ID)
+	}
+}
+
+func TestGetInstanceStats(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	stats, err := GetInstanceStats(s)
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), stats.Users)
+	assert.Equal(t, int64(10), stats.Teams)
+	assert.NotZero(t, stats.Projects)
+	assert.NotZero(t, stats.Tasks)
+}
@@ -0,0 +1,318 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/web"
+
+	"golang.org/x/crypto/bcrypt"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+const (
+	// APITokenPrefix is the prefix of every api token. It is used to tell api tokens and jwt tokens apart.
+	APITokenPrefix = "tk_"
+
+	apiTokenSize = 40
+
+	// APITokenScopeRead allows all GET requests to a route group
+	APITokenScopeRead = "read"
+	// APITokenScopeWrite allows all PUT, POST, PATCH and DELETE requests to a route group
+	APITokenScopeWrite = "write"
+)
+
+// apiTokenRouteGroups are all route groups which can be accessed with an api token.
+var apiTokenRouteGroups = []string{
+	"filters",
+	"labels",
+	"migration",
+	"notifications",
+	"projects",
+	"subscriptions",
+	"tasks",
+	"teams",
+	"user",
+	"webhooks",
+}
+
+// apiTokenRoutes maps registered route paths to the route group they belong to. A path ending in /* matches the
+// path itself and everything below it, all other paths only match exactly. When more than one path matches a
+// route, the longest one wins.
+// Routes which are not in here, like the admin routes or routes which create or change credentials,
+// can't be accessed with an api token at all.
+var apiTokenRoutes = map[string]string{
+	"/filters/*":                           "filters",
+	"/labels/*":                            "labels",
+	"/migration/*":                         "migration",
+	"/notifications/*":                     "notifications",
+	"/projects/*":                          "projects",
+	"/projects/:project/tasks":             "tasks",
+	"/projects/:project/views/:view/tasks": "tasks",
+	"/projects/:project/views/:view/buckets/:bucket/tasks": "tasks",
+	"/projects/:project/webhooks/*":                        "webhooks",
+	"/subscriptions/*":                                     "subscriptions",
+	"/tasks/*":                                             "tasks",
+	"/teams/*":                                             "teams",
+	"/user":                                                "user",
+	"/users":                                               "user",
+	"/user/settings/avatar/*":                              "user",
+	"/user/settings/general":                               "user",
+	"/user/timezones":                                      "user",
+	"/webhooks/events":                                     "webhooks",
+}
+
+// APIToken is a personal token a user can use to access the api without logging in.
+type APIToken struct {
+	// The unique, numeric id of this api token.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"token"`
+	// A human-readable name for this token
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The actual api token. Only visible after creating the token, it is not possible to retrieve it again.
+	Token string `xorm:"-" json:"token,omitempty"`
+
+	TokenHash      string `xorm:"varchar(450) not null" json:"-"`
+	TokenLastEight string `xorm:"varchar(8) not null index" json:"-"`
+
+	// The scopes this token has access to. Each scope is a route group and either "read" or "write", for example "tasks:read".
+	Scopes []string `xorm:"JSON not null" json:"scopes" valid:"-"`
+	// The date when this token expires.
+	ExpiresAt time.Time `xorm:"not null" json:"expires_at" valid:"required"`
+
+	// A timestamp when this api token was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	OwnerID int64 `xorm:"bigint not null" json:"-"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for api tokens
+func (*APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// GetAvailableAPITokenScopes returns all scopes which can be used when creating an api token.
+func GetAvailableAPITokenScopes() []string {
+	scopes := make([]string, 0, len(apiTokenRouteGroups)*2)
+	for _, group := range apiTokenRouteGroups {
+		scopes = append(scopes, group+":"+APITokenScopeRead, group+":"+APITokenScopeWrite)
+	}
+	return scopes
+}
+
+func isValidAPITokenScope(scope string) bool {
+	group, right, has := strings.Cut(scope, ":")
+	if !has || (right != APITokenScopeRead && right != APITokenScopeWrite) {
+		return false
+	}
+
+	for _, g := range apiTokenRouteGroups {
+		if g == group {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetAPITokenRouteGroup returns the route group of a registered api route path like /api/v1/projects/:project/tasks.
+// It returns an empty string if the route does not belong to any group.
+func GetAPITokenRouteGroup(path string) string {
+	path = strings.TrimPrefix(path, "/api/v1")
+
+	var group string
+	var matched int
+	for route, g := range apiTokenRoutes {
+		if len(route) <= matched {
+			continue
+		}
+
+		base, isPrefix := strings.CutSuffix(route, "/*")
+		if path == base || (isPrefix && strings.HasPrefix(path, base+"/")) {
+			group = g
+			matched = len(route)
+		}
+	}
+
+	return group
+}
+
+// CanAccessRoute checks if the scopes of an api token allow a request with this method to a route.
+func (t *APIToken) CanAccessRoute(method, path string) bool {
+	group := GetAPITokenRouteGroup(path)
+	if group == "" {
+		return false
+	}
+
+	right := APITokenScopeWrite
+	if method == "GET" || method == "HEAD" {
+		right = APITokenScopeRead
+	}
+
+	for _, scope := range t.Scopes {
+		if scope == group+":"+right {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getAPITokenByID(s *xorm.Session, id int64) (token *APIToken, err error) {
+	token = &APIToken{}
+	exists, err := s.Where("id = ?", id).Get(token)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrAPITokenDoesNotExist{TokenID: id}
+	}
+	return
+}
+
+// GetTokenFromTokenString returns the api token matching a clear text token.
+// Expired tokens are not returned.
+func GetTokenFromTokenString(s *xorm.Session, token string) (apiToken *APIToken, err error) {
+	if !strings.HasPrefix(token, APITokenPrefix) || len(token) < 8 {
+		return nil, &ErrAPITokenInvalid{}
+	}
+
+	tokens := []*APIToken{}
+	err = s.Where("token_last_eight = ?", token[len(token)-8:]).Find(&tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tokens {
+		err = bcrypt.CompareHashAndPassword([]byte(t.TokenHash), []byte(token))
+		if err != nil {
+			continue
+		}
+
+		if t.ExpiresAt.Before(time.Now()) {
+			return nil, &ErrAPITokenInvalid{}
+		}
+
+		return t, nil
+	}
+
+	return nil, &ErrAPITokenInvalid{}
+}
+
+// Create creates a new api token
+// @Summary Create a new api token
+// @Description Create a new api token to use on behalf of the user creating it. The token will only be shown once in the response, it is not possible to retrieve it again.
+// @tags api
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param token body models.APIToken true "The token object with required fields"
+// @Success 200 {object} models.APIToken "The created token."
+// @Failure 400 {object} web.HTTPError "Invalid token object provided."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tokens [put]
+func (t *APIToken) Create(s *xorm.Session, a web.Auth) (err error) {
+	if t.ExpiresAt.Before(time.Now()) {
+		return &ErrInvalidAPITokenExpiry{}
+	}
+
+	if len(t.Scopes) == 0 {
+		return &ErrInvalidAPITokenScope{}
+	}
+	for _, scope := range t.Scopes {
+		if !isValidAPITokenScope(scope) {
+			return &ErrInvalidAPITokenScope{Scope: scope}
+		}
+	}
+
+	t.ID = 0
+	t.OwnerID = a.GetID()
+	t.Token = APITokenPrefix + utils.MakeRandomString(apiTokenSize)
+	t.TokenLastEight = t.Token[len(t.Token)-8:]
+	t.TokenHash, err = user.HashPassword(t.Token)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(t)
+	return
+}
+
+// ReadAll returns all api tokens the current user has created
+// @Summary Get all api tokens of the current user
+// @Description Returns all api tokens the current user has created. The tokens themselves are not included.
+// @tags api
+// @Accept json
+// @Produce json
+// @Param page query int false "The page number, used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of tokens per page. This parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search tokens by their title."
+// @Security JWTKeyAuth
+// @Success 200 {array} models.APIToken "The list of all tokens"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /tokens [get]
+func (t *APIToken) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.And(builder.Eq{"owner_id": a.GetID()})
+	if search != "" {
+		cond = cond.And(db.ILIKE("title", search))
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	query := s.Where(cond).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	tokens := []*APIToken{}
+	err = query.Find(&tokens)
+	if err != nil {
+		return
+	}
+
+	numberOfTotalItems, err = s.Where(cond).Count(&APIToken{})
+	return tokens, len(tokens), numberOfTotalItems, err
+}
+
+// Delete deletes an api token
+// @Summary Deletes an existing api token
+// @Description Delete any of the user's api tokens. Requests made with this token will fail afterwards.
+// @tags api
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tokenID path int true "Token ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 404 {object} web.HTTPError "The token does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tokens/{tokenID} [delete]
+func (t *APIToken) Delete(s *xorm.Session, a web.Auth) (err error) {
+	_, err = s.Where("id = ? AND owner_id = ?", t.ID, a.GetID()).Delete(&APIToken{})
+	return
+}
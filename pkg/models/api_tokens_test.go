@@ -0,0 +1,239 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAPIToken_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:read", "projects:write"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		err := token.Create(s, u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token.ID)
+		assert.Regexp(t, "^tk_", token.Token)
+		assert.NotEqual(t, token.Token, token.TokenHash)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(token.TokenHash), []byte(token.Token)))
+		db.AssertExists(t, "api_tokens", map[string]interface{}{
+			"id":               token.ID,
+			"owner_id":         1,
+			"token_last_eight": token.Token[len(token.Token)-8:],
+		}, false)
+	})
+	t.Run("invalid scope", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:delete"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		err := token.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAPITokenScope(err))
+	})
+	t.Run("no scopes", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{
+			Title:     "new token",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		err := token.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAPITokenScope(err))
+	})
+	t.Run("expiry in the past", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:read"},
+			ExpiresAt: time.Now().Add(-time.Hour),
+		}
+		err := token.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAPITokenExpiry(err))
+	})
+}
+
+func TestAPIToken_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	token := &APIToken{}
+	result, count, total, err := token.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(2), total)
+	tokens := result.([]*APIToken)
+	assert.Equal(t, int64(1), tokens[0].ID)
+	assert.Equal(t, int64(2), tokens[1].ID)
+	assert.Empty(t, tokens[0].Token)
+}
+
+func TestAPIToken_CanDelete(t *testing.T) {
+	t.Run("own token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{ID: 1}
+		can, err := token.CanDelete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("other user's token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{ID: 3}
+		can, err := token.CanDelete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{ID: 9999}
+		_, err := token.CanDelete(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenDoesNotExist(err))
+	})
+}
+
+func TestAPIToken_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	token := &APIToken{ID: 1}
+	err := token.Delete(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+	db.AssertMissing(t, "api_tokens", map[string]interface{}{
+		"id": 1,
+	})
+}
+
+func TestGetTokenFromTokenString(t *testing.T) {
+	t.Run("valid token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token, err := GetTokenFromTokenString(s, "tk_2eef46f40ebab3304919ab2e7e39993f75f29d2e")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), token.ID)
+		assert.Equal(t, int64(1), token.OwnerID)
+	})
+	t.Run("expired token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTokenFromTokenString(s, "tk_a5e6f92ddbad68f49ee2c63e52174db0235008c8")
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenInvalid(err))
+	})
+	t.Run("wrong token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTokenFromTokenString(s, "tk_00000000000000000000000000000075f29d2e")
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenInvalid(err))
+	})
+	t.Run("no prefix", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTokenFromTokenString(s, "2eef46f40ebab3304919ab2e7e39993f75f29d2e")
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenInvalid(err))
+	})
+}
+
+func TestAPIToken_CanAccessRoute(t *testing.T) {
+	token := &APIToken{Scopes: []string{"tasks:read", "projects:write"}}
+
+	assert.True(t, token.CanAccessRoute("GET", "/api/v1/tasks/:projecttask"))
+	assert.True(t, token.CanAccessRoute("GET", "/api/v1/tasks/:task/comments"))
+	assert.True(t, token.CanAccessRoute("GET", "/api/v1/projects/:project/tasks"))
+	assert.False(t, token.CanAccessRoute("POST", "/api/v1/tasks/:projecttask"))
+	assert.True(t, token.CanAccessRoute("POST", "/api/v1/projects/:project"))
+	assert.False(t, token.CanAccessRoute("GET", "/api/v1/projects"))
+	assert.False(t, token.CanAccessRoute("GET", "/api/v1/labels"))
+	assert.False(t, token.CanAccessRoute("GET", "/api/v1/tokens"))
+}
+
+func TestGetAPITokenRouteGroup(t *testing.T) {
+	assert.Equal(t, "projects", GetAPITokenRouteGroup("/api/v1/projects"))
+	assert.Equal(t, "projects", GetAPITokenRouteGroup("/api/v1/projects/:project/teams"))
+	assert.Equal(t, "tasks", GetAPITokenRouteGroup("/api/v1/projects/:project/views/:view/tasks"))
+	assert.Equal(t, "webhooks", GetAPITokenRouteGroup("/api/v1/projects/:project/webhooks/:webhook"))
+	assert.Equal(t, "tasks", GetAPITokenRouteGroup("/api/v1/tasks/:task/labels"))
+	assert.Equal(t, "user", GetAPITokenRouteGroup("/api/v1/user"))
+	assert.Equal(t, "user", GetAPITokenRouteGroup("/api/v1/user/settings/general"))
+
+	t.Run("admin routes", func(t *testing.T) {
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/admin/projects"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/admin/teams"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/admin/users/:user"))
+	})
+	t.Run("credential routes", func(t *testing.T) {
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/tokens"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/user/password"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/user/token"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/user/settings/token/caldav"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/user/settings/token/feeds"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/user/settings/totp/enable"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/user/settings/email"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/user/deletion/confirm"))
+		assert.Empty(t, GetAPITokenRouteGroup("/api/v1/shares/:share/auth"))
+	})
+}
@@ -60,6 +60,18 @@ func (bt *BulkTask) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
 		return false, err
 	}
 
+	// Moving the tasks into a different project needs write access to that project as well
+	if bt.Task.ProjectID != 0 && bt.Task.ProjectID != bt.Tasks[0].ProjectID {
+		newProject := &Project{ID: bt.Task.ProjectID}
+		can, err := newProject.CanWrite(s, a)
+		if err != nil {
+			return false, err
+		}
+		if !can {
+			return false, ErrGenericForbidden{}
+		}
+	}
+
 	// A user can update an task if he has write acces to its project
 	l := &Project{ID: bt.Tasks[0].ProjectID}
 	return l.CanWrite(s, a)
@@ -80,9 +92,20 @@ func (bt *BulkTask) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
 // @Router /tasks/bulk [post]
 func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 	for _, oldtask := range bt.Tasks {
+		original := *oldtask
+
+		if bt.Task.Done && !oldtask.Done {
+			err = checkTaskIsNotBlocked(s, oldtask)
+			if err != nil {
+				return err
+			}
+		}
 
 		// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
-		updateDone(oldtask, &bt.Task)
+		err = updateDone(oldtask, &bt.Task)
+		if err != nil {
+			return err
+		}
 
 		// Update the assignees
 		if err := oldtask.updateTaskAssignees(s, bt.Assignees, a); err != nil {
@@ -101,20 +124,59 @@ func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 			oldtask.Done = false
 		}
 
+		colsToUpdate := []string{
+			"title",
+			"description",
+			"done",
+			"due_date",
+			"reminders",
+			"repeat_after",
+			"repeat_rule",
+			"priority",
+			"start_date",
+			"end_date",
+		}
+
+		// If the task is being moved between projects, make sure to move the bucket + index as well
+		movedBetweenProjects := oldtask.ProjectID != original.ProjectID
+		if movedBetweenProjects {
+			_, err = setTaskBucket(s, oldtask, &original, false)
+			if err != nil {
+				return err
+			}
+			oldtask.Index, err = getNextTaskIndex(s, oldtask.ProjectID)
+			if err != nil {
+				return err
+			}
+			colsToUpdate = append(colsToUpdate, "project_id", "bucket_id", "index")
+		}
+
 		_, err = s.ID(oldtask.ID).
-			Cols("title",
-				"description",
-				"done",
-				"due_date",
-				"reminders",
-				"repeat_after",
-				"priority",
-				"start_date",
-				"end_date").
+			Cols(colsToUpdate...).
 			Update(oldtask)
 		if err != nil {
 			return err
 		}
+
+		if movedBetweenProjects {
+			err = removeTaskFromProjectViews(s, oldtask.ID)
+			if err != nil {
+				return err
+			}
+			err = addTaskToKanbanViewsWithOwnBuckets(s, oldtask)
+			if err != nil {
+				return err
+			}
+			err = recordTaskSyncDeletion(s, original.ProjectID, &Task{ID: oldtask.ID, UID: original.UID})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = recordTaskSyncChange(s, oldtask)
+		if err != nil {
+			return err
+		}
 	}
 
 	return
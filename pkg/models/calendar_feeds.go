@@ -0,0 +1,257 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+const (
+	// CalendarFeedKindProject is a feed with all tasks of a project
+	CalendarFeedKindProject = "project"
+	// CalendarFeedKindFilter is a feed with all tasks of a saved filter
+	CalendarFeedKindFilter = "filter"
+	// CalendarFeedKindAssigned is a feed with all tasks assigned to the user who created the feed
+	CalendarFeedKindAssigned = "assigned"
+
+	calendarFeedTokenSize = 40
+)
+
+// CalendarFeed is a secret, read-only iCalendar feed of tasks which can be subscribed to without logging in.
+type CalendarFeed struct {
+	// The unique, numeric id of this feed.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"feed"`
+	// A human-readable name for this feed. It is used as the name of the calendar.
+	// If empty, the title of the project or saved filter will be used.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(0|250)" maxLength:"250"`
+	// What tasks this feed contains. Can be "project", "filter" or "assigned".
+	Kind string `xorm:"varchar(20) not null" json:"kind"`
+	// The project whose tasks are in the feed. Only used with the "project" kind.
+	ProjectID int64 `xorm:"bigint null" json:"project_id"`
+	// The saved filter whose tasks are in the feed. Only used with the "filter" kind.
+	FilterID int64 `xorm:"bigint null" json:"filter_id"`
+	// If true, all tasks are included as todos in addition to the events for tasks with a start or due date.
+	IncludeTodos bool `xorm:"bool not null default false" json:"include_todos"`
+
+	// The secret token of this feed, used in its url. Only visible after creating the feed, it is not possible to retrieve it again.
+	Token     string `xorm:"-" json:"token,omitempty"`
+	TokenHash string `xorm:"varchar(64) not null unique" json:"-"`
+
+	// A timestamp when this feed was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	UserID int64 `xorm:"bigint not null INDEX" json:"-"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for calendar feeds
+func (*CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
+
+func hashCalendarFeedToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+func getCalendarFeedByID(s *xorm.Session, id int64) (feed *CalendarFeed, err error) {
+	feed = &CalendarFeed{}
+	exists, err := s.Where("id = ?", id).Get(feed)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrCalendarFeedDoesNotExist{}
+	}
+	return
+}
+
+// GetCalendarFeedByToken returns the calendar feed matching a clear text token.
+func GetCalendarFeedByToken(s *xorm.Session, token string) (feed *CalendarFeed, err error) {
+	if token == "" {
+		return nil, &ErrCalendarFeedDoesNotExist{}
+	}
+
+	feed = &CalendarFeed{}
+	exists, err := s.Where("token_hash = ?", hashCalendarFeedToken(token)).Get(feed)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrCalendarFeedDoesNotExist{}
+	}
+	return
+}
+
+// GetTasks returns the tasks of a feed along with the name of the calendar.
+// Access is checked with the rights the creator of the feed currently has.
+func (f *CalendarFeed) GetTasks(s *xorm.Session) (name string, tasks []*Task, err error) {
+	u, err := user.GetUserByID(s, f.UserID)
+	if err != nil {
+		if user.IsErrUserDoesNotExist(err) {
+			return "", nil, &ErrCalendarFeedDoesNotExist{}
+		}
+		return "", nil, err
+	}
+	if u.Status != user.StatusActive {
+		return "", nil, &ErrCalendarFeedDoesNotExist{}
+	}
+
+	name = f.Title
+	tc := &TaskCollection{}
+
+	switch f.Kind {
+	case CalendarFeedKindProject:
+		project, err := GetProjectSimpleByID(s, f.ProjectID)
+		if err != nil {
+			return "", nil, err
+		}
+		if name == "" {
+			name = project.Title
+		}
+		tc.ProjectID = project.ID
+	case CalendarFeedKindFilter:
+		sf, err := getSavedFilterSimpleByID(s, f.FilterID)
+		if err != nil {
+			return "", nil, err
+		}
+		if sf.OwnerID != u.ID {
+			return "", nil, &ErrCalendarFeedDoesNotExist{}
+		}
+		if name == "" {
+			name = sf.Title
+		}
+		tc.ProjectID = getProjectIDFromSavedFilterID(sf.ID)
+	case CalendarFeedKindAssigned:
+		if name == "" {
+			name = "Assigned to " + u.GetName()
+		}
+		tc.FilterBy = []string{"assignees"}
+		tc.FilterComparator = []string{"equals"}
+		tc.FilterValue = []string{u.Username}
+	default:
+		return "", nil, &ErrInvalidCalendarFeedKind{Kind: f.Kind}
+	}
+
+	result, _, _, err := tc.ReadAll(s, u, "", -1, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return name, result.([]*Task), nil
+}
+
+// Create creates a new calendar feed
+// @Summary Create a new calendar feed
+// @Description Create a new read-only iCalendar feed for a project, a saved filter or all tasks assigned to the current user. The token will only be shown once in the response, it is not possible to retrieve it again.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param feed body models.CalendarFeed true "The feed object with required fields"
+// @Success 200 {object} models.CalendarFeed "The created feed."
+// @Failure 400 {object} web.HTTPError "Invalid feed object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project or saved filter."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/settings/token/feeds [put]
+func (f *CalendarFeed) Create(s *xorm.Session, a web.Auth) (err error) {
+	switch f.Kind {
+	case CalendarFeedKindProject:
+		f.FilterID = 0
+	case CalendarFeedKindFilter:
+		f.ProjectID = 0
+	case CalendarFeedKindAssigned:
+		f.ProjectID = 0
+		f.FilterID = 0
+	}
+
+	f.ID = 0
+	f.UserID = a.GetID()
+	f.Token = utils.MakeRandomString(calendarFeedTokenSize)
+	f.TokenHash = hashCalendarFeedToken(f.Token)
+
+	_, err = s.Insert(f)
+	return
+}
+
+// ReadAll returns all calendar feeds of the current user
+// @Summary Get all calendar feeds of the current user
+// @Description Returns all calendar feeds the current user has created. The tokens themselves are not included.
+// @tags user
+// @Accept json
+// @Produce json
+// @Param page query int false "The page number, used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of feeds per page. This parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search feeds by their title."
+// @Security JWTKeyAuth
+// @Success 200 {array} models.CalendarFeed "The list of all feeds"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /user/settings/token/feeds [get]
+func (f *CalendarFeed) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.And(builder.Eq{"user_id": a.GetID()})
+	if search != "" {
+		cond = cond.And(db.ILIKE("title", search))
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	query := s.Where(cond).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	feeds := []*CalendarFeed{}
+	err = query.Find(&feeds)
+	if err != nil {
+		return
+	}
+
+	numberOfTotalItems, err = s.Where(cond).Count(&CalendarFeed{})
+	return feeds, len(feeds), numberOfTotalItems, err
+}
+
+// Delete deletes a calendar feed
+// @Summary Revokes a calendar feed
+// @Description Delete one of the user's calendar feeds. Calendar apps subscribed to it won't get any tasks afterwards.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param feedID path int true "Feed ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 404 {object} web.HTTPError "The feed does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/settings/token/feeds/{feedID} [delete]
+func (f *CalendarFeed) Delete(s *xorm.Session, a web.Auth) (err error) {
+	_, err = s.Where("id = ? AND user_id = ?", f.ID, a.GetID()).Delete(&CalendarFeed{})
+	return
+}
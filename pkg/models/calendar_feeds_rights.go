@@ -0,0 +1,61 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanDelete checks if a user can delete a calendar feed
+func (f *CalendarFeed) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	feed, err := getCalendarFeedByID(s, f.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return feed.UserID == a.GetID(), nil
+}
+
+// CanCreate checks if a user can create a calendar feed with the tasks of the project or saved filter of the feed
+func (f *CalendarFeed) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	switch f.Kind {
+	case CalendarFeedKindProject:
+		// Pseudo projects like saved filters or favorites have ids < 1
+		if f.ProjectID < 1 {
+			return false, ErrProjectDoesNotExist{ID: f.ProjectID}
+		}
+		project := &Project{ID: f.ProjectID}
+		can, _, err := project.CanRead(s, a)
+		return can, err
+	case CalendarFeedKindFilter:
+		sf := &SavedFilter{ID: f.FilterID}
+		can, _, err := sf.CanRead(s, a)
+		return can, err
+	case CalendarFeedKindAssigned:
+		return true, nil
+	default:
+		return false, &ErrInvalidCalendarFeedKind{Kind: f.Kind}
+	}
+}
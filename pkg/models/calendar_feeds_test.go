@@ -0,0 +1,253 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarFeed_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{
+			Kind:      CalendarFeedKindProject,
+			ProjectID: 1,
+			FilterID:  1,
+		}
+		can, err := feed.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = feed.Create(s, u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, feed.ID)
+		assert.NotEmpty(t, feed.Token)
+		assert.Equal(t, hashCalendarFeedToken(feed.Token), feed.TokenHash)
+		db.AssertExists(t, "calendar_feeds", map[string]interface{}{
+			"id":         feed.ID,
+			"user_id":    1,
+			"kind":       CalendarFeedKindProject,
+			"project_id": 1,
+			"filter_id":  0,
+		}, false)
+	})
+	t.Run("assigned tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{
+			Kind:      CalendarFeedKindAssigned,
+			ProjectID: 1,
+		}
+		can, err := feed.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = feed.Create(s, u)
+		assert.NoError(t, err)
+		db.AssertExists(t, "calendar_feeds", map[string]interface{}{
+			"id":         feed.ID,
+			"kind":       CalendarFeedKindAssigned,
+			"project_id": 0,
+		}, false)
+	})
+	t.Run("invalid kind", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{Kind: "everything"}
+		_, err := feed.CanCreate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCalendarFeedKind(err))
+	})
+	t.Run("project without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{
+			Kind:      CalendarFeedKindProject,
+			ProjectID: 1,
+		}
+		can, err := feed.CanCreate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("pseudo project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{
+			Kind:      CalendarFeedKindProject,
+			ProjectID: FavoritesPseudoProject.ID,
+		}
+		_, err := feed.CanCreate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrProjectDoesNotExist(err))
+	})
+	t.Run("saved filter of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{
+			Kind:     CalendarFeedKindFilter,
+			FilterID: 1,
+		}
+		can, err := feed.CanCreate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestCalendarFeed_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	feed := &CalendarFeed{}
+	feeds, _, _, err := feed.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	assert.NoError(t, err)
+	assert.Len(t, feeds, 3)
+	for _, f := range feeds.([]*CalendarFeed) {
+		assert.Empty(t, f.Token)
+	}
+}
+
+func TestCalendarFeed_Delete(t *testing.T) {
+	t.Run("own feed", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		feed := &CalendarFeed{ID: 1}
+		can, err := feed.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = feed.Delete(s, u)
+		assert.NoError(t, err)
+		db.AssertMissing(t, "calendar_feeds", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("feed of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed := &CalendarFeed{ID: 4}
+		can, err := feed.CanDelete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestGetCalendarFeedByToken(t *testing.T) {
+	t.Run("valid token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed, err := GetCalendarFeedByToken(s, "calendarfeedtoken1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), feed.ID)
+	})
+	t.Run("invalid token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetCalendarFeedByToken(s, "invalidtoken")
+		assert.Error(t, err)
+		assert.True(t, IsErrCalendarFeedDoesNotExist(err))
+	})
+}
+
+func TestCalendarFeed_GetTasks(t *testing.T) {
+	t.Run("project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed, err := getCalendarFeedByID(s, 1)
+		assert.NoError(t, err)
+		name, tasks, err := feed.GetTasks(s)
+		assert.NoError(t, err)
+		assert.Equal(t, "Project feed", name)
+		assert.NotEmpty(t, tasks)
+		for _, task := range tasks {
+			assert.Equal(t, int64(1), task.ProjectID)
+		}
+	})
+	t.Run("saved filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed, err := getCalendarFeedByID(s, 3)
+		assert.NoError(t, err)
+		name, _, err := feed.GetTasks(s)
+		assert.NoError(t, err)
+		assert.Equal(t, "testfilter1", name)
+	})
+	t.Run("assigned tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed, err := getCalendarFeedByID(s, 2)
+		assert.NoError(t, err)
+		_, tasks, err := feed.GetTasks(s)
+		assert.NoError(t, err)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, int64(30), tasks[0].ID)
+	})
+	t.Run("no access to the project anymore", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed, err := getCalendarFeedByID(s, 4)
+		assert.NoError(t, err)
+		_, _, err = feed.GetTasks(s)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToProject(err))
+	})
+	t.Run("inactive user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed, err := getCalendarFeedByID(s, 1)
+		assert.NoError(t, err)
+		feed.UserID = 4
+		_, _, err = feed.GetTasks(s)
+		assert.Error(t, err)
+		assert.True(t, IsErrCalendarFeedDoesNotExist(err))
+	})
+}
@@ -347,6 +347,63 @@ func (err *ErrProjectCannotHaveACyclicRelationship) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrInvalidBurndownRange represents an error where the date range of a burndown is invalid
+type ErrInvalidBurndownRange struct {
+	ProjectID int64
+	From      string
+	To        string
+}
+
+// IsErrInvalidBurndownRange checks if an error is ErrInvalidBurndownRange.
+func IsErrInvalidBurndownRange(err error) bool {
+	_, ok := err.(*ErrInvalidBurndownRange)
+	return ok
+}
+
+func (err *ErrInvalidBurndownRange) Error() string {
+	return fmt.Sprintf("Burndown date range is invalid [ProjectID: %d, From: %s, To: %s]", err.ProjectID, err.From, err.To)
+}
+
+// ErrCodeInvalidBurndownRange holds the unique world-error code of this error
+const ErrCodeInvalidBurndownRange = 3012
+
+// HTTPError holds the http error description
+func (err *ErrInvalidBurndownRange) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidBurndownRange,
+		Message:  "The burndown date range is invalid. Both dates need to be valid, from needs to be before to and the range cannot be longer than a year.",
+	}
+}
+
+// ErrInvalidProjectStatsWeeks represents an error where the number of weeks of project stats is out of range
+type ErrInvalidProjectStatsWeeks struct {
+	ProjectID int64
+	Weeks     int
+}
+
+// IsErrInvalidProjectStatsWeeks checks if an error is ErrInvalidProjectStatsWeeks.
+func IsErrInvalidProjectStatsWeeks(err error) bool {
+	_, ok := err.(*ErrInvalidProjectStatsWeeks)
+	return ok
+}
+
+func (err *ErrInvalidProjectStatsWeeks) Error() string {
+	return fmt.Sprintf("Project stats weeks are out of range [ProjectID: %d, Weeks: %d]", err.ProjectID, err.Weeks)
+}
+
+// ErrCodeInvalidProjectStatsWeeks holds the unique world-error code of this error
+const ErrCodeInvalidProjectStatsWeeks = 3013
+
+// HTTPError holds the http error description
+func (err *ErrInvalidProjectStatsWeeks) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidProjectStatsWeeks,
+		Message:  fmt.Sprintf("The number of weeks needs to be between 1 and %d.", projectStatsMaxWeeks),
+	}
+}
+
 // ==============
 // Task errors
 // ==============
@@ -938,6 +995,147 @@ func (err ErrReminderRelativeToMissing) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrInvalidTaskFilterExpression represents an error where a filter expression could not be parsed
+type ErrInvalidTaskFilterExpression struct {
+	Expression string
+	Position   int
+	Message    string
+}
+
+// IsErrInvalidTaskFilterExpression checks if an error is ErrInvalidTaskFilterExpression.
+func IsErrInvalidTaskFilterExpression(err error) bool {
+	_, ok := err.(ErrInvalidTaskFilterExpression)
+	return ok
+}
+
+func (err ErrInvalidTaskFilterExpression) Error() string {
+	return fmt.Sprintf("Task filter expression is invalid [Expression: %s, Position: %d, Message: %s]", err.Expression, err.Position, err.Message)
+}
+
+// ErrCodeInvalidTaskFilterExpression holds the unique world-error code of this error
+const ErrCodeInvalidTaskFilterExpression = 4023
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskFilterExpression) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskFilterExpression,
+		Message:  fmt.Sprintf("The filter is invalid at position %d: %s", err.Position, err.Message),
+	}
+}
+
+// ErrInvalidRepeatRule represents an error where a task has an invalid recurrence rule
+type ErrInvalidRepeatRule struct {
+	Rule    string
+	Message string
+}
+
+// IsErrInvalidRepeatRule checks if an error is ErrInvalidRepeatRule.
+func IsErrInvalidRepeatRule(err error) bool {
+	_, ok := err.(ErrInvalidRepeatRule)
+	return ok
+}
+
+func (err ErrInvalidRepeatRule) Error() string {
+	return fmt.Sprintf("Task repeat rule is invalid [Rule: %s, Message: %s]", err.Rule, err.Message)
+}
+
+// ErrCodeInvalidRepeatRule holds the unique world-error code of this error
+const ErrCodeInvalidRepeatRule = 4024
+
+// HTTPError holds the http error description
+func (err ErrInvalidRepeatRule) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidRepeatRule,
+		Message:  "The repeat rule is invalid: " + err.Message,
+	}
+}
+
+// ErrTaskIsBlocked represents an error where a task is marked done while other tasks still block it
+type ErrTaskIsBlocked struct {
+	TaskID     int64
+	BlockerIDs []int64
+}
+
+// IsErrTaskIsBlocked checks if an error is ErrTaskIsBlocked.
+func IsErrTaskIsBlocked(err error) bool {
+	_, ok := err.(ErrTaskIsBlocked)
+	return ok
+}
+
+func (err ErrTaskIsBlocked) Error() string {
+	return fmt.Sprintf("Task is blocked by undone tasks [TaskID: %d, BlockerIDs: %v]", err.TaskID, err.BlockerIDs)
+}
+
+// ErrCodeTaskIsBlocked holds the unique world-error code of this error
+const ErrCodeTaskIsBlocked = 4025
+
+// HTTPError holds the http error description
+func (err ErrTaskIsBlocked) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskIsBlocked,
+		Message:  "This task cannot be marked as done because it is blocked by tasks which are not done yet.",
+	}
+}
+
+// ErrRelationCreatesCycle represents an error where a new blocking or precedes relation would create a cycle
+type ErrRelationCreatesCycle struct {
+	TaskID      int64
+	OtherTaskID int64
+	Kind        RelationKind
+}
+
+// IsErrRelationCreatesCycle checks if an error is ErrRelationCreatesCycle.
+func IsErrRelationCreatesCycle(err error) bool {
+	_, ok := err.(ErrRelationCreatesCycle)
+	return ok
+}
+
+func (err ErrRelationCreatesCycle) Error() string {
+	return fmt.Sprintf("Task relation would create a cycle [TaskID: %d, OtherTaskID: %d, Kind: %s]", err.TaskID, err.OtherTaskID, err.Kind)
+}
+
+// ErrCodeRelationCreatesCycle holds the unique world-error code of this error
+const ErrCodeRelationCreatesCycle = 4026
+
+// HTTPError holds the http error description
+func (err ErrRelationCreatesCycle) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeRelationCreatesCycle,
+		Message:  "This relation would create a circular dependency between tasks.",
+	}
+}
+
+// ErrInvalidTaskEstimate represents an error where the story points or the time estimate of a task are negative
+type ErrInvalidTaskEstimate struct {
+	TaskID int64
+}
+
+// IsErrInvalidTaskEstimate checks if an error is ErrInvalidTaskEstimate.
+func IsErrInvalidTaskEstimate(err error) bool {
+	_, ok := err.(ErrInvalidTaskEstimate)
+	return ok
+}
+
+func (err ErrInvalidTaskEstimate) Error() string {
+	return fmt.Sprintf("Task estimate is invalid [TaskID: %d]", err.TaskID)
+}
+
+// ErrCodeInvalidTaskEstimate holds the unique world-error code of this error
+const ErrCodeInvalidTaskEstimate = 4027
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskEstimate) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskEstimate,
+		Message:  "The story points and time estimate of a task cannot be negative.",
+	}
+}
+
 // ============
 // Team errors
 // ============
@@ -1084,6 +1282,29 @@ func (err ErrTeamDoesNotHaveAccessToProject) HTTPError() web.HTTPError {
 	return web.HTTPError{HTTPCode: http.StatusForbidden, Code: ErrCodeTeamDoesNotHaveAccessToProject, Message: "This team does not have access to the project."}
 }
 
+// ErrExternalTeamMembersCannotBeChanged represents an error where a user wants to change the members of a team managed by an external auth provider
+type ErrExternalTeamMembersCannotBeChanged struct {
+	TeamID int64
+}
+
+// IsErrExternalTeamMembersCannotBeChanged checks if an error is ErrExternalTeamMembersCannotBeChanged.
+func IsErrExternalTeamMembersCannotBeChanged(err error) bool {
+	_, ok := err.(ErrExternalTeamMembersCannotBeChanged)
+	return ok
+}
+
+func (err ErrExternalTeamMembersCannotBeChanged) Error() string {
+	return fmt.Sprintf("The members of an external team cannot be changed. [Team ID: %d]", err.TeamID)
+}
+
+// ErrCodeExternalTeamMembersCannotBeChanged holds the unique world-error code of this error
+const ErrCodeExternalTeamMembersCannotBeChanged = 6008
+
+// HTTPError holds the http error description
+func (err ErrExternalTeamMembersCannotBeChanged) HTTPError() web.HTTPError {
+	return web.HTTPError{HTTPCode: http.StatusPreconditionFailed, Code: ErrCodeExternalTeamMembersCannotBeChanged, Message: "The members of this team are managed by an external auth provider and cannot be changed in Vikunja."}
+}
+
 // ====================
 // User <-> Project errors
 // ====================
@@ -1601,3 +1822,921 @@ func (err ErrLinkShareTokenInvalid) HTTPError() web.HTTPError {
 		Message:  "The provided link share token is invalid.",
 	}
 }
+
+// ErrLinkShareExpired represents an error where a link share is used after its expiry date
+type ErrLinkShareExpired struct {
+	ShareID int64
+}
+
+// IsErrLinkShareExpired checks if an error is ErrLinkShareExpired.
+func IsErrLinkShareExpired(err error) bool {
+	_, ok := err.(*ErrLinkShareExpired)
+	return ok
+}
+
+func (err *ErrLinkShareExpired) Error() string {
+	return fmt.Sprintf("Link Share has expired [ShareID: %d]", err.ShareID)
+}
+
+// ErrCodeLinkShareExpired holds the unique world-error code of this error
+const ErrCodeLinkShareExpired = 13004
+
+// HTTPError holds the http error description
+func (err ErrLinkShareExpired) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeLinkShareExpired,
+		Message:  "This link share has expired.",
+	}
+}
+
+// ErrLinkShareUsageLimitReached represents an error where a link share was used as often as it is allowed to
+type ErrLinkShareUsageLimitReached struct {
+	ShareID int64
+}
+
+// IsErrLinkShareUsageLimitReached checks if an error is ErrLinkShareUsageLimitReached.
+func IsErrLinkShareUsageLimitReached(err error) bool {
+	_, ok := err.(*ErrLinkShareUsageLimitReached)
+	return ok
+}
+
+func (err *ErrLinkShareUsageLimitReached) Error() string {
+	return fmt.Sprintf("Link Share has reached its usage limit [ShareID: %d]", err.ShareID)
+}
+
+// ErrCodeLinkShareUsageLimitReached holds the unique world-error code of this error
+const ErrCodeLinkShareUsageLimitReached = 13005
+
+// HTTPError holds the http error description
+func (err ErrLinkShareUsageLimitReached) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeLinkShareUsageLimitReached,
+		Message:  "This link share has been used as often as it is allowed to.",
+	}
+}
+
+// ==============
+// Webhook errors
+// ==============
+
+// ErrWebhookDoesNotExist represents an error where a webhook does not exist
+type ErrWebhookDoesNotExist struct {
+	WebhookID int64
+}
+
+// IsErrWebhookDoesNotExist checks if an error is ErrWebhookDoesNotExist.
+func IsErrWebhookDoesNotExist(err error) bool {
+	_, ok := err.(*ErrWebhookDoesNotExist)
+	return ok
+}
+
+func (err *ErrWebhookDoesNotExist) Error() string {
+	return fmt.Sprintf("Webhook does not exist [WebhookID: %d]", err.WebhookID)
+}
+
+// ErrCodeWebhookDoesNotExist holds the unique world-error code of this error
+const ErrCodeWebhookDoesNotExist = 14001
+
+// HTTPError holds the http error description
+func (err ErrWebhookDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeWebhookDoesNotExist,
+		Message:  "This webhook does not exist.",
+	}
+}
+
+// ErrInvalidWebhookEvent represents an error where a webhook event is not available
+type ErrInvalidWebhookEvent struct {
+	EventName string
+}
+
+// IsErrInvalidWebhookEvent checks if an error is ErrInvalidWebhookEvent.
+func IsErrInvalidWebhookEvent(err error) bool {
+	_, ok := err.(*ErrInvalidWebhookEvent)
+	return ok
+}
+
+func (err *ErrInvalidWebhookEvent) Error() string {
+	return fmt.Sprintf("Webhook event is invalid [EventName: %s]", err.EventName)
+}
+
+// ErrCodeInvalidWebhookEvent holds the unique world-error code of this error
+const ErrCodeInvalidWebhookEvent = 14002
+
+// HTTPError holds the http error description
+func (err ErrInvalidWebhookEvent) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidWebhookEvent,
+		Message:  "The webhook needs at least one event and all events must be available for webhooks.",
+	}
+}
+
+// ErrInvalidWebhookTargetURL represents an error where a webhook target url is invalid
+type ErrInvalidWebhookTargetURL struct {
+	TargetURL string
+}
+
+// IsErrInvalidWebhookTargetURL checks if an error is ErrInvalidWebhookTargetURL.
+func IsErrInvalidWebhookTargetURL(err error) bool {
+	_, ok := err.(*ErrInvalidWebhookTargetURL)
+	return ok
+}
+
+func (err *ErrInvalidWebhookTargetURL) Error() string {
+	return fmt.Sprintf("Webhook target url is invalid [TargetURL: %s]", err.TargetURL)
+}
+
+// ErrCodeInvalidWebhookTargetURL holds the unique world-error code of this error
+const ErrCodeInvalidWebhookTargetURL = 14003
+
+// HTTPError holds the http error description
+func (err ErrInvalidWebhookTargetURL) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidWebhookTargetURL,
+		Message:  "The webhook target url must be a http or https url.",
+	}
+}
+
+// ErrWebhookDeliveryFailed represents an error where the target of a webhook responded with an error status code
+type ErrWebhookDeliveryFailed struct {
+	WebhookID  int64
+	StatusCode int
+}
+
+// IsErrWebhookDeliveryFailed checks if an error is ErrWebhookDeliveryFailed.
+func IsErrWebhookDeliveryFailed(err error) bool {
+	_, ok := err.(*ErrWebhookDeliveryFailed)
+	return ok
+}
+
+func (err *ErrWebhookDeliveryFailed) Error() string {
+	return fmt.Sprintf("Webhook target responded with an error [WebhookID: %d, StatusCode: %d]", err.WebhookID, err.StatusCode)
+}
+
+// ================
+// API Token errors
+// ================
+
+// ErrAPITokenDoesNotExist represents an error where an api token does not exist
+type ErrAPITokenDoesNotExist struct {
+	TokenID int64
+}
+
+// IsErrAPITokenDoesNotExist checks if an error is ErrAPITokenDoesNotExist.
+func IsErrAPITokenDoesNotExist(err error) bool {
+	_, ok := err.(*ErrAPITokenDoesNotExist)
+	return ok
+}
+
+func (err *ErrAPITokenDoesNotExist) Error() string {
+	return fmt.Sprintf("API token does not exist [TokenID: %d]", err.TokenID)
+}
+
+// ErrCodeAPITokenDoesNotExist holds the unique world-error code of this error
+const ErrCodeAPITokenDoesNotExist = 15001
+
+// HTTPError holds the http error description
+func (err ErrAPITokenDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeAPITokenDoesNotExist,
+		Message:  "This api token does not exist.",
+	}
+}
+
+// ErrInvalidAPITokenScope represents an error where an api token scope is invalid
+type ErrInvalidAPITokenScope struct {
+	Scope string
+}
+
+// IsErrInvalidAPITokenScope checks if an error is ErrInvalidAPITokenScope.
+func IsErrInvalidAPITokenScope(err error) bool {
+	_, ok := err.(*ErrInvalidAPITokenScope)
+	return ok
+}
+
+func (err *ErrInvalidAPITokenScope) Error() string {
+	return fmt.Sprintf("API token scope is invalid [Scope: %s]", err.Scope)
+}
+
+// ErrCodeInvalidAPITokenScope holds the unique world-error code of this error
+const ErrCodeInvalidAPITokenScope = 15002
+
+// HTTPError holds the http error description
+func (err ErrInvalidAPITokenScope) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidAPITokenScope,
+		Message:  "The api token needs at least one scope and all scopes must be available.",
+	}
+}
+
+// ErrInvalidAPITokenExpiry represents an error where the expiry date of an api token is invalid
+type ErrInvalidAPITokenExpiry struct{}
+
+// IsErrInvalidAPITokenExpiry checks if an error is ErrInvalidAPITokenExpiry.
+func IsErrInvalidAPITokenExpiry(err error) bool {
+	_, ok := err.(*ErrInvalidAPITokenExpiry)
+	return ok
+}
+
+func (err *ErrInvalidAPITokenExpiry) Error() string {
+	return "API token expiry date is invalid"
+}
+
+// ErrCodeInvalidAPITokenExpiry holds the unique world-error code of this error
+const ErrCodeInvalidAPITokenExpiry = 15003
+
+// HTTPError holds the http error description
+func (err ErrInvalidAPITokenExpiry) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidAPITokenExpiry,
+		Message:  "The api token expiry date must be in the future.",
+	}
+}
+
+// ErrAPITokenInvalid represents an error where an api token is invalid, expired or does not exist
+type ErrAPITokenInvalid struct{}
+
+// IsErrAPITokenInvalid checks if an error is ErrAPITokenInvalid.
+func IsErrAPITokenInvalid(err error) bool {
+	_, ok := err.(*ErrAPITokenInvalid)
+	return ok
+}
+
+func (err *ErrAPITokenInvalid) Error() string {
+	return "API token is invalid"
+}
+
+// ErrCodeAPITokenInvalid holds the unique world-error code of this error
+const ErrCodeAPITokenInvalid = 15004
+
+// HTTPError holds the http error description
+func (err ErrAPITokenInvalid) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusUnauthorized,
+		Code:     ErrCodeAPITokenInvalid,
+		Message:  "The provided api token is invalid or expired.",
+	}
+}
+
+// ===================
+// Project view errors
+// ===================
+
+// ErrProjectViewDoesNotExist represents an error where a project view does not exist
+type ErrProjectViewDoesNotExist struct {
+	ProjectViewID int64
+}
+
+// IsErrProjectViewDoesNotExist checks if an error is ErrProjectViewDoesNotExist.
+func IsErrProjectViewDoesNotExist(err error) bool {
+	_, ok := err.(*ErrProjectViewDoesNotExist)
+	return ok
+}
+
+func (err *ErrProjectViewDoesNotExist) Error() string {
+	return fmt.Sprintf("Project view does not exist [ProjectViewID: %d]", err.ProjectViewID)
+}
+
+// ErrCodeProjectViewDoesNotExist holds the unique world-error code of this error
+const ErrCodeProjectViewDoesNotExist = 16001
+
+// HTTPError holds the http error description
+func (err ErrProjectViewDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeProjectViewDoesNotExist,
+		Message:  "This project view does not exist.",
+	}
+}
+
+// ErrInvalidProjectViewKind represents an error where a project view kind is invalid
+type ErrInvalidProjectViewKind struct {
+	ViewKind ProjectViewKind
+}
+
+// IsErrInvalidProjectViewKind checks if an error is ErrInvalidProjectViewKind.
+func IsErrInvalidProjectViewKind(err error) bool {
+	_, ok := err.(*ErrInvalidProjectViewKind)
+	return ok
+}
+
+func (err *ErrInvalidProjectViewKind) Error() string {
+	return fmt.Sprintf("Project view kind is invalid [ViewKind: %d]", err.ViewKind)
+}
+
+// ErrCodeInvalidProjectViewKind holds the unique world-error code of this error
+const ErrCodeInvalidProjectViewKind = 16002
+
+// HTTPError holds the http error description
+func (err ErrInvalidProjectViewKind) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidProjectViewKind,
+		Message:  "The project view kind is invalid.",
+	}
+}
+
+// ErrCannotDeleteDefaultProjectView represents an error where a default project view is being deleted
+type ErrCannotDeleteDefaultProjectView struct {
+	ProjectViewID int64
+}
+
+// IsErrCannotDeleteDefaultProjectView checks if an error is ErrCannotDeleteDefaultProjectView.
+func IsErrCannotDeleteDefaultProjectView(err error) bool {
+	_, ok := err.(*ErrCannotDeleteDefaultProjectView)
+	return ok
+}
+
+func (err *ErrCannotDeleteDefaultProjectView) Error() string {
+	return fmt.Sprintf("Cannot delete default project view [ProjectViewID: %d]", err.ProjectViewID)
+}
+
+// ErrCodeCannotDeleteDefaultProjectView holds the unique world-error code of this error
+const ErrCodeCannotDeleteDefaultProjectView = 16003
+
+// HTTPError holds the http error description
+func (err ErrCannotDeleteDefaultProjectView) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeCannotDeleteDefaultProjectView,
+		Message:  "You cannot delete one of the default views of a project.",
+	}
+}
+
+// ErrProjectViewIsNotKanban represents an error where kanban buckets are used with a view which is not a kanban view
+type ErrProjectViewIsNotKanban struct {
+	ProjectViewID int64
+}
+
+// IsErrProjectViewIsNotKanban checks if an error is ErrProjectViewIsNotKanban.
+func IsErrProjectViewIsNotKanban(err error) bool {
+	_, ok := err.(*ErrProjectViewIsNotKanban)
+	return ok
+}
+
+func (err *ErrProjectViewIsNotKanban) Error() string {
+	return fmt.Sprintf("Project view is not a kanban view [ProjectViewID: %d]", err.ProjectViewID)
+}
+
+// ErrCodeProjectViewIsNotKanban holds the unique world-error code of this error
+const ErrCodeProjectViewIsNotKanban = 16004
+
+// HTTPError holds the http error description
+func (err ErrProjectViewIsNotKanban) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeProjectViewIsNotKanban,
+		Message:  "Kanban buckets can only be used with kanban views.",
+	}
+}
+
+// ====================
+// Time tracking errors
+// ====================
+
+// ErrTaskTimeEntryDoesNotExist represents an error where a time entry does not exist
+type ErrTaskTimeEntryDoesNotExist struct {
+	ID     int64
+	TaskID int64
+}
+
+// IsErrTaskTimeEntryDoesNotExist checks if an error is ErrTaskTimeEntryDoesNotExist.
+func IsErrTaskTimeEntryDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTaskTimeEntryDoesNotExist)
+	return ok
+}
+
+func (err *ErrTaskTimeEntryDoesNotExist) Error() string {
+	return fmt.Sprintf("Time entry does not exist [ID: %d, TaskID: %d]", err.ID, err.TaskID)
+}
+
+// ErrCodeTaskTimeEntryDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskTimeEntryDoesNotExist = 17001
+
+// HTTPError holds the http error description
+func (err ErrTaskTimeEntryDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskTimeEntryDoesNotExist,
+		Message:  "This time entry does not exist.",
+	}
+}
+
+// ErrInvalidTaskTimeEntry represents an error where the dates of a time entry are invalid
+type ErrInvalidTaskTimeEntry struct {
+	TimeEntryID int64
+	Reason      string
+}
+
+// IsErrInvalidTaskTimeEntry checks if an error is ErrInvalidTaskTimeEntry.
+func IsErrInvalidTaskTimeEntry(err error) bool {
+	_, ok := err.(*ErrInvalidTaskTimeEntry)
+	return ok
+}
+
+func (err *ErrInvalidTaskTimeEntry) Error() string {
+	return fmt.Sprintf("Time entry is invalid [TimeEntryID: %d, Reason: %s]", err.TimeEntryID, err.Reason)
+}
+
+// ErrCodeInvalidTaskTimeEntry holds the unique world-error code of this error
+const ErrCodeInvalidTaskTimeEntry = 17002
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskTimeEntry) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskTimeEntry,
+		Message:  "The time entry is invalid: " + err.Reason + ".",
+	}
+}
+
+// ErrTimerAlreadyRunning represents an error where a user tries to start a timer while another one is still running
+type ErrTimerAlreadyRunning struct {
+	TimeEntryID int64
+	TaskID      int64
+}
+
+// IsErrTimerAlreadyRunning checks if an error is ErrTimerAlreadyRunning.
+func IsErrTimerAlreadyRunning(err error) bool {
+	_, ok := err.(*ErrTimerAlreadyRunning)
+	return ok
+}
+
+func (err *ErrTimerAlreadyRunning) Error() string {
+	return fmt.Sprintf("Timer is already running [TimeEntryID: %d, TaskID: %d]", err.TimeEntryID, err.TaskID)
+}
+
+// ErrCodeTimerAlreadyRunning holds the unique world-error code of this error
+const ErrCodeTimerAlreadyRunning = 17003
+
+// HTTPError holds the http error description
+func (err ErrTimerAlreadyRunning) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTimerAlreadyRunning,
+		Message:  fmt.Sprintf("You already have a running timer on task %d. Stop it before starting a new one.", err.TaskID),
+	}
+}
+
+// ErrNoRunningTimer represents an error where a user tries to stop a timer which is not running
+type ErrNoRunningTimer struct {
+	TaskID int64
+	UserID int64
+}
+
+// IsErrNoRunningTimer checks if an error is ErrNoRunningTimer.
+func IsErrNoRunningTimer(err error) bool {
+	_, ok := err.(*ErrNoRunningTimer)
+	return ok
+}
+
+func (err *ErrNoRunningTimer) Error() string {
+	return fmt.Sprintf("No timer is running [TaskID: %d, UserID: %d]", err.TaskID, err.UserID)
+}
+
+// ErrCodeNoRunningTimer holds the unique world-error code of this error
+const ErrCodeNoRunningTimer = 17004
+
+// HTTPError holds the http error description
+func (err ErrNoRunningTimer) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeNoRunningTimer,
+		Message:  "You don't have a running timer on this task.",
+	}
+}
+
+// ===================
+// Custom field errors
+// ===================
+
+// ErrProjectCustomFieldDoesNotExist represents an error where a custom field does not exist
+type ErrProjectCustomFieldDoesNotExist struct {
+	CustomFieldID int64
+}
+
+// IsErrProjectCustomFieldDoesNotExist checks if an error is ErrProjectCustomFieldDoesNotExist.
+func IsErrProjectCustomFieldDoesNotExist(err error) bool {
+	_, ok := err.(*ErrProjectCustomFieldDoesNotExist)
+	return ok
+}
+
+func (err *ErrProjectCustomFieldDoesNotExist) Error() string {
+	return fmt.Sprintf("Custom field does not exist [CustomFieldID: %d]", err.CustomFieldID)
+}
+
+// ErrCodeProjectCustomFieldDoesNotExist holds the unique world-error code of this error
+const ErrCodeProjectCustomFieldDoesNotExist = 18001
+
+// HTTPError holds the http error description
+func (err ErrProjectCustomFieldDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeProjectCustomFieldDoesNotExist,
+		Message:  "This custom field does not exist.",
+	}
+}
+
+// ErrInvalidProjectCustomFieldType represents an error where a custom field has an invalid type
+type ErrInvalidProjectCustomFieldType struct {
+	FieldType ProjectCustomFieldType
+}
+
+// IsErrInvalidProjectCustomFieldType checks if an error is ErrInvalidProjectCustomFieldType.
+func IsErrInvalidProjectCustomFieldType(err error) bool {
+	_, ok := err.(*ErrInvalidProjectCustomFieldType)
+	return ok
+}
+
+func (err *ErrInvalidProjectCustomFieldType) Error() string {
+	return fmt.Sprintf("Custom field type is invalid [FieldType: %d]", err.FieldType)
+}
+
+// ErrCodeInvalidProjectCustomFieldType holds the unique world-error code of this error
+const ErrCodeInvalidProjectCustomFieldType = 18002
+
+// HTTPError holds the http error description
+func (err ErrInvalidProjectCustomFieldType) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidProjectCustomFieldType,
+		Message:  "The custom field type is invalid.",
+	}
+}
+
+// ErrProjectCustomFieldNeedsOptions represents an error where a single select custom field has no options
+type ErrProjectCustomFieldNeedsOptions struct {
+	CustomFieldID int64
+}
+
+// IsErrProjectCustomFieldNeedsOptions checks if an error is ErrProjectCustomFieldNeedsOptions.
+func IsErrProjectCustomFieldNeedsOptions(err error) bool {
+	_, ok := err.(*ErrProjectCustomFieldNeedsOptions)
+	return ok
+}
+
+func (err *ErrProjectCustomFieldNeedsOptions) Error() string {
+	return fmt.Sprintf("Single select custom field needs options [CustomFieldID: %d]", err.CustomFieldID)
+}
+
+// ErrCodeProjectCustomFieldNeedsOptions holds the unique world-error code of this error
+const ErrCodeProjectCustomFieldNeedsOptions = 18003
+
+// HTTPError holds the http error description
+func (err ErrProjectCustomFieldNeedsOptions) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeProjectCustomFieldNeedsOptions,
+		Message:  "A single select custom field needs at least one option.",
+	}
+}
+
+// ErrInvalidCustomFieldValue represents an error where a value does not match the type of its custom field
+type ErrInvalidCustomFieldValue struct {
+	CustomFieldID int64
+	Value         interface{}
+}
+
+// IsErrInvalidCustomFieldValue checks if an error is ErrInvalidCustomFieldValue.
+func IsErrInvalidCustomFieldValue(err error) bool {
+	_, ok := err.(*ErrInvalidCustomFieldValue)
+	return ok
+}
+
+func (err *ErrInvalidCustomFieldValue) Error() string {
+	return fmt.Sprintf("Custom field value is invalid [CustomFieldID: %d, Value: %v]", err.CustomFieldID, err.Value)
+}
+
+// ErrCodeInvalidCustomFieldValue holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldValue = 18004
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldValue) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldValue,
+		Message:  "The value does not match the type of the custom field.",
+	}
+}
+
+// ErrCustomFieldNotAvailableForTask represents an error where a custom field is used with a task of another project
+type ErrCustomFieldNotAvailableForTask struct {
+	CustomFieldID int64
+	TaskID        int64
+}
+
+// IsErrCustomFieldNotAvailableForTask checks if an error is ErrCustomFieldNotAvailableForTask.
+func IsErrCustomFieldNotAvailableForTask(err error) bool {
+	_, ok := err.(*ErrCustomFieldNotAvailableForTask)
+	return ok
+}
+
+func (err *ErrCustomFieldNotAvailableForTask) Error() string {
+	return fmt.Sprintf("Custom field is not available for task [CustomFieldID: %d, TaskID: %d]", err.CustomFieldID, err.TaskID)
+}
+
+// ErrCodeCustomFieldNotAvailableForTask holds the unique world-error code of this error
+const ErrCodeCustomFieldNotAvailableForTask = 18005
+
+// HTTPError holds the http error description
+func (err ErrCustomFieldNotAvailableForTask) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeCustomFieldNotAvailableForTask,
+		Message:  "This custom field is not defined on the project of the task or one of its parent projects.",
+	}
+}
+
+// ErrCustomFieldNotAvailableForProject represents an error where a custom field of another project is used to filter or sort tasks
+type ErrCustomFieldNotAvailableForProject struct {
+	CustomFieldID int64
+}
+
+// IsErrCustomFieldNotAvailableForProject checks if an error is ErrCustomFieldNotAvailableForProject.
+func IsErrCustomFieldNotAvailableForProject(err error) bool {
+	_, ok := err.(*ErrCustomFieldNotAvailableForProject)
+	return ok
+}
+
+func (err *ErrCustomFieldNotAvailableForProject) Error() string {
+	return fmt.Sprintf("Custom field is not available for the project [CustomFieldID: %d]", err.CustomFieldID)
+}
+
+// ErrCodeCustomFieldNotAvailableForProject holds the unique world-error code of this error
+const ErrCodeCustomFieldNotAvailableForProject = 18006
+
+// HTTPError holds the http error description
+func (err ErrCustomFieldNotAvailableForProject) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeCustomFieldNotAvailableForProject,
+		Message:  "This custom field is not defined on the project or one of its parent projects.",
+	}
+}
+
+// ===============
+// Reaction errors
+// ===============
+
+// ErrInvalidReactionValue represents an error where a reaction value is empty or too long
+type ErrInvalidReactionValue struct {
+	Value string
+}
+
+// IsErrInvalidReactionValue checks if an error is ErrInvalidReactionValue.
+func IsErrInvalidReactionValue(err error) bool {
+	_, ok := err.(*ErrInvalidReactionValue)
+	return ok
+}
+
+func (err *ErrInvalidReactionValue) Error() string {
+	return fmt.Sprintf("Reaction value is invalid [Value: %s]", err.Value)
+}
+
+// ErrCodeInvalidReactionValue holds the unique world-error code of this error
+const ErrCodeInvalidReactionValue = 19001
+
+// HTTPError holds the http error description
+func (err ErrInvalidReactionValue) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidReactionValue,
+		Message:  "A reaction needs a value of at most 20 characters.",
+	}
+}
+
+// =======================
+// Project template errors
+// =======================
+
+// ErrProjectIsNotTemplate represents an error where a project is used as a template but isn't one
+type ErrProjectIsNotTemplate struct {
+	ProjectID int64
+}
+
+// IsErrProjectIsNotTemplate checks if an error is ErrProjectIsNotTemplate.
+func IsErrProjectIsNotTemplate(err error) bool {
+	_, ok := err.(*ErrProjectIsNotTemplate)
+	return ok
+}
+
+func (err *ErrProjectIsNotTemplate) Error() string {
+	return fmt.Sprintf("Project is not a template [ProjectID: %d]", err.ProjectID)
+}
+
+// ErrCodeProjectIsNotTemplate holds the unique world-error code of this error
+const ErrCodeProjectIsNotTemplate = 20001
+
+// HTTPError holds the http error description
+func (err ErrProjectIsNotTemplate) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeProjectIsNotTemplate,
+		Message:  "This project is not a template.",
+	}
+}
+
+// ErrProjectIsAlreadyTemplate represents an error where a project is marked as a template twice
+type ErrProjectIsAlreadyTemplate struct {
+	ProjectID int64
+}
+
+// IsErrProjectIsAlreadyTemplate checks if an error is ErrProjectIsAlreadyTemplate.
+func IsErrProjectIsAlreadyTemplate(err error) bool {
+	_, ok := err.(*ErrProjectIsAlreadyTemplate)
+	return ok
+}
+
+func (err *ErrProjectIsAlreadyTemplate) Error() string {
+	return fmt.Sprintf("Project is already a template [ProjectID: %d]", err.ProjectID)
+}
+
+// ErrCodeProjectIsAlreadyTemplate holds the unique world-error code of this error
+const ErrCodeProjectIsAlreadyTemplate = 20002
+
+// HTTPError holds the http error description
+func (err ErrProjectIsAlreadyTemplate) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeProjectIsAlreadyTemplate,
+		Message:  "This project is already a template.",
+	}
+}
+
+// ErrInvalidProjectTemplateSchedule represents an error where the schedule of a template is not a valid cron expression
+type ErrInvalidProjectTemplateSchedule struct {
+	Schedule string
+}
+
+// IsErrInvalidProjectTemplateSchedule checks if an error is ErrInvalidProjectTemplateSchedule.
+func IsErrInvalidProjectTemplateSchedule(err error) bool {
+	_, ok := err.(*ErrInvalidProjectTemplateSchedule)
+	return ok
+}
+
+func (err *ErrInvalidProjectTemplateSchedule) Error() string {
+	return fmt.Sprintf("Project template schedule is invalid [Schedule: %s]", err.Schedule)
+}
+
+// ErrCodeInvalidProjectTemplateSchedule holds the unique world-error code of this error
+const ErrCodeInvalidProjectTemplateSchedule = 20003
+
+// HTTPError holds the http error description
+func (err ErrInvalidProjectTemplateSchedule) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidProjectTemplateSchedule,
+		Message:  "The schedule is not a valid cron expression.",
+	}
+}
+
+// ============
+// Trash errors
+// ============
+
+// ErrInvalidTrashItemKind represents an error where a trash item kind is not known
+type ErrInvalidTrashItemKind struct {
+	Kind string
+}
+
+// IsErrInvalidTrashItemKind checks if an error is ErrInvalidTrashItemKind.
+func IsErrInvalidTrashItemKind(err error) bool {
+	_, ok := err.(*ErrInvalidTrashItemKind)
+	return ok
+}
+
+func (err *ErrInvalidTrashItemKind) Error() string {
+	return fmt.Sprintf("Trash item kind is invalid [Kind: %s]", err.Kind)
+}
+
+// ErrCodeInvalidTrashItemKind holds the unique world-error code of this error
+const ErrCodeInvalidTrashItemKind = 21001
+
+// HTTPError holds the http error description
+func (err ErrInvalidTrashItemKind) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTrashItemKind,
+		Message:  "The trash item kind is invalid. It must be one of project, task, comment or bucket.",
+	}
+}
+
+// ErrTrashItemDoesNotExist represents an error where an item is not in the trash
+type ErrTrashItemDoesNotExist struct {
+	Kind string
+	ID   int64
+}
+
+// IsErrTrashItemDoesNotExist checks if an error is ErrTrashItemDoesNotExist.
+func IsErrTrashItemDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTrashItemDoesNotExist)
+	return ok
+}
+
+func (err *ErrTrashItemDoesNotExist) Error() string {
+	return fmt.Sprintf("Trash item does not exist [Kind: %s, ID: %d]", err.Kind, err.ID)
+}
+
+// ErrCodeTrashItemDoesNotExist holds the unique world-error code of this error
+const ErrCodeTrashItemDoesNotExist = 21002
+
+// HTTPError holds the http error description
+func (err ErrTrashItemDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTrashItemDoesNotExist,
+		Message:  "This item is not in the trash.",
+	}
+}
+
+// ErrTrashItemParentIsDeleted represents an error where an item cannot be restored because its parent is in the trash as well
+type ErrTrashItemParentIsDeleted struct {
+	Kind string
+	ID   int64
+}
+
+// IsErrTrashItemParentIsDeleted checks if an error is ErrTrashItemParentIsDeleted.
+func IsErrTrashItemParentIsDeleted(err error) bool {
+	_, ok := err.(*ErrTrashItemParentIsDeleted)
+	return ok
+}
+
+func (err *ErrTrashItemParentIsDeleted) Error() string {
+	return fmt.Sprintf("Parent of trash item is deleted [Kind: %s, ID: %d]", err.Kind, err.ID)
+}
+
+// ErrCodeTrashItemParentIsDeleted holds the unique world-error code of this error
+const ErrCodeTrashItemParentIsDeleted = 21003
+
+// HTTPError holds the http error description
+func (err ErrTrashItemParentIsDeleted) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTrashItemParentIsDeleted,
+		Message:  "The project or task this item belongs to is in the trash as well. Restore it first.",
+	}
+}
+
+// ====================
+// Calendar feed errors
+// ====================
+
+// ErrCalendarFeedDoesNotExist represents an error where a calendar feed does not exist
+type ErrCalendarFeedDoesNotExist struct{}
+
+// IsErrCalendarFeedDoesNotExist checks if an error is ErrCalendarFeedDoesNotExist.
+func IsErrCalendarFeedDoesNotExist(err error) bool {
+	_, ok := err.(*ErrCalendarFeedDoesNotExist)
+	return ok
+}
+
+func (err *ErrCalendarFeedDoesNotExist) Error() string {
+	return "Calendar feed does not exist"
+}
+
+// ErrCodeCalendarFeedDoesNotExist holds the unique world-error code of this error
+const ErrCodeCalendarFeedDoesNotExist = 22001
+
+// HTTPError holds the http error description
+func (err ErrCalendarFeedDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeCalendarFeedDoesNotExist,
+		Message:  "This calendar feed does not exist.",
+	}
+}
+
+// ErrInvalidCalendarFeedKind represents an error where a calendar feed kind is not known
+type ErrInvalidCalendarFeedKind struct {
+	Kind string
+}
+
+// IsErrInvalidCalendarFeedKind checks if an error is ErrInvalidCalendarFeedKind.
+func IsErrInvalidCalendarFeedKind(err error) bool {
+	_, ok := err.(*ErrInvalidCalendarFeedKind)
+	return ok
+}
+
+func (err *ErrInvalidCalendarFeedKind) Error() string {
+	return fmt.Sprintf("Calendar feed kind is invalid [Kind: %s]", err.Kind)
+}
+
+// ErrCodeInvalidCalendarFeedKind holds the unique world-error code of this error
+const ErrCodeInvalidCalendarFeedKind = 22002
+
+// HTTPError holds the http error description
+func (err ErrInvalidCalendarFeedKind) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCalendarFeedKind,
+		Message:  "The calendar feed kind is invalid. It must be one of project, filter or assigned.",
+	}
+}
@@ -58,6 +58,17 @@ func (t *TaskDeletedEvent) Name() string {
 	return "task.deleted"
 }
 
+// TaskRestoredEvent represents an event where a task has been restored from the trash
+type TaskRestoredEvent struct {
+	Task *Task
+	Doer *user.User
+}
+
+// Name defines the name for TaskRestoredEvent
+func (t *TaskRestoredEvent) Name() string {
+	return "task.restored"
+}
+
 // TaskAssigneeCreatedEvent represents an event where a task has been assigned to a user
 type TaskAssigneeCreatedEvent struct {
 	Task     *Task
@@ -82,6 +93,30 @@ func (t *TaskAssigneeDeletedEvent) Name() string {
 	return "task.assignee.deleted"
 }
 
+// TaskLabelCreatedEvent represents an event where a label has been added to a task
+type TaskLabelCreatedEvent struct {
+	Task  *Task
+	Label *Label
+	Doer  *user.User
+}
+
+// Name defines the name for TaskLabelCreatedEvent
+func (t *TaskLabelCreatedEvent) Name() string {
+	return "task.label.created"
+}
+
+// TaskLabelDeletedEvent represents an event where a label has been removed from a task
+type TaskLabelDeletedEvent struct {
+	Task  *Task
+	Label *Label
+	Doer  *user.User
+}
+
+// Name defines the name for TaskLabelDeletedEvent
+func (t *TaskLabelDeletedEvent) Name() string {
+	return "task.label.deleted"
+}
+
 // TaskCommentCreatedEvent represents an event where a task comment has been created
 type TaskCommentCreatedEvent struct {
 	Task    *Task
@@ -166,6 +201,18 @@ func (t *TaskRelationDeletedEvent) Name() string {
 	return "task.relation.deleted"
 }
 
+// TaskReactionCreatedEvent represents an event where a user reacted on a task or comment
+type TaskReactionCreatedEvent struct {
+	Task     *Task
+	Reaction *Reaction
+	Doer     *user.User
+}
+
+// Name defines the name for TaskReactionCreatedEvent
+func (t *TaskReactionCreatedEvent) Name() string {
+	return "task.reaction.created"
+}
+
 ////////////////////
 // Project Events //
 ////////////////////
@@ -196,6 +243,9 @@ func (l *ProjectUpdatedEvent) Name() string {
 type ProjectDeletedEvent struct {
 	Project *Project
 	Doer    web.Auth
+	// The ids of all parent projects, collected before the project was deleted because
+	// they can't be looked up anymore afterwards.
+	ParentProjectIDs []int64 `json:"parent_project_ids"`
 }
 
 // Name defines the name for ProjectDeletedEvent
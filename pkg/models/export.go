@@ -178,6 +178,7 @@ func exportProjectsAndTasks(s *xorm.Session, u *user.User, wr *zip.Writer) (task
 	err = s.
 		Join("LEFT", "tasks", "tasks.id = task_comments.task_id").
 		In("tasks.project_id", projectIDs).
+		And("tasks.deleted IS NULL").
 		Find(&comments)
 	if err != nil {
 		return
@@ -191,6 +192,32 @@ func exportProjectsAndTasks(s *xorm.Session, u *user.User, wr *zip.Writer) (task
 		taskMap[c.TaskID].Comments = append(taskMap[c.TaskID].Comments, c)
 	}
 
+	timeEntries := []*TaskTimeEntry{}
+	err = s.
+		Join("LEFT", "tasks", "tasks.id = task_time_entries.task_id").
+		In("tasks.project_id", projectIDs).
+		And("tasks.deleted IS NULL").
+		Find(&timeEntries)
+	if err != nil {
+		return
+	}
+
+	for _, te := range timeEntries {
+		if _, exists := taskMap[te.TaskID]; !exists {
+			log.Debugf("[User Data Export] Task %d does not exist for time entry %d, omitting", te.TaskID, te.ID)
+			continue
+		}
+		taskMap[te.TaskID].TimeEntries = append(taskMap[te.TaskID].TimeEntries, te)
+	}
+
+	activities, err := getTaskActivitiesForTasks(s, taskIDs)
+	if err != nil {
+		return
+	}
+	for taskID, a := range activities {
+		taskMap[taskID].Activity = a
+	}
+
 	buckets := []*Bucket{}
 	err = s.In("project_id", projectIDs).Find(&buckets)
 	if err != nil {
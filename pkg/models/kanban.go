@@ -33,6 +33,8 @@ type Bucket struct {
 	Title string `xorm:"text not null" valid:"required" minLength:"1" json:"title"`
 	// The project this bucket belongs to.
 	ProjectID int64 `xorm:"bigint not null" json:"project_id" param:"project"`
+	// The kanban view this bucket belongs to.
+	ProjectViewID int64 `xorm:"bigint not null default 0 index" json:"project_view_id" param:"view"`
 	// All tasks which belong to this bucket.
 	Tasks []*Task `xorm:"-" json:"tasks"`
 
@@ -48,6 +50,8 @@ type Bucket struct {
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this bucket was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
+	// A timestamp when this bucket was moved to the trash. Deleted buckets are only visible in the trash.
+	Deleted time.Time `xorm:"deleted INDEX null" json:"-"`
 
 	// The user who initially created the bucket.
 	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
@@ -77,19 +81,19 @@ func getBucketByID(s *xorm.Session, id int64) (b *Bucket, err error) {
 	return
 }
 
-func getDefaultBucket(s *xorm.Session, projectID int64) (bucket *Bucket, err error) {
+func getDefaultBucket(s *xorm.Session, projectViewID int64) (bucket *Bucket, err error) {
 	bucket = &Bucket{}
 	_, err = s.
-		Where("project_id = ?", projectID).
+		Where("project_view_id = ?", projectViewID).
 		OrderBy("position asc").
 		Get(bucket)
 	return
 }
 
-func getDoneBucketForProject(s *xorm.Session, projectID int64) (bucket *Bucket, err error) {
+func getDoneBucketForView(s *xorm.Session, projectViewID int64) (bucket *Bucket, err error) {
 	bucket = &Bucket{}
 	exists, err := s.
-		Where("project_id = ? and is_done_bucket = ?", projectID, true).
+		Where("project_view_id = ? and is_done_bucket = ?", projectViewID, true).
 		Get(bucket)
 	if err != nil {
 		return nil, err
@@ -101,6 +105,40 @@ func getDoneBucketForProject(s *xorm.Session, projectID int64) (bucket *Bucket,
 	return
 }
 
+// getProjectView returns the kanban view of the bucket. If no view was specified, the default kanban view of the
+// project is used.
+func (b *Bucket) getProjectView(s *xorm.Session) (view *ProjectView, err error) {
+	if b.ProjectViewID == 0 {
+		view, err = getDefaultProjectView(s, b.ProjectID, ProjectViewKindKanban)
+	} else {
+		view, err = getProjectViewByIDAndProject(s, b.ProjectViewID, b.ProjectID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if view.ViewKind != ProjectViewKindKanban {
+		return nil, &ErrProjectViewIsNotKanban{ProjectViewID: view.ID}
+	}
+
+	b.ProjectViewID = view.ID
+	return view, nil
+}
+
+// setProjectViewIDFromBucket sets the view id of an existing bucket in case it was not provided.
+func (b *Bucket) setProjectViewIDFromBucket(s *xorm.Session) error {
+	if b.ProjectViewID != 0 {
+		return nil
+	}
+
+	bb, err := getBucketByID(s, b.ID)
+	if err != nil {
+		return err
+	}
+	b.ProjectViewID = bb.ProjectViewID
+	return nil
+}
+
 // ReadAll returns all buckets with their tasks for a certain project
 // @Summary Get all kanban buckets of a project
 // @Description Returns all kanban buckets with belong to a project including their tasks. Buckets are always sorted by their `position` in ascending order. Tasks are sorted by their `kanban_position` in ascending order.
@@ -120,6 +158,7 @@ func getDoneBucketForProject(s *xorm.Session, projectID int64) (bucket *Bucket,
 // @Success 200 {array} models.Bucket "The buckets with their tasks"
 // @Failure 500 {object} models.Message "Internal server error"
 // @Router /projects/{id}/buckets [get]
+// @Router /projects/{id}/views/{view}/buckets [get]
 func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
 
 	project, err := GetProjectSimpleByID(s, b.ProjectID)
@@ -135,10 +174,15 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 		return nil, 0, 0, ErrGenericForbidden{}
 	}
 
-	// Get all buckets for this project
+	view, err := b.getProjectView(s)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Get all buckets for this view
 	buckets := []*Bucket{}
 	err = s.
-		Where("project_id = ?", b.ProjectID).
+		Where("project_view_id = ?", view.ID).
 		OrderBy("position").
 		Find(&buckets)
 	if err != nil {
@@ -180,6 +224,14 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 	opts.perPage = perPage
 	opts.search = search
 	opts.filterConcat = filterConcatAnd
+	opts.projectView = view
+
+	if view.Filter != nil {
+		opts.projectViewFilter, err = getTaskFilterOptsFromCollection(view.Filter)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
 
 	var bucketFilterIndex int
 	for i, filter := range opts.filters {
@@ -207,6 +259,12 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 			return nil, 0, 0, err
 		}
 
+		if view.usesOwnTaskData() {
+			for _, t := range ts {
+				t.BucketID = id
+			}
+		}
+
 		tasks = append(tasks, ts...)
 	}
 
@@ -220,6 +278,11 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 		return nil, 0, 0, err
 	}
 
+	err = setTaskPositionsForView(s, view, taskMap)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
 	// Put all tasks in their buckets
 	// All tasks which are not associated to any bucket will have bucket id 0 which is the nil value for int64
 	// Since we created a bucked with that id at the beginning, all tasks should be in there.
@@ -249,7 +312,13 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 // @Failure 404 {object} web.HTTPError "The project does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{id}/buckets [put]
+// @Router /projects/{id}/views/{view}/buckets [put]
 func (b *Bucket) Create(s *xorm.Session, a web.Auth) (err error) {
+	_, err = b.getProjectView(s)
+	if err != nil {
+		return
+	}
+
 	b.CreatedBy, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		return
@@ -281,8 +350,14 @@ func (b *Bucket) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Failure 404 {object} web.HTTPError "The bucket does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/buckets/{bucketID} [post]
+// @Router /projects/{projectID}/views/{view}/buckets/{bucketID} [post]
 func (b *Bucket) Update(s *xorm.Session, _ web.Auth) (err error) {
-	doneBucket, err := getDoneBucketForProject(s, b.ProjectID)
+	err = b.setProjectViewIDFromBucket(s)
+	if err != nil {
+		return err
+	}
+
+	doneBucket, err := getDoneBucketForView(s, b.ProjectViewID)
 	if err != nil {
 		return err
 	}
@@ -320,10 +395,21 @@ func (b *Bucket) Update(s *xorm.Session, _ web.Auth) (err error) {
 // @Failure 404 {object} web.HTTPError "The bucket does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/buckets/{bucketID} [delete]
+// @Router /projects/{projectID}/views/{view}/buckets/{bucketID} [delete]
 func (b *Bucket) Delete(s *xorm.Session, _ web.Auth) (err error) {
 
+	err = b.setProjectViewIDFromBucket(s)
+	if err != nil {
+		return
+	}
+
+	view, err := getProjectViewByID(s, b.ProjectViewID)
+	if err != nil {
+		return
+	}
+
 	// Prevent removing the last bucket
-	total, err := s.Where("project_id = ?", b.ProjectID).Count(&Bucket{})
+	total, err := s.Where("project_view_id = ?", view.ID).Count(&Bucket{})
 	if err != nil {
 		return
 	}
@@ -341,12 +427,20 @@ func (b *Bucket) Delete(s *xorm.Session, _ web.Auth) (err error) {
 	}
 
 	// Get the default bucket
-	defaultBucket, err := getDefaultBucket(s, b.ProjectID)
+	defaultBucket, err := getDefaultBucket(s, view.ID)
 	if err != nil {
 		return
 	}
 
 	// Remove all associations of tasks to that bucket
+	if view.usesOwnTaskData() {
+		_, err = s.
+			Where("bucket_id = ?", b.ID).
+			Cols("bucket_id").
+			Update(&TaskBucket{BucketID: defaultBucket.ID})
+		return
+	}
+
 	_, err = s.
 		Where("bucket_id = ?", b.ID).
 		Cols("bucket_id").
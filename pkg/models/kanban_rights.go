@@ -43,6 +43,10 @@ func (b *Bucket) canDoBucket(s *xorm.Session, a web.Auth) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if b.ProjectViewID != 0 && bb.ProjectViewID != b.ProjectViewID {
+		return false, ErrBucketDoesNotExist{BucketID: b.ID}
+	}
+	b.ProjectViewID = bb.ProjectViewID
 	l := &Project{ID: bb.ProjectID}
 	return l.CanWrite(s, a)
 }
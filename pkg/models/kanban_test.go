@@ -19,6 +19,7 @@ package models
 import (
 	"testing"
 
+	"xorm.io/builder"
 	"xorm.io/xorm"
 
 	"code.vikunja.io/api/pkg/db"
@@ -148,10 +149,7 @@ func TestBucket_Delete(t *testing.T) {
 		err = s.Where("bucket_id = ?", 1).Find(&tasks)
 		assert.NoError(t, err)
 		assert.Len(t, tasks, 16)
-		db.AssertMissing(t, "buckets", map[string]interface{}{
-			"id":         2,
-			"project_id": 1,
-		})
+		db.AssertCount(t, "buckets", builder.And(builder.Eq{"id": 2, "project_id": 1}, builder.NotNull{"deleted"}), 1)
 	})
 	t.Run("last bucket in project", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
@@ -77,7 +77,10 @@ func (l *Label) hasAccessToLabel(s *xorm.Session, a web.Auth) (has bool, maxRigh
 		builder.
 			Select("id").
 			From("tasks").
-			Where(builder.In("project_id", getUserProjectsStatement(nil, u.ID, "", false).Select("l.id"))),
+			Where(builder.And(
+				builder.In("project_id", getUserProjectsStatement(nil, u.ID, "", false).Select("l.id")),
+				builder.IsNull{"deleted"},
+			)),
 	)
 
 	ll := &LabelTask{}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
 
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/user"
@@ -50,6 +51,21 @@ func (LabelTask) TableName() string {
 	return "label_tasks"
 }
 
+func (lt *LabelTask) addActivity(s *xorm.Session, a web.Auth, kind TaskActivityKind) error {
+	label, err := getLabelByIDSimple(s, lt.LabelID)
+	if IsErrLabelDoesNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if kind == TaskActivityKindAdded {
+		return addTaskActivity(s, lt.TaskID, a, kind, "labels", "", label.Title)
+	}
+	return addTaskActivity(s, lt.TaskID, a, kind, "labels", label.Title, "")
+}
+
 // Delete deletes a label on a task
 // @Summary Remove a label from a task
 // @Description Remove a label from a task. The user needs to have write-access to the project to be able do this.
@@ -64,9 +80,36 @@ func (LabelTask) TableName() string {
 // @Failure 404 {object} web.HTTPError "Label not found."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{task}/labels/{label} [delete]
-func (lt *LabelTask) Delete(s *xorm.Session, _ web.Auth) (err error) {
+func (lt *LabelTask) Delete(s *xorm.Session, a web.Auth) (err error) {
 	_, err = s.Delete(&LabelTask{LabelID: lt.LabelID, TaskID: lt.TaskID})
-	return err
+	if err != nil {
+		return err
+	}
+
+	err = lt.addActivity(s, a, TaskActivityKindRemoved)
+	if err != nil {
+		return err
+	}
+
+	err = recordTaskSyncChangeByTaskID(s, lt.TaskID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, lt.TaskID)
+	if IsErrTaskDoesNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	return events.Dispatch(&TaskLabelDeletedEvent{
+		Task:  &task,
+		Label: &Label{ID: lt.LabelID},
+		Doer:  doer,
+	})
 }
 
 // Create adds a label to a task
@@ -84,7 +127,7 @@ func (lt *LabelTask) Delete(s *xorm.Session, _ web.Auth) (err error) {
 // @Failure 404 {object} web.HTTPError "The label does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{task}/labels [put]
-func (lt *LabelTask) Create(s *xorm.Session, _ web.Auth) (err error) {
+func (lt *LabelTask) Create(s *xorm.Session, a web.Auth) (err error) {
 	// Check if the label is already added
 	exists, err := s.Exist(&LabelTask{LabelID: lt.LabelID, TaskID: lt.TaskID})
 	if err != nil {
@@ -100,8 +143,32 @@ func (lt *LabelTask) Create(s *xorm.Session, _ web.Auth) (err error) {
 		return err
 	}
 
+	err = lt.addActivity(s, a, TaskActivityKindAdded)
+	if err != nil {
+		return err
+	}
+
+	err = recordTaskSyncChangeByTaskID(s, lt.TaskID)
+	if err != nil {
+		return err
+	}
+
 	err = updateProjectByTaskID(s, lt.TaskID)
-	return
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, lt.TaskID)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	return events.Dispatch(&TaskLabelCreatedEvent{
+		Task:  &task,
+		Label: &Label{ID: lt.LabelID},
+		Doer:  doer,
+	})
 }
 
 // ReadAll gets all labels on a task
@@ -180,7 +247,10 @@ func GetLabelsByTaskIDs(s *xorm.Session, opts *LabelByTaskIDsOptions) (ls []*Lab
 			builder.
 				Select("id").
 				From("tasks").
-				Where(builder.In("project_id", getUserProjectsStatement(nil, opts.GetForUser, "", false).Select("l.id"))),
+				Where(builder.And(
+					builder.In("project_id", getUserProjectsStatement(nil, opts.GetForUser, "", false).Select("l.id")),
+					builder.IsNull{"deleted"},
+				)),
 		), cond)
 	}
 	if opts.GetUnusedLabels {
@@ -286,7 +356,32 @@ func (t *Task) UpdateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 	if len(labels) == 0 && len(t.Labels) > 0 {
 		_, err = s.Where("task_id = ?", t.ID).
 			Delete(LabelTask{})
-		return err
+		if err != nil {
+			return err
+		}
+		for _, oldLabel := range t.Labels {
+			err = addTaskActivity(s, t.ID, creator, TaskActivityKindRemoved, "labels", oldLabel.Title, "")
+			if err != nil {
+				return err
+			}
+		}
+		err = recordTaskSyncChangeByTaskID(s, t.ID)
+		if err != nil {
+			return err
+		}
+
+		doer, _ := user.GetFromAuth(creator)
+		for _, oldLabel := range t.Labels {
+			err = events.Dispatch(&TaskLabelDeletedEvent{
+				Task:  t,
+				Label: oldLabel,
+				Doer:  doer,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
 	// If we didn't change anything (from 0 to zero) don't do anything.
@@ -315,6 +410,10 @@ func (t *Task) UpdateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 		// Put all labels which are only on the old project to the trash
 		if !found {
 			labelsToDelete = append(labelsToDelete, oldLabel.ID)
+			err = addTaskActivity(s, t.ID, creator, TaskActivityKindRemoved, "labels", oldLabel.Title, "")
+			if err != nil {
+				return err
+			}
 		} else {
 			t.Labels = append(t.Labels, oldLabel)
 		}
@@ -334,6 +433,7 @@ func (t *Task) UpdateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 	}
 
 	// Loop through our labels and add them
+	addedLabels := []*Label{}
 	for _, l := range labels {
 		// Check if the label is already added on the task and only add it if not
 		if oldLabels[l.ID] != nil {
@@ -362,11 +462,46 @@ func (t *Task) UpdateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 		if err != nil {
 			return err
 		}
+		err = addTaskActivity(s, t.ID, creator, TaskActivityKindAdded, "labels", "", label.Title)
+		if err != nil {
+			return err
+		}
 		t.Labels = append(t.Labels, label)
+		addedLabels = append(addedLabels, label)
+	}
+
+	err = recordTaskSyncChangeByTaskID(s, t.ID)
+	if err != nil {
+		return err
 	}
 
 	err = updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
-	return
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(creator)
+	for _, labelID := range labelsToDelete {
+		err = events.Dispatch(&TaskLabelDeletedEvent{
+			Task:  t,
+			Label: oldLabels[labelID],
+			Doer:  doer,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, l := range addedLabels {
+		err = events.Dispatch(&TaskLabelCreatedEvent{
+			Task:  t,
+			Label: l,
+			Doer:  doer,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // LabelTaskBulk is a helper struct to update a bunch of labels at once
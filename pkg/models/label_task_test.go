@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"gopkg.in/d4l3k/messagediff.v1"
 
@@ -252,6 +253,7 @@ func TestLabelTask_Create(t *testing.T) {
 					"task_id":  l.TaskID,
 					"label_id": l.LabelID,
 				}, false)
+				events.AssertDispatched(t, &TaskLabelCreatedEvent{})
 			}
 			s.Close()
 		})
@@ -350,6 +352,7 @@ func TestLabelTask_Delete(t *testing.T) {
 					"label_id": l.LabelID,
 					"task_id":  l.TaskID,
 				})
+				events.AssertDispatched(t, &TaskLabelDeletedEvent{})
 			}
 			s.Close()
 		})
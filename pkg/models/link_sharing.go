@@ -61,6 +61,19 @@ type LinkSharing struct {
 	// The password of this link share. You can only set it, not retrieve it after the link share has been created.
 	Password string `xorm:"text null" json:"password"`
 
+	// The date after which this link share can't be used anymore. Link shares without an expiry date never expire.
+	ExpiresAt time.Time `xorm:"datetime null" json:"expires_at"`
+	// How often someone can authenticate with this link share. 0 means unlimited.
+	MaxUses int64 `xorm:"bigint not null default 0" json:"max_uses"`
+	// How often someone authenticated with this link share. You cannot change this value.
+	UseCount int64 `xorm:"bigint not null default 0" json:"use_count"`
+	// If true, the link share also gives access to all child projects of the shared project.
+	IncludeChildProjects bool `xorm:"bool not null default false" json:"include_child_projects"`
+	// When someone last authenticated with this link share. You cannot change this value.
+	LastUsed time.Time `xorm:"datetime null" json:"last_used"`
+	// The ip address this link share was last used from. You cannot change this value.
+	LastUsedIP string `xorm:"varchar(50) null" json:"last_used_ip"`
+
 	// The user who shared this project
 	SharedBy   *user.User `xorm:"-" json:"shared_by"`
 	SharedByID int64      `xorm:"bigint INDEX not null" json:"-"`
@@ -143,6 +156,12 @@ func (share *LinkSharing) Create(s *xorm.Session, a web.Auth) (err error) {
 
 	share.SharedByID = a.GetID()
 	share.Hash = utils.MakeRandomString(40)
+	share.UseCount = 0
+	share.LastUsed = time.Time{}
+	share.LastUsedIP = ""
+	if share.MaxUses < 0 {
+		share.MaxUses = 0
+	}
 
 	if share.Password != "" {
 		share.SharingType = SharingTypeWithPassword
@@ -323,6 +342,75 @@ func GetLinkSharesByIDs(s *xorm.Session, ids []int64) (shares map[int64]*LinkSha
 	return
 }
 
+// IsExpired checks if the expiry date of a link share has passed.
+func (share *LinkSharing) IsExpired() bool {
+	return !share.ExpiresAt.IsZero() && share.ExpiresAt.Before(time.Now())
+}
+
+// CheckLinkShareCanBeUsed checks if someone can still authenticate with a link share.
+func CheckLinkShareCanBeUsed(share *LinkSharing) error {
+	if share.IsExpired() {
+		return &ErrLinkShareExpired{ShareID: share.ID}
+	}
+	if share.MaxUses > 0 && share.UseCount >= share.MaxUses {
+		return &ErrLinkShareUsageLimitReached{ShareID: share.ID}
+	}
+	return nil
+}
+
+// RecordLinkShareUsage counts an authentication with a link share and remembers when and from where it happened.
+// The usage limit is checked again in the same query so concurrent authentications can't exceed it.
+func RecordLinkShareUsage(s *xorm.Session, share *LinkSharing, ipAddress string) (err error) {
+	share.LastUsed = time.Now()
+	share.LastUsedIP = ipAddress
+	updated, err := s.
+		Where("id = ?", share.ID).
+		And(builder.Or(
+			builder.Eq{"max_uses": 0},
+			builder.Expr("use_count < max_uses"),
+		)).
+		Incr("use_count").
+		Cols("last_used", "last_used_ip").
+		Update(share)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return &ErrLinkShareUsageLimitReached{ShareID: share.ID}
+	}
+
+	share.UseCount++
+	return nil
+}
+
+// GetLinkShareForAuth returns the link share a jwt was issued for.
+// The share is read from the database so shares which were deleted or expired after the token was issued can't be used anymore.
+func GetLinkShareForAuth(s *xorm.Session, claims jwt.MapClaims) (share *LinkSharing, err error) {
+	fromClaims, err := GetLinkShareFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err = GetLinkShareByID(s, fromClaims.ID)
+	if err != nil {
+		if IsErrProjectShareDoesNotExist(err) {
+			return nil, &ErrLinkShareTokenInvalid{}
+		}
+		return nil, err
+	}
+
+	if share.Hash != fromClaims.Hash {
+		return nil, &ErrLinkShareTokenInvalid{}
+	}
+
+	if share.IsExpired() {
+		return nil, &ErrLinkShareExpired{ShareID: share.ID}
+	}
+
+	share.Password = ""
+	return share, nil
+}
+
 // VerifyLinkSharePassword checks if a password of a link share matches a provided one.
 func VerifyLinkSharePassword(share *LinkSharing, password string) (err error) {
 	if password == "" {
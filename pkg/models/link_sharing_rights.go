@@ -68,3 +68,28 @@ func (share *LinkSharing) canDoLinkShare(s *xorm.Session, a web.Auth) (bool, err
 
 	return l.CanWrite(s, a)
 }
+
+// canAccessProject checks if a link share covers a project.
+// A share always covers the shared project and, if enabled, all of its child projects.
+func (share *LinkSharing) canAccessProject(s *xorm.Session, project *Project) (bool, error) {
+	if project.ID == share.ProjectID {
+		return true, nil
+	}
+	if !share.IncludeChildProjects {
+		return false, nil
+	}
+
+	parentID := project.ParentProjectID
+	for parentID != 0 {
+		if parentID == share.ProjectID {
+			return true, nil
+		}
+		parent, err := GetProjectSimpleByID(s, parentID)
+		if err != nil {
+			return false, err
+		}
+		parentID = parent.ParentProjectID
+	}
+
+	return false, nil
+}
@@ -153,3 +153,93 @@ func TestLinkSharing_ReadOne(t *testing.T) {
 		assert.Empty(t, share.Password)
 	})
 }
+
+func TestRecordLinkShareUsage(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share, err := GetLinkShareByID(s, 1)
+		assert.NoError(t, err)
+		err = RecordLinkShareUsage(s, share, "127.0.0.1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), share.UseCount)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "link_shares", map[string]interface{}{
+			"id":           1,
+			"use_count":    1,
+			"last_used_ip": "127.0.0.1",
+		}, false)
+	})
+	t.Run("usage limit reached", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share, err := GetLinkShareByID(s, 6)
+		assert.NoError(t, err)
+		err = RecordLinkShareUsage(s, share, "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkShareUsageLimitReached(err))
+	})
+}
+
+func TestLinkSharing_ChildProjects(t *testing.T) {
+	t.Run("child project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share, err := GetLinkShareByID(s, 7)
+		assert.NoError(t, err)
+
+		// 26 is a child of 25, which is a child of 12, which is a child of 27
+		can, _, err := (&Project{ID: 26}).CanRead(s, share)
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("unrelated project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share, err := GetLinkShareByID(s, 7)
+		assert.NoError(t, err)
+
+		can, _, err := (&Project{ID: 1}).CanRead(s, share)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("child projects not included", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share, err := GetLinkShareByID(s, 7)
+		assert.NoError(t, err)
+		share.IncludeChildProjects = false
+
+		can, _, err := (&Project{ID: 26}).CanRead(s, share)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("project list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		share, err := GetLinkShareByID(s, 7)
+		assert.NoError(t, err)
+
+		all, _, _, err := (&Project{}).ReadAll(s, share, "", 0, 0)
+		assert.NoError(t, err)
+		ids := []int64{}
+		for _, p := range all.([]*Project) {
+			ids = append(ids, p.ID)
+		}
+		assert.ElementsMatch(t, []int64{27, 12, 25, 26}, ids)
+	})
+}
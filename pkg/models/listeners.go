@@ -18,12 +18,15 @@ package models
 
 import (
 	"encoding/json"
+	"time"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/metrics"
 	"code.vikunja.io/api/pkg/modules/keyvalue"
+	"code.vikunja.io/api/pkg/modules/search"
 	"code.vikunja.io/api/pkg/notifications"
 	"code.vikunja.io/api/pkg/user"
 
@@ -58,6 +61,44 @@ func RegisterListeners() {
 	events.RegisterListener((&TaskAttachmentDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	events.RegisterListener((&TaskRelationCreatedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	events.RegisterListener((&TaskRelationDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
+
+	if search.KeepsIndex() {
+		events.RegisterListener((&TaskCreatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+		events.RegisterListener((&TaskUpdatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+		events.RegisterListener((&TaskCommentCreatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+		events.RegisterListener((&TaskCommentUpdatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+		events.RegisterListener((&TaskCommentDeletedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+		events.RegisterListener((&TaskLabelCreatedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+		events.RegisterListener((&TaskLabelDeletedEvent{}).Name(), &UpdateTaskInSearchIndex{})
+		events.RegisterListener((&TaskDeletedEvent{}).Name(), &RemoveTaskFromSearchIndex{})
+		events.RegisterListener((&TaskRestoredEvent{}).Name(), &UpdateTaskInSearchIndex{})
+	}
+
+	if config.WebhooksEnabled.GetBool() {
+		registerWebhookEvents()
+	}
+}
+
+// registerWebhookEvents makes all events available to webhooks which belong to a project.
+func registerWebhookEvents() {
+	RegisterEventForWebhook(&TaskCreatedEvent{})
+	RegisterEventForWebhook(&TaskUpdatedEvent{})
+	RegisterEventForWebhook(&TaskDeletedEvent{})
+	RegisterEventForWebhook(&TaskRestoredEvent{})
+	RegisterEventForWebhook(&TaskAssigneeCreatedEvent{})
+	RegisterEventForWebhook(&TaskAssigneeDeletedEvent{})
+	RegisterEventForWebhook(&TaskCommentCreatedEvent{})
+	RegisterEventForWebhook(&TaskCommentUpdatedEvent{})
+	RegisterEventForWebhook(&TaskCommentDeletedEvent{})
+	RegisterEventForWebhook(&TaskAttachmentCreatedEvent{})
+	RegisterEventForWebhook(&TaskAttachmentDeletedEvent{})
+	RegisterEventForWebhook(&TaskRelationCreatedEvent{})
+	RegisterEventForWebhook(&TaskRelationDeletedEvent{})
+	RegisterEventForWebhook(&TaskReactionCreatedEvent{})
+	RegisterEventForWebhook(&ProjectUpdatedEvent{})
+	RegisterEventForWebhook(&ProjectDeletedEvent{})
+	RegisterEventForWebhook(&ProjectSharedWithUserEvent{})
+	RegisterEventForWebhook(&ProjectSharedWithTeamEvent{})
 }
 
 //////
@@ -167,6 +208,11 @@ func (s *SendTaskCommentNotification) Handle(msg *message.Message) (err error) {
 		return err
 	}
 
+	parentAuthorID, err := notifyParentCommentAuthor(sess, event, mentionedUsers)
+	if err != nil {
+		return err
+	}
+
 	subscribers, err := getSubscribersForEntity(sess, SubscriptionEntityTask, event.Task.ID)
 	if err != nil {
 		return err
@@ -183,6 +229,10 @@ func (s *SendTaskCommentNotification) Handle(msg *message.Message) (err error) {
 			continue
 		}
 
+		if subscriber.UserID == parentAuthorID {
+			continue
+		}
+
 		n := &TaskCommentNotification{
 			Doer:    event.Doer,
 			Task:    event.Task,
@@ -197,6 +247,56 @@ func (s *SendTaskCommentNotification) Handle(msg *message.Message) (err error) {
 	return
 }
 
+// notifyParentCommentAuthor notifies the author of the comment a new comment replies to.
+// It returns the id of the notified user or 0 if nobody was notified.
+func notifyParentCommentAuthor(s *xorm.Session, event *TaskCommentCreatedEvent, mentionedUsers map[int64]*user.User) (notifiedUserID int64, err error) {
+	if event.Comment.ParentID == 0 {
+		return 0, nil
+	}
+
+	parent := &TaskComment{ID: event.Comment.ParentID, TaskID: event.Task.ID}
+	err = getTaskCommentSimple(s, parent)
+	if IsErrTaskCommentDoesNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Link shares can't receive notifications
+	if parent.AuthorID <= 0 || parent.AuthorID == event.Doer.ID {
+		return 0, nil
+	}
+
+	if _, has := mentionedUsers[parent.AuthorID]; has {
+		return 0, nil
+	}
+
+	author, err := user.GetUserByID(s, parent.AuthorID)
+	if err != nil {
+		return 0, err
+	}
+
+	// The author might have lost access to the task since writing the comment
+	can, _, err := event.Task.CanRead(s, author)
+	if err != nil || !can {
+		return 0, err
+	}
+
+	n := &TaskCommentReplyNotification{
+		Doer:          event.Doer,
+		Task:          event.Task,
+		Comment:       event.Comment,
+		ParentComment: parent,
+	}
+	err = notifications.Notify(author, n)
+	if err != nil {
+		return 0, err
+	}
+
+	return author.ID, nil
+}
+
 // HandleTaskCommentEditMentions  represents a listener
 type HandleTaskCommentEditMentions struct {
 }
@@ -426,46 +526,103 @@ func (s *HandleTaskUpdateLastUpdated) Name() string {
 
 // Handle is executed when the event HandleTaskUpdateLastUpdated listens on is fired
 func (s *HandleTaskUpdateLastUpdated) Handle(msg *message.Message) (err error) {
-	// Using a map here allows us to plug this listener to all kinds of task events
+	taskID, err := getTaskIDFromEventPayload(msg.Payload)
+	if err != nil || taskID == 0 {
+		return err
+	}
+
+	sess := db.NewSession()
+	defer sess.Close()
+
+	return updateTaskLastUpdated(sess, &Task{ID: taskID})
+}
+
+// getTaskIDFromEventPayload returns the id of the task in the payload of any task event.
+// If the payload does not contain a task id, it returns 0.
+func getTaskIDFromEventPayload(payload []byte) (taskID int64, err error) {
+	// Using a map here allows us to plug this into all kinds of task events
 	event := map[string]interface{}{}
-	err = json.Unmarshal(msg.Payload, &event)
+	err = json.Unmarshal(payload, &event)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	task, is := event["Task"].(map[string]interface{})
 	if !is {
 		log.Errorf("Event payload does not contain task ID")
-		return
+		return 0, nil
 	}
 
-	taskID, is := task["id"]
+	rawTaskID, is := task["id"]
 	if !is {
 		log.Errorf("Event payload does not contain a valid task ID")
-		return
+		return 0, nil
 	}
 
-	var taskIDInt int64
-	switch v := taskID.(type) {
+	switch v := rawTaskID.(type) {
 	case int64:
-		taskIDInt = v
+		return v, nil
 	case int:
-		taskIDInt = int64(v)
+		return int64(v), nil
 	case int32:
-		taskIDInt = int64(v)
+		return int64(v), nil
 	case float64:
-		taskIDInt = int64(v)
+		return int64(v), nil
 	case float32:
-		taskIDInt = int64(v)
+		return int64(v), nil
 	default:
 		log.Errorf("Event payload does not contain a valid task ID")
-		return
+		return 0, nil
+	}
+}
+
+// UpdateTaskInSearchIndex represents a listener
+type UpdateTaskInSearchIndex struct {
+}
+
+// Name defines the name for the UpdateTaskInSearchIndex listener
+func (s *UpdateTaskInSearchIndex) Name() string {
+	return "task.search.index.update"
+}
+
+// Handle is executed when the event UpdateTaskInSearchIndex listens on is fired
+func (s *UpdateTaskInSearchIndex) Handle(msg *message.Message) (err error) {
+	taskID, err := getTaskIDFromEventPayload(msg.Payload)
+	if err != nil || taskID == 0 {
+		return err
 	}
 
 	sess := db.NewSession()
 	defer sess.Close()
 
-	return updateTaskLastUpdated(sess, &Task{ID: taskIDInt})
+	doc, err := getSearchDocumentForTask(sess, taskID)
+	if err != nil {
+		if IsErrTaskDoesNotExist(err) {
+			return search.Delete(taskID)
+		}
+		return err
+	}
+
+	return search.Index(doc)
+}
+
+// RemoveTaskFromSearchIndex represents a listener
+type RemoveTaskFromSearchIndex struct {
+}
+
+// Name defines the name for the RemoveTaskFromSearchIndex listener
+func (s *RemoveTaskFromSearchIndex) Name() string {
+	return "task.search.index.remove"
+}
+
+// Handle is executed when the event RemoveTaskFromSearchIndex listens on is fired
+func (s *RemoveTaskFromSearchIndex) Handle(msg *message.Message) (err error) {
+	taskID, err := getTaskIDFromEventPayload(msg.Payload)
+	if err != nil || taskID == 0 {
+		return err
+	}
+
+	return search.Delete(taskID)
 }
 
 ///////
@@ -635,3 +792,148 @@ func (s *HandleUserDataExport) Handle(msg *message.Message) (err error) {
 	err = sess.Commit()
 	return err
 }
+
+///////
+// Webhooks
+
+// WebhookListener represents a listener which delivers an event to all webhooks interested in it
+type WebhookListener struct {
+	EventName string
+}
+
+// Name defines the name for the WebhookListener listener
+func (wl *WebhookListener) Name() string {
+	return "webhook.listener"
+}
+
+// getIDFromEventPayload returns the numeric value of a property of a json object in the event payload.
+func getIDFromEventPayload(payload map[string]interface{}, object, property string) int64 {
+	o, is := payload[object].(map[string]interface{})
+	if !is {
+		return 0
+	}
+
+	id, is := o[property].(float64)
+	if !is {
+		return 0
+	}
+
+	return int64(id)
+}
+
+// getProjectIDFromAnyEvent figures out the project an event belongs to, based on the task or project in its payload.
+func getProjectIDFromAnyEvent(payload map[string]interface{}) int64 {
+	if projectID := getIDFromEventPayload(payload, "Task", "project_id"); projectID > 0 {
+		return projectID
+	}
+
+	return getIDFromEventPayload(payload, "Project", "id")
+}
+
+// getProjectIDOfEventTask returns the project of the task in the event payload, even if the task is in the trash.
+func getProjectIDOfEventTask(s *xorm.Session, payload map[string]interface{}) (projectID int64, err error) {
+	taskID := getIDFromEventPayload(payload, "Task", "id")
+	if taskID == 0 {
+		return 0, nil
+	}
+
+	task := &Task{}
+	_, err = s.Unscoped().Where("id = ?", taskID).Cols("project_id").Get(task)
+	return task.ProjectID, err
+}
+
+// Handle is executed when the event WebhookListener listens on is fired
+func (wl *WebhookListener) Handle(msg *message.Message) (err error) {
+	var event map[string]interface{}
+	err = json.Unmarshal(msg.Payload, &event)
+	if err != nil {
+		return err
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	projectID := getProjectIDFromAnyEvent(event)
+	if projectID == 0 {
+		// Some events only contain the id of their task
+		projectID, err = getProjectIDOfEventTask(s, event)
+		if err != nil {
+			return err
+		}
+	}
+	if projectID == 0 {
+		log.Debugf("Event %s does not contain a project id, not sending webhook", wl.EventName)
+		return nil
+	}
+
+	// Webhooks of parent projects get the events of all their child projects as well
+	projectIDs := []int64{projectID}
+	project, err := GetProjectSimpleByID(s, projectID)
+	if err != nil && !IsErrProjectDoesNotExist(err) {
+		return err
+	}
+	if IsErrProjectDoesNotExist(err) {
+		// Deleted projects carry their parents in the event since they can't be looked up anymore
+		projectIDs = append(projectIDs, getParentProjectIDsFromEvent(event)...)
+	}
+	if err == nil {
+		err = project.GetAllParentProjects(s)
+		if err != nil {
+			return err
+		}
+		for parent := project.ParentProject; parent != nil; parent = parent.ParentProject {
+			projectIDs = append(projectIDs, parent.ID)
+		}
+	}
+
+	ws := []*Webhook{}
+	err = s.In("project_id", projectIDs).Find(&ws)
+	if err != nil {
+		return err
+	}
+
+	payload := &WebhookPayload{
+		EventName: wl.EventName,
+		Time:      time.Now(),
+		Data:      event,
+	}
+
+	for _, w := range ws {
+		var subscribed bool
+		for _, e := range w.Events {
+			if e == wl.EventName {
+				subscribed = true
+				break
+			}
+		}
+
+		if !subscribed {
+			continue
+		}
+
+		// Delivery failures are recorded with the webhook and retried with a separate event. Returning an
+		// error here would retry the whole message and deliver it again to all other webhooks.
+		err = w.deliver(s, payload, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getParentProjectIDsFromEvent returns the parent project ids a ProjectDeletedEvent carries in its payload.
+func getParentProjectIDsFromEvent(payload map[string]interface{}) (ids []int64) {
+	raw, is := payload["parent_project_ids"].([]interface{})
+	if !is {
+		return
+	}
+
+	for _, id := range raw {
+		if i, is := id.(float64); is {
+			ids = append(ids, int64(i))
+		}
+	}
+
+	return
+}
@@ -58,6 +58,21 @@ func GetTables() []interface{} {
 		&SavedFilter{},
 		&Subscription{},
 		&Favorite{},
+		&Webhook{},
+		&WebhookRetry{},
+		&APIToken{},
+		&ProjectView{},
+		&TaskBucket{},
+		&TaskPosition{},
+		&TaskTimeEntry{},
+		&ProjectCustomField{},
+		&TaskCustomFieldValue{},
+		&TaskActivity{},
+		&Reaction{},
+		&ProjectTemplate{},
+		&CalendarFeed{},
+		&TaskSyncChange{},
+		&ProjectSyncSequence{},
 	}
 }
 
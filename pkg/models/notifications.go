@@ -102,6 +102,44 @@ func (n *TaskCommentNotification) Name() string {
 	return "task.comment"
 }
 
+// TaskCommentReplyNotification represents a TaskCommentReplyNotification notification
+type TaskCommentReplyNotification struct {
+	Doer          *user.User   `json:"doer"`
+	Task          *Task        `json:"task"`
+	Comment       *TaskComment `json:"comment"`
+	ParentComment *TaskComment `json:"parent_comment"`
+}
+
+func (n *TaskCommentReplyNotification) SubjectID() int64 {
+	return n.Comment.ID
+}
+
+// ToMail returns the mail notification for TaskCommentReplyNotification
+func (n *TaskCommentReplyNotification) ToMail() *notifications.Mail {
+	mail := notifications.NewMail().
+		From(n.Doer.GetNameAndFromEmail()).
+		Subject(n.Doer.GetName() + ` replied to your comment in "` + n.Task.Title + `"`).
+		Line("**" + n.Doer.GetName() + "** replied to your comment:")
+
+	lines := bufio.NewScanner(strings.NewReader(n.Comment.Comment))
+	for lines.Scan() {
+		mail.Line(lines.Text())
+	}
+
+	return mail.
+		Action("View Task", n.Task.GetFrontendURL())
+}
+
+// ToDB returns the TaskCommentReplyNotification notification in a format which can be saved in the db
+func (n *TaskCommentReplyNotification) ToDB() interface{} {
+	return n
+}
+
+// Name returns the name of the notification
+func (n *TaskCommentReplyNotification) Name() string {
+	return "task.comment.reply"
+}
+
 // TaskAssignedNotification represents a TaskAssignedNotification notification
 type TaskAssignedNotification struct {
 	Doer     *user.User `json:"doer"`
@@ -57,6 +57,9 @@ type Project struct {
 	// Whether a project is archived.
 	IsArchived bool `xorm:"not null default false" json:"is_archived" query:"is_archived"`
 
+	// If true, tasks of this project can't be marked as done while they are blocked by other tasks which are not done yet.
+	EnforceBlockingRelations bool `xorm:"not null default false" json:"enforce_blocking_relations"`
+
 	// The id of the file this project has set as background
 	BackgroundFileID int64 `xorm:"null" json:"-"`
 	// Holds extra information about the background set since some background providers require attribution or similar. If not null, the background can be accessed at /projects/{projectID}/background
@@ -74,10 +77,15 @@ type Project struct {
 	// The position this project has when querying all projects. See the tasks.position property on how to use this.
 	Position float64 `xorm:"double null" json:"position"`
 
+	// The total time in seconds all users tracked on tasks of this project. Only contains finished time entries, running timers are not included.
+	TimeSpent int64 `xorm:"-" json:"time_spent"`
+
 	// A timestamp when this project was created. You cannot change this value.
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this project was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
+	// A timestamp when this project was moved to the trash. Deleted projects are only visible in the trash.
+	Deleted time.Time `xorm:"deleted INDEX null" json:"-"`
 
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
@@ -155,11 +163,18 @@ func (p *Project) ReadAll(s *xorm.Session, a web.Auth, search string, page int,
 	// Check if we're dealing with a share auth
 	shareAuth, ok := a.(*LinkSharing)
 	if ok {
-		project, err := GetProjectSimpleByID(s, shareAuth.ProjectID)
+		projectIDs := []int64{shareAuth.ProjectID}
+		if shareAuth.IncludeChildProjects {
+			projectIDs, err = getProjectIDsWithChildren(s, shareAuth.ProjectID)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+		}
+		projects := []*Project{}
+		err = s.In("id", projectIDs).OrderBy("position asc, id asc").Find(&projects)
 		if err != nil {
 			return nil, 0, 0, err
 		}
-		projects := []*Project{project}
 		err = addProjectDetails(s, projects, a)
 		return projects, 0, 0, err
 	}
@@ -234,9 +249,14 @@ func (p *Project) ReadOne(s *xorm.Session, a web.Auth) (err error) {
 		}
 		p.Title = sf.Title
 		p.Description = sf.Description
+		p.IsFavorite = sf.IsFavorite
 		p.Created = sf.Created
 		p.Updated = sf.Updated
 		p.OwnerID = sf.OwnerID
+
+		// A saved filter has no archive state, background, time entries or subscriptions of its own
+		p.Owner, err = user.GetUserByID(s, p.OwnerID)
+		return err
 	}
 
 	// Get project owner
@@ -271,6 +291,12 @@ func (p *Project) ReadOne(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	timeSpent, err := getTimeSpentForProjects(s, []int64{p.ID})
+	if err != nil {
+		return
+	}
+	p.TimeSpent = timeSpent[p.ID]
+
 	p.Subscription, err = GetSubscription(s, SubscriptionEntityProject, p.ID, a)
 	return
 }
@@ -307,7 +333,7 @@ func GetProjectSimplByTaskID(s *xorm.Session, taskID int64) (l *Project, err err
 	exists, err := s.
 		Select("projects.*").
 		Table(Project{}).
-		Join("INNER", "tasks", "projects.id = tasks.project_id").
+		Join("INNER", "tasks", "projects.id = tasks.project_id AND tasks.deleted IS NULL").
 		Where("tasks.id = ?", taskID).
 		Get(&project)
 	if err != nil {
@@ -400,6 +426,7 @@ func getUserProjectsStatement(parentProjectIDs []int64, userID int64, search str
 			filterCond,
 			getArchivedCond,
 			parentCondition,
+			builder.IsNull{"l.deleted"},
 		)).
 		OrderBy("position").
 		GroupBy("l.id")
@@ -548,10 +575,16 @@ func addProjectDetails(s *xorm.Session, projects []*Project, a web.Auth) (err er
 		subscriptions = make(map[int64][]*Subscription)
 	}
 
+	timeSpent, err := getTimeSpentForProjects(s, projectIDs)
+	if err != nil {
+		return err
+	}
+
 	for _, p := range projects {
 		if o, exists := owners[p.OwnerID]; exists {
 			p.Owner = o
 		}
+		p.TimeSpent = timeSpent[p.ID]
 		if p.BackgroundFileID != 0 {
 			p.BackgroundInformation = &ProjectBackgroundType{Type: ProjectBackgroundUpload}
 		}
@@ -705,18 +738,19 @@ func CreateProject(s *xorm.Session, project *Project, auth web.Auth) (err error)
 	if err != nil {
 		return
 	}
+
+	err = createProjectSyncSequence(s, project.ID)
+	if err != nil {
+		return
+	}
 	if project.IsFavorite {
 		if err := addToFavorites(s, project.ID, auth, FavoriteKindProject); err != nil {
 			return err
 		}
 	}
 
-	// Create a new first bucket for this project
-	b := &Bucket{
-		ProjectID: project.ID,
-		Title:     "Backlog",
-	}
-	err = b.Create(s, auth)
+	// Create the default views, this includes the first bucket of the kanban view
+	err = createDefaultViewsForProject(s, project, auth)
 	if err != nil {
 		return
 	}
@@ -761,6 +795,7 @@ func UpdateProject(s *xorm.Session, project *Project, auth web.Auth, updateProje
 		"hex_color",
 		"parent_project_id",
 		"position",
+		"enforce_blocking_relations",
 	}
 	if project.Description != "" {
 		colsToUpdate = append(colsToUpdate, "description")
@@ -935,26 +970,64 @@ func (p *Project) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Router /projects/{id} [delete]
 func (p *Project) Delete(s *xorm.Session, a web.Auth) (err error) {
 
-	fullList, err := GetProjectSimpleByID(s, p.ID)
+	// Webhooks of parent projects should get the event as well, but the parents can't be found from
+	// the project once it is in the trash.
+	full, err := GetProjectSimpleByID(s, p.ID)
+	if err != nil {
+		return err
+	}
+	err = full.GetAllParentProjects(s)
+	if err != nil {
+		return err
+	}
+	parentIDs := []int64{}
+	for parent := full.ParentProject; parent != nil; parent = parent.ParentProject {
+		parentIDs = append(parentIDs, parent.ID)
+	}
+
+	// Child projects go to the trash together with the project so that they are restored with it.
+	// Children which already are in the trash keep their own deletion date.
+	projectIDs, err := getProjectIDsWithChildren(s, p.ID)
+	if err != nil {
+		return err
+	}
+
+	// The project is only moved to the trash. Its tasks, views and everything else stay in place
+	// but are not reachable any more until the project is restored or purged.
+	_, err = s.In("id", projectIDs).Delete(&Project{})
 	if err != nil {
 		return
 	}
 
-	// Delete the project
-	_, err = s.ID(p.ID).Delete(&Project{})
+	return events.Dispatch(&ProjectDeletedEvent{
+		Project:          p,
+		Doer:             a,
+		ParentProjectIDs: parentIDs,
+	})
+}
+
+// purgeProject permanently removes a project with all of its tasks, views and files.
+func purgeProject(s *xorm.Session, p *Project) (err error) {
+
+	fullList := &Project{}
+	exists, err := s.Unscoped().Where("id = ?", p.ID).Get(fullList)
 	if err != nil {
 		return
 	}
+	if !exists {
+		return ErrProjectDoesNotExist{ID: p.ID}
+	}
 
-	// Delete all tasks on that project
+	// Delete all tasks on that project, including the ones already in the trash
 	// Using the loop to make sure all related entities to all tasks are properly deleted as well.
-	tasks, _, _, err := getRawTasksForProjects(s, []*Project{p}, a, &taskOptions{})
+	tasks := []*Task{}
+	err = s.Unscoped().Where("project_id = ?", p.ID).Find(&tasks)
 	if err != nil {
 		return
 	}
 
 	for _, task := range tasks {
-		err = task.Delete(s, a)
+		err = purgeTask(s, task)
 		if err != nil {
 			return err
 		}
@@ -965,10 +1038,56 @@ func (p *Project) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
-	return events.Dispatch(&ProjectDeletedEvent{
-		Project: p,
-		Doer:    a,
-	})
+	err = deleteCustomFieldsOfProject(s, p.ID)
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("project_id = ?", p.ID).Delete(&ProjectTemplate{})
+	if err != nil {
+		return
+	}
+
+	// Scheduled templates should not create projects at the top level instead
+	_, err = s.
+		Where("schedule_parent_project_id = ?", p.ID).
+		Cols("schedule", "schedule_parent_project_id").
+		Update(&ProjectTemplate{})
+	if err != nil {
+		return
+	}
+
+	// Delete all webhooks of that project
+	_, err = s.
+		In("webhook_id", builder.Select("id").From("webhooks").Where(builder.Eq{"project_id": p.ID})).
+		Delete(&WebhookRetry{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("project_id = ?", p.ID).Delete(&Webhook{})
+	if err != nil {
+		return
+	}
+
+	err = deleteTaskSyncChangesOfProject(s, p.ID)
+	if err != nil {
+		return
+	}
+
+	// Delete all views and their buckets
+	_, err = s.Unscoped().Where("project_id = ?", p.ID).Delete(&Bucket{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("project_id = ?", p.ID).Delete(&ProjectView{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Unscoped().ID(p.ID).Delete(&Project{})
+	return
 }
 
 // DeleteBackgroundFileIfExists deletes the list's background file from the db and the filesystem,
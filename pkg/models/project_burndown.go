@@ -0,0 +1,228 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+const (
+	burndownDateFormat  = "2006-01-02"
+	burndownDefaultDays = 14
+	burndownMaxDays     = 366
+)
+
+// ProjectBurndown holds the remaining estimate of all tasks of a project and its child projects for every day in a date range.
+type ProjectBurndown struct {
+	ProjectID int64 `json:"-" param:"project"`
+	// The first day of the burndown as a date like 2023-06-01. Defaults to 13 days before the last day.
+	From string `json:"-" query:"from"`
+	// The last day of the burndown as a date like 2023-06-14. Defaults to today.
+	To string `json:"-" query:"to"`
+
+	// The ids of the project and all of its child projects whose tasks are included.
+	ProjectIDs []int64 `json:"project_ids"`
+	// One entry for every day in the range, starting with the first one.
+	Days []*BurndownDay `json:"days"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// BurndownDay holds the burndown values at the end of one day.
+type BurndownDay struct {
+	// The day as a date like 2023-06-01.
+	Date string `json:"date"`
+	// The sum of the story points of all tasks which were created but not done at the end of the day.
+	RemainingStoryPoints float64 `json:"remaining_story_points"`
+	// The sum of the time estimates in seconds of all tasks which were created but not done at the end of the day.
+	RemainingTimeEstimate int64 `json:"remaining_time_estimate"`
+	// The story points of all tasks which were done on this day.
+	CompletedStoryPoints float64 `json:"completed_story_points"`
+	// The time estimates in seconds of all tasks which were done on this day.
+	CompletedTimeEstimate int64 `json:"completed_time_estimate"`
+	// The remaining story points if the work was spread evenly over the range, starting with the remaining story points of the first day.
+	IdealStoryPoints float64 `json:"ideal_story_points"`
+	// The remaining time estimate if the work was spread evenly over the range, starting with the remaining time estimate of the first day.
+	IdealTimeEstimate int64 `json:"ideal_time_estimate"`
+}
+
+// getProjectIDsWithChildren returns the id of a project and the ids of all of its child projects, recursively.
+func getProjectIDsWithChildren(s *xorm.Session, projectID int64) (projectIDs []int64, err error) {
+	projectIDs = []int64{projectID}
+	seen := map[int64]bool{projectID: true}
+	parentIDs := []int64{projectID}
+
+	for len(parentIDs) > 0 {
+		children := []*Project{}
+		err = s.
+			Cols("id").
+			In("parent_project_id", parentIDs).
+			Find(&children)
+		if err != nil {
+			return nil, err
+		}
+
+		parentIDs = []int64{}
+		for _, child := range children {
+			if seen[child.ID] {
+				continue
+			}
+			seen[child.ID] = true
+			projectIDs = append(projectIDs, child.ID)
+			parentIDs = append(parentIDs, child.ID)
+		}
+	}
+
+	return
+}
+
+func parseBurndownDate(value string) (date time.Time, err error) {
+	parsed, err := parseTimeFromUserInput(value)
+	if err != nil {
+		return
+	}
+	// Only the day matters, regardless of the time zone the date was given in
+	return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, config.GetTimeZone()), nil
+}
+
+func (b *ProjectBurndown) getDateRange() (from, to time.Time, err error) {
+	invalid := &ErrInvalidBurndownRange{ProjectID: b.ProjectID, From: b.From, To: b.To}
+
+	now := time.Now().In(config.GetTimeZone())
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, config.GetTimeZone())
+	if b.To != "" {
+		to, err = parseBurndownDate(b.To)
+		if err != nil {
+			return from, to, invalid
+		}
+	}
+
+	from = to.AddDate(0, 0, -(burndownDefaultDays - 1))
+	if b.From != "" {
+		from, err = parseBurndownDate(b.From)
+		if err != nil {
+			return from, to, invalid
+		}
+	}
+
+	if from.After(to) || from.AddDate(0, 0, burndownMaxDays).Before(to) {
+		return from, to, invalid
+	}
+
+	return from, to, nil
+}
+
+// doneAtForBurndown returns when a task was done. Tasks done before the done date was tracked use their last update instead.
+func (t *Task) doneAtForBurndown() time.Time {
+	if !t.DoneAt.IsZero() {
+		return t.DoneAt
+	}
+	return t.Updated
+}
+
+// ReadOne calculates the burndown of a project
+// @Summary Get the burndown of a project
+// @Description Returns the remaining story points and time estimate of all tasks in a project and its child projects for every day in a date range. A task counts as remaining from the day it was created until the day it was done.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param from query string false "The first day of the burndown, for example 2023-06-01. Defaults to 13 days before to."
+// @Param to query string false "The last day of the burndown, for example 2023-06-14. Defaults to today."
+// @Success 200 {object} models.ProjectBurndown "The burndown."
+// @Failure 400 {object} web.HTTPError "Invalid date range."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/burndown [get]
+func (b *ProjectBurndown) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	from, to, err := b.getDateRange()
+	if err != nil {
+		return err
+	}
+
+	b.ProjectIDs, err = getProjectIDsWithChildren(s, b.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	tasks := []*Task{}
+	err = s.
+		Cols("id", "done", "done_at", "created", "updated", "story_points", "time_estimate").
+		Where(builder.And(
+			builder.In("project_id", b.ProjectIDs),
+			builder.Or(
+				builder.Gt{"story_points": 0},
+				builder.Gt{"time_estimate": 0},
+			),
+		)).
+		Find(&tasks)
+	if err != nil {
+		return err
+	}
+
+	end := to.AddDate(0, 0, 1)
+	b.Days = []*BurndownDay{}
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+		bd := &BurndownDay{Date: day.Format(burndownDateFormat)}
+
+		for _, t := range tasks {
+			if !t.Created.Before(dayEnd) {
+				continue
+			}
+
+			if t.Done {
+				doneAt := t.doneAtForBurndown()
+				if doneAt.Before(dayEnd) {
+					if !doneAt.Before(day) {
+						bd.CompletedStoryPoints += t.StoryPoints
+						bd.CompletedTimeEstimate += t.TimeEstimate
+					}
+					continue
+				}
+			}
+
+			bd.RemainingStoryPoints += t.StoryPoints
+			bd.RemainingTimeEstimate += t.TimeEstimate
+		}
+
+		b.Days = append(b.Days, bd)
+	}
+
+	// The ideal line goes from the remaining estimate of the first day down to zero on the last day
+	first := b.Days[0]
+	steps := len(b.Days) - 1
+	for i, bd := range b.Days {
+		if steps == 0 {
+			bd.IdealStoryPoints = first.RemainingStoryPoints
+			bd.IdealTimeEstimate = first.RemainingTimeEstimate
+			continue
+		}
+		left := float64(steps-i) / float64(steps)
+		bd.IdealStoryPoints = first.RemainingStoryPoints * left
+		bd.IdealTimeEstimate = int64(float64(first.RemainingTimeEstimate) * left)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectBurndown_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	setEstimates := func(t *testing.T) {
+		_, err := db.NewSession().ID(36).Cols("story_points", "time_estimate").Update(&Task{StoryPoints: 5, TimeEstimate: 3600})
+		require.NoError(t, err)
+		doneAt := time.Date(2018, 12, 2, 10, 0, 0, 0, config.GetTimeZone())
+		_, err = db.NewSession().ID(38).Cols("story_points", "done_at").Update(&Task{StoryPoints: 3, DoneAt: doneAt})
+		require.NoError(t, err)
+		// Task 35 is in project 21, a child project of project 22
+		_, err = db.NewSession().ID(35).Cols("story_points").Update(&Task{StoryPoints: 2})
+		require.NoError(t, err)
+	}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setEstimates(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &ProjectBurndown{ProjectID: 22, From: "2018-11-30", To: "2018-12-03"}
+		can, _, err := b.CanRead(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = b.ReadOne(s, u)
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []int64{22, 21}, b.ProjectIDs)
+		require.Len(t, b.Days, 4)
+		assert.Equal(t, "2018-11-30", b.Days[0].Date)
+		assert.Equal(t, 0.0, b.Days[0].RemainingStoryPoints)
+		assert.Equal(t, 10.0, b.Days[1].RemainingStoryPoints)
+		assert.Equal(t, int64(3600), b.Days[1].RemainingTimeEstimate)
+		assert.Equal(t, 7.0, b.Days[2].RemainingStoryPoints)
+		assert.Equal(t, 3.0, b.Days[2].CompletedStoryPoints)
+		assert.Equal(t, 7.0, b.Days[3].RemainingStoryPoints)
+		assert.Equal(t, "2018-12-03", b.Days[3].Date)
+	})
+	t.Run("ideal line", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		setEstimates(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &ProjectBurndown{ProjectID: 22, From: "2018-12-01", To: "2018-12-05"}
+		err := b.ReadOne(s, u)
+		require.NoError(t, err)
+
+		require.Len(t, b.Days, 5)
+		assert.Equal(t, 10.0, b.Days[0].IdealStoryPoints)
+		assert.Equal(t, 5.0, b.Days[2].IdealStoryPoints)
+		assert.Equal(t, int64(1800), b.Days[2].IdealTimeEstimate)
+		assert.Equal(t, 0.0, b.Days[4].IdealStoryPoints)
+	})
+	t.Run("default range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &ProjectBurndown{ProjectID: 1}
+		err := b.ReadOne(s, u)
+		require.NoError(t, err)
+
+		require.Len(t, b.Days, 14)
+		assert.Equal(t, time.Now().In(config.GetTimeZone()).Format("2006-01-02"), b.Days[13].Date)
+	})
+	t.Run("invalid range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &ProjectBurndown{ProjectID: 1, From: "2018-12-05", To: "2018-12-01"}
+		err := b.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidBurndownRange(err))
+
+		b = &ProjectBurndown{ProjectID: 1, From: "2016-01-01", To: "2018-12-01"}
+		err = b.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidBurndownRange(err))
+
+		b = &ProjectBurndown{ProjectID: 1, From: "yesterday"}
+		err = b.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidBurndownRange(err))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &ProjectBurndown{ProjectID: 22}
+		can, _, err := b.CanRead(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
@@ -0,0 +1,769 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// ProjectCustomFieldType defines which kind of values a custom field holds
+type ProjectCustomFieldType int
+
+const (
+	ProjectCustomFieldTypeText ProjectCustomFieldType = iota
+	ProjectCustomFieldTypeNumber
+	ProjectCustomFieldTypeDate
+	ProjectCustomFieldTypeSelect
+	ProjectCustomFieldTypeUser
+)
+
+// Custom fields are filtered and sorted by with this prefix and their id, for example custom_field_3.
+const taskPropertyCustomFieldPrefix = "custom_field_"
+
+// ProjectCustomField is an extra attribute tasks of a project and all its child projects can have.
+type ProjectCustomField struct {
+	// The unique, numeric id of this custom field.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"customfield"`
+	// The title of this custom field.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The project this custom field was defined in. The field is available for all tasks of that project and its child projects.
+	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// The type of values this field holds. Can be `0` (text), `1` (number), `2` (date), `3` (single select) or `4` (user). The type can't be changed after the field was created.
+	FieldType ProjectCustomFieldType `xorm:"not null default 0" json:"field_type"`
+	// The values to choose from for single select fields.
+	Options []string `xorm:"JSON null" json:"options"`
+	// The position of this custom field in the list of all custom fields.
+	Position float64 `xorm:"double null" json:"position"`
+
+	// A timestamp when this custom field was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this custom field was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for custom fields
+func (*ProjectCustomField) TableName() string {
+	return "project_custom_fields"
+}
+
+// TaskCustomFieldValue is the value of a custom field on a task.
+type TaskCustomFieldValue struct {
+	ID      int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	TaskID  int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+	FieldID int64 `xorm:"bigint not null index" json:"field_id" param:"customfield"`
+
+	// The value of the custom field. Text and single select fields hold a string, number fields a number and date fields a date.
+	// When setting the value of a user field, pass either the id or the username of the user, you'll get the user object back.
+	Value interface{} `xorm:"-" json:"value"`
+
+	ValueText   string    `xorm:"text null" json:"-"`
+	ValueNumber float64   `xorm:"double null" json:"-"`
+	ValueDate   time.Time `xorm:"DATETIME null" json:"-"`
+	ValueUserID int64     `xorm:"bigint null" json:"-"`
+
+	// A timestamp when this value was first set. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this value was last changed. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for custom field values
+func (*TaskCustomFieldValue) TableName() string {
+	return "task_custom_field_values"
+}
+
+// column returns the column of the custom field values table which holds values of this type.
+func (t ProjectCustomFieldType) column() string {
+	switch t {
+	case ProjectCustomFieldTypeNumber:
+		return "value_number"
+	case ProjectCustomFieldTypeDate:
+		return "value_date"
+	case ProjectCustomFieldTypeUser:
+		return "value_user_id"
+	default:
+		return "value_text"
+	}
+}
+
+// filterValueType returns the type filter values for fields of this type are converted to.
+func (t ProjectCustomFieldType) filterValueType() reflect.Type {
+	switch t {
+	case ProjectCustomFieldTypeNumber:
+		return reflect.TypeOf(float64(0))
+	case ProjectCustomFieldTypeDate:
+		return schemas.TimeType
+	case ProjectCustomFieldTypeUser:
+		return reflect.TypeOf(int64(0))
+	default:
+		return reflect.TypeOf("")
+	}
+}
+
+// parseCustomFieldProperty returns the custom field id of a task property like custom_field_3.
+func parseCustomFieldProperty(property string) (fieldID int64, is bool) {
+	rawID, has := strings.CutPrefix(property, taskPropertyCustomFieldPrefix)
+	if !has {
+		return 0, false
+	}
+	fieldID, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil || fieldID < 1 {
+		return 0, false
+	}
+	return fieldID, true
+}
+
+func (cf *ProjectCustomField) validate() error {
+	if cf.FieldType < ProjectCustomFieldTypeText || cf.FieldType > ProjectCustomFieldTypeUser {
+		return &ErrInvalidProjectCustomFieldType{FieldType: cf.FieldType}
+	}
+
+	if cf.FieldType != ProjectCustomFieldTypeSelect {
+		cf.Options = nil
+		return nil
+	}
+
+	if len(cf.Options) == 0 {
+		return &ErrProjectCustomFieldNeedsOptions{CustomFieldID: cf.ID}
+	}
+	return nil
+}
+
+func (cf *ProjectCustomField) hasOption(option string) bool {
+	for _, o := range cf.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func getProjectCustomFieldByID(s *xorm.Session, id int64) (field *ProjectCustomField, err error) {
+	field = &ProjectCustomField{}
+	exists, err := s.Where("id = ?", id).Get(field)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrProjectCustomFieldDoesNotExist{CustomFieldID: id}
+	}
+	return
+}
+
+// getProjectCustomFieldByIDAndProject returns a custom field and makes sure it was defined in the project.
+func getProjectCustomFieldByIDAndProject(s *xorm.Session, id, projectID int64) (field *ProjectCustomField, err error) {
+	field, err = getProjectCustomFieldByID(s, id)
+	if err != nil {
+		return nil, err
+	}
+	if field.ProjectID != projectID {
+		return nil, &ErrProjectCustomFieldDoesNotExist{CustomFieldID: id}
+	}
+	return
+}
+
+func getProjectCustomFieldsByIDs(s *xorm.Session, ids []int64) (fields map[int64]*ProjectCustomField, err error) {
+	fields = make(map[int64]*ProjectCustomField, len(ids))
+	if len(ids) == 0 {
+		return
+	}
+	err = s.In("id", ids).Find(&fields)
+	return
+}
+
+// getProjectIDWithParentIDs returns the id of a project and the ids of all its parent projects.
+func getProjectIDWithParentIDs(s *xorm.Session, projectID int64) (projectIDs []int64, err error) {
+	seen := make(map[int64]bool)
+	for projectID > 0 && !seen[projectID] {
+		seen[projectID] = true
+		projectIDs = append(projectIDs, projectID)
+
+		project, err := GetProjectSimpleByID(s, projectID)
+		if err != nil {
+			return nil, err
+		}
+		projectID = project.ParentProjectID
+	}
+	return
+}
+
+// getProjectIDsWithParentIDs returns the ids of multiple projects and all their parent projects.
+func getProjectIDsWithParentIDs(s *xorm.Session, projectIDs []int64) (allProjectIDs map[int64]bool, err error) {
+	allProjectIDs = make(map[int64]bool, len(projectIDs))
+	for _, projectID := range projectIDs {
+		for projectID > 0 && !allProjectIDs[projectID] {
+			allProjectIDs[projectID] = true
+
+			project, err := GetProjectSimpleByID(s, projectID)
+			if err != nil {
+				return nil, err
+			}
+			projectID = project.ParentProjectID
+		}
+	}
+	return
+}
+
+// checkCustomFieldIsAvailableForTask makes sure a custom field was defined in the project of a task or one of its parents.
+func checkCustomFieldIsAvailableForTask(s *xorm.Session, field *ProjectCustomField, task *Task) error {
+	projectIDs, err := getProjectIDWithParentIDs(s, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	for _, id := range projectIDs {
+		if id == field.ProjectID {
+			return nil
+		}
+	}
+	return &ErrCustomFieldNotAvailableForTask{CustomFieldID: field.ID, TaskID: task.ID}
+}
+
+// Create creates a new custom field
+// @Summary Create a custom field
+// @Description Creates a new custom field in a project. The field is available for all tasks of the project and all of its child projects. You need to be admin of the project to do this.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param field body models.ProjectCustomField true "The custom field"
+// @Success 201 {object} models.ProjectCustomField "The created custom field."
+// @Failure 400 {object} web.HTTPError "Invalid custom field object provided."
+// @Failure 403 {object} web.HTTPError "The user is not admin of the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields [put]
+func (cf *ProjectCustomField) Create(s *xorm.Session, _ web.Auth) (err error) {
+	cf.ID = 0
+	err = cf.validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(cf)
+	return
+}
+
+// ReadOne returns a single custom field
+// @Summary Get one custom field
+// @Description Returns a custom field of a project.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param field path int true "Custom field ID"
+// @Success 200 {object} models.ProjectCustomField "The custom field."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields/{field} [get]
+func (cf *ProjectCustomField) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	field, err := getProjectCustomFieldByIDAndProject(s, cf.ID, cf.ProjectID)
+	if err != nil {
+		return err
+	}
+	*cf = *field
+	return
+}
+
+// ReadAll returns all custom fields of a project
+// @Summary Get all custom fields of a project
+// @Description Returns all custom fields which are available for tasks of a project. This includes the custom fields of all parent projects.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Success 200 {array} models.ProjectCustomField "The custom fields"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields [get]
+func (cf *ProjectCustomField) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	p := &Project{ID: cf.ProjectID}
+	can, _, err := p.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	projectIDs, err := getProjectIDWithParentIDs(s, cf.ProjectID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	fields := []*ProjectCustomField{}
+	err = s.
+		In("project_id", projectIDs).
+		OrderBy("position asc, id asc").
+		Find(&fields)
+	if err != nil {
+		return
+	}
+
+	return fields, len(fields), int64(len(fields)), nil
+}
+
+// Update changes a custom field
+// @Summary Update a custom field
+// @Description Updates the title, options and position of a custom field. The type of a field can't be changed. You need to be admin of the project the field was defined in to do this.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param field path int true "Custom field ID"
+// @Param customField body models.ProjectCustomField true "The custom field"
+// @Success 200 {object} models.ProjectCustomField "The updated custom field."
+// @Failure 400 {object} web.HTTPError "Invalid custom field object provided."
+// @Failure 403 {object} web.HTTPError "The user is not admin of the project."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields/{field} [post]
+func (cf *ProjectCustomField) Update(s *xorm.Session, _ web.Auth) (err error) {
+	existing, err := getProjectCustomFieldByIDAndProject(s, cf.ID, cf.ProjectID)
+	if err != nil {
+		return err
+	}
+	cf.FieldType = existing.FieldType
+
+	err = cf.validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		ID(cf.ID).
+		Cols("title", "options", "position").
+		Update(cf)
+	if err != nil {
+		return err
+	}
+
+	return cf.ReadOne(s, nil)
+}
+
+// Delete removes a custom field
+// @Summary Delete a custom field
+// @Description Deletes a custom field and its values on all tasks. You need to be admin of the project the field was defined in to do this.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param field path int true "Custom field ID"
+// @Success 200 {object} models.Message "The custom field was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user is not admin of the project."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/custom-fields/{field} [delete]
+func (cf *ProjectCustomField) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("field_id = ?", cf.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("id = ? AND project_id = ?", cf.ID, cf.ProjectID).Delete(&ProjectCustomField{})
+	return
+}
+
+// deleteCustomFieldsOfProject removes all custom fields which were defined in a project, along with their values.
+func deleteCustomFieldsOfProject(s *xorm.Session, projectID int64) (err error) {
+	_, err = s.
+		Where(builder.In("field_id", builder.Select("id").From("project_custom_fields").Where(builder.Eq{"project_id": projectID}))).
+		Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("project_id = ?", projectID).Delete(&ProjectCustomField{})
+	return
+}
+
+// setValue converts the raw value from the request to the type of the custom field.
+func (tv *TaskCustomFieldValue) setValue(s *xorm.Session, field *ProjectCustomField, raw interface{}) (err error) {
+	invalid := &ErrInvalidCustomFieldValue{CustomFieldID: field.ID, Value: raw}
+
+	tv.ValueText = ""
+	tv.ValueNumber = 0
+	tv.ValueDate = time.Time{}
+	tv.ValueUserID = 0
+
+	switch field.FieldType {
+	case ProjectCustomFieldTypeText, ProjectCustomFieldTypeSelect:
+		text, is := raw.(string)
+		if !is {
+			return invalid
+		}
+		if field.FieldType == ProjectCustomFieldTypeSelect && !field.hasOption(text) {
+			return invalid
+		}
+		tv.ValueText = text
+	case ProjectCustomFieldTypeNumber:
+		switch v := raw.(type) {
+		case float64:
+			tv.ValueNumber = v
+		case string:
+			tv.ValueNumber, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				return invalid
+			}
+		default:
+			return invalid
+		}
+	case ProjectCustomFieldTypeDate:
+		date, is := raw.(string)
+		if !is {
+			return invalid
+		}
+		tv.ValueDate, err = parseTimeFromUserInput(date)
+		if err != nil {
+			return invalid
+		}
+	case ProjectCustomFieldTypeUser:
+		var u *user.User
+		switch v := raw.(type) {
+		case float64:
+			u, err = user.GetUserByID(s, int64(v))
+		case string:
+			u, err = user.GetUserByUsername(s, v)
+		default:
+			return invalid
+		}
+		if err != nil {
+			if user.IsErrUserDoesNotExist(err) {
+				return invalid
+			}
+			return err
+		}
+		tv.ValueUserID = u.ID
+	}
+
+	return nil
+}
+
+// getValue returns the value of the custom field in its native type.
+func (tv *TaskCustomFieldValue) getValue(field *ProjectCustomField, users map[int64]*user.User) interface{} {
+	switch field.FieldType {
+	case ProjectCustomFieldTypeNumber:
+		return tv.ValueNumber
+	case ProjectCustomFieldTypeDate:
+		return tv.ValueDate
+	case ProjectCustomFieldTypeUser:
+		return users[tv.ValueUserID]
+	default:
+		return tv.ValueText
+	}
+}
+
+// Update sets the value of a custom field on a task
+// @Summary Set a custom field value
+// @Description Sets the value of a custom field on a task. The field has to be defined in the project of the task or one of its parent projects.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param field path int true "Custom field ID"
+// @Param value body models.TaskCustomFieldValue true "The value"
+// @Success 200 {object} models.TaskCustomFieldValue "The value of the custom field."
+// @Failure 400 {object} web.HTTPError "The value does not match the type of the custom field."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/custom-fields/{field} [post]
+func (tv *TaskCustomFieldValue) Update(s *xorm.Session, a web.Auth) (err error) {
+	field, err := getProjectCustomFieldByID(s, tv.FieldID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, tv.TaskID)
+	if err != nil {
+		return err
+	}
+
+	err = tv.setValue(s, field, tv.Value)
+	if err != nil {
+		return err
+	}
+
+	users := make(map[int64]*user.User)
+	if field.FieldType == ProjectCustomFieldTypeUser {
+		u, err := user.GetUserByID(s, tv.ValueUserID)
+		if err != nil {
+			return err
+		}
+		project := &Project{ID: task.ProjectID}
+		canRead, _, err := project.CanRead(s, u)
+		if err != nil {
+			return err
+		}
+		if !canRead {
+			return ErrUserDoesNotHaveAccessToProject{ProjectID: task.ProjectID, UserID: u.ID}
+		}
+		users[u.ID] = u
+	}
+
+	existing := &TaskCustomFieldValue{}
+	exists, err := s.
+		Where("task_id = ? AND field_id = ?", tv.TaskID, tv.FieldID).
+		Get(existing)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		tv.ID = existing.ID
+		_, err = s.
+			ID(tv.ID).
+			Cols("value_text", "value_number", "value_date", "value_user_id").
+			Update(tv)
+	} else {
+		tv.ID = 0
+		_, err = s.Insert(tv)
+	}
+	if err != nil {
+		return err
+	}
+
+	tv.Value = tv.getValue(field, users)
+
+	doer, _ := user.GetFromAuth(a)
+	return events.Dispatch(&TaskUpdatedEvent{
+		Task: &task,
+		Doer: doer,
+	})
+}
+
+// Delete removes the value of a custom field from a task
+// @Summary Remove a custom field value
+// @Description Removes the value of a custom field from a task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param field path int true "Custom field ID"
+// @Success 200 {object} models.Message "The value was successfully removed."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/custom-fields/{field} [delete]
+func (tv *TaskCustomFieldValue) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.
+		Where("task_id = ? AND field_id = ?", tv.TaskID, tv.FieldID).
+		Delete(&TaskCustomFieldValue{})
+	return
+}
+
+func addCustomFieldValuesToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) (err error) {
+	values := []*TaskCustomFieldValue{}
+	err = s.In("task_id", taskIDs).Find(&values)
+	if err != nil {
+		return err
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	fieldIDs := make([]int64, 0, len(values))
+	userIDs := []int64{}
+	for _, v := range values {
+		fieldIDs = append(fieldIDs, v.FieldID)
+		if v.ValueUserID != 0 {
+			userIDs = append(userIDs, v.ValueUserID)
+		}
+	}
+
+	fields, err := getProjectCustomFieldsByIDs(s, fieldIDs)
+	if err != nil {
+		return err
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		field, exists := fields[v.FieldID]
+		if !exists {
+			continue
+		}
+		task, exists := taskMap[v.TaskID]
+		if !exists {
+			continue
+		}
+		if task.CustomFields == nil {
+			task.CustomFields = make(map[int64]interface{})
+		}
+		task.CustomFields[v.FieldID] = v.getValue(field, users)
+	}
+
+	return nil
+}
+
+// getCustomFieldFilterValue converts the raw filter value of a custom field filter to the type of the field.
+func getCustomFieldFilterValue(field *ProjectCustomField, comparator taskFilterComparator, rawValue string) (value interface{}, err error) {
+	structField := reflect.StructField{
+		Name: taskPropertyCustomFieldPrefix + strconv.FormatInt(field.ID, 10),
+		Type: field.FieldType.filterValueType(),
+	}
+
+	if comparator == taskFilterComparatorIn {
+		values := []interface{}{}
+		for _, raw := range strings.Split(rawValue, ",") {
+			v, err := getValueForField(structField, raw)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+
+	if comparator == taskFilterComparatorLike && structField.Type.Kind() != reflect.String {
+		return nil, ErrInvalidTaskFilterValue{Field: structField.Name, Value: rawValue}
+	}
+
+	return getValueForField(structField, rawValue)
+}
+
+// resolveCustomFields loads the custom fields used in filters or for sorting and converts the filter values to the type of their field.
+// Only custom fields defined in one of the queried projects or one of their parent projects can be used.
+func (opts *taskOptions) resolveCustomFields(s *xorm.Session, projectIDs []int64) (err error) {
+	filters := append([]*taskFilter{}, opts.filters...)
+	filters = append(filters, getTaskFilterQueryFilters(opts.filterQuery)...)
+
+	fieldIDs := []int64{}
+	for _, f := range filters {
+		if id, is := parseCustomFieldProperty(f.field); is && f.customField == nil {
+			fieldIDs = append(fieldIDs, id)
+		}
+	}
+	for _, param := range opts.sortby {
+		if id, is := parseCustomFieldProperty(param.sortBy); is && param.customField == nil {
+			fieldIDs = append(fieldIDs, id)
+		}
+	}
+
+	if len(fieldIDs) > 0 {
+		fields, err := getProjectCustomFieldsByIDs(s, fieldIDs)
+		if err != nil {
+			return err
+		}
+
+		availableProjectIDs, err := getProjectIDsWithParentIDs(s, projectIDs)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			if !availableProjectIDs[field.ProjectID] {
+				return &ErrCustomFieldNotAvailableForProject{CustomFieldID: field.ID}
+			}
+		}
+
+		for _, f := range filters {
+			id, is := parseCustomFieldProperty(f.field)
+			if !is || f.customField != nil {
+				continue
+			}
+			field, exists := fields[id]
+			if !exists {
+				return &ErrProjectCustomFieldDoesNotExist{CustomFieldID: id}
+			}
+			rawValue, _ := f.value.(string)
+			f.value, err = getCustomFieldFilterValue(field, f.comparator, rawValue)
+			if err != nil {
+				return ErrInvalidTaskFilterValue{Field: f.field, Value: rawValue}
+			}
+			f.customField = field
+		}
+
+		for _, param := range opts.sortby {
+			id, is := parseCustomFieldProperty(param.sortBy)
+			if !is || param.customField != nil {
+				continue
+			}
+			field, exists := fields[id]
+			if !exists {
+				return &ErrProjectCustomFieldDoesNotExist{CustomFieldID: id}
+			}
+			param.customField = field
+		}
+	}
+
+	if opts.projectViewFilter != nil {
+		return opts.projectViewFilter.resolveCustomFields(s, projectIDs)
+	}
+
+	return nil
+}
+
+// getCustomFieldFilterCond returns the db condition for a filter on a custom field.
+// Tasks without a value for the custom field are only included if nulls are included.
+func getCustomFieldFilterCond(f *taskFilter, includeNulls bool) (cond builder.Cond, err error) {
+	valueFilter := *f
+	valueFilter.field = f.customField.FieldType.column()
+	valueCond, err := getFilterCond(&valueFilter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cond = builder.In(
+		"id",
+		builder.
+			Select("task_id").
+			From("task_custom_field_values").
+			Where(builder.And(builder.Eq{"field_id": f.customField.ID}, valueCond)),
+	)
+
+	if includeNulls {
+		cond = builder.Or(cond, builder.NotIn(
+			"id",
+			builder.
+				Select("task_id").
+				From("task_custom_field_values").
+				Where(builder.Eq{"field_id": f.customField.ID}),
+		))
+	}
+
+	return cond, nil
+}
+
+// getCustomFieldSortColumn returns a subquery with the value of a custom field for a task which can be used for sorting.
+func getCustomFieldSortColumn(field *ProjectCustomField) string {
+	return "(SELECT task_custom_field_values." + field.FieldType.column() +
+		" FROM task_custom_field_values WHERE task_custom_field_values.task_id = tasks.id AND task_custom_field_values.field_id = " +
+		strconv.FormatInt(field.ID, 10) + ")"
+}
@@ -0,0 +1,90 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read a custom field
+func (cf *ProjectCustomField) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: cf.ProjectID}
+	return p.CanRead(s, a)
+}
+
+// CanCreate checks if a user can create a custom field
+func (cf *ProjectCustomField) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	// Saved filters and the favorites pseudo project don't have custom fields
+	if cf.ProjectID < 1 {
+		return false, nil
+	}
+
+	p := &Project{ID: cf.ProjectID}
+	return p.IsAdmin(s, a)
+}
+
+// CanUpdate checks if a user can update a custom field
+func (cf *ProjectCustomField) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return cf.canDoProjectCustomField(s, a)
+}
+
+// CanDelete checks if a user can delete a custom field
+func (cf *ProjectCustomField) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return cf.canDoProjectCustomField(s, a)
+}
+
+func (cf *ProjectCustomField) canDoProjectCustomField(s *xorm.Session, a web.Auth) (bool, error) {
+	_, err := getProjectCustomFieldByIDAndProject(s, cf.ID, cf.ProjectID)
+	if err != nil {
+		return false, err
+	}
+
+	p := &Project{ID: cf.ProjectID}
+	return p.IsAdmin(s, a)
+}
+
+// CanUpdate checks if a user can set the value of a custom field on a task
+func (tv *TaskCustomFieldValue) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return tv.canDoTaskCustomFieldValue(s, a)
+}
+
+// CanDelete checks if a user can remove the value of a custom field from a task
+func (tv *TaskCustomFieldValue) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return tv.canDoTaskCustomFieldValue(s, a)
+}
+
+func (tv *TaskCustomFieldValue) canDoTaskCustomFieldValue(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: tv.TaskID}
+	canWrite, err := t.CanWrite(s, a)
+	if err != nil || !canWrite {
+		return false, err
+	}
+
+	task, err := GetTaskByIDSimple(s, tv.TaskID)
+	if err != nil {
+		return false, err
+	}
+
+	field, err := getProjectCustomFieldByID(s, tv.FieldID)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkCustomFieldIsAvailableForTask(s, field, &task)
+	return err == nil, err
+}
@@ -0,0 +1,341 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectCustomField_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ProjectID: 1,
+			Title:     "Priority level",
+			FieldType: ProjectCustomFieldTypeSelect,
+			Options:   []string{"low", "high"},
+		}
+		can, err := cf.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = cf.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "project_custom_fields", map[string]interface{}{
+			"id":         cf.ID,
+			"project_id": 1,
+			"title":      "Priority level",
+			"field_type": ProjectCustomFieldTypeSelect,
+		}, false)
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ProjectID: 1,
+			Title:     "Invalid",
+			FieldType: 42,
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidProjectCustomFieldType(err))
+	})
+	t.Run("select without options", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{
+			ProjectID: 1,
+			Title:     "Select",
+			FieldType: ProjectCustomFieldTypeSelect,
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrProjectCustomFieldNeedsOptions(err))
+	})
+	t.Run("no admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{ProjectID: 1}
+		can, err := cf.CanCreate(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestProjectCustomField_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{ProjectID: 1}
+		res, _, _, err := cf.ReadAll(s, &user.User{ID: 1}, "", 0, -1)
+		require.NoError(t, err)
+		fields := res.([]*ProjectCustomField)
+		require.Len(t, fields, 5)
+		assert.Equal(t, "Customer", fields[0].Title)
+		assert.Equal(t, []string{"dev", "staging", "prod"}, fields[2].Options)
+	})
+	t.Run("inherited from parent projects", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{ProjectID: 25}
+		res, _, _, err := cf.ReadAll(s, &user.User{ID: 6}, "", 0, -1)
+		require.NoError(t, err)
+		fields := res.([]*ProjectCustomField)
+		require.Len(t, fields, 1)
+		assert.Equal(t, int64(6), fields[0].ID)
+		assert.Equal(t, int64(27), fields[0].ProjectID)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{ProjectID: 1}
+		_, _, _, err := cf.ReadAll(s, &user.User{ID: 2}, "", 0, -1)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestProjectCustomField_Update(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+	u := &user.User{ID: 1}
+
+	cf := &ProjectCustomField{
+		ID:        1,
+		ProjectID: 1,
+		Title:     "Client",
+		FieldType: ProjectCustomFieldTypeNumber,
+	}
+	can, err := cf.CanUpdate(s, u)
+	require.NoError(t, err)
+	assert.True(t, can)
+	err = cf.Update(s, u)
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	// The type can't be changed
+	db.AssertExists(t, "project_custom_fields", map[string]interface{}{
+		"id":         1,
+		"title":      "Client",
+		"field_type": ProjectCustomFieldTypeText,
+	}, false)
+}
+
+func TestProjectCustomField_Delete(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		u := &user.User{ID: 1}
+
+		cf := &ProjectCustomField{ID: 2, ProjectID: 1}
+		can, err := cf.CanDelete(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = cf.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "project_custom_fields", map[string]interface{}{
+			"id": 2,
+		})
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"field_id": 2,
+		})
+	})
+	t.Run("only in the project it was defined in", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &ProjectCustomField{ID: 6, ProjectID: 25}
+		_, err := cf.CanDelete(s, &user.User{ID: 6})
+		assert.Error(t, err)
+		assert.True(t, IsErrProjectCustomFieldDoesNotExist(err))
+	})
+}
+
+func TestTaskCustomFieldValue_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("text", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 1, FieldID: 1, Value: "Globex"}
+		can, err := tv.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = tv.Update(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, "Globex", tv.Value)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"id":         1,
+			"task_id":    1,
+			"field_id":   1,
+			"value_text": "Globex",
+		}, false)
+	})
+	t.Run("number from string", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 4, FieldID: 2, Value: "13.5"}
+		err := tv.Update(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, 13.5, tv.Value)
+	})
+	t.Run("date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 4, FieldID: 4, Value: "2023-07-01T12:00:00Z"}
+		err := tv.Update(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC).In(config.GetTimeZone()), tv.Value)
+	})
+	t.Run("user by username", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 4, FieldID: 5, Value: "user1"}
+		err := tv.Update(s, u)
+		require.NoError(t, err)
+		reviewer, is := tv.Value.(*user.User)
+		require.True(t, is)
+		assert.Equal(t, int64(1), reviewer.ID)
+	})
+	t.Run("user without access to the project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 4, FieldID: 5, Value: float64(2)}
+		err := tv.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToProject(err))
+	})
+	t.Run("invalid select option", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 1, FieldID: 3, Value: "qa"}
+		err := tv.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("wrong type", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 1, FieldID: 2, Value: "lots"}
+		err := tv.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("field of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 1, FieldID: 6}
+		_, err := tv.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldNotAvailableForTask(err))
+	})
+	t.Run("inherited field", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tv := &TaskCustomFieldValue{TaskID: 39, FieldID: 6, Value: "Frontend"}
+		can, err := tv.CanUpdate(s, &user.User{ID: 6})
+		require.NoError(t, err)
+		assert.True(t, can)
+	})
+}
+
+func TestTaskCustomFieldValue_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+	u := &user.User{ID: 1}
+
+	tv := &TaskCustomFieldValue{TaskID: 1, FieldID: 1}
+	can, err := tv.CanDelete(s, u)
+	require.NoError(t, err)
+	assert.True(t, can)
+	err = tv.Delete(s, u)
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+		"task_id":  1,
+		"field_id": 1,
+	})
+}
+
+func TestTask_ReadOne_CustomFields(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	task := &Task{ID: 39}
+	err := task.ReadOne(s, &user.User{ID: 6})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]interface{}{6: "Backend"}, task.CustomFields)
+}
@@ -91,28 +91,21 @@ func (ld *ProjectDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 
 	log.Debugf("Duplicated project %d into new project %d", ld.ProjectID, ld.Project.ID)
 
-	// Duplicate kanban buckets
-	// Old bucket ID as key, new id as value
-	// Used to map the newly created tasks to their new buckets
-	bucketMap := make(map[int64]int64)
-	buckets := []*Bucket{}
-	err = s.Where("project_id = ?", ld.ProjectID).Find(&buckets)
+	viewMap, err := duplicateProjectViews(s, ld, doer)
 	if err != nil {
 		return
 	}
-	for _, b := range buckets {
-		oldID := b.ID
-		b.ID = 0
-		b.ProjectID = ld.Project.ID
-		if err := b.Create(s, doer); err != nil {
-			return err
-		}
-		bucketMap[oldID] = b.ID
+
+	log.Debugf("Duplicated all views from project %d into %d", ld.ProjectID, ld.Project.ID)
+
+	bucketMap, err := duplicateBuckets(s, ld, doer, viewMap)
+	if err != nil {
+		return
 	}
 
 	log.Debugf("Duplicated all buckets from project %d into %d", ld.ProjectID, ld.Project.ID)
 
-	err = duplicateTasks(s, doer, ld, bucketMap)
+	err = duplicateTasks(s, doer, ld, viewMap, bucketMap)
 	if err != nil {
 		return
 	}
@@ -206,7 +199,74 @@ func (ld *ProjectDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 	return
 }
 
-func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucketMap map[int64]int64) (err error) {
+// duplicateProjectViews copies all views of a project into the duplicated project.
+// It returns a map with the old view id as key and the new id as value.
+func duplicateProjectViews(s *xorm.Session, ld *ProjectDuplicate, doer web.Auth) (viewMap map[int64]int64, err error) {
+	viewMap = make(map[int64]int64)
+
+	views := []*ProjectView{}
+	err = s.Where("project_id = ?", ld.ProjectID).Find(&views)
+	if err != nil {
+		return
+	}
+
+	for _, view := range views {
+		oldID := view.ID
+
+		// The new project already has its default views, we only need to copy their settings
+		if view.IsDefault {
+			newView, err := getDefaultProjectView(s, ld.Project.ID, view.ViewKind)
+			if err != nil {
+				return nil, err
+			}
+			newView.Title = view.Title
+			newView.Filter = view.Filter
+			newView.Position = view.Position
+			_, err = s.ID(newView.ID).Cols("title", "filter", "position").Update(newView)
+			if err != nil {
+				return nil, err
+			}
+			viewMap[oldID] = newView.ID
+			continue
+		}
+
+		view.ID = 0
+		view.ProjectID = ld.Project.ID
+		// The buckets are copied separately
+		err = createProjectView(s, view, doer, false)
+		if err != nil {
+			return nil, err
+		}
+		viewMap[oldID] = view.ID
+	}
+
+	return
+}
+
+// duplicateBuckets copies all kanban buckets of a project into the duplicated project.
+// It returns a map with the old bucket id as key and the new id as value which is
+// used to map the newly created tasks to their new buckets.
+func duplicateBuckets(s *xorm.Session, ld *ProjectDuplicate, doer web.Auth, viewMap map[int64]int64) (bucketMap map[int64]int64, err error) {
+	bucketMap = make(map[int64]int64)
+	buckets := []*Bucket{}
+	err = s.Where("project_id = ?", ld.ProjectID).Find(&buckets)
+	if err != nil {
+		return
+	}
+	for _, b := range buckets {
+		oldID := b.ID
+		b.ID = 0
+		b.ProjectID = ld.Project.ID
+		b.ProjectViewID = viewMap[b.ProjectViewID]
+		if err := b.Create(s, doer); err != nil {
+			return nil, err
+		}
+		bucketMap[oldID] = b.ID
+	}
+	return
+}
+
+func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, viewMap map[int64]int64, bucketMap map[int64]int64) (err error) {
 	// Get all tasks + all task details
 	tasks, _, _, err := getTasksForProjects(s, []*Project{{ID: ld.ProjectID}}, doer, &taskOptions{})
 	if err != nil {
@@ -238,6 +298,13 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 
 	log.Debugf("Duplicated all tasks from project %d into %d", ld.ProjectID, ld.Project.ID)
 
+	err = duplicateTaskBucketsAndPositions(s, oldTaskIDs, taskMap, viewMap, bucketMap)
+	if err != nil {
+		return
+	}
+
+	log.Debugf("Duplicated all task buckets and positions from project %d into %d", ld.ProjectID, ld.Project.ID)
+
 	// Save all attachments
 	// We also duplicate all underlying files since they could be modified in one project which would result in
 	// file changes in the other project which is not something we want.
@@ -281,21 +348,11 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 
 	log.Debugf("Duplicated all attachments from project %d into %d", ld.ProjectID, ld.Project.ID)
 
-	// Copy label tasks (not the labels)
-	labelTasks := []*LabelTask{}
-	err = s.In("task_id", oldTaskIDs).Find(&labelTasks)
+	err = duplicateLabelTasks(s, oldTaskIDs, taskMap)
 	if err != nil {
 		return
 	}
 
-	for _, lt := range labelTasks {
-		lt.ID = 0
-		lt.TaskID = taskMap[lt.TaskID]
-		if _, err := s.Insert(lt); err != nil {
-			return err
-		}
-	}
-
 	log.Debugf("Duplicated all labels from project %d into %d", ld.ProjectID, ld.Project.ID)
 
 	// Assignees
@@ -336,9 +393,105 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 
 	log.Debugf("Duplicated all comments from project %d into %d", ld.ProjectID, ld.Project.ID)
 
-	// Relations in that project
-	// Low-Effort: Only copy those relations which are between tasks in the same project
-	// because we can do that without a lot of hassle
+	// Time entries
+	// Running timers are not copied because a user can only have one running timer.
+	timeEntries := []*TaskTimeEntry{}
+	err = s.
+		In("task_id", oldTaskIDs).
+		And("end_time IS NOT NULL").
+		Find(&timeEntries)
+	if err != nil {
+		return
+	}
+	for _, te := range timeEntries {
+		te.ID = 0
+		te.TaskID = taskMap[te.TaskID]
+		if _, err := s.Insert(te); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Duplicated all time entries from project %d into %d", ld.ProjectID, ld.Project.ID)
+
+	err = duplicateTaskRelations(s, oldTaskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	log.Debugf("Duplicated all task relations from project %d into %d", ld.ProjectID, ld.Project.ID)
+
+	return nil
+}
+
+// duplicateTaskBucketsAndPositions copies the buckets and positions tasks have in views which keep their own.
+func duplicateTaskBucketsAndPositions(s *xorm.Session, oldTaskIDs []int64, taskMap, viewMap, bucketMap map[int64]int64) (err error) {
+	taskBuckets := []*TaskBucket{}
+	err = s.In("task_id", oldTaskIDs).Find(&taskBuckets)
+	if err != nil {
+		return
+	}
+	for _, tb := range taskBuckets {
+		newTaskID, exists := taskMap[tb.TaskID]
+		if !exists {
+			continue
+		}
+		err = setTaskBucketForView(s, newTaskID, &Bucket{
+			ID:            bucketMap[tb.BucketID],
+			ProjectViewID: viewMap[tb.ProjectViewID],
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	taskPositions := []*TaskPosition{}
+	err = s.In("task_id", oldTaskIDs).Find(&taskPositions)
+	if err != nil {
+		return
+	}
+	for _, tp := range taskPositions {
+		newTaskID, exists := taskMap[tp.TaskID]
+		if !exists {
+			continue
+		}
+		tp.ID = 0
+		tp.TaskID = newTaskID
+		tp.ProjectViewID = viewMap[tp.ProjectViewID]
+		if _, err := s.Insert(tp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// duplicateLabelTasks copies the label associations (not the labels) of tasks.
+func duplicateLabelTasks(s *xorm.Session, oldTaskIDs []int64, taskMap map[int64]int64) (err error) {
+	labelTasks := []*LabelTask{}
+	err = s.In("task_id", oldTaskIDs).Find(&labelTasks)
+	if err != nil {
+		return
+	}
+
+	for _, lt := range labelTasks {
+		newTaskID, exists := taskMap[lt.TaskID]
+		if !exists {
+			continue
+		}
+		lt.ID = 0
+		lt.TaskID = newTaskID
+		if _, err := s.Insert(lt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// duplicateTaskRelations copies all relations between the copied tasks.
+// Low-Effort: Only copy those relations which are between tasks in the same project
+// because we can do that without a lot of hassle
+func duplicateTaskRelations(s *xorm.Session, oldTaskIDs []int64, taskMap map[int64]int64) (err error) {
 	relations := []*TaskRelation{}
 	err = s.In("task_id", oldTaskIDs).Find(&relations)
 	if err != nil {
@@ -349,15 +502,17 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 		if !exists {
 			continue
 		}
+		taskID, exists := taskMap[r.TaskID]
+		if !exists {
+			continue
+		}
 		r.ID = 0
 		r.OtherTaskID = otherTaskID
-		r.TaskID = taskMap[r.TaskID]
+		r.TaskID = taskID
 		if _, err := s.Insert(r); err != nil {
 			return err
 		}
 	}
 
-	log.Debugf("Duplicated all task relations from project %d into %d", ld.ProjectID, ld.Project.ID)
-
 	return nil
 }
@@ -44,6 +44,26 @@ func TestProjectDuplicate(t *testing.T) {
 	assert.True(t, can)
 	err = l.Create(s, u)
 	assert.NoError(t, err)
+
+	views := []*ProjectView{}
+	err = s.Where("project_id = ?", l.Project.ID).OrderBy("position asc").Find(&views)
+	assert.NoError(t, err)
+	assert.Len(t, views, 6)
+	ownKanban := views[4]
+	assert.Equal(t, "Own Kanban", ownKanban.Title)
+	count, err := s.Where("project_view_id = ?", ownKanban.ID).Count(&Bucket{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	count, err = s.Where("project_view_id = ?", ownKanban.ID).Count(&TaskBucket{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(18), count)
+	// Running timers are not duplicated
+	count, err = s.
+		Join("INNER", "tasks", "tasks.id = task_time_entries.task_id").
+		Where("tasks.project_id = ?", l.Project.ID).
+		Count(&TaskTimeEntry{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
 	// To make this test 100% useful, it would need to assert a lot more stuff, but it is good enough for now.
 	// Also, we're lacking utility functions to do all needed assertions.
 }
@@ -48,7 +48,11 @@ func (p *Project) CanWrite(s *xorm.Session, a web.Auth) (bool, error) {
 	// Check if we're dealing with a share auth
 	shareAuth, ok := a.(*LinkSharing)
 	if ok {
-		return originalProject.ID == shareAuth.ProjectID &&
+		covered, err := shareAuth.canAccessProject(s, originalProject)
+		if err != nil {
+			return false, err
+		}
+		return covered &&
 			(shareAuth.Right == RightWrite || shareAuth.Right == RightAdmin), errIsArchived
 	}
 
@@ -101,7 +105,11 @@ func (p *Project) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
 	// Check if we're dealing with a share auth
 	shareAuth, ok := a.(*LinkSharing)
 	if ok {
-		return p.ID == shareAuth.ProjectID &&
+		covered, err := shareAuth.canAccessProject(s, p)
+		if err != nil {
+			return false, 0, err
+		}
+		return covered &&
 			(shareAuth.Right == RightRead || shareAuth.Right == RightWrite || shareAuth.Right == RightAdmin), int(shareAuth.Right), nil
 	}
 
@@ -191,7 +199,11 @@ func (p *Project) IsAdmin(s *xorm.Session, a web.Auth) (bool, error) {
 	// Check if we're dealing with a share auth
 	shareAuth, ok := a.(*LinkSharing)
 	if ok {
-		return originalProject.ID == shareAuth.ProjectID && shareAuth.Right == RightAdmin, nil
+		covered, err := shareAuth.canAccessProject(s, originalProject)
+		if err != nil {
+			return false, err
+		}
+		return covered && shareAuth.Right == RightAdmin, nil
 	}
 
 	// Check all the things
@@ -0,0 +1,426 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+const (
+	projectStatsDefaultWeeks = 12
+	projectStatsMaxWeeks     = 104
+)
+
+// ProjectStats holds aggregated task counts of a project.
+type ProjectStats struct {
+	ProjectID int64 `json:"-" param:"project"`
+	// If true, the tasks of all child projects are included as well.
+	IncludeChildProjects bool `json:"-" query:"include_child_projects"`
+	// The number of weeks, including the current one, for which created and completed tasks are counted. Defaults to 12.
+	Weeks int `json:"-" query:"weeks"`
+
+	// The ids of all projects whose tasks are included.
+	ProjectIDs []int64 `json:"project_ids"`
+	// The number of tasks which are not done.
+	OpenTasks int64 `json:"open_tasks"`
+	// The number of tasks which are done.
+	DoneTasks int64 `json:"done_tasks"`
+	// The number of tasks which are not done and have a due date in the past.
+	OverdueTasks int64 `json:"overdue_tasks"`
+	// The number of tasks per assigned user.
+	Assignees []*ProjectStatsAssignee `json:"assignees"`
+	// The number of tasks per label.
+	Labels []*ProjectStatsLabel `json:"labels"`
+	// The number of tasks per kanban bucket. Buckets without tasks are not included.
+	Buckets []*ProjectStatsBucket `json:"buckets"`
+	// The number of created and completed tasks per week, starting with the oldest one.
+	Weekly []*ProjectStatsWeek `json:"weekly"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// ProjectStatsAssignee holds the task counts of one assignee.
+type ProjectStatsAssignee struct {
+	User      *user.User `json:"user"`
+	OpenTasks int64      `json:"open_tasks"`
+	DoneTasks int64      `json:"done_tasks"`
+}
+
+// ProjectStatsLabel holds the task counts of one label.
+type ProjectStatsLabel struct {
+	LabelID   int64  `json:"label_id"`
+	Title     string `json:"title"`
+	HexColor  string `json:"hex_color"`
+	OpenTasks int64  `json:"open_tasks"`
+	DoneTasks int64  `json:"done_tasks"`
+}
+
+// ProjectStatsBucket holds the number of tasks in one kanban bucket.
+type ProjectStatsBucket struct {
+	BucketID      int64   `json:"bucket_id"`
+	Title         string  `json:"title"`
+	ProjectViewID int64   `json:"project_view_id"`
+	Position      float64 `json:"-"`
+	Tasks         int64   `json:"tasks"`
+}
+
+// ProjectStatsWeek holds the number of tasks created and completed in one week.
+type ProjectStatsWeek struct {
+	// The monday the week starts with as a date like 2023-06-05.
+	Week string `json:"week"`
+	// The number of tasks created in this week.
+	Created int64 `json:"created"`
+	// The number of tasks done in this week.
+	Completed int64 `json:"completed"`
+}
+
+type projectStatsCount struct {
+	ID       int64
+	Title    string
+	HexColor string
+	Done     bool
+	Count    int64
+}
+
+type projectStatsWeekCount struct {
+	Week  int64
+	Count int64
+}
+
+// getWeekStarts returns the start of every week in the stats window, starting with the oldest one.
+func (ps *ProjectStats) getWeekStarts() (weekStarts []time.Time, err error) {
+	if ps.Weeks == 0 {
+		ps.Weeks = projectStatsDefaultWeeks
+	}
+	if ps.Weeks < 1 || ps.Weeks > projectStatsMaxWeeks {
+		return nil, &ErrInvalidProjectStatsWeeks{ProjectID: ps.ProjectID, Weeks: ps.Weeks}
+	}
+
+	now := time.Now().In(config.GetTimeZone())
+	// Weeks start on monday
+	daysSinceMonday := (int(now.Weekday()) + 6) % 7
+	current := time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, config.GetTimeZone())
+
+	weekStarts = make([]time.Time, 0, ps.Weeks)
+	for i := ps.Weeks - 1; i >= 0; i-- {
+		weekStarts = append(weekStarts, current.AddDate(0, 0, -7*i))
+	}
+	return
+}
+
+// weekCaseExpression returns an sql expression mapping a date column to the index of its week in weekStarts.
+// Dates outside the window map to NULL. The week starts are generated by the server, so inlining them is safe.
+// The database stores all dates in GMT.
+func weekCaseExpression(column string, weekStarts []time.Time) string {
+	expr := strings.Builder{}
+	expr.WriteString("CASE")
+	for i, start := range weekStarts {
+		end := start.AddDate(0, 0, 7)
+		expr.WriteString(" WHEN " + column + " >= '" + start.UTC().Format(dbTimeFormat) + "'")
+		expr.WriteString(" AND " + column + " < '" + end.UTC().Format(dbTimeFormat) + "'")
+		expr.WriteString(" THEN " + strconv.Itoa(i))
+	}
+	expr.WriteString(" END")
+	return expr.String()
+}
+
+func (ps *ProjectStats) countTasks(s *xorm.Session, taskCond builder.Cond) (err error) {
+	counts := []*projectStatsCount{}
+	err = s.
+		Select("tasks.done, COUNT(*) AS count").
+		Table("tasks").
+		Where(taskCond).
+		GroupBy("tasks.done").
+		Find(&counts)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range counts {
+		if c.Done {
+			ps.DoneTasks += c.Count
+		} else {
+			ps.OpenTasks += c.Count
+		}
+	}
+
+	ps.OverdueTasks, err = s.
+		Table("tasks").
+		Where(builder.And(
+			taskCond,
+			builder.Eq{"tasks.done": false},
+			builder.NotNull{"tasks.due_date"},
+			builder.Lt{"tasks.due_date": time.Now().UTC().Format(dbTimeFormat)},
+		)).
+		Count()
+	return err
+}
+
+func (ps *ProjectStats) countAssignees(s *xorm.Session, taskCond builder.Cond) (err error) {
+	counts := []*projectStatsCount{}
+	err = s.
+		Select("task_assignees.user_id AS id, tasks.done, COUNT(*) AS count").
+		Table("task_assignees").
+		Join("INNER", "tasks", "tasks.id = task_assignees.task_id").
+		Where(taskCond).
+		GroupBy("task_assignees.user_id, tasks.done").
+		Find(&counts)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]int64, 0, len(counts))
+	for _, c := range counts {
+		userIDs = append(userIDs, c.ID)
+	}
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	assignees := make(map[int64]*ProjectStatsAssignee)
+	ps.Assignees = []*ProjectStatsAssignee{}
+	for _, c := range counts {
+		u, has := users[c.ID]
+		if !has {
+			continue
+		}
+		a, has := assignees[c.ID]
+		if !has {
+			u.Email = ""
+			a = &ProjectStatsAssignee{User: u}
+			assignees[c.ID] = a
+			ps.Assignees = append(ps.Assignees, a)
+		}
+		if c.Done {
+			a.DoneTasks += c.Count
+		} else {
+			a.OpenTasks += c.Count
+		}
+	}
+
+	sort.Slice(ps.Assignees, func(i, j int) bool {
+		return ps.Assignees[i].User.ID < ps.Assignees[j].User.ID
+	})
+	return nil
+}
+
+func (ps *ProjectStats) countLabels(s *xorm.Session, taskCond builder.Cond) (err error) {
+	counts := []*projectStatsCount{}
+	err = s.
+		Select("labels.id, labels.title, labels.hex_color, tasks.done, COUNT(*) AS count").
+		Table("label_tasks").
+		Join("INNER", "tasks", "tasks.id = label_tasks.task_id").
+		Join("INNER", "labels", "labels.id = label_tasks.label_id").
+		Where(taskCond).
+		GroupBy("labels.id, labels.title, labels.hex_color, tasks.done").
+		Find(&counts)
+	if err != nil {
+		return err
+	}
+
+	labels := make(map[int64]*ProjectStatsLabel)
+	ps.Labels = []*ProjectStatsLabel{}
+	for _, c := range counts {
+		l, has := labels[c.ID]
+		if !has {
+			l = &ProjectStatsLabel{LabelID: c.ID, Title: c.Title, HexColor: c.HexColor}
+			labels[c.ID] = l
+			ps.Labels = append(ps.Labels, l)
+		}
+		if c.Done {
+			l.DoneTasks += c.Count
+		} else {
+			l.OpenTasks += c.Count
+		}
+	}
+
+	sort.Slice(ps.Labels, func(i, j int) bool {
+		return ps.Labels[i].LabelID < ps.Labels[j].LabelID
+	})
+	return nil
+}
+
+func (ps *ProjectStats) countBuckets(s *xorm.Session, taskCond builder.Cond) (err error) {
+	const (
+		bucketCols   = "buckets.id, buckets.title, buckets.project_view_id, buckets.position"
+		bucketSelect = "buckets.id AS bucket_id, buckets.title, buckets.project_view_id, buckets.position, COUNT(*) AS tasks"
+	)
+
+	// The default kanban view of a project stores the bucket of a task in the task itself,
+	// all other kanban views use the task_buckets table.
+	ps.Buckets = []*ProjectStatsBucket{}
+	err = s.
+		Select(bucketSelect).
+		Table("tasks").
+		Join("INNER", "buckets", "buckets.id = tasks.bucket_id").
+		Join("INNER", "project_views", "project_views.id = buckets.project_view_id").
+		Where(builder.And(
+			taskCond,
+			builder.IsNull{"buckets.deleted"},
+			builder.Eq{"project_views.is_default": true},
+		)).
+		GroupBy(bucketCols).
+		Find(&ps.Buckets)
+	if err != nil {
+		return err
+	}
+
+	viewBuckets := []*ProjectStatsBucket{}
+	err = s.
+		Select(bucketSelect).
+		Table("task_buckets").
+		Join("INNER", "tasks", "tasks.id = task_buckets.task_id").
+		Join("INNER", "buckets", "buckets.id = task_buckets.bucket_id").
+		Join("INNER", "project_views", "project_views.id = buckets.project_view_id").
+		Where(builder.And(
+			taskCond,
+			builder.IsNull{"buckets.deleted"},
+			builder.Eq{"project_views.is_default": false},
+		)).
+		GroupBy(bucketCols).
+		Find(&viewBuckets)
+	if err != nil {
+		return err
+	}
+	ps.Buckets = append(ps.Buckets, viewBuckets...)
+
+	sort.Slice(ps.Buckets, func(i, j int) bool {
+		if ps.Buckets[i].ProjectViewID != ps.Buckets[j].ProjectViewID {
+			return ps.Buckets[i].ProjectViewID < ps.Buckets[j].ProjectViewID
+		}
+		return ps.Buckets[i].Position < ps.Buckets[j].Position
+	})
+	return nil
+}
+
+func (ps *ProjectStats) countWeekly(s *xorm.Session, taskCond builder.Cond, weekStarts []time.Time) (err error) {
+	ps.Weekly = make([]*ProjectStatsWeek, 0, len(weekStarts))
+	for _, start := range weekStarts {
+		ps.Weekly = append(ps.Weekly, &ProjectStatsWeek{Week: start.Format(burndownDateFormat)})
+	}
+
+	windowStart := weekStarts[0].UTC().Format(dbTimeFormat)
+	windowEnd := weekStarts[len(weekStarts)-1].AddDate(0, 0, 7).UTC().Format(dbTimeFormat)
+
+	created := []*projectStatsWeekCount{}
+	err = s.
+		Select(weekCaseExpression("tasks.created", weekStarts) + " AS week, COUNT(*) AS count").
+		Table("tasks").
+		Where(builder.And(
+			taskCond,
+			builder.Gte{"tasks.created": windowStart},
+			builder.Lt{"tasks.created": windowEnd},
+		)).
+		GroupBy("week").
+		Find(&created)
+	if err != nil {
+		return err
+	}
+
+	completed := []*projectStatsWeekCount{}
+	err = s.
+		Select(weekCaseExpression("tasks.done_at", weekStarts) + " AS week, COUNT(*) AS count").
+		Table("tasks").
+		Where(builder.And(
+			taskCond,
+			builder.Eq{"tasks.done": true},
+			builder.Gte{"tasks.done_at": windowStart},
+			builder.Lt{"tasks.done_at": windowEnd},
+		)).
+		GroupBy("week").
+		Find(&completed)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range created {
+		ps.Weekly[c.Week].Created = c.Count
+	}
+	for _, c := range completed {
+		ps.Weekly[c.Week].Completed = c.Count
+	}
+
+	return nil
+}
+
+// ReadOne calculates the stats of a project
+// @Summary Get the stats of a project
+// @Description Returns the number of open, done and overdue tasks of a project, the number of tasks per assignee, label and kanban bucket and the number of created and completed tasks per week.
+// @tags project
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param include_child_projects query bool false "If true, the tasks of all child projects are included as well."
+// @Param weeks query int false "The number of weeks, including the current one, for which created and completed tasks are counted. Defaults to 12, the maximum is 104."
+// @Success 200 {object} models.ProjectStats "The project stats."
+// @Failure 400 {object} web.HTTPError "Invalid number of weeks."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/stats [get]
+func (ps *ProjectStats) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	weekStarts, err := ps.getWeekStarts()
+	if err != nil {
+		return err
+	}
+
+	ps.ProjectIDs = []int64{ps.ProjectID}
+	if ps.IncludeChildProjects {
+		ps.ProjectIDs, err = getProjectIDsWithChildren(s, ps.ProjectID)
+		if err != nil {
+			return err
+		}
+	}
+
+	taskCond := builder.And(
+		builder.In("tasks.project_id", ps.ProjectIDs),
+		builder.IsNull{"tasks.deleted"},
+	)
+
+	err = ps.countTasks(s, taskCond)
+	if err != nil {
+		return err
+	}
+
+	err = ps.countAssignees(s, taskCond)
+	if err != nil {
+		return err
+	}
+
+	err = ps.countLabels(s, taskCond)
+	if err != nil {
+		return err
+	}
+
+	err = ps.countBuckets(s, taskCond)
+	if err != nil {
+		return err
+	}
+
+	return ps.countWeekly(s, taskCond, weekStarts)
+}
@@ -0,0 +1,149 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectStats_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ps := &ProjectStats{ProjectID: 22}
+		can, _, err := ps.CanRead(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = ps.ReadOne(s, u)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int64{22}, ps.ProjectIDs)
+		assert.Equal(t, int64(1), ps.OpenTasks)
+		assert.Equal(t, int64(1), ps.DoneTasks)
+		assert.Equal(t, int64(1), ps.OverdueTasks)
+		require.Len(t, ps.Assignees, 1)
+		assert.Equal(t, int64(2), ps.Assignees[0].User.ID)
+		assert.Empty(t, ps.Assignees[0].User.Email)
+		assert.Equal(t, int64(1), ps.Assignees[0].OpenTasks)
+		require.Len(t, ps.Labels, 1)
+		assert.Equal(t, int64(4), ps.Labels[0].LabelID)
+		assert.Equal(t, int64(1), ps.Labels[0].OpenTasks)
+		require.Len(t, ps.Buckets, 1)
+		assert.Equal(t, int64(20), ps.Buckets[0].BucketID)
+		assert.Equal(t, int64(88), ps.Buckets[0].ProjectViewID)
+		assert.Equal(t, int64(1), ps.Buckets[0].Tasks)
+		assert.Len(t, ps.Weekly, projectStatsDefaultWeeks)
+	})
+	t.Run("with child projects", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ps := &ProjectStats{ProjectID: 22, IncludeChildProjects: true}
+		err := ps.ReadOne(s, u)
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []int64{22, 21}, ps.ProjectIDs)
+		assert.Equal(t, int64(2), ps.OpenTasks)
+		assert.Equal(t, int64(1), ps.DoneTasks)
+		require.Len(t, ps.Assignees, 1)
+		assert.Equal(t, int64(2), ps.Assignees[0].OpenTasks)
+		require.Len(t, ps.Labels, 1)
+		assert.Equal(t, int64(2), ps.Labels[0].OpenTasks)
+		require.Len(t, ps.Buckets, 2)
+		assert.Equal(t, int64(19), ps.Buckets[0].BucketID)
+		assert.Equal(t, int64(20), ps.Buckets[1].BucketID)
+	})
+	t.Run("weekly", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{Title: "new task", ProjectID: 22}
+		err := task.Create(s, u)
+		require.NoError(t, err)
+		task.Done = true
+		err = task.Update(s, u)
+		require.NoError(t, err)
+
+		ps := &ProjectStats{ProjectID: 22, Weeks: 2}
+		err = ps.ReadOne(s, u)
+		require.NoError(t, err)
+
+		require.Len(t, ps.Weekly, 2)
+		assert.Equal(t, int64(0), ps.Weekly[0].Created)
+		assert.Equal(t, int64(1), ps.Weekly[1].Created)
+		assert.Equal(t, int64(1), ps.Weekly[1].Completed)
+		assert.Equal(t, time.Monday, mustParseStatsWeek(t, ps.Weekly[1].Week).Weekday())
+		assert.Equal(t, int64(2), ps.DoneTasks)
+	})
+	t.Run("deleted tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 36}).Delete(s, u)
+		require.NoError(t, err)
+
+		ps := &ProjectStats{ProjectID: 22}
+		err = ps.ReadOne(s, u)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(0), ps.OpenTasks)
+		assert.Equal(t, int64(0), ps.OverdueTasks)
+		assert.Empty(t, ps.Assignees)
+		assert.Empty(t, ps.Labels)
+		assert.Empty(t, ps.Buckets)
+	})
+	t.Run("invalid weeks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ps := &ProjectStats{ProjectID: 22, Weeks: projectStatsMaxWeeks + 1}
+		err := ps.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidProjectStatsWeeks(err))
+	})
+	t.Run("no rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ps := &ProjectStats{ProjectID: 1}
+		can, _, err := ps.CanRead(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func mustParseStatsWeek(t *testing.T, week string) time.Time {
+	parsed, err := time.Parse(burndownDateFormat, week)
+	require.NoError(t, err)
+	return parsed
+}
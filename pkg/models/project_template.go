@@ -0,0 +1,666 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// ProjectTemplate marks a project as a template which can be used to create new projects.
+type ProjectTemplate struct {
+	// The unique, numeric id of this template.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The project which is the template.
+	ProjectID int64 `xorm:"bigint not null unique" json:"project_id" param:"projectid"`
+
+	// All dates of the tasks in the template are relative to this date. When creating a project from the template,
+	// they are moved by the number of days between this date and the day the project is created.
+	// Defaults to the time the project was marked as a template.
+	BaseDate time.Time `xorm:"DATETIME not null" json:"base_date"`
+
+	// A cron expression like "0 9 * * 1". If set, a new project is created from this template every time the schedule is due.
+	Schedule string `xorm:"varchar(250) null" json:"schedule"`
+	// The parent project for projects created through the schedule. 0 creates them as top level projects.
+	ScheduleParentProjectID int64 `xorm:"bigint null" json:"schedule_parent_project_id"`
+	// The values for the placeholders used when creating projects through the schedule.
+	ScheduleVariables map[string]string `xorm:"json null" json:"schedule_variables"`
+	// The last time a project was created through the schedule.
+	LastInstantiated time.Time `xorm:"DATETIME null" json:"last_instantiated"`
+
+	CreatedByID int64 `xorm:"bigint not null" json:"-"`
+	// The user who made the project a template. Projects created through the schedule are created on behalf of this user.
+	CreatedBy *user.User `xorm:"-" json:"created_by"`
+
+	// A timestamp when this template was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this template was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for project templates
+func (*ProjectTemplate) TableName() string {
+	return "project_templates"
+}
+
+func getProjectTemplateByProjectID(s *xorm.Session, projectID int64) (template *ProjectTemplate, err error) {
+	template = &ProjectTemplate{}
+	exists, err := s.Where("project_id = ?", projectID).Get(template)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrProjectIsNotTemplate{ProjectID: projectID}
+	}
+	return
+}
+
+func (pt *ProjectTemplate) validateSchedule(s *xorm.Session, a web.Auth) error {
+	if pt.Schedule == "" {
+		return nil
+	}
+
+	_, err := cron.NextRun(pt.Schedule, time.Now())
+	if err != nil {
+		return &ErrInvalidProjectTemplateSchedule{Schedule: pt.Schedule}
+	}
+
+	if pt.ScheduleParentProjectID == 0 {
+		return nil
+	}
+
+	can, err := (&Project{ParentProjectID: pt.ScheduleParentProjectID}).CanCreate(s, a)
+	if err != nil {
+		return err
+	}
+	if !can {
+		return ErrGenericForbidden{}
+	}
+	return nil
+}
+
+// Create marks a project as a template
+// @Summary Mark a project as a template
+// @Description Marks an existing project as a template. New projects can then be created from it.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param template body models.ProjectTemplate true "The template settings"
+// @Success 201 {object} models.ProjectTemplate "The created template."
+// @Failure 400 {object} web.HTTPError "Invalid template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/template [put]
+func (pt *ProjectTemplate) Create(s *xorm.Session, a web.Auth) (err error) {
+	exists, err := s.Where("project_id = ?", pt.ProjectID).Exist(&ProjectTemplate{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &ErrProjectIsAlreadyTemplate{ProjectID: pt.ProjectID}
+	}
+
+	err = pt.validateSchedule(s, a)
+	if err != nil {
+		return err
+	}
+
+	pt.ID = 0
+	pt.LastInstantiated = time.Time{}
+	if pt.BaseDate.IsZero() {
+		pt.BaseDate = time.Now()
+	}
+	pt.CreatedByID = a.GetID()
+	pt.CreatedBy, err = user.GetUserByID(s, pt.CreatedByID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(pt)
+	return
+}
+
+// ReadOne returns the template settings of a project
+// @Summary Get the template settings of a project
+// @Description Returns the template settings of a project. Returns an error if the project is not a template.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Success 200 {object} models.ProjectTemplate "The template."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 404 {object} web.HTTPError "The project is not a template."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/template [get]
+func (pt *ProjectTemplate) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	template, err := getProjectTemplateByProjectID(s, pt.ProjectID)
+	if err != nil {
+		return err
+	}
+	*pt = *template
+
+	pt.CreatedBy, err = user.GetUserByID(s, pt.CreatedByID)
+	return
+}
+
+// Update changes the template settings of a project
+// @Summary Update the template settings of a project
+// @Description Updates the base date and schedule of a template.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param template body models.ProjectTemplate true "The template settings"
+// @Success 200 {object} models.ProjectTemplate "The updated template."
+// @Failure 400 {object} web.HTTPError "Invalid template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 404 {object} web.HTTPError "The project is not a template."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/template [post]
+func (pt *ProjectTemplate) Update(s *xorm.Session, a web.Auth) (err error) {
+	existing, err := getProjectTemplateByProjectID(s, pt.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	err = pt.validateSchedule(s, a)
+	if err != nil {
+		return err
+	}
+
+	pt.ID = existing.ID
+	if pt.BaseDate.IsZero() {
+		pt.BaseDate = existing.BaseDate
+	}
+
+	_, err = s.
+		ID(pt.ID).
+		Cols("base_date", "schedule", "schedule_parent_project_id", "schedule_variables").
+		Update(pt)
+	if err != nil {
+		return err
+	}
+
+	return pt.ReadOne(s, a)
+}
+
+// Delete removes the template mark from a project
+// @Summary Remove the template mark from a project
+// @Description Removes the template mark from a project. The project itself is not deleted.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Success 200 {object} models.Message "The project is no longer a template."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 404 {object} web.HTTPError "The project is not a template."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/template [delete]
+func (pt *ProjectTemplate) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	deleted, err := s.Where("project_id = ?", pt.ProjectID).Delete(&ProjectTemplate{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return &ErrProjectIsNotTemplate{ProjectID: pt.ProjectID}
+	}
+	return nil
+}
+
+// ProjectTemplateInstantiation holds everything needed to create a new project from a template
+type ProjectTemplateInstantiation struct {
+	// The id of the template project
+	TemplateProjectID int64 `json:"-" param:"projectid"`
+	// The parent of the new project. 0 creates a top level project.
+	ParentProjectID int64 `json:"parent_project_id"`
+	// The title of the new project. Defaults to the title of the template. Can contain placeholders.
+	Title string `json:"title"`
+	// The values for placeholders like {{name}} in titles and descriptions.
+	Variables map[string]string `json:"variables"`
+
+	// The created project
+	Project *Project `json:"project,omitempty"`
+
+	web.Rights   `json:"-"`
+	web.CRUDable `json:"-"`
+}
+
+// CanCreate checks if a user can create a project from a template
+func (pti *ProjectTemplateInstantiation) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return canCopyProjectInto(s, a, pti.TemplateProjectID, pti.ParentProjectID)
+}
+
+// Create creates a new project from a template
+// @Summary Create a new project from a template
+// @Description Copies all views, buckets, undone tasks and their labels, reminders and relations from the template into a new project. Placeholders in titles and descriptions are replaced and all dates are moved relative to today.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "The id of the template project"
+// @Param instantiation body models.ProjectTemplateInstantiation true "The parent project and variables for the new project"
+// @Success 201 {object} models.ProjectTemplateInstantiation "The created project."
+// @Failure 400 {object} web.HTTPError "Invalid object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the template or the parent project."
+// @Failure 404 {object} web.HTTPError "The project is not a template."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/template/instantiate [put]
+func (pti *ProjectTemplateInstantiation) Create(s *xorm.Session, a web.Auth) (err error) {
+	template, err := getProjectTemplateByProjectID(s, pti.TemplateProjectID)
+	if err != nil {
+		return err
+	}
+
+	pti.Project, err = instantiateProjectTemplate(s, template, pti.ParentProjectID, pti.Title, pti.Variables, a, time.Now())
+	return
+}
+
+// ProjectSaveAsTemplate holds everything needed to save an existing project as a new template
+type ProjectSaveAsTemplate struct {
+	// The project to create the template from
+	ProjectID int64 `json:"-" param:"projectid"`
+	// The parent of the new template project. 0 creates a top level project.
+	ParentProjectID int64 `json:"parent_project_id"`
+	// The title of the new template project. Defaults to the title of the project.
+	Title string `json:"title"`
+
+	// The created template project
+	Project *Project `json:"project,omitempty"`
+	// The template settings of the created project
+	Template *ProjectTemplate `json:"template,omitempty"`
+
+	web.Rights   `json:"-"`
+	web.CRUDable `json:"-"`
+}
+
+// CanCreate checks if a user can save a project as a template
+func (psat *ProjectSaveAsTemplate) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return canCopyProjectInto(s, a, psat.ProjectID, psat.ParentProjectID)
+}
+
+// Create saves a copy of a project as a new template
+// @Summary Save a project as a template
+// @Description Creates a new template project with all views, buckets and undone tasks of the project. Comments, assignees, attachments and done tasks are not copied.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "The id of the project"
+// @Param template body models.ProjectSaveAsTemplate true "The parent project and title of the new template"
+// @Success 201 {object} models.ProjectSaveAsTemplate "The created template project."
+// @Failure 400 {object} web.HTTPError "Invalid object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project or the parent project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/save-as-template [put]
+func (psat *ProjectSaveAsTemplate) Create(s *xorm.Session, a web.Auth) (err error) {
+	source, err := GetProjectSimpleByID(s, psat.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	psat.Project = &Project{
+		Title:           source.Title,
+		Description:     source.Description,
+		HexColor:        source.HexColor,
+		ParentProjectID: psat.ParentProjectID,
+	}
+	if psat.Title != "" {
+		psat.Project.Title = psat.Title
+	}
+
+	err = copyProjectFromTemplate(s, psat.ProjectID, psat.Project, a, func(text string) string { return text }, 0)
+	if err != nil {
+		return err
+	}
+
+	psat.Template = &ProjectTemplate{ProjectID: psat.Project.ID}
+	return psat.Template.Create(s, a)
+}
+
+// canCopyProjectInto checks if a user can read a project and create new projects in the parent.
+func canCopyProjectInto(s *xorm.Session, a web.Auth, projectID, parentProjectID int64) (bool, error) {
+	// Link shares can't create projects
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	canRead, _, err := (&Project{ID: projectID}).CanRead(s, a)
+	if err != nil || !canRead {
+		return canRead, err
+	}
+
+	if parentProjectID == 0 {
+		return true, nil
+	}
+
+	return (&Project{ParentProjectID: parentProjectID}).CanCreate(s, a)
+}
+
+var templatePlaceholderRegex = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// replaceTemplatePlaceholders replaces all {{name}} placeholders in a text with their values.
+// Placeholders without a value are kept as they are.
+func replaceTemplatePlaceholders(text string, variables map[string]string) string {
+	return templatePlaceholderRegex.ReplaceAllStringFunc(text, func(match string) string {
+		name := templatePlaceholderRegex.FindStringSubmatch(match)[1]
+		if value, has := variables[name]; has {
+			return value
+		}
+		return match
+	})
+}
+
+// daysBetween returns the number of calendar days between two times in the configured time zone.
+func daysBetween(from, to time.Time) int {
+	tz := config.GetTimeZone()
+	from = from.In(tz)
+	to = to.In(tz)
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+func instantiateProjectTemplate(s *xorm.Session, template *ProjectTemplate, parentProjectID int64, title string, variables map[string]string, a web.Auth, now time.Time) (project *Project, err error) {
+	templateProject, err := GetProjectSimpleByID(s, template.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{
+		"date": now.In(config.GetTimeZone()).Format("2006-01-02"),
+	}
+	for name, value := range variables {
+		vars[name] = value
+	}
+	replace := func(text string) string {
+		return replaceTemplatePlaceholders(text, vars)
+	}
+
+	if title == "" {
+		title = templateProject.Title
+	}
+
+	project = &Project{
+		Title:           replace(title),
+		Description:     replace(templateProject.Description),
+		HexColor:        templateProject.HexColor,
+		ParentProjectID: parentProjectID,
+	}
+
+	err = copyProjectFromTemplate(s, template.ProjectID, project, a, replace, daysBetween(template.BaseDate, now))
+	return
+}
+
+// copyProjectFromTemplate creates the target project and copies all views, buckets and undone tasks
+// of the source project into it.
+func copyProjectFromTemplate(s *xorm.Session, sourceProjectID int64, target *Project, a web.Auth, replace func(string) string, shiftDays int) (err error) {
+	err = CreateProject(s, target, a)
+	if err != nil {
+		return err
+	}
+
+	ld := &ProjectDuplicate{ProjectID: sourceProjectID, Project: target}
+	viewMap, err := duplicateProjectViews(s, ld, a)
+	if err != nil {
+		return
+	}
+
+	bucketMap, err := duplicateBuckets(s, ld, a, viewMap)
+	if err != nil {
+		return
+	}
+
+	tasks, _, _, err := getTasksForProjects(s, []*Project{{ID: sourceProjectID}}, a, &taskOptions{})
+	if err != nil {
+		return err
+	}
+
+	shift := func(date time.Time) time.Time {
+		if date.IsZero() {
+			return date
+		}
+		return date.AddDate(0, 0, shiftDays)
+	}
+
+	taskMap := make(map[int64]int64)
+	oldTaskIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		if t.Done {
+			continue
+		}
+
+		newTask := &Task{
+			Title:          replace(t.Title),
+			Description:    replace(t.Description),
+			ProjectID:      target.ID,
+			BucketID:       bucketMap[t.BucketID],
+			Priority:       t.Priority,
+			HexColor:       t.HexColor,
+			PercentDone:    t.PercentDone,
+			StoryPoints:    t.StoryPoints,
+			TimeEstimate:   t.TimeEstimate,
+			RepeatAfter:    t.RepeatAfter,
+			RepeatMode:     t.RepeatMode,
+			RepeatRule:     t.RepeatRule,
+			DueDate:        shift(t.DueDate),
+			StartDate:      shift(t.StartDate),
+			EndDate:        shift(t.EndDate),
+			Position:       t.Position,
+			KanbanPosition: t.KanbanPosition,
+		}
+		for _, r := range t.Reminders {
+			newTask.Reminders = append(newTask.Reminders, &TaskReminder{
+				Reminder:       shift(r.Reminder),
+				RelativePeriod: r.RelativePeriod,
+				RelativeTo:     r.RelativeTo,
+			})
+		}
+
+		err = createTask(s, newTask, a, false)
+		if err != nil {
+			return err
+		}
+		taskMap[t.ID] = newTask.ID
+		oldTaskIDs = append(oldTaskIDs, t.ID)
+	}
+
+	if len(oldTaskIDs) == 0 {
+		return nil
+	}
+
+	err = duplicateTaskBucketsAndPositions(s, oldTaskIDs, taskMap, viewMap, bucketMap)
+	if err != nil {
+		return
+	}
+
+	err = duplicateLabelTasks(s, oldTaskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	err = duplicateTaskRelations(s, oldTaskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	log.Debugf("Created project %d from template project %d", target.ID, sourceProjectID)
+
+	return nil
+}
+
+// getDueProjectTemplates returns all templates with a schedule which is due at the given time.
+func getDueProjectTemplates(s *xorm.Session, now time.Time) (due []*ProjectTemplate, err error) {
+	templates := []*ProjectTemplate{}
+	err = s.
+		Where(builder.And(
+			builder.NotNull{"schedule"},
+			builder.Neq{"schedule": ""},
+		)).
+		Find(&templates)
+	if err != nil {
+		return nil, err
+	}
+
+	tz := config.GetTimeZone()
+	for _, template := range templates {
+		last := template.LastInstantiated
+		if last.IsZero() {
+			last = template.Created
+		}
+
+		next, err := cron.NextRun(template.Schedule, last.In(tz))
+		if err != nil {
+			log.Errorf("[Project Template Cron] Template %d has an invalid schedule %s: %s", template.ID, template.Schedule, err)
+			continue
+		}
+
+		if !next.After(now) {
+			due = append(due, template)
+		}
+	}
+
+	return
+}
+
+func setProjectTemplateLastInstantiated(s *xorm.Session, template *ProjectTemplate, now time.Time) (err error) {
+	template.LastInstantiated = now
+	_, err = s.ID(template.ID).Cols("last_instantiated").NoAutoTime().Update(template)
+	return
+}
+
+// claimProjectTemplate sets the last instantiation of a template to now, but only if nobody else did that since
+// the template was loaded. This makes sure multiple instances running the cron don't instantiate a template twice.
+func claimProjectTemplate(s *xorm.Session, template *ProjectTemplate, now time.Time) (claimed bool, err error) {
+	// The condition bean makes xorm format the saved time the same way it stores it
+	query := s.Cols("last_instantiated").NoAutoTime()
+	if template.LastInstantiated.IsZero() {
+		query = query.Where(builder.IsNull{"last_instantiated"})
+	}
+
+	updated, err := query.Update(
+		&ProjectTemplate{LastInstantiated: now},
+		&ProjectTemplate{ID: template.ID, LastInstantiated: template.LastInstantiated},
+	)
+	if err != nil {
+		return false, err
+	}
+	if updated == 0 {
+		return false, nil
+	}
+
+	template.LastInstantiated = now
+	return true, nil
+}
+
+// instantiateScheduledProjectTemplate creates a new project from a template on behalf of the user who created the template.
+// If the template was already instantiated by someone else since it was loaded, it returns no project and no error.
+func instantiateScheduledProjectTemplate(s *xorm.Session, template *ProjectTemplate, now time.Time) (project *Project, err error) {
+	claimed, err := claimProjectTemplate(s, template, now)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, nil
+	}
+
+	u, err := user.GetUserByID(s, template.CreatedByID)
+	if err != nil {
+		return nil, err
+	}
+
+	can, err := canCopyProjectInto(s, u, template.ProjectID, template.ScheduleParentProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !can {
+		return nil, ErrGenericForbidden{}
+	}
+
+	return instantiateProjectTemplate(s, template, template.ScheduleParentProjectID, "", template.ScheduleVariables, u, now)
+}
+
+// RegisterProjectTemplateCron registers a cron function which runs every minute to create new projects
+// from all templates whose schedule is due.
+func RegisterProjectTemplateCron() {
+	const logPrefix = "[Project Template Cron] "
+
+	err := cron.Schedule("* * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		now := time.Now()
+		templates, err := getDueProjectTemplates(s, now)
+		if err != nil {
+			log.Errorf(logPrefix+"Could not get due templates: %s", err)
+			return
+		}
+
+		for _, template := range templates {
+			err = s.Begin()
+			if err != nil {
+				log.Errorf(logPrefix+"Could not start transaction: %s", err)
+				return
+			}
+
+			project, err := instantiateScheduledProjectTemplate(s, template, now)
+			if err != nil {
+				_ = s.Rollback()
+				log.Errorf(logPrefix+"Could not create project from template %d: %s", template.ID, err)
+				// Don't retry a failing template every minute, only with its next due date
+				if err := setProjectTemplateLastInstantiated(s, template, now); err != nil {
+					log.Errorf(logPrefix+"Could not update template %d: %s", template.ID, err)
+				}
+				continue
+			}
+
+			if project == nil {
+				_ = s.Rollback()
+				log.Debugf(logPrefix+"Template %d was already instantiated by another instance, skipping", template.ID)
+				continue
+			}
+
+			err = s.Commit()
+			if err != nil {
+				log.Errorf(logPrefix+"Could not commit project from template %d: %s", template.ID, err)
+				continue
+			}
+
+			log.Debugf(logPrefix+"Created project %d from template %d", project.ID, template.ID)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register project template cron: %s", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can see the template settings of a project
+func (pt *ProjectTemplate) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	return (&Project{ID: pt.ProjectID}).CanRead(s, a)
+}
+
+// CanCreate checks if a user can mark a project as a template
+func (pt *ProjectTemplate) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	// Scheduled templates create projects on behalf of a user, which is why link shares can't manage templates.
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	return (&Project{ID: pt.ProjectID}).CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can change the template settings of a project
+func (pt *ProjectTemplate) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return pt.CanCreate(s, a)
+}
+
+// CanDelete checks if a user can remove the template mark of a project
+func (pt *ProjectTemplate) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return pt.CanCreate(s, a)
+}
@@ -0,0 +1,319 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pt := &ProjectTemplate{ProjectID: 1, Schedule: "0 9 * * *"}
+		can, err := pt.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+
+		err = pt.Create(s, u)
+		require.NoError(t, err)
+		assert.False(t, pt.BaseDate.IsZero())
+		assert.Equal(t, int64(1), pt.CreatedBy.ID)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "project_templates", map[string]interface{}{
+			"id":            pt.ID,
+			"project_id":    1,
+			"schedule":      "0 9 * * *",
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("already a template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pt := &ProjectTemplate{ProjectID: 22}
+		err := pt.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrProjectIsAlreadyTemplate(err))
+	})
+	t.Run("invalid schedule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pt := &ProjectTemplate{ProjectID: 1, Schedule: "every monday"}
+		err := pt.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidProjectTemplateSchedule(err))
+	})
+	t.Run("no write access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pt := &ProjectTemplate{ProjectID: 1}
+		can, err := pt.CanCreate(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pt := &ProjectTemplate{ProjectID: 1}
+		can, err := pt.CanCreate(s, &LinkSharing{ID: 1, ProjectID: 1, Right: RightAdmin})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestProjectTemplate_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pt := &ProjectTemplate{ProjectID: 22}
+		err := pt.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), pt.ID)
+		assert.Equal(t, "0 9 * * 1", pt.Schedule)
+		assert.Equal(t, "user1", pt.CreatedBy.Username)
+	})
+	t.Run("not a template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pt := &ProjectTemplate{ProjectID: 1}
+		err := pt.ReadOne(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrProjectIsNotTemplate(err))
+	})
+}
+
+func TestProjectTemplate_Update(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	pt := &ProjectTemplate{ProjectID: 22, Schedule: "0 8 1 * *", ScheduleParentProjectID: 1}
+	err := pt.Update(s, u)
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertExists(t, "project_templates", map[string]interface{}{
+		"id":                         1,
+		"schedule":                   "0 8 1 * *",
+		"schedule_parent_project_id": 1,
+	}, false)
+}
+
+func TestProjectTemplate_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	pt := &ProjectTemplate{ProjectID: 22}
+	err := pt.Delete(s, u)
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertMissing(t, "project_templates", map[string]interface{}{
+		"id": 1,
+	})
+	db.AssertExists(t, "projects", map[string]interface{}{
+		"id": 22,
+	}, false)
+}
+
+func TestProjectTemplateInstantiation(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		template, err := getProjectTemplateByProjectID(s, 22)
+		require.NoError(t, err)
+
+		now := time.Date(2018, 10, 25, 12, 0, 0, 0, time.UTC)
+		project, err := instantiateProjectTemplate(s, template, 1, "Release {{version}} ({{date}})", map[string]string{"version": "1.0"}, u, now)
+		require.NoError(t, err)
+		assert.Equal(t, "Release 1.0 (2018-10-25)", project.Title)
+		assert.Equal(t, int64(1), project.ParentProjectID)
+
+		tasks := []*Task{}
+		err = s.Where("project_id = ?", project.ID).Find(&tasks)
+		require.NoError(t, err)
+		// Done tasks are not copied
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "task #36", tasks[0].Title)
+		assert.False(t, tasks[0].Done)
+		// Moved by the two days between the base date and now
+		assert.Equal(t, time.Date(2018, 11, 1, 22, 25, 24, 0, time.UTC), tasks[0].DueDate.UTC())
+	})
+	t.Run("not a template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pti := &ProjectTemplateInstantiation{TemplateProjectID: 1}
+		can, err := pti.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = pti.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrProjectIsNotTemplate(err))
+	})
+	t.Run("no access to the parent", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pti := &ProjectTemplateInstantiation{TemplateProjectID: 22, ParentProjectID: 20}
+		can, err := pti.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestProjectSaveAsTemplate(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	psat := &ProjectSaveAsTemplate{ProjectID: 22, Title: "Checklist"}
+	can, err := psat.CanCreate(s, u)
+	require.NoError(t, err)
+	assert.True(t, can)
+
+	err = psat.Create(s, u)
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertExists(t, "projects", map[string]interface{}{
+		"id":    psat.Project.ID,
+		"title": "Checklist",
+	}, false)
+	db.AssertExists(t, "project_templates", map[string]interface{}{
+		"project_id": psat.Project.ID,
+	}, false)
+	db.AssertExists(t, "tasks", map[string]interface{}{
+		"project_id": psat.Project.ID,
+		"title":      "task #36",
+		"due_date":   "2018-10-30 22:25:24",
+	}, false)
+}
+
+func TestProjectTemplate_Schedule(t *testing.T) {
+	t.Run("due", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// The first monday after the template was created
+		now := time.Date(2018, 12, 3, 10, 0, 0, 0, time.UTC)
+		templates, err := getDueProjectTemplates(s, now)
+		require.NoError(t, err)
+		require.Len(t, templates, 1)
+		assert.Equal(t, int64(1), templates[0].ID)
+
+		project, err := instantiateScheduledProjectTemplate(s, templates[0], now)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "projects", map[string]interface{}{
+			"id":       project.ID,
+			"title":    "Test22 archived individually",
+			"owner_id": 1,
+		}, false)
+
+		// Not due again until the next monday
+		templates, err = getDueProjectTemplates(s, now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, templates)
+	})
+	t.Run("already instantiated by another instance", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		now := time.Date(2018, 12, 3, 10, 0, 0, 0, time.UTC)
+		templates, err := getDueProjectTemplates(s, now)
+		require.NoError(t, err)
+		require.Len(t, templates, 1)
+		stale := *templates[0]
+
+		project, err := instantiateScheduledProjectTemplate(s, templates[0], now)
+		require.NoError(t, err)
+		require.NotNil(t, project)
+
+		project, err = instantiateScheduledProjectTemplate(s, &stale, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Nil(t, project)
+
+		// The next run loads the template with the saved value and is able to claim it again
+		templates, err = getDueProjectTemplates(s, now.Add(7*24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, templates, 1)
+		claimed, err := claimProjectTemplate(s, templates[0], now.Add(7*24*time.Hour))
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	})
+	t.Run("not yet due", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		templates, err := getDueProjectTemplates(s, time.Date(2018, 12, 2, 10, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Empty(t, templates)
+	})
+}
+
+func TestReplaceTemplatePlaceholders(t *testing.T) {
+	vars := map[string]string{"name": "Jane", "version": "2.0"}
+	assert.Equal(t, "Onboarding Jane", replaceTemplatePlaceholders("Onboarding {{name}}", vars))
+	assert.Equal(t, "Release 2.0", replaceTemplatePlaceholders("Release {{ version }}", vars))
+	assert.Equal(t, "Hello {{unknown}}", replaceTemplatePlaceholders("Hello {{unknown}}", vars))
+}
@@ -24,6 +24,7 @@ import (
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
 	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
 )
 
 func TestProject_CreateOrUpdate(t *testing.T) {
@@ -233,18 +234,19 @@ func TestProject_Delete(t *testing.T) {
 		assert.NoError(t, err)
 		err = s.Commit()
 		assert.NoError(t, err)
-		db.AssertMissing(t, "projects", map[string]interface{}{
-			"id": 1,
-		})
+		db.AssertCount(t, "projects", builder.And(builder.Eq{"id": 1}, builder.NotNull{"deleted"}), 1)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"project_id": 1,
+		}, false)
 	})
-	t.Run("with background", func(t *testing.T) {
+	t.Run("purge with background", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		files.InitTestFileFixtures(t)
 		s := db.NewSession()
-		project := Project{
+		project := &Project{
 			ID: 35,
 		}
-		err := project.Delete(s, &user.User{ID: 6})
+		err := purgeProject(s, project)
 		assert.NoError(t, err)
 		err = s.Commit()
 		assert.NoError(t, err)
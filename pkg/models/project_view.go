@@ -0,0 +1,392 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// ProjectViewKind defines how a view shows the tasks of a project
+type ProjectViewKind int
+
+const (
+	ProjectViewKindList ProjectViewKind = iota
+	ProjectViewKindGantt
+	ProjectViewKindTable
+	ProjectViewKindKanban
+)
+
+// ProjectView represents one way of showing the tasks of a project, with its own filter, ordering and kanban buckets.
+type ProjectView struct {
+	// The unique numeric id of this view
+	ID int64 `xorm:"autoincr not null unique pk" json:"id" param:"view"`
+	// The title of this view
+	Title string `xorm:"varchar(255) not null" json:"title" valid:"required,runelength(1|250)"`
+	// The project this view belongs to
+	ProjectID int64 `xorm:"not null index" json:"project_id" param:"project"`
+	// The kind of this view. Can be `0` (list), `1` (gantt), `2` (table) or `3` (kanban).
+	ViewKind ProjectViewKind `xorm:"not null default 0" json:"view_kind"`
+
+	// The filter which is applied to all tasks shown in this view. Uses the same format as saved filters.
+	Filter *TaskCollection `xorm:"JSON null default null" json:"filter"`
+	// The position of this view in the list. The list of all views will be sorted by this parameter.
+	Position float64 `xorm:"double null" json:"position"`
+
+	// Whether this is one of the views every project is created with. Default views can't be deleted.
+	// The task positions and kanban buckets of default views are the ones stored with the task itself.
+	IsDefault bool `xorm:"not null default false" json:"is_default"`
+
+	// A timestamp when this view was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this view was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for project views
+func (*ProjectView) TableName() string {
+	return "project_views"
+}
+
+// usesOwnTaskData returns true if the view keeps its own task positions and buckets instead of the ones stored with the task.
+func (pv *ProjectView) usesOwnTaskData() bool {
+	return !pv.IsDefault
+}
+
+func getProjectViewByID(s *xorm.Session, id int64) (view *ProjectView, err error) {
+	view = &ProjectView{}
+	exists, err := s.Where("id = ?", id).Get(view)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrProjectViewDoesNotExist{ProjectViewID: id}
+	}
+	return
+}
+
+// getProjectViewByIDAndProject returns a view and makes sure it belongs to the project.
+func getProjectViewByIDAndProject(s *xorm.Session, id, projectID int64) (view *ProjectView, err error) {
+	view, err = getProjectViewByID(s, id)
+	if err != nil {
+		return nil, err
+	}
+	if view.ProjectID != projectID {
+		return nil, &ErrProjectViewDoesNotExist{ProjectViewID: id}
+	}
+	return
+}
+
+// getDefaultProjectView returns the default view of a kind for a project.
+func getDefaultProjectView(s *xorm.Session, projectID int64, kind ProjectViewKind) (view *ProjectView, err error) {
+	view = &ProjectView{}
+	exists, err := s.
+		Where("project_id = ? AND view_kind = ? AND is_default = ?", projectID, kind, true).
+		OrderBy("id asc").
+		Get(view)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrProjectViewDoesNotExist{}
+	}
+	return
+}
+
+func getDefaultKanbanViewID(s *xorm.Session, projectID int64) (viewID int64, err error) {
+	view, err := getDefaultProjectView(s, projectID, ProjectViewKindKanban)
+	if err != nil {
+		return 0, err
+	}
+	return view.ID, nil
+}
+
+// getKanbanViewsWithOwnBuckets returns all kanban views of a project which don't use the bucket stored with the task.
+func getKanbanViewsWithOwnBuckets(s *xorm.Session, projectID int64) (views []*ProjectView, err error) {
+	views = []*ProjectView{}
+	err = s.
+		Where("project_id = ? AND view_kind = ? AND is_default = ?", projectID, ProjectViewKindKanban, false).
+		Find(&views)
+	return
+}
+
+func (pv *ProjectView) validate() error {
+	if pv.ViewKind < ProjectViewKindList || pv.ViewKind > ProjectViewKindKanban {
+		return &ErrInvalidProjectViewKind{ViewKind: pv.ViewKind}
+	}
+
+	if pv.Filter != nil {
+		_, err := getTaskFilterOptsFromCollection(pv.Filter)
+		return err
+	}
+
+	return nil
+}
+
+// ReadAll returns all views of a project
+// @Summary Get all project views for a project
+// @Description Returns all project views for a specific project, sorted by their position.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Success 200 {array} models.ProjectView "The project views"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views [get]
+func (pv *ProjectView) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	p := &Project{ID: pv.ProjectID}
+	can, _, err := p.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	views := []*ProjectView{}
+	err = s.
+		Where("project_id = ?", pv.ProjectID).
+		OrderBy("position asc, id asc").
+		Find(&views)
+	if err != nil {
+		return
+	}
+
+	return views, len(views), int64(len(views)), nil
+}
+
+// ReadOne returns one project view
+// @Summary Get one project view
+// @Description Returns a project view by its ID.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param id path int true "Project View ID"
+// @Success 200 {object} models.ProjectView "The project view"
+// @Failure 403 {object} web.HTTPError "The user does not have access to this project view"
+// @Failure 404 {object} web.HTTPError "The project view does not exist"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views/{id} [get]
+func (pv *ProjectView) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	view, err := getProjectViewByIDAndProject(s, pv.ID, pv.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	*pv = *view
+	return
+}
+
+// Create adds a new project view
+// @Summary Create a project view
+// @Description Create a project view in a specific project. Kanban views get a first bucket which contains all tasks of the project.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param view body models.ProjectView true "The project view you want to create."
+// @Success 200 {object} models.ProjectView "The created project view"
+// @Failure 400 {object} web.HTTPError "Invalid project view object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views [put]
+func (pv *ProjectView) Create(s *xorm.Session, a web.Auth) (err error) {
+	pv.ID = 0
+	pv.IsDefault = false
+	return createProjectView(s, pv, a, true)
+}
+
+func createProjectView(s *xorm.Session, pv *ProjectView, a web.Auth, createBacklogBucket bool) (err error) {
+	err = pv.validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(pv)
+	if err != nil {
+		return err
+	}
+
+	pv.Position = calculateDefaultPosition(pv.ID, pv.Position)
+	_, err = s.Where("id = ?", pv.ID).Cols("position").Update(pv)
+	if err != nil {
+		return err
+	}
+
+	if !createBacklogBucket || pv.ViewKind != ProjectViewKindKanban {
+		return nil
+	}
+
+	b := &Bucket{
+		ProjectID:     pv.ProjectID,
+		ProjectViewID: pv.ID,
+		Title:         "Backlog",
+	}
+	err = b.Create(s, a)
+	if err != nil {
+		return err
+	}
+
+	if !pv.usesOwnTaskData() {
+		return nil
+	}
+
+	// Put all existing tasks of the project into the first bucket of the new view
+	tasks := []*Task{}
+	err = s.Where("project_id = ?", pv.ProjectID).Cols("id").Find(&tasks)
+	if err != nil {
+		return err
+	}
+
+	taskBuckets := make([]*TaskBucket, 0, len(tasks))
+	for _, t := range tasks {
+		taskBuckets = append(taskBuckets, &TaskBucket{
+			TaskID:        t.ID,
+			BucketID:      b.ID,
+			ProjectViewID: pv.ID,
+		})
+	}
+	if len(taskBuckets) == 0 {
+		return nil
+	}
+
+	_, err = s.Insert(&taskBuckets)
+	return err
+}
+
+// createDefaultViewsForProject creates the default views every project has.
+func createDefaultViewsForProject(s *xorm.Session, project *Project, a web.Auth) (err error) {
+	views := []*ProjectView{
+		{Title: "List", ViewKind: ProjectViewKindList},
+		{Title: "Gantt", ViewKind: ProjectViewKindGantt},
+		{Title: "Table", ViewKind: ProjectViewKindTable},
+		{Title: "Kanban", ViewKind: ProjectViewKindKanban},
+	}
+
+	for _, view := range views {
+		view.ProjectID = project.ID
+		view.IsDefault = true
+		err = createProjectView(s, view, a, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update is the handler to update a project view
+// @Summary Updates a project view
+// @Description Updates a project view. The kind of a view can't be changed.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param id path int true "Project View ID"
+// @Param view body models.ProjectView true "The project view with updated values you want to change."
+// @Success 200 {object} models.ProjectView "The updated project view."
+// @Failure 400 {object} web.HTTPError "Invalid project view object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project"
+// @Failure 404 {object} web.HTTPError "The project view does not exist"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views/{id} [post]
+func (pv *ProjectView) Update(s *xorm.Session, _ web.Auth) (err error) {
+	view, err := getProjectViewByIDAndProject(s, pv.ID, pv.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	pv.ViewKind = view.ViewKind
+	err = pv.validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		ID(pv.ID).
+		Cols(
+			"title",
+			"filter",
+			"position",
+		).
+		Update(pv)
+	if err != nil {
+		return err
+	}
+
+	updated, err := getProjectViewByID(s, pv.ID)
+	if err != nil {
+		return err
+	}
+
+	*pv = *updated
+	return
+}
+
+// Delete removes the project view
+// @Summary Delete a project view
+// @Description Deletes a project view with its kanban buckets and task positions. Tasks are not deleted. Default views can't be deleted.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param id path int true "Project View ID"
+// @Success 200 {object} models.Message "The project view was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project"
+// @Failure 404 {object} web.HTTPError "The project view does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views/{id} [delete]
+func (pv *ProjectView) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	view, err := getProjectViewByIDAndProject(s, pv.ID, pv.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if view.IsDefault {
+		return &ErrCannotDeleteDefaultProjectView{ProjectViewID: view.ID}
+	}
+
+	_, err = s.Where("project_view_id = ?", view.ID).Delete(&TaskBucket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("project_view_id = ?", view.ID).Delete(&TaskPosition{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Unscoped().Where("project_view_id = ?", view.ID).Delete(&Bucket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("id = ?", view.ID).Delete(&ProjectView{})
+	return err
+}
@@ -0,0 +1,59 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read a project view
+func (pv *ProjectView) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: pv.ProjectID}
+	return p.CanRead(s, a)
+}
+
+// CanCreate checks if a user can create a project view
+func (pv *ProjectView) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	// Saved filters and the favorites pseudo project don't have views
+	if pv.ProjectID < 1 {
+		return false, nil
+	}
+
+	p := &Project{ID: pv.ProjectID}
+	return p.IsAdmin(s, a)
+}
+
+// CanUpdate checks if a user can update a project view
+func (pv *ProjectView) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return pv.canDoProjectView(s, a)
+}
+
+// CanDelete checks if a user can delete a project view
+func (pv *ProjectView) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return pv.canDoProjectView(s, a)
+}
+
+func (pv *ProjectView) canDoProjectView(s *xorm.Session, a web.Auth) (bool, error) {
+	_, err := getProjectViewByIDAndProject(s, pv.ID, pv.ProjectID)
+	if err != nil {
+		return false, err
+	}
+
+	p := &Project{ID: pv.ProjectID}
+	return p.IsAdmin(s, a)
+}
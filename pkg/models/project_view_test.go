@@ -0,0 +1,343 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectView_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	pv := &ProjectView{ProjectID: 1}
+	result, _, total, err := pv.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	assert.NoError(t, err)
+	views := result.([]*ProjectView)
+	assert.Len(t, views, 6)
+	assert.Equal(t, int64(6), total)
+	assert.Equal(t, int64(1), views[0].ID)
+	assert.True(t, views[0].IsDefault)
+	assert.Equal(t, int64(145), views[4].ID)
+	assert.False(t, views[4].IsDefault)
+}
+
+func TestProjectView_Create(t *testing.T) {
+	t.Run("list view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			Title:     "Test",
+			ProjectID: 1,
+			ViewKind:  ProjectViewKindList,
+			IsDefault: true,
+		}
+		err := pv.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		assert.False(t, pv.IsDefault)
+		db.AssertExists(t, "project_views", map[string]interface{}{
+			"id":         pv.ID,
+			"title":      "Test",
+			"project_id": 1,
+			"is_default": false,
+		}, false)
+		db.AssertMissing(t, "buckets", map[string]interface{}{
+			"project_view_id": pv.ID,
+		})
+	})
+	t.Run("kanban view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			Title:     "Test",
+			ProjectID: 1,
+			ViewKind:  ProjectViewKindKanban,
+		}
+		err := pv.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		bucket := &Bucket{}
+		has, err := s.Where("project_view_id = ?", pv.ID).Get(bucket)
+		assert.NoError(t, err)
+		assert.True(t, has)
+		assert.Equal(t, "Backlog", bucket.Title)
+
+		count, err := s.Where("project_view_id = ? AND bucket_id = ?", pv.ID, bucket.ID).Count(&TaskBucket{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(18), count)
+	})
+	t.Run("invalid kind", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			Title:     "Test",
+			ProjectID: 1,
+			ViewKind:  42,
+		}
+		err := pv.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidProjectViewKind(err))
+	})
+}
+
+func TestProjectView_Delete(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{ID: 145, ProjectID: 1}
+		err := pv.Delete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "project_views", map[string]interface{}{
+			"id": 145,
+		})
+		db.AssertMissing(t, "buckets", map[string]interface{}{
+			"project_view_id": 145,
+		})
+		db.AssertMissing(t, "task_buckets", map[string]interface{}{
+			"project_view_id": 145,
+		})
+	})
+	t.Run("default view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{ID: 4, ProjectID: 1}
+		err := pv.Delete(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrCannotDeleteDefaultProjectView(err))
+	})
+	t.Run("view of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{ID: 145, ProjectID: 2}
+		can, err := pv.CanDelete(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestProjectView_Tasks(t *testing.T) {
+	t.Run("filter of the view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ProjectID: 1, ProjectViewID: 146}
+		result, _, _, err := tc.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		tasks := result.([]*Task)
+		for _, task := range tasks {
+			assert.False(t, task.Done)
+		}
+		// Tasks with a position in the view come first
+		assert.Equal(t, int64(4), tasks[0].ID)
+		assert.Equal(t, float64(100), tasks[0].Position)
+		assert.Equal(t, int64(3), tasks[1].ID)
+		assert.Equal(t, int64(1), tasks[2].ID)
+	})
+	t.Run("kanban view with own buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{ProjectID: 1, ProjectViewID: 145}
+		result, _, _, err := tc.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		buckets := result.([]*Bucket)
+		assert.Len(t, buckets, 2)
+		assert.Equal(t, int64(39), buckets[0].ID)
+		assert.Len(t, buckets[0].Tasks, 17)
+		assert.Equal(t, int64(40), buckets[1].ID)
+		assert.Len(t, buckets[1].Tasks, 1)
+		assert.Equal(t, int64(40), buckets[1].Tasks[0].BucketID)
+	})
+}
+
+func TestTaskBucket_Update(t *testing.T) {
+	t.Run("own buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &TaskBucket{
+			TaskID:        1,
+			BucketID:      40,
+			ProjectViewID: 145,
+			ProjectID:     1,
+		}
+		err := tb.Update(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		assert.True(t, tb.Task.Done)
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":         1,
+			"bucket_id":       40,
+			"project_view_id": 145,
+		}, false)
+		// Marking the task done moves it into the done bucket of the default kanban view as well
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":        1,
+			"bucket_id": 3,
+			"done":      true,
+		}, false)
+	})
+	t.Run("default view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &TaskBucket{
+			TaskID:        3,
+			BucketID:      1,
+			ProjectViewID: 4,
+			ProjectID:     1,
+		}
+		err := tb.Update(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":        3,
+			"bucket_id": 1,
+		}, false)
+		db.AssertMissing(t, "task_buckets", map[string]interface{}{
+			"task_id":         3,
+			"project_view_id": 4,
+		})
+	})
+	t.Run("bucket of another view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &TaskBucket{
+			TaskID:        1,
+			BucketID:      2,
+			ProjectViewID: 145,
+			ProjectID:     1,
+		}
+		err := tb.Update(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrBucketDoesNotExist(err))
+	})
+	t.Run("not a kanban view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tb := &TaskBucket{
+			TaskID:        1,
+			BucketID:      39,
+			ProjectViewID: 146,
+			ProjectID:     1,
+		}
+		err := tb.Update(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrProjectViewIsNotKanban(err))
+	})
+}
+
+func TestTaskPosition_Update(t *testing.T) {
+	t.Run("own positions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tp := &TaskPosition{
+			TaskID:        1,
+			ProjectViewID: 146,
+			Position:      50,
+		}
+		err := tp.Update(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_positions", map[string]interface{}{
+			"task_id":         1,
+			"project_view_id": 146,
+			"position":        50,
+		}, false)
+		db.AssertMissing(t, "task_positions", map[string]interface{}{
+			"task_id":         1,
+			"project_view_id": 146,
+			"position":        300,
+		})
+	})
+	t.Run("default view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tp := &TaskPosition{
+			TaskID:        1,
+			ProjectViewID: 1,
+			Position:      50,
+		}
+		err := tp.Update(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       1,
+			"position": 50,
+		}, false)
+	})
+	t.Run("view of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tp := &TaskPosition{
+			TaskID:        1,
+			ProjectViewID: 5,
+			Position:      50,
+		}
+		err := tp.Update(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrProjectViewDoesNotExist(err))
+	})
+}
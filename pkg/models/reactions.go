@@ -0,0 +1,301 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// ReactionKind is the kind of entity a reaction belongs to
+type ReactionKind int
+
+const (
+	// ReactionKindTask is used for reactions on tasks
+	ReactionKindTask ReactionKind = iota
+	// ReactionKindComment is used for reactions on task comments
+	ReactionKindComment
+)
+
+const maxReactionValueLength = 20
+
+// Reaction is a single emoji reaction of a user on a task or comment
+type Reaction struct {
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+
+	UserID int64 `xorm:"bigint not null index" json:"-"`
+	// The user who reacted.
+	User *user.User `xorm:"-" json:"user"`
+
+	EntityID   int64        `xorm:"bigint not null index" json:"-"`
+	EntityKind ReactionKind `xorm:"int not null index" json:"-"`
+
+	// The task the reaction belongs to. For comment reactions this is the task of the comment.
+	TaskID int64 `xorm:"-" json:"-" param:"task"`
+	// The comment the reaction belongs to, if it is a comment reaction.
+	CommentID int64 `xorm:"-" json:"-" param:"commentid"`
+
+	// The reaction itself, usually an emoji.
+	Value string `xorm:"varchar(20) not null index" json:"value"`
+
+	// A timestamp when this reaction was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for reactions
+func (*Reaction) TableName() string {
+	return "reactions"
+}
+
+// ReactionMap holds all users who reacted on something, grouped by the reaction value.
+type ReactionMap map[string][]*user.User
+
+// setEntity sets the entity of the reaction from the task and comment ids passed in the url.
+func (r *Reaction) setEntity() {
+	if r.CommentID != 0 {
+		r.EntityKind = ReactionKindComment
+		r.EntityID = r.CommentID
+		return
+	}
+	r.EntityKind = ReactionKindTask
+	r.EntityID = r.TaskID
+}
+
+// getTask returns the task of the reaction and makes sure the comment belongs to it.
+func (r *Reaction) getTask(s *xorm.Session) (task *Task, err error) {
+	if r.CommentID != 0 {
+		err = getTaskCommentSimple(s, &TaskComment{ID: r.CommentID, TaskID: r.TaskID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := GetTaskByIDSimple(s, r.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ReadAll returns all reactions on a task or comment
+// @Summary Get all reactions on a task
+// @Description Returns all reactions on a task, grouped by their value. The same endpoint exists for comments at /tasks/{task}/comments/{commentID}/reactions.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Success 200 {object} models.ReactionMap "The reactions"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/reactions [get]
+func (r *Reaction) ReadAll(s *xorm.Session, _ web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	r.setEntity()
+
+	reactions, err := getReactionsForEntityIDs(s, r.EntityKind, []int64{r.EntityID})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	reactionMap := reactions[r.EntityID]
+	if reactionMap == nil {
+		reactionMap = ReactionMap{}
+	}
+	return reactionMap, len(reactionMap), int64(len(reactionMap)), nil
+}
+
+// Create adds a new reaction to a task or comment
+// @Summary Add a reaction to a task
+// @Description Adds a reaction of the current user to a task. Adding the same reaction twice does nothing. The same endpoint exists for comments at /tasks/{task}/comments/{commentID}/reactions.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param reaction body models.Reaction true "The reaction"
+// @Success 201 {object} models.Reaction "The created reaction"
+// @Failure 400 {object} web.HTTPError "Invalid reaction provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/reactions [put]
+func (r *Reaction) Create(s *xorm.Session, a web.Auth) (err error) {
+	r.Value = strings.TrimSpace(r.Value)
+	if r.Value == "" || utf8.RuneCountInString(r.Value) > maxReactionValueLength {
+		return &ErrInvalidReactionValue{Value: r.Value}
+	}
+
+	r.setEntity()
+	r.ID = 0
+	r.UserID = a.GetID()
+
+	exists, err := s.Where(builder.Eq{
+		"user_id":     r.UserID,
+		"entity_id":   r.EntityID,
+		"entity_kind": r.EntityKind,
+		"value":       r.Value,
+	}).Exist(&Reaction{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	_, err = s.Insert(r)
+	if err != nil {
+		return err
+	}
+
+	r.User, err = user.GetUserByID(s, r.UserID)
+	if err != nil {
+		return err
+	}
+
+	task, err := r.getTask(s)
+	if err != nil {
+		return err
+	}
+
+	return events.Dispatch(&TaskReactionCreatedEvent{
+		Task:     task,
+		Reaction: r,
+		Doer:     r.User,
+	})
+}
+
+// Delete removes a reaction of the current user from a task or comment
+// @Summary Remove a reaction from a task
+// @Description Removes a reaction of the current user from a task. The same endpoint exists for comments at /tasks/{task}/comments/{commentID}/reactions/delete.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param reaction body models.Reaction true "The reaction to remove"
+// @Success 200 {object} models.Message "The reaction was successfully removed."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/reactions/delete [post]
+func (r *Reaction) Delete(s *xorm.Session, a web.Auth) (err error) {
+	r.setEntity()
+	_, err = s.Where(builder.Eq{
+		"user_id":     a.GetID(),
+		"entity_id":   r.EntityID,
+		"entity_kind": r.EntityKind,
+		"value":       strings.TrimSpace(r.Value),
+	}).Delete(&Reaction{})
+	return
+}
+
+func getReactionsForEntityIDs(s *xorm.Session, kind ReactionKind, entityIDs []int64) (reactionsByEntity map[int64]ReactionMap, err error) {
+	reactionsByEntity = make(map[int64]ReactionMap)
+	if len(entityIDs) == 0 {
+		return
+	}
+
+	reactions := []*Reaction{}
+	err = s.
+		Where("entity_kind = ?", kind).
+		In("entity_id", entityIDs).
+		OrderBy("created asc, id asc").
+		Find(&reactions)
+	if err != nil {
+		return nil, err
+	}
+	if len(reactions) == 0 {
+		return
+	}
+
+	userIDs := make([]int64, 0, len(reactions))
+	for _, reaction := range reactions {
+		userIDs = append(userIDs, reaction.UserID)
+	}
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reaction := range reactions {
+		u, has := users[reaction.UserID]
+		if !has {
+			continue
+		}
+		if reactionsByEntity[reaction.EntityID] == nil {
+			reactionsByEntity[reaction.EntityID] = ReactionMap{}
+		}
+		reactionsByEntity[reaction.EntityID][reaction.Value] = append(reactionsByEntity[reaction.EntityID][reaction.Value], u)
+	}
+
+	return
+}
+
+func addReactionsToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) error {
+	reactions, err := getReactionsForEntityIDs(s, ReactionKindTask, taskIDs)
+	if err != nil {
+		return err
+	}
+	for id, r := range reactions {
+		if task, has := taskMap[id]; has {
+			task.Reactions = r
+		}
+	}
+	return nil
+}
+
+func addReactionsToComments(s *xorm.Session, comments []*TaskComment) error {
+	commentIDs := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		commentIDs = append(commentIDs, comment.ID)
+	}
+
+	reactions, err := getReactionsForEntityIDs(s, ReactionKindComment, commentIDs)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		comment.Reactions = reactions[comment.ID]
+	}
+	return nil
+}
+
+// deleteReactionsOfTask removes all reactions on a task and its comments.
+func deleteReactionsOfTask(s *xorm.Session, taskID int64) (err error) {
+	_, err = s.
+		Where(builder.Or(
+			builder.And(
+				builder.Eq{"entity_kind": ReactionKindTask},
+				builder.Eq{"entity_id": taskID},
+			),
+			builder.And(
+				builder.Eq{"entity_kind": ReactionKindComment},
+				builder.In("entity_id", builder.Select("id").From("task_comments").Where(builder.Eq{"task_id": taskID})),
+			),
+		)).
+		Delete(&Reaction{})
+	return
+}
@@ -0,0 +1,47 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can see the reactions of a task or comment
+func (r *Reaction) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	task, err := r.getTask(s)
+	if err != nil {
+		return false, 0, err
+	}
+	return task.CanRead(s, a)
+}
+
+// CanCreate checks if a user can react on a task or comment.
+// Everyone who can see the task can react, except link shares.
+func (r *Reaction) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	can, _, err := r.CanRead(s, a)
+	return can, err
+}
+
+// CanDelete checks if a user can remove their reaction
+func (r *Reaction) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return r.CanCreate(s, a)
+}
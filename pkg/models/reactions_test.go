@@ -0,0 +1,210 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaction_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1}
+		can, _, err := r.CanRead(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+
+		res, _, _, err := r.ReadAll(s, u, "", 0, -1)
+		require.NoError(t, err)
+		reactions := res.(ReactionMap)
+		assert.Len(t, reactions, 1)
+		require.Len(t, reactions["👋"], 2)
+		assert.Equal(t, int64(1), reactions["👋"][0].ID)
+		assert.Equal(t, int64(2), reactions["👋"][1].ID)
+	})
+	t.Run("comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, CommentID: 1}
+		res, _, _, err := r.ReadAll(s, u, "", 0, -1)
+		require.NoError(t, err)
+		reactions := res.(ReactionMap)
+		assert.Len(t, reactions, 1)
+		require.Len(t, reactions["🎉"], 1)
+		assert.Equal(t, int64(1), reactions["🎉"][0].ID)
+	})
+	t.Run("comment of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 2, CommentID: 1}
+		_, _, err := r.CanRead(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskCommentDoesNotExist(err))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1}
+		can, _, err := r.CanRead(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestReaction_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, Value: "🚀"}
+		can, err := r.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+
+		err = r.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "reactions", map[string]interface{}{
+			"user_id":     1,
+			"entity_id":   1,
+			"entity_kind": ReactionKindTask,
+			"value":       "🚀",
+		}, false)
+	})
+	t.Run("on a comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, CommentID: 1, Value: "👍"}
+		err := r.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "reactions", map[string]interface{}{
+			"user_id":     1,
+			"entity_id":   1,
+			"entity_kind": ReactionKindComment,
+			"value":       "👍",
+		}, false)
+	})
+	t.Run("twice", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, Value: "👋"}
+		err := r.Create(s, u)
+		require.NoError(t, err)
+
+		count, err := s.Where("user_id = ? AND entity_id = ? AND entity_kind = ? AND value = ?", 1, 1, ReactionKindTask, "👋").Count(&Reaction{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, Value: "   "}
+		err := r.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidReactionValue(err))
+
+		r = &Reaction{TaskID: 1, Value: "this reaction is way too long"}
+		err = r.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidReactionValue(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &Reaction{TaskID: 1, Value: "🚀"}
+		can, err := r.CanCreate(s, &LinkSharing{ID: 1, ProjectID: 1, Right: RightWrite})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestReaction_Delete(t *testing.T) {
+	t.Run("own reaction", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		r := &Reaction{TaskID: 1, Value: "👋"}
+		can, err := r.CanDelete(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+
+		err = r.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "reactions", map[string]interface{}{
+			"id": 1,
+		})
+		// Reactions of other users are kept
+		db.AssertExists(t, "reactions", map[string]interface{}{
+			"id": 2,
+		}, false)
+	})
+	t.Run("purged with the task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := purgeTask(s, &Task{ID: 1})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "reactions", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "reactions", map[string]interface{}{
+			"id": 3,
+		})
+	})
+}
@@ -30,6 +30,8 @@ type SavedFilter struct {
 	ID int64 `xorm:"autoincr not null unique pk" json:"id" param:"filter"`
 	// The actual filters this filter contains
 	Filters *TaskCollection `xorm:"JSON not null" json:"filters" valid:"required"`
+	// A filter expression like `done = false && (priority >= 3 || labels in 4, 5)`, applied in addition to the filters.
+	Filter string `xorm:"text null" json:"filter"`
 	// The title of the filter.
 	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
 	// The description of the filter
@@ -59,9 +61,26 @@ func (sf *SavedFilter) TableName() string {
 func (sf *SavedFilter) getTaskCollection() *TaskCollection {
 	// We're resetting the projectID to return tasks from all projects
 	sf.Filters.ProjectID = 0
+	sf.Filters.Filter = combineTaskFilterQueries(sf.Filter, sf.Filters.Filter)
 	return sf.Filters
 }
 
+// validateFilterQueries checks if the filter expressions of the saved filter can be parsed
+func (sf *SavedFilter) validateFilterQueries() (err error) {
+	if sf.Filter != "" {
+		_, err = parseTaskFilterQuery(sf.Filter)
+		if err != nil {
+			return err
+		}
+	}
+
+	if sf.Filters != nil && sf.Filters.Filter != "" {
+		_, err = parseTaskFilterQuery(sf.Filters.Filter)
+	}
+
+	return err
+}
+
 // Returns the saved filter ID from a project ID. Will not check if the filter actually exists.
 // If the returned ID is zero, means that it is probably invalid.
 func getSavedFilterIDFromProjectID(projectID int64) (filterID int64) {
@@ -106,6 +125,20 @@ func (sf *SavedFilter) toProject() *Project {
 	}
 }
 
+// GetSavedFiltersAsProjects returns all saved filters of a user as pseudo projects
+func GetSavedFiltersAsProjects(s *xorm.Session, auth web.Auth) (projects []*Project, err error) {
+	filters, err := getSavedFiltersForUser(s, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	projects = make([]*Project, 0, len(filters))
+	for _, filter := range filters {
+		projects = append(projects, filter.toProject())
+	}
+	return
+}
+
 // Create creates a new saved filter
 // @Summary Creates a new saved filter
 // @Description Creates a new saved filter
@@ -114,12 +147,18 @@ func (sf *SavedFilter) toProject() *Project {
 // @Produce json
 // @Security JWTKeyAuth
 // @Success 201 {object} models.SavedFilter "The Saved Filter"
+// @Failure 400 {object} web.HTTPError "The filter expression is invalid."
 // @Failure 403 {object} web.HTTPError "The user does not have access to that saved filter."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /filters [put]
 func (sf *SavedFilter) Create(s *xorm.Session, auth web.Auth) error {
+	err := sf.validateFilterQueries()
+	if err != nil {
+		return err
+	}
+
 	sf.OwnerID = auth.GetID()
-	_, err := s.Insert(sf)
+	_, err = s.Insert(sf)
 	return err
 }
 
@@ -165,6 +204,7 @@ func (sf *SavedFilter) ReadOne(s *xorm.Session, _ web.Auth) error {
 // @Security JWTKeyAuth
 // @Param id path int true "Filter ID"
 // @Success 200 {object} models.SavedFilter "The Saved Filter"
+// @Failure 400 {object} web.HTTPError "The filter expression is invalid."
 // @Failure 403 {object} web.HTTPError "The user does not have access to that saved filter."
 // @Failure 404 {object} web.HTTPError "The saved filter does not exist."
 // @Failure 500 {object} models.Message "Internal error"
@@ -179,12 +219,18 @@ func (sf *SavedFilter) Update(s *xorm.Session, _ web.Auth) error {
 		sf.Filters = origFilter.Filters
 	}
 
+	err = sf.validateFilterQueries()
+	if err != nil {
+		return err
+	}
+
 	_, err = s.
 		Where("id = ?", sf.ID).
 		Cols(
 			"title",
 			"description",
 			"filters",
+			"filter",
 			"is_favorite",
 		).
 		Update(sf)
@@ -63,7 +63,7 @@ func TestSavedFilter_Create(t *testing.T) {
 	vals := map[string]interface{}{
 		"title":       "'test'",
 		"description": "'Lorem Ipsum dolor sit amet'",
-		"filters":     "'{\"sort_by\":null,\"order_by\":null,\"filter_by\":null,\"filter_value\":null,\"filter_comparator\":null,\"filter_concat\":\"\",\"filter_include_nulls\":false}'",
+		"filters":     "'{\"sort_by\":null,\"order_by\":null,\"filter_by\":null,\"filter_value\":null,\"filter_comparator\":null,\"filter_concat\":\"\",\"filter_include_nulls\":false,\"filter\":\"\"}'",
 		"owner_id":    1,
 	}
 	// Postgres can't compare json values directly, see https://dba.stackexchange.com/a/106290/210721
@@ -74,6 +74,22 @@ func TestSavedFilter_Create(t *testing.T) {
 	db.AssertExists(t, "saved_filters", vals, true)
 }
 
+func TestSavedFilter_CreateWithInvalidFilterExpression(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	sf := &SavedFilter{
+		Title:   "test",
+		Filters: &TaskCollection{},
+		Filter:  "done = false && (priority > 2",
+	}
+
+	err := sf.Create(s, &user.User{ID: 1})
+	assert.Error(t, err)
+	assert.True(t, IsErrInvalidTaskFilterExpression(err))
+}
+
 func TestSavedFilter_ReadOne(t *testing.T) {
 	user1 := &user.User{ID: 1}
 	db.LoadAndAssertFixtures(t)
@@ -0,0 +1,239 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TaskActivityKind describes what happened to a field of a task
+type TaskActivityKind string
+
+const (
+	// TaskActivityKindChanged is used when the value of a task field was changed
+	TaskActivityKindChanged TaskActivityKind = "changed"
+	// TaskActivityKindAdded is used when something was added to a task, like an assignee or a label
+	TaskActivityKindAdded TaskActivityKind = "added"
+	// TaskActivityKindRemoved is used when something was removed from a task
+	TaskActivityKindRemoved TaskActivityKind = "removed"
+)
+
+// TaskActivity is a single field-level change of a task
+type TaskActivity struct {
+	// The unique, numeric id of this activity.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The task this activity belongs to.
+	TaskID int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+	DoerID int64 `xorm:"bigint not null" json:"-"`
+	// The user or link share who made this change.
+	Doer *user.User `xorm:"-" json:"doer"`
+	// What happened to the field. Can be `changed`, `added` or `removed`.
+	Kind TaskActivityKind `xorm:"varchar(50) not null" json:"kind"`
+	// The changed field. Either a task property like `due_date` or one of `assignees`, `labels`, `relations`, `attachments` and `bucket`.
+	Field string `xorm:"varchar(250) not null" json:"field"`
+	// The value before the change. Empty when something was added.
+	OldValue string `xorm:"text null" json:"old_value"`
+	// The value after the change. Empty when something was removed.
+	NewValue string `xorm:"text null" json:"new_value"`
+
+	// A timestamp when this change was made. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for task activities
+func (*TaskActivity) TableName() string {
+	return "task_activities"
+}
+
+type taskActivityValue struct {
+	field string
+	value string
+}
+
+func formatTaskActivityTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// activityValues returns all task fields which are tracked in the activity log, formatted as strings.
+func (t *Task) activityValues() []taskActivityValue {
+	return []taskActivityValue{
+		{field: "title", value: t.Title},
+		{field: "description", value: t.Description},
+		{field: "done", value: strconv.FormatBool(t.Done)},
+		{field: "due_date", value: formatTaskActivityTime(t.DueDate)},
+		{field: "start_date", value: formatTaskActivityTime(t.StartDate)},
+		{field: "end_date", value: formatTaskActivityTime(t.EndDate)},
+		{field: "priority", value: strconv.FormatInt(t.Priority, 10)},
+		{field: "percent_done", value: strconv.FormatFloat(t.PercentDone, 'f', -1, 64)},
+		{field: "story_points", value: strconv.FormatFloat(t.StoryPoints, 'f', -1, 64)},
+		{field: "time_estimate", value: strconv.FormatInt(t.TimeEstimate, 10)},
+		{field: "hex_color", value: t.HexColor},
+		{field: "repeat_after", value: strconv.FormatInt(t.RepeatAfter, 10)},
+		{field: "repeat_mode", value: strconv.Itoa(int(t.RepeatMode))},
+		{field: "repeat_rule", value: t.RepeatRule},
+		{field: "project_id", value: strconv.FormatInt(t.ProjectID, 10)},
+	}
+}
+
+func getTaskActivityDoerID(a web.Auth) int64 {
+	switch doer := a.(type) {
+	case *user.User:
+		return doer.ID
+	case *LinkSharing:
+		return doer.getUserID()
+	}
+	return 0
+}
+
+func addTaskActivity(s *xorm.Session, taskID int64, a web.Auth, kind TaskActivityKind, field, oldValue, newValue string) (err error) {
+	_, err = s.Insert(&TaskActivity{
+		TaskID:   taskID,
+		DoerID:   getTaskActivityDoerID(a),
+		Kind:     kind,
+		Field:    field,
+		OldValue: oldValue,
+		NewValue: newValue,
+	})
+	return
+}
+
+// addTaskActivitiesForChangedFields records all fields whose values differ between old and new.
+// Both slices need to come from activityValues.
+func addTaskActivitiesForChangedFields(s *xorm.Session, taskID int64, a web.Auth, oldValues, newValues []taskActivityValue) (err error) {
+	for i, old := range oldValues {
+		if old.value == newValues[i].value {
+			continue
+		}
+		err = addTaskActivity(s, taskID, a, TaskActivityKindChanged, old.field, old.value, newValues[i].value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTaskBucketActivity records that a task was moved from one bucket to another, using the bucket titles as values.
+func addTaskBucketActivity(s *xorm.Session, taskID int64, a web.Auth, oldBucketID, newBucketID int64) error {
+	titles := make([]string, 2)
+	for i, id := range []int64{oldBucketID, newBucketID} {
+		if id == 0 {
+			continue
+		}
+		bucket, err := getBucketByID(s, id)
+		if err != nil && !IsErrBucketDoesNotExist(err) {
+			return err
+		}
+		if bucket != nil {
+			titles[i] = bucket.Title
+		}
+	}
+
+	return addTaskActivity(s, taskID, a, TaskActivityKindChanged, "bucket", titles[0], titles[1])
+}
+
+// ReadAll returns all activities of a task, newest first
+// @Summary Get the activity of a task
+// @Description Returns all field-level changes of a task with the user who made them, newest first. The user needs to have at least read access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskActivity "The activities of the task"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task"
+// @Failure 404 {object} web.HTTPError "The task does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/activity [get]
+func (ta *TaskActivity) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	activities := []*TaskActivity{}
+	query := s.
+		Where("task_id = ?", ta.TaskID).
+		OrderBy("created desc, id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&activities)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addDoersToTaskActivities(s, activities)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where("task_id = ?", ta.TaskID).
+		Count(&TaskActivity{})
+	return activities, len(activities), numberOfTotalItems, err
+}
+
+func addDoersToTaskActivities(s *xorm.Session, activities []*TaskActivity) (err error) {
+	doerIDs := make([]int64, 0, len(activities))
+	for _, activity := range activities {
+		doerIDs = append(doerIDs, activity.DoerID)
+	}
+
+	doers, err := getUsersOrLinkSharesFromIDs(s, doerIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, activity := range activities {
+		activity.Doer = doers[activity.DoerID]
+	}
+	return nil
+}
+
+func getTaskActivitiesForTasks(s *xorm.Session, taskIDs []int64) (activities map[int64][]*TaskActivity, err error) {
+	activities = make(map[int64][]*TaskActivity)
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	all := []*TaskActivity{}
+	err = s.In("task_id", taskIDs).OrderBy("created asc, id asc").Find(&all)
+	if err != nil {
+		return nil, err
+	}
+
+	err = addDoersToTaskActivities(s, all)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, activity := range all {
+		activities[activity.TaskID] = append(activities[activity.TaskID], activity)
+	}
+	return
+}
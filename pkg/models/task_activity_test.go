@@ -0,0 +1,212 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskActivity_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskActivity{TaskID: 1}
+		can, _, err := ta.CanRead(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+
+		res, count, total, err := ta.ReadAll(s, u, "", 0, -1)
+		require.NoError(t, err)
+		activities := res.([]*TaskActivity)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, int64(3), total)
+		// Newest first
+		assert.Equal(t, int64(3), activities[0].ID)
+		assert.Equal(t, "priority", activities[0].Field)
+		assert.Equal(t, int64(-4), activities[0].Doer.ID)
+		assert.Equal(t, int64(1), activities[2].ID)
+		assert.Equal(t, "user1", activities[2].Doer.Username)
+	})
+	t.Run("pagination", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskActivity{TaskID: 1}
+		res, count, total, err := ta.ReadAll(s, u, "", 2, 2)
+		require.NoError(t, err)
+		activities := res.([]*TaskActivity)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, int64(1), activities[0].ID)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskActivity{TaskID: 1}
+		can, _, err := ta.CanRead(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskActivity_Recording(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task fields", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "task #1 done",
+			Description: "Lorem Ipsum",
+			Priority:    4,
+			ProjectID:   1,
+		}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"doer_id":   1,
+			"kind":      TaskActivityKindChanged,
+			"field":     "priority",
+			"old_value": "0",
+			"new_value": "4",
+		}, false)
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"field":     "title",
+			"old_value": "task #1",
+			"new_value": "task #1 done",
+		}, false)
+		// Unchanged fields are not recorded
+		db.AssertMissing(t, "task_activities", map[string]interface{}{
+			"task_id": 1,
+			"field":   "description",
+		})
+	})
+	t.Run("assignees", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskAssginee{TaskID: 1, UserID: 1}
+		err := ta.Create(s, u)
+		require.NoError(t, err)
+		err = ta.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"kind":      TaskActivityKindAdded,
+			"field":     "assignees",
+			"new_value": "user1",
+		}, false)
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"kind":      TaskActivityKindRemoved,
+			"field":     "assignees",
+			"old_value": "user1",
+		}, false)
+	})
+	t.Run("labels", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lt := &LabelTask{TaskID: 1, LabelID: 1}
+		err := lt.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"kind":      TaskActivityKindAdded,
+			"field":     "labels",
+			"new_value": "Label #1",
+		}, false)
+	})
+	t.Run("relations", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := &TaskRelation{TaskID: 1, OtherTaskID: 2, RelationKind: RelationKindBlocking}
+		err := rel.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"kind":      TaskActivityKindAdded,
+			"field":     "relations",
+			"new_value": "blocking:2",
+		}, false)
+	})
+	t.Run("bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			Title:     "task #1 done",
+			ProjectID: 1,
+			BucketID:  3,
+		}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"kind":      TaskActivityKindChanged,
+			"field":     "bucket",
+			"old_value": "testbucket1",
+			"new_value": "testbucket3",
+		}, false)
+		// Moving the task into the done bucket marks it as done
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"field":     "done",
+			"old_value": "false",
+			"new_value": "true",
+		}, false)
+	})
+}
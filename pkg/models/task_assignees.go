@@ -80,8 +80,17 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User, doer
 	if len(assignees) == 0 && len(t.Assignees) > 0 {
 		_, err = s.Where("task_id = ?", t.ID).
 			Delete(TaskAssginee{})
+		if err != nil {
+			return err
+		}
+		for _, oldAssignee := range t.Assignees {
+			err = addTaskActivity(s, t.ID, doer, TaskActivityKindRemoved, "assignees", oldAssignee.Username, "")
+			if err != nil {
+				return err
+			}
+		}
 		t.setTaskAssignees(assignees)
-		return err
+		return nil
 	}
 
 	// If we didn't change anything (from 0 to zero) don't do anything.
@@ -108,6 +117,10 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User, doer
 		// Put all assignees which are only on the old project to the trash
 		if !found {
 			assigneesToDelete = append(assigneesToDelete, oldAssignee.ID)
+			err = addTaskActivity(s, t.ID, doer, TaskActivityKindRemoved, "assignees", oldAssignee.Username, "")
+			if err != nil {
+				return err
+			}
 		}
 
 		oldAssignees[oldAssignee.ID] = oldAssignee
@@ -178,14 +191,30 @@ func (la *TaskAssginee) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	assignee, err := user.GetUserByID(s, la.UserID)
+	if err != nil && !user.IsErrUserDoesNotExist(err) {
+		return err
+	}
+	if assignee != nil {
+		err = addTaskActivity(s, la.TaskID, a, TaskActivityKindRemoved, "assignees", assignee.Username, "")
+		if err != nil {
+			return err
+		}
+	}
+
 	err = updateProjectByTaskID(s, la.TaskID)
 	if err != nil {
 		return err
 	}
 
+	task, err := GetTaskByIDSimple(s, la.TaskID)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	return events.Dispatch(&TaskAssigneeDeletedEvent{
-		Task:     &Task{ID: la.TaskID},
+		Task:     &task,
 		Assignee: &user.User{ID: la.UserID},
 		Doer:     doer,
 	})
@@ -251,6 +280,11 @@ func (t *Task) addNewAssigneeByID(s *xorm.Session, newAssigneeID int64, project
 		return err
 	}
 
+	err = addTaskActivity(s, t.ID, auth, TaskActivityKindAdded, "assignees", "", newAssignee.Username)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(auth)
 	err = events.Dispatch(&TaskAssigneeCreatedEvent{
 		Task:     t,
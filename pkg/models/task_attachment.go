@@ -86,8 +86,18 @@ func (ta *TaskAttachment) NewAttachment(s *xorm.Session, f io.ReadCloser, realna
 		return err
 	}
 
+	err = addTaskActivity(s, ta.TaskID, a, TaskActivityKindAdded, "attachments", "", file.Name)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, ta.TaskID)
+	if err != nil {
+		return err
+	}
+
 	return events.Dispatch(&TaskAttachmentCreatedEvent{
-		Task:       &Task{ID: ta.TaskID},
+		Task:       &task,
 		Attachment: ta,
 		Doer:       ta.CreatedBy,
 	})
@@ -209,6 +219,15 @@ func (ta *TaskAttachment) Delete(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	var fileName string
+	if ta.File != nil {
+		fileName = ta.File.Name
+	}
+	err = addTaskActivity(s, ta.TaskID, a, TaskActivityKindRemoved, "attachments", fileName, "")
+	if err != nil {
+		return err
+	}
+
 	// Delete the underlying file
 	err = ta.File.Delete()
 	// If the file does not exist, we don't want to error out
@@ -219,9 +238,14 @@ func (ta *TaskAttachment) Delete(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	task, err := GetTaskByIDSimple(s, ta.TaskID)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	return events.Dispatch(&TaskAttachmentDeletedEvent{
-		Task:       &Task{ID: ta.TaskID},
+		Task:       &task,
 		Attachment: ta,
 		Doer:       doer,
 	})
@@ -0,0 +1,250 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// TaskBucket holds the bucket a task is in for a kanban view which does not use the bucket stored with the task.
+type TaskBucket struct {
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	// The bucket the task should be in
+	BucketID int64 `xorm:"bigint not null index" json:"bucket_id" param:"bucket"`
+	// The task which should be moved into the bucket
+	TaskID int64 `xorm:"bigint not null index" json:"task_id"`
+	// The kanban view the bucket belongs to
+	ProjectViewID int64 `xorm:"bigint not null index" json:"project_view_id" param:"view"`
+
+	ProjectID int64 `xorm:"-" json:"-" param:"project"`
+
+	// The updated task
+	Task *Task `xorm:"-" json:"task"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for task buckets
+func (*TaskBucket) TableName() string {
+	return "task_buckets"
+}
+
+// addTaskToKanbanViewsWithOwnBuckets puts a task into the first bucket of all kanban views of its project
+// which don't use the bucket stored with the task.
+func addTaskToKanbanViewsWithOwnBuckets(s *xorm.Session, task *Task) (err error) {
+	views, err := getKanbanViewsWithOwnBuckets(s, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	for _, view := range views {
+		bucket, err := getDefaultBucket(s, view.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Insert(&TaskBucket{
+			TaskID:        task.ID,
+			BucketID:      bucket.ID,
+			ProjectViewID: view.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveTaskToDoneBuckets moves a task into the done bucket of all kanban views of its project which don't use the
+// bucket stored with the task and have a done bucket.
+func moveTaskToDoneBuckets(s *xorm.Session, task *Task) (err error) {
+	views, err := getKanbanViewsWithOwnBuckets(s, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	for _, view := range views {
+		doneBucket, err := getDoneBucketForView(s, view.ID)
+		if err != nil {
+			return err
+		}
+		if doneBucket == nil {
+			continue
+		}
+
+		err = setTaskBucketForView(s, task.ID, doneBucket)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeTaskFromProjectViews removes all buckets and positions views keep for a task.
+func removeTaskFromProjectViews(s *xorm.Session, taskID int64) (err error) {
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskBucket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskPosition{})
+	return err
+}
+
+func setTaskBucketForView(s *xorm.Session, taskID int64, bucket *Bucket) (err error) {
+	_, err = s.
+		Where("task_id = ? AND project_view_id = ?", taskID, bucket.ProjectViewID).
+		Delete(&TaskBucket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(&TaskBucket{
+		TaskID:        taskID,
+		BucketID:      bucket.ID,
+		ProjectViewID: bucket.ProjectViewID,
+	})
+	return err
+}
+
+// Update moves a task into a bucket of a kanban view
+// @Summary Move a task into a bucket
+// @Description Moves a task into a kanban bucket of a kanban view. Moving a task into the done bucket of the view will mark it as done, moving it out of the done bucket will mark it as undone.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Param view path int true "Project View ID"
+// @Param bucket path int true "Bucket ID"
+// @Param taskBucket body models.TaskBucket true "The id of the task you want to move into the bucket."
+// @Success 200 {object} models.TaskBucket "The updated task bucket."
+// @Failure 400 {object} web.HTTPError "Invalid task bucket object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the project"
+// @Failure 404 {object} web.HTTPError "The project view or bucket does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/views/{view}/buckets/{bucket}/tasks [post]
+func (tb *TaskBucket) Update(s *xorm.Session, a web.Auth) (err error) {
+	view, err := getProjectViewByIDAndProject(s, tb.ProjectViewID, tb.ProjectID)
+	if err != nil {
+		return err
+	}
+	if view.ViewKind != ProjectViewKindKanban {
+		return &ErrProjectViewIsNotKanban{ProjectViewID: view.ID}
+	}
+
+	bucket, err := getBucketByID(s, tb.BucketID)
+	if err != nil {
+		return err
+	}
+	if bucket.ProjectViewID != view.ID {
+		return ErrBucketDoesNotExist{BucketID: tb.BucketID}
+	}
+
+	task, err := GetTaskByIDSimple(s, tb.TaskID)
+	if err != nil {
+		return err
+	}
+	if task.ProjectID != view.ProjectID {
+		return ErrBucketDoesNotBelongToProject{BucketID: bucket.ID, ProjectID: task.ProjectID}
+	}
+
+	tb.Task = &Task{ID: task.ID}
+	err = tb.Task.ReadOne(s, a)
+	if err != nil {
+		return err
+	}
+
+	// Default views use the bucket stored with the task, moving a task there works like updating the task.
+	if !view.usesOwnTaskData() {
+		tb.Task.BucketID = bucket.ID
+		return tb.Task.Update(s, a)
+	}
+
+	oldTaskBucket := &TaskBucket{}
+	has, err := s.
+		Where("task_id = ? AND project_view_id = ?", task.ID, view.ID).
+		Get(oldTaskBucket)
+	if err != nil {
+		return err
+	}
+	if has && oldTaskBucket.BucketID == bucket.ID {
+		tb.Task.BucketID = bucket.ID
+		return nil
+	}
+
+	if bucket.Limit > 0 {
+		taskCount, err := s.Where("bucket_id = ?", bucket.ID).Count(&TaskBucket{})
+		if err != nil {
+			return err
+		}
+		if taskCount >= bucket.Limit {
+			return ErrBucketLimitExceeded{TaskID: task.ID, BucketID: bucket.ID, Limit: bucket.Limit}
+		}
+	}
+
+	err = setTaskBucketForView(s, task.ID, bucket)
+	if err != nil {
+		return err
+	}
+
+	var oldBucket *Bucket
+	if has {
+		oldBucket, err = getBucketByID(s, oldTaskBucket.BucketID)
+		if err != nil && !IsErrBucketDoesNotExist(err) {
+			return err
+		}
+	}
+
+	switch {
+	case bucket.IsDoneBucket && !task.Done:
+		tb.Task.Done = true
+		err = tb.Task.Update(s, a)
+		if err != nil {
+			return err
+		}
+		// Repeating tasks are not marked done but rescheduled, they stay where they were.
+		if !tb.Task.Done && oldBucket != nil {
+			err = setTaskBucketForView(s, task.ID, oldBucket)
+		}
+	case oldBucket != nil && oldBucket.IsDoneBucket && task.Done:
+		tb.Task.Done = false
+		err = tb.Task.Update(s, a)
+	}
+	if err != nil {
+		return err
+	}
+
+	// A rescheduled repeating task stays in its old bucket
+	if tb.Task.Done || oldBucket == nil || !bucket.IsDoneBucket {
+		var oldBucketID int64
+		if has {
+			oldBucketID = oldTaskBucket.BucketID
+		}
+		err = addTaskBucketActivity(s, task.ID, a, oldBucketID, bucket.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	tb.Task.BucketID = bucket.ID
+	return nil
+}
@@ -24,7 +24,8 @@ import (
 
 // TaskCollection is a struct used to hold filter details and not clutter the Task struct with information not related to actual tasks.
 type TaskCollection struct {
-	ProjectID int64 `param:"project" json:"-"`
+	ProjectID     int64 `param:"project" json:"-"`
+	ProjectViewID int64 `param:"view" json:"-"`
 
 	// The query parameter to sort by. This is for ex. done, priority, etc.
 	SortBy    []string `query:"sort_by" json:"sort_by"`
@@ -46,6 +47,9 @@ type TaskCollection struct {
 	FilterConcat string `query:"filter_concat" json:"filter_concat"`
 	// If set to true, the result will also include null values
 	FilterIncludeNulls bool `query:"filter_include_nulls" json:"filter_include_nulls"`
+	// A filter expression like `done = false && (priority >= 3 || labels in 4, 5)`.
+	// It is applied in addition to the filters in filter_by, filter_value and filter_comparator.
+	Filter string `query:"filter" json:"filter"`
 
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
@@ -68,6 +72,8 @@ func validateTaskField(fieldName string) error {
 		taskPropertyEndDate,
 		taskPropertyHexColor,
 		taskPropertyPercentDone,
+		taskPropertyStoryPoints,
+		taskPropertyTimeEstimate,
 		taskPropertyUID,
 		taskPropertyCreated,
 		taskPropertyUpdated,
@@ -77,6 +83,9 @@ func validateTaskField(fieldName string) error {
 		taskPropertyIndex:
 		return nil
 	}
+	if _, is := parseCustomFieldProperty(fieldName); is {
+		return nil
+	}
 	return ErrInvalidTaskField{TaskField: fieldName}
 }
 
@@ -115,12 +124,20 @@ func getTaskFilterOptsFromCollection(tf *TaskCollection) (opts *taskOptions, err
 	}
 
 	opts.filters, err = getTaskFiltersByCollections(tf)
+	if err != nil {
+		return nil, err
+	}
+
+	if tf.Filter != "" {
+		opts.filterQuery, err = parseTaskFilterQuery(tf.Filter)
+	}
+
 	return opts, err
 }
 
 // ReadAll gets all tasks for a collection
 // @Summary Get tasks in a project
-// @Description Returns all tasks for the current project.
+// @Description Returns all tasks for the current project. If a view is specified, its filter is applied and list views are sorted by the position of the tasks in the view. For kanban views, the buckets of the view with their tasks are returned instead.
 // @tags task
 // @Accept json
 // @Produce json
@@ -128,19 +145,40 @@ func getTaskFilterOptsFromCollection(tf *TaskCollection) (opts *taskOptions, err
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
 // @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `story_points`, `time_estimate`, `uid`, `created`, `updated` and `custom_field_<id>` for a custom field of the project. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
-// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
+// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. Custom fields are filtered with `custom_field_<id>`. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
 // @Param filter_value query string false "The value to filter for. You can use [grafana](https://grafana.com/docs/grafana/latest/dashboards/time-range-controls)- or [elasticsearch](https://www.elastic.co/guide/en/elasticsearch/reference/7.3/common-options.html#date-math)-style relative dates for all date fields like `due_date`, `start_date`, `end_date`, etc."
 // @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like` and `in`. `in` expects comma-separated values in `filter_value`. Defaults to `equals`"
 // @Param filter_concat query string false "The concatinator to use for filters. Available values are `and` or `or`. Defaults to `or`."
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
+// @Param filter query string false "A filter expression like `done = false && (priority >= 3 || labels in 4, 5)`. Supports parentheses, `&&`, `||`, `!` for negation and the comparators `=`, `!=`, `>`, `>=`, `<`, `<=`, `like` and `in`. Applied in addition to all other filters."
 // @Security JWTKeyAuth
 // @Success 200 {array} models.Task "The tasks"
+// @Failure 400 {object} web.HTTPError "The filter expression is invalid."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/tasks [get]
+// @Router /projects/{projectID}/views/{view}/tasks [get]
 func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
 
+	// Tasks of a view are filtered by the filter of that view. Kanban views return their buckets with the tasks in them.
+	var view *ProjectView
+	if tf.ProjectViewID != 0 {
+		view, err = getProjectViewByIDAndProject(s, tf.ProjectViewID, tf.ProjectID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		if view.ViewKind == ProjectViewKindKanban {
+			bucket := &Bucket{
+				ProjectID:      tf.ProjectID,
+				ProjectViewID:  view.ID,
+				TaskCollection: *tf,
+			}
+			return bucket.ReadAll(s, a, search, page, perPage)
+		}
+	}
+
 	// If the project id is < -1 this means we're dealing with a saved filter - in that case we get and populate the filter
 	// -1 is the favorites project which works as intended
 	if tf.ProjectID < -1 {
@@ -157,7 +195,10 @@ func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 		sf.Filters.OrderBy = append(sf.Filters.OrderBy, tf.OrderBy...)
 		sf.Filters.OrderByArr = tf.OrderByArr
 
-		return sf.getTaskCollection().ReadAll(s, a, search, page, perPage)
+		tc := sf.getTaskCollection()
+		tc.Filter = combineTaskFilterQueries(tc.Filter, tf.Filter)
+
+		return tc.ReadAll(s, a, search, page, perPage)
 	}
 
 	taskopts, err := getTaskFilterOptsFromCollection(tf)
@@ -169,13 +210,47 @@ func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 	taskopts.page = page
 	taskopts.perPage = perPage
 
+	if view != nil {
+		taskopts.projectView = view
+		if view.Filter != nil {
+			taskopts.projectViewFilter, err = getTaskFilterOptsFromCollection(view.Filter)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+		}
+
+		// List views show their tasks in the order of their positions unless something else was requested
+		if len(taskopts.sortby) == 0 && view.ViewKind == ProjectViewKindList {
+			taskopts.sortby = []*sortParam{
+				{
+					sortBy:  taskPropertyPosition,
+					orderBy: orderAscending,
+				},
+			}
+		}
+	}
+
 	shareAuth, is := a.(*LinkSharing)
 	if is {
-		project, err := GetProjectSimpleByID(s, shareAuth.ProjectID)
+		projectID := shareAuth.ProjectID
+		if tf.ProjectID != 0 {
+			projectID = tf.ProjectID
+		}
+		if view != nil && view.ProjectID != projectID {
+			return nil, 0, 0, ErrGenericForbidden{}
+		}
+		project, err := GetProjectSimpleByID(s, projectID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		covered, err := shareAuth.canAccessProject(s, project)
 		if err != nil {
 			return nil, 0, 0, err
 		}
-		return getTasksForProjects(s, []*Project{project}, a, taskopts)
+		if !covered {
+			return nil, 0, 0, ErrGenericForbidden{}
+		}
+		return getTasksForProjectView(s, []*Project{project}, a, taskopts)
 	}
 
 	// If the project ID is not set, we get all tasks for the user.
@@ -205,5 +280,22 @@ func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 		projects = []*Project{{ID: tf.ProjectID}}
 	}
 
-	return getTasksForProjects(s, projects, a, taskopts)
+	return getTasksForProjectView(s, projects, a, taskopts)
+}
+
+// getTasksForProjectView returns the tasks for projects like getTasksForProjects, but with the positions of the view
+// set if the tasks are shown in a view.
+func getTasksForProjectView(s *xorm.Session, projects []*Project, a web.Auth, opts *taskOptions) (tasks []*Task, resultCount int, totalItems int64, err error) {
+	tasks, resultCount, totalItems, err = getTasksForProjects(s, projects, a, opts)
+	if err != nil || opts.projectView == nil {
+		return
+	}
+
+	taskMap := make(map[int64]*Task, len(tasks))
+	for _, t := range tasks {
+		taskMap[t.ID] = t
+	}
+
+	err = setTaskPositionsForView(s, opts.projectView, taskMap)
+	return tasks, resultCount, totalItems, err
 }
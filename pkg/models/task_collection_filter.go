@@ -54,6 +54,8 @@ type taskFilter struct {
 	value      interface{} // Needs to be an interface to be able to hold the field's native value
 	comparator taskFilterComparator
 	isNumeric  bool
+	// Only set for filters on custom fields, after their field was loaded from the db.
+	customField *ProjectCustomField
 }
 
 func parseTimeFromUserInput(timeString string) (value time.Time, err error) {
@@ -235,6 +237,11 @@ func getValueForField(field reflect.StructField, rawValue string) (value interfa
 
 func getNativeValueForTaskField(fieldName string, comparator taskFilterComparator, value string) (reflectField *reflect.StructField, nativeValue interface{}, err error) {
 
+	// Custom field values are converted once the type of the field is known
+	if _, is := parseCustomFieldProperty(fieldName); is {
+		return nil, value, nil
+	}
+
 	realFieldName := strings.ReplaceAll(strcase.ToCamel(fieldName), "Id", "ID")
 
 	if realFieldName == "Assignees" {
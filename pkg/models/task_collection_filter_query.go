@@ -0,0 +1,469 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"xorm.io/builder"
+)
+
+// This file contains the parser for textual filter expressions like
+// `done = false && (priority >= 3 || labels in 4, 5)`.
+// An expression is parsed into a tree of nodes which is then compiled into a db condition,
+// using the same helpers as the filters passed with filter_by, filter_value and filter_comparator.
+//
+// The grammar looks like this:
+//
+//	expression := and ( "||" and )*
+//	and        := unary ( "&&" unary )*
+//	unary      := "!" unary | primary
+//	primary    := "(" expression ")" | comparison
+//	comparison := field comparator value ( "," value )*
+//
+// Values are either bare words or quoted with " or '. Values containing spaces, parentheses, commas or
+// any of the operators need to be quoted, for example `due_date < "2023-01-01 12:00"`.
+
+type taskFilterQueryTokenKind int
+
+const (
+	taskFilterQueryTokenEOF taskFilterQueryTokenKind = iota
+	taskFilterQueryTokenWord
+	taskFilterQueryTokenString
+	taskFilterQueryTokenComparator
+	taskFilterQueryTokenAnd
+	taskFilterQueryTokenOr
+	taskFilterQueryTokenNot
+	taskFilterQueryTokenOpenParen
+	taskFilterQueryTokenCloseParen
+	taskFilterQueryTokenComma
+)
+
+type taskFilterQueryToken struct {
+	kind  taskFilterQueryTokenKind
+	value string
+	// The position of the first character of the token in the expression
+	pos int
+}
+
+func (t *taskFilterQueryToken) String() string {
+	switch t.kind {
+	case taskFilterQueryTokenEOF:
+		return "end of filter"
+	case taskFilterQueryTokenString:
+		return "\"" + t.value + "\""
+	default:
+		return "'" + t.value + "'"
+	}
+}
+
+// taskFilterQueryNode is a node in a parsed filter expression.
+type taskFilterQueryNode interface {
+	toDBCond(includeNulls bool, view *ProjectView) (builder.Cond, error)
+}
+
+// taskFilterQueryGroup holds multiple nodes which are all concatenated with the same concatinator.
+type taskFilterQueryGroup struct {
+	concat   taskFilterConcatinator
+	children []taskFilterQueryNode
+}
+
+// taskFilterQueryNot negates its child node.
+type taskFilterQueryNot struct {
+	child taskFilterQueryNode
+}
+
+// taskFilterQueryComparison is a single comparison of a field with one or more values.
+type taskFilterQueryComparison struct {
+	filter *taskFilter
+}
+
+func (g *taskFilterQueryGroup) toDBCond(includeNulls bool, view *ProjectView) (builder.Cond, error) {
+	conds := make([]builder.Cond, 0, len(g.children))
+	for _, child := range g.children {
+		cond, err := child.toDBCond(includeNulls, view)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+
+	if g.concat == filterConcatOr {
+		return builder.Or(conds...), nil
+	}
+	return builder.And(conds...), nil
+}
+
+func (n *taskFilterQueryNot) toDBCond(includeNulls bool, view *ProjectView) (builder.Cond, error) {
+	cond, err := n.child.toDBCond(includeNulls, view)
+	if err != nil {
+		return nil, err
+	}
+	return builder.Not{cond}, nil
+}
+
+func (c *taskFilterQueryComparison) toDBCond(includeNulls bool, view *ProjectView) (builder.Cond, error) {
+	// convertFiltersToDBFilterCond changes the field name of some filters, hence the copy
+	filter := *c.filter
+	return convertFiltersToDBFilterCond([]*taskFilter{&filter}, includeNulls, filterConcatAnd, view)
+}
+
+// getTaskFilterQueryFilters returns all comparisons of a filter expression.
+func getTaskFilterQueryFilters(node taskFilterQueryNode) []*taskFilter {
+	switch n := node.(type) {
+	case *taskFilterQueryGroup:
+		filters := []*taskFilter{}
+		for _, child := range n.children {
+			filters = append(filters, getTaskFilterQueryFilters(child)...)
+		}
+		return filters
+	case *taskFilterQueryNot:
+		return getTaskFilterQueryFilters(n.child)
+	case *taskFilterQueryComparison:
+		return []*taskFilter{n.filter}
+	}
+	return nil
+}
+
+type taskFilterQueryParser struct {
+	expression string
+	tokens     []*taskFilterQueryToken
+	current    int
+}
+
+// parseTaskFilterQuery parses a filter expression into a tree of nodes which can be converted to a db condition.
+func parseTaskFilterQuery(expression string) (node taskFilterQueryNode, err error) {
+	tokens, err := lexTaskFilterQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &taskFilterQueryParser{
+		expression: expression,
+		tokens:     tokens,
+	}
+
+	if p.peek().kind == taskFilterQueryTokenEOF {
+		return nil, p.errorAt(p.peek(), "the filter is empty")
+	}
+
+	node, err = p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != taskFilterQueryTokenEOF {
+		return nil, p.errorAt(t, fmt.Sprintf("unexpected %s", t))
+	}
+
+	return node, nil
+}
+
+func isTaskFilterQueryDelimiter(runes []rune, i int) bool {
+	r := runes[i]
+	if unicode.IsSpace(r) {
+		return true
+	}
+	switch r {
+	case '(', ')', ',', '=', '!', '<', '>', '"', '\'':
+		return true
+	case '&', '|':
+		return i+1 < len(runes) && runes[i+1] == r
+	}
+	return false
+}
+
+//nolint:gocyclo
+func lexTaskFilterQuery(expression string) (tokens []*taskFilterQueryToken, err error) {
+	runes := []rune(expression)
+	tokens = []*taskFilterQueryToken{}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		if unicode.IsSpace(r) {
+			i++
+			continue
+		}
+
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		token := &taskFilterQueryToken{pos: i}
+		switch {
+		case r == '(':
+			token.kind = taskFilterQueryTokenOpenParen
+			token.value = "("
+		case r == ')':
+			token.kind = taskFilterQueryTokenCloseParen
+			token.value = ")"
+		case r == ',':
+			token.kind = taskFilterQueryTokenComma
+			token.value = ","
+		case r == '&' && next == '&':
+			token.kind = taskFilterQueryTokenAnd
+			token.value = "&&"
+		case r == '|' && next == '|':
+			token.kind = taskFilterQueryTokenOr
+			token.value = "||"
+		case r == '!' && next == '=':
+			token.kind = taskFilterQueryTokenComparator
+			token.value = "!="
+		case r == '!':
+			token.kind = taskFilterQueryTokenNot
+			token.value = "!"
+		case r == '=' && next == '=':
+			token.kind = taskFilterQueryTokenComparator
+			token.value = "=="
+		case (r == '>' || r == '<') && next == '=':
+			token.kind = taskFilterQueryTokenComparator
+			token.value = string(r) + "="
+		case r == '=' || r == '>' || r == '<':
+			token.kind = taskFilterQueryTokenComparator
+			token.value = string(r)
+		case r == '"' || r == '\'':
+			token.kind = taskFilterQueryTokenString
+			value := strings.Builder{}
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				value.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, ErrInvalidTaskFilterExpression{
+					Expression: expression,
+					Position:   i,
+					Message:    "missing closing quote",
+				}
+			}
+			token.value = value.String()
+			tokens = append(tokens, token)
+			i = j + 1
+			continue
+		default:
+			token.kind = taskFilterQueryTokenWord
+			j := i
+			for j < len(runes) && !isTaskFilterQueryDelimiter(runes, j) {
+				j++
+			}
+			token.value = string(runes[i:j])
+			tokens = append(tokens, token)
+			i = j
+			continue
+		}
+
+		tokens = append(tokens, token)
+		i += len([]rune(token.value))
+	}
+
+	tokens = append(tokens, &taskFilterQueryToken{
+		kind: taskFilterQueryTokenEOF,
+		pos:  len(runes),
+	})
+
+	return tokens, nil
+}
+
+func (p *taskFilterQueryParser) peek() *taskFilterQueryToken {
+	return p.tokens[p.current]
+}
+
+func (p *taskFilterQueryParser) next() *taskFilterQueryToken {
+	t := p.tokens[p.current]
+	if t.kind != taskFilterQueryTokenEOF {
+		p.current++
+	}
+	return t
+}
+
+func (p *taskFilterQueryParser) errorAt(t *taskFilterQueryToken, message string) error {
+	return ErrInvalidTaskFilterExpression{
+		Expression: p.expression,
+		Position:   t.pos,
+		Message:    message,
+	}
+}
+
+func (p *taskFilterQueryParser) parseOr() (taskFilterQueryNode, error) {
+	return p.parseGroup(taskFilterQueryTokenOr, filterConcatOr, p.parseAnd)
+}
+
+func (p *taskFilterQueryParser) parseAnd() (taskFilterQueryNode, error) {
+	return p.parseGroup(taskFilterQueryTokenAnd, filterConcatAnd, p.parseUnary)
+}
+
+func (p *taskFilterQueryParser) parseGroup(
+	operator taskFilterQueryTokenKind,
+	concat taskFilterConcatinator,
+	parseChild func() (taskFilterQueryNode, error),
+) (taskFilterQueryNode, error) {
+	first, err := parseChild()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []taskFilterQueryNode{first}
+	for p.peek().kind == operator {
+		p.next()
+		child, err := parseChild()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+
+	return &taskFilterQueryGroup{
+		concat:   concat,
+		children: children,
+	}, nil
+}
+
+func (p *taskFilterQueryParser) parseUnary() (taskFilterQueryNode, error) {
+	if p.peek().kind == taskFilterQueryTokenNot {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &taskFilterQueryNot{child: child}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *taskFilterQueryParser) parsePrimary() (taskFilterQueryNode, error) {
+	if p.peek().kind != taskFilterQueryTokenOpenParen {
+		return p.parseComparison()
+	}
+
+	open := p.next()
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != taskFilterQueryTokenCloseParen {
+		return nil, p.errorAt(p.peek(), fmt.Sprintf("expected ')' to close the '(' at position %d, got %s", open.pos, p.peek()))
+	}
+	p.next()
+
+	return node, nil
+}
+
+func getTaskFilterComparatorFromQueryOperator(operator string) taskFilterComparator {
+	switch strings.ToLower(operator) {
+	case "=", "==":
+		return taskFilterComparatorEquals
+	case "!=":
+		return taskFilterComparatorNotEquals
+	case ">":
+		return taskFilterComparatorGreater
+	case ">=":
+		return taskFilterComparatorGreateEquals
+	case "<":
+		return taskFilterComparatorLess
+	case "<=":
+		return taskFilterComparatorLessEquals
+	case "like":
+		return taskFilterComparatorLike
+	case "in":
+		return taskFilterComparatorIn
+	default:
+		return taskFilterComparatorInvalid
+	}
+}
+
+func validateTaskFilterQueryField(field string) bool {
+	switch field {
+	case "assignees", "labels", "reminders":
+		return true
+	}
+	return validateTaskField(field) == nil
+}
+
+func (p *taskFilterQueryParser) parseComparison() (taskFilterQueryNode, error) {
+	fieldToken := p.next()
+	if fieldToken.kind != taskFilterQueryTokenWord {
+		return nil, p.errorAt(fieldToken, fmt.Sprintf("expected a field name, got %s", fieldToken))
+	}
+	if !validateTaskFilterQueryField(fieldToken.value) {
+		return nil, p.errorAt(fieldToken, fmt.Sprintf("unknown field '%s'", fieldToken.value))
+	}
+
+	comparatorToken := p.next()
+	comparator := taskFilterComparatorInvalid
+	if comparatorToken.kind == taskFilterQueryTokenComparator || comparatorToken.kind == taskFilterQueryTokenWord {
+		comparator = getTaskFilterComparatorFromQueryOperator(comparatorToken.value)
+	}
+	if comparator == taskFilterComparatorInvalid {
+		return nil, p.errorAt(comparatorToken, fmt.Sprintf("expected a comparator after '%s', got %s", fieldToken.value, comparatorToken))
+	}
+
+	valueToken := p.next()
+	if valueToken.kind != taskFilterQueryTokenWord && valueToken.kind != taskFilterQueryTokenString {
+		return nil, p.errorAt(valueToken, fmt.Sprintf("expected a value for '%s', got %s", fieldToken.value, valueToken))
+	}
+	values := []string{valueToken.value}
+
+	for comparator == taskFilterComparatorIn && p.peek().kind == taskFilterQueryTokenComma {
+		p.next()
+		t := p.next()
+		if t.kind != taskFilterQueryTokenWord && t.kind != taskFilterQueryTokenString {
+			return nil, p.errorAt(t, fmt.Sprintf("expected a value for '%s', got %s", fieldToken.value, t))
+		}
+		values = append(values, t.value)
+	}
+
+	filter := &taskFilter{
+		field:      fieldToken.value,
+		comparator: comparator,
+	}
+
+	// Cast the field value to its native type
+	reflectValue, value, err := getNativeValueForTaskField(filter.field, filter.comparator, strings.Join(values, ","))
+	if err != nil {
+		return nil, p.errorAt(valueToken, fmt.Sprintf("invalid value for '%s'", fieldToken.value))
+	}
+	filter.value = value
+	if reflectValue != nil {
+		filter.isNumeric = reflectValue.Type.Kind() == reflect.Int64
+	}
+
+	return &taskFilterQueryComparison{filter: filter}, nil
+}
+
+// combineTaskFilterQueries returns a filter expression which only matches if both expressions match.
+func combineTaskFilterQueries(a, b string) string {
+	if strings.TrimSpace(a) == "" {
+		return b
+	}
+	if strings.TrimSpace(b) == "" {
+		return a
+	}
+	return "(" + a + ") && (" + b + ")"
+}
@@ -0,0 +1,130 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
+)
+
+func TestParseTaskFilterQuery(t *testing.T) {
+	t.Run("single comparison", func(t *testing.T) {
+		node, err := parseTaskFilterQuery("done = false")
+		require.NoError(t, err)
+		comparison, is := node.(*taskFilterQueryComparison)
+		require.True(t, is)
+		assert.Equal(t, "done", comparison.filter.field)
+		assert.Equal(t, taskFilterComparatorEquals, comparison.filter.comparator)
+		assert.Equal(t, false, comparison.filter.value)
+	})
+	t.Run("estimate", func(t *testing.T) {
+		node, err := parseTaskFilterQuery("story_points >= 2.5")
+		require.NoError(t, err)
+		comparison, is := node.(*taskFilterQueryComparison)
+		require.True(t, is)
+		assert.Equal(t, "story_points", comparison.filter.field)
+		assert.Equal(t, taskFilterComparatorGreateEquals, comparison.filter.comparator)
+		assert.Equal(t, 2.5, comparison.filter.value)
+	})
+	t.Run("and binds stronger than or", func(t *testing.T) {
+		node, err := parseTaskFilterQuery("done = false || priority >= 3 && percent_done < 0.5")
+		require.NoError(t, err)
+		or, is := node.(*taskFilterQueryGroup)
+		require.True(t, is)
+		assert.Equal(t, taskFilterConcatinator(filterConcatOr), or.concat)
+		require.Len(t, or.children, 2)
+		and, is := or.children[1].(*taskFilterQueryGroup)
+		require.True(t, is)
+		assert.Equal(t, taskFilterConcatinator(filterConcatAnd), and.concat)
+		assert.Len(t, and.children, 2)
+	})
+	t.Run("parentheses", func(t *testing.T) {
+		node, err := parseTaskFilterQuery("done = false && (priority >= 3 || labels in 4, 5)")
+		require.NoError(t, err)
+		and, is := node.(*taskFilterQueryGroup)
+		require.True(t, is)
+		assert.Equal(t, taskFilterConcatinator(filterConcatAnd), and.concat)
+		require.Len(t, and.children, 2)
+		or, is := and.children[1].(*taskFilterQueryGroup)
+		require.True(t, is)
+		assert.Equal(t, taskFilterConcatinator(filterConcatOr), or.concat)
+		labels, is := or.children[1].(*taskFilterQueryComparison)
+		require.True(t, is)
+		assert.Equal(t, taskFilterComparatorIn, labels.filter.comparator)
+		assert.Equal(t, []interface{}{int64(4), int64(5)}, labels.filter.value)
+	})
+	t.Run("negation", func(t *testing.T) {
+		node, err := parseTaskFilterQuery("!(done = true)")
+		require.NoError(t, err)
+		not, is := node.(*taskFilterQueryNot)
+		require.True(t, is)
+		_, is = not.child.(*taskFilterQueryComparison)
+		assert.True(t, is)
+	})
+	t.Run("quoted value", func(t *testing.T) {
+		node, err := parseTaskFilterQuery(`title like "task #1 (with && stuff)"`)
+		require.NoError(t, err)
+		comparison, is := node.(*taskFilterQueryComparison)
+		require.True(t, is)
+		assert.Equal(t, taskFilterComparatorLike, comparison.filter.comparator)
+		assert.Equal(t, "task #1 (with && stuff)", comparison.filter.value)
+	})
+	t.Run("relative date", func(t *testing.T) {
+		_, err := parseTaskFilterQuery("due_date < now+7d/d")
+		assert.NoError(t, err)
+	})
+	t.Run("compiles to a db condition", func(t *testing.T) {
+		node, err := parseTaskFilterQuery("done = false && !(priority >= 3 || labels = 4)")
+		require.NoError(t, err)
+		cond, err := node.toDBCond(false, nil)
+		require.NoError(t, err)
+		sql, args, err := builder.ToSQL(cond)
+		require.NoError(t, err)
+		assert.Equal(t, "`done`=? AND NOT ((`priority`>=?) OR (id IN (SELECT task_id FROM label_tasks WHERE `label_id`=?)))", sql)
+		assert.Equal(t, []interface{}{false, int64(3), int64(4)}, args)
+	})
+}
+
+func TestParseTaskFilterQuery_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		position   int
+	}{
+		{name: "empty", expression: "  ", position: 2},
+		{name: "unknown field", expression: "done = false && foo = 1", position: 16},
+		{name: "missing comparator", expression: "done false", position: 5},
+		{name: "missing value", expression: "priority >=", position: 11},
+		{name: "invalid value", expression: "priority = high", position: 11},
+		{name: "unclosed parenthesis", expression: "(done = true || priority = 1", position: 28},
+		{name: "unexpected closing parenthesis", expression: "done = true)", position: 11},
+		{name: "missing closing quote", expression: `title = "foo`, position: 8},
+		{name: "dangling operator", expression: "done = true &&", position: 14},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTaskFilterQuery(tt.expression)
+			require.Error(t, err)
+			require.True(t, IsErrInvalidTaskFilterExpression(err))
+			assert.Equal(t, tt.position, err.(ErrInvalidTaskFilterExpression).Position)
+		})
+	}
+}
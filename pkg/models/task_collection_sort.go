@@ -20,6 +20,8 @@ type (
 	sortParam struct {
 		sortBy  string
 		orderBy sortOrder // asc or desc
+		// Only set when sorting by a custom field, after the field was loaded from the db.
+		customField *ProjectCustomField
 	}
 
 	sortOrder string
@@ -40,6 +42,8 @@ const (
 	taskPropertyEndDate        string = "end_date"
 	taskPropertyHexColor       string = "hex_color"
 	taskPropertyPercentDone    string = "percent_done"
+	taskPropertyStoryPoints    string = "story_points"
+	taskPropertyTimeEstimate   string = "time_estimate"
 	taskPropertyUID            string = "uid"
 	taskPropertyCreated        string = "created"
 	taskPropertyUpdated        string = "updated"
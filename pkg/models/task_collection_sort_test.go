@@ -57,6 +57,8 @@ func TestSortParamValidation(t *testing.T) {
 			taskPropertyEndDate,
 			taskPropertyHexColor,
 			taskPropertyPercentDone,
+			taskPropertyStoryPoints,
+			taskPropertyTimeEstimate,
 			taskPropertyUID,
 			taskPropertyCreated,
 			taskPropertyUpdated,
@@ -26,6 +26,7 @@ import (
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/d4l3k/messagediff.v1"
 )
 
@@ -95,6 +96,15 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		BucketID:    1,
 		IsFavorite:  true,
 		Position:    2,
+		TimeSpent:   8700,
+		CustomFields: map[int64]interface{}{
+			1: "ACME",
+			2: float64(5),
+			3: "prod",
+		},
+		Reactions: ReactionMap{
+			"👋": {user1, user2},
+		},
 		Labels: []*Label{
 			label4,
 		},
@@ -166,6 +176,11 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		ProjectID:   1,
 		BucketID:    1,
 		Position:    4,
+		TimeSpent:   3600,
+		CustomFields: map[int64]interface{}{
+			2: float64(3),
+			3: "dev",
+		},
 		Labels: []*Label{
 			label4,
 		},
@@ -185,13 +200,16 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Updated: time.Unix(1543626724, 0).In(loc),
 	}
 	task3 := &Task{
-		ID:           3,
-		Title:        "task #3 high prio",
-		Identifier:   "test1-3",
-		Index:        3,
-		CreatedByID:  1,
-		CreatedBy:    user1,
-		ProjectID:    1,
+		ID:          3,
+		Title:       "task #3 high prio",
+		Identifier:  "test1-3",
+		Index:       3,
+		CreatedByID: 1,
+		CreatedBy:   user1,
+		ProjectID:   1,
+		CustomFields: map[int64]interface{}{
+			2: float64(8),
+		},
 		RelatedTasks: map[RelationKind][]*Task{},
 		Created:      time.Unix(1543626724, 0).In(loc),
 		Updated:      time.Unix(1543626724, 0).In(loc),
@@ -625,6 +643,7 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		FilterValue        []string
 		FilterComparator   []string
 		FilterIncludeNulls bool
+		Filter             string
 
 		CRUDable web.CRUDable
 		Rights   web.Rights
@@ -1082,6 +1101,119 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "filter expression",
+			fields: fields{
+				Filter: "labels = 4 && done = false",
+			},
+			args: defaultArgs,
+			want: []*Task{
+				task1,
+			},
+			wantErr: false,
+		},
+		{
+			name: "filter expression with grouping",
+			fields: fields{
+				Filter: "(index = 5 || labels in 4, 5) && !(done = true)",
+			},
+			args: defaultArgs,
+			want: []*Task{
+				task1,
+				task5,
+			},
+			wantErr: false,
+		},
+		{
+			name: "filter expression combined with other filters",
+			fields: fields{
+				FilterBy:         []string{"project_id"},
+				FilterValue:      []string{"1"},
+				FilterComparator: []string{"equals"},
+				Filter:           "index = 5 || index = 15",
+			},
+			args: defaultArgs,
+			want: []*Task{
+				task5,
+				task30,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid filter expression",
+			fields: fields{
+				Filter: "done = false && (",
+			},
+			args:    defaultArgs,
+			wantErr: true,
+		},
+		{
+			name: "filter by custom field",
+			fields: fields{
+				FilterBy:         []string{"custom_field_2"},
+				FilterValue:      []string{"4"},
+				FilterComparator: []string{"greater"},
+			},
+			args: defaultArgs,
+			want: []*Task{
+				task1,
+				task3,
+			},
+			wantErr: false,
+		},
+		{
+			name: "filter expression with custom fields",
+			fields: fields{
+				Filter: "custom_field_3 in dev, staging || custom_field_1 like ACM",
+			},
+			args: defaultArgs,
+			want: []*Task{
+				task1,
+				task2,
+			},
+			wantErr: false,
+		},
+		{
+			name: "sort by custom field",
+			fields: fields{
+				Filter:  "custom_field_2 > 0",
+				SortBy:  []string{"custom_field_2"},
+				OrderBy: []string{"desc"},
+			},
+			args: defaultArgs,
+			want: []*Task{
+				task3,
+				task1,
+				task2,
+			},
+			wantErr: false,
+		},
+		{
+			name: "filter by nonexisting custom field",
+			fields: fields{
+				Filter: "custom_field_9999 = 1",
+			},
+			args:    defaultArgs,
+			wantErr: true,
+		},
+		{
+			name: "filter by custom field of another project",
+			fields: fields{
+				ProjectID: 1,
+				Filter:    "custom_field_6 = Backend",
+			},
+			args:    defaultArgs,
+			wantErr: true,
+		},
+		{
+			name: "sort by custom field of another project",
+			fields: fields{
+				ProjectID: 1,
+				SortBy:    []string{"custom_field_6"},
+			},
+			args:    defaultArgs,
+			wantErr: true,
+		},
 		// TODO filter parent project?
 		{
 			name: "filter by index",
@@ -1109,6 +1241,54 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "search in description",
+			fields: fields{},
+			args: args{
+				search: "lorem",
+				a:      &user.User{ID: 1},
+			},
+			want: []*Task{
+				task1,
+			},
+		},
+		{
+			name:   "search in comments",
+			fields: fields{},
+			args: args{
+				search: "Dolor Sit",
+				a:      &user.User{ID: 1},
+			},
+			want: []*Task{
+				task1,
+			},
+		},
+		{
+			name: "search in favorited tasks",
+			fields: fields{
+				ProjectID: FavoritesPseudoProject.ID,
+			},
+			args: args{
+				search: "task",
+				a:      &user.User{ID: 1},
+			},
+			want: []*Task{
+				task1,
+				task15,
+			},
+		},
+		{
+			name:   "search in labels",
+			fields: fields{},
+			args: args{
+				search: "visible via other task",
+				a:      &user.User{ID: 1},
+			},
+			want: []*Task{
+				task1,
+				task2,
+			},
+		},
 		{
 			name: "order by position",
 			fields: fields{
@@ -1234,6 +1414,7 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 				FilterValue:        tt.fields.FilterValue,
 				FilterComparator:   tt.fields.FilterComparator,
 				FilterIncludeNulls: tt.fields.FilterIncludeNulls,
+				Filter:             tt.fields.Filter,
 
 				CRUDable: tt.fields.CRUDable,
 				Rights:   tt.fields.Rights,
@@ -1277,3 +1458,36 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		})
 	}
 }
+
+func TestTaskCollection_ReadAll_SearchPagination(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	tc := &TaskCollection{ProjectID: 1}
+
+	all, _, total, err := tc.ReadAll(s, u, "task", 0, 0)
+	assert.NoError(t, err)
+	allTasks := all.([]*Task)
+	assert.Greater(t, len(allTasks), 5)
+	assert.Equal(t, int64(len(allTasks)), total)
+
+	// Every page must contain the next matches in the order of the full result and the total must include all matches
+	perPage := 2
+	for page := 1; (page-1)*perPage < len(allTasks); page++ {
+		got, count, total, err := tc.ReadAll(s, u, "task", page, perPage)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(allTasks)), total)
+
+		end := page * perPage
+		if end > len(allTasks) {
+			end = len(allTasks)
+		}
+		want := allTasks[(page-1)*perPage : end]
+		assert.Equal(t, len(want), count)
+		for i, task := range got.([]*Task) {
+			assert.Equal(t, want[i].ID, task.ID)
+		}
+	}
+}
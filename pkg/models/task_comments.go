@@ -37,8 +37,19 @@ type TaskComment struct {
 	Author   *user.User `xorm:"-" json:"author"`
 	TaskID   int64      `xorm:"not null" json:"-" param:"task"`
 
+	// The id of the comment this comment is a reply to. 0 if this comment is not a reply.
+	// This can only be set when creating a comment.
+	ParentID int64 `xorm:"bigint INDEX null" json:"parent_id"`
+	// The number of direct replies to this comment.
+	ReplyCount int64 `xorm:"-" json:"reply_count"`
+
+	// All reactions on this comment, grouped by their value.
+	Reactions ReactionMap `xorm:"-" json:"reactions"`
+
 	Created time.Time `xorm:"created" json:"created"`
 	Updated time.Time `xorm:"updated" json:"updated"`
+	// A timestamp when this comment was moved to the trash. Deleted comments are only visible in the trash.
+	Deleted time.Time `xorm:"deleted INDEX null" json:"-"`
 
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
@@ -51,7 +62,7 @@ func (tc *TaskComment) TableName() string {
 
 // Create creates a new task comment
 // @Summary Create a new task comment
-// @Description Create a new task comment. The user doing this need to have at least write access to the task this comment should belong to.
+// @Description Create a new task comment. The user doing this need to have at least write access to the task this comment should belong to. Set parent_id to reply to another comment of the same task.
 // @tags task
 // @Accept json
 // @Produce json
@@ -75,6 +86,14 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 	}
 	tc.AuthorID = tc.Author.ID
 
+	if tc.ParentID != 0 {
+		// The parent must be a comment of the same task
+		err = getTaskCommentSimple(s, &TaskComment{ID: tc.ParentID, TaskID: tc.TaskID})
+		if err != nil {
+			return err
+		}
+	}
+
 	_, err = s.Insert(tc)
 	if err != nil {
 		return
@@ -89,7 +108,7 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 
 // Delete removes a task comment
 // @Summary Remove a task comment
-// @Description Remove a task comment. The user doing this need to have at least write access to the task this comment belongs to.
+// @Description Remove a task comment. The user doing this need to have at least write access to the task this comment belongs to. Replies stay attached to the comment while it is in the trash and are moved up to the parent of the comment once it is purged.
 // @tags task
 // @Accept json
 // @Produce json
@@ -102,6 +121,15 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments/{commentID} [delete]
 func (tc *TaskComment) Delete(s *xorm.Session, _ web.Auth) error {
+	existing := &TaskComment{}
+	exists, err := s.ID(tc.ID).NoAutoCondition().Get(existing)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTaskCommentDoesNotExist{ID: tc.ID}
+	}
+
 	deleted, err := s.
 		ID(tc.ID).
 		NoAutoCondition().
@@ -114,13 +142,61 @@ func (tc *TaskComment) Delete(s *xorm.Session, _ web.Auth) error {
 		return err
 	}
 
+	task, err := GetTaskByIDSimple(s, existing.TaskID)
+	if err != nil {
+		return err
+	}
+
 	return events.Dispatch(&TaskCommentDeletedEvent{
-		Task:    &Task{ID: tc.TaskID},
+		Task:    &task,
 		Comment: tc,
 		Doer:    tc.Author,
 	})
 }
 
+// reparentRepliesOfPurgedComments moves the replies of comments which are about to be purged up to the closest
+// parent comment which is kept, so that the replies are not lost when their parent is removed.
+func reparentRepliesOfPurgedComments(s *xorm.Session, purgeCond builder.Cond) (err error) {
+	purged := []*TaskComment{}
+	err = s.Unscoped().Where(purgeCond).Find(&purged)
+	if err != nil || len(purged) == 0 {
+		return err
+	}
+
+	parents := make(map[int64]int64, len(purged))
+	purgedIDs := make([]int64, 0, len(purged))
+	for _, c := range purged {
+		parents[c.ID] = c.ParentID
+		purgedIDs = append(purgedIDs, c.ID)
+	}
+
+	for _, c := range purged {
+		newParentID := c.ParentID
+		for {
+			parentID, isPurged := parents[newParentID]
+			if !isPurged {
+				break
+			}
+			newParentID = parentID
+		}
+
+		_, err = s.
+			Unscoped().
+			Where(builder.And(
+				builder.Eq{"parent_id": c.ID},
+				builder.NotIn("id", purgedIDs),
+			)).
+			Cols("parent_id").
+			NoAutoTime().
+			Update(&TaskComment{ParentID: newParentID})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Update updates a task text by its ID
 // @Summary Update an existing task comment
 // @Description Update an existing task comment. The user doing this need to have at least write access to the task this comment belongs to.
@@ -203,13 +279,22 @@ func (tc *TaskComment) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
 	_, err = s.
 		Where("id = ?", tc.AuthorID).
 		Get(author)
+	if err != nil {
+		return err
+	}
 	tc.Author = author
-	return
+
+	err = addReplyCountsToComments(s, []*TaskComment{tc})
+	if err != nil {
+		return err
+	}
+
+	return addReactionsToComments(s, []*TaskComment{tc})
 }
 
 // ReadAll returns all comments for a task
 // @Summary Get all task comments
-// @Description Get all task comments. The user doing this need to have at least read access to the task.
+// @Description Get all task comments. The user doing this need to have at least read access to the task. Comments are returned as a flat list, use parent_id to build the threads.
 // @tags task
 // @Accept json
 // @Produce json
@@ -266,8 +351,54 @@ func (tc *TaskComment) ReadAll(s *xorm.Session, auth web.Auth, search string, pa
 		comment.Author = authors[comment.AuthorID]
 	}
 
+	err = addReplyCountsToComments(s, comments)
+	if err != nil {
+		return
+	}
+
+	err = addReactionsToComments(s, comments)
+	if err != nil {
+		return
+	}
+
 	numberOfTotalItems, err = s.
 		Where("task_id = ? AND comment like ?", tc.TaskID, "%"+search+"%").
 		Count(&TaskCommentWithAuthor{})
 	return comments, len(comments), numberOfTotalItems, err
 }
+
+func addReplyCountsToComments(s *xorm.Session, comments []*TaskComment) (err error) {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	commentIDs := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		commentIDs = append(commentIDs, comment.ID)
+	}
+
+	type replyCount struct {
+		ParentID int64
+		Count    int64
+	}
+	counts := []*replyCount{}
+	err = s.
+		Select("parent_id, count(*) AS count").
+		Table("task_comments").
+		In("parent_id", commentIDs).
+		And("deleted IS NULL").
+		GroupBy("parent_id").
+		Find(&counts)
+	if err != nil {
+		return err
+	}
+
+	countMap := make(map[int64]int64, len(counts))
+	for _, c := range counts {
+		countMap[c.ParentID] = c.Count
+	}
+	for _, comment := range comments {
+		comment.ReplyCount = countMap[comment.ID]
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/user"
 	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
 )
 
 func TestTaskComment_Create(t *testing.T) {
@@ -91,6 +92,77 @@ func TestTaskComment_Create(t *testing.T) {
 			"name":          (&TaskCommentNotification{}).Name(),
 		}, false)
 	})
+	t.Run("reply", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{
+			Comment:  "reply",
+			TaskID:   35,
+			ParentID: 15,
+		}
+		err := tc.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"id":        tc.ID,
+			"parent_id": 15,
+			"task_id":   35,
+		}, false)
+	})
+	t.Run("reply to a comment of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{
+			Comment:  "reply",
+			TaskID:   1,
+			ParentID: 15,
+		}
+		err := tc.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCommentDoesNotExist(err))
+	})
+	t.Run("should notify the author of the parent comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task, err := GetTaskByIDSimple(s, 32)
+		assert.NoError(t, err)
+		parent := &TaskComment{
+			Comment: "parent",
+			TaskID:  32,
+		}
+		err = parent.Create(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		tc := &TaskComment{
+			Comment:  "reply",
+			TaskID:   32,
+			ParentID: parent.ID,
+		}
+		err = tc.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		ev := &TaskCommentCreatedEvent{
+			Task:    &task,
+			Doer:    u,
+			Comment: tc,
+		}
+
+		events.TestListener(t, ev, &SendTaskCommentNotification{})
+		db.AssertExists(t, "notifications", map[string]interface{}{
+			"subject_id":    tc.ID,
+			"notifiable_id": 2,
+			"name":          (&TaskCommentReplyNotification{}).Name(),
+		}, false)
+	})
 }
 
 func TestTaskComment_Delete(t *testing.T) {
@@ -107,9 +179,25 @@ func TestTaskComment_Delete(t *testing.T) {
 		err = s.Commit()
 		assert.NoError(t, err)
 
-		db.AssertMissing(t, "task_comments", map[string]interface{}{
-			"id": 1,
-		})
+		db.AssertCount(t, "task_comments", builder.And(builder.Eq{"id": 1}, builder.NotNull{"deleted"}), 1)
+	})
+	t.Run("with replies", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{ID: 15}
+		err := tc.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertCount(t, "task_comments", builder.And(builder.Eq{"id": 15}, builder.NotNull{"deleted"}), 1)
+		// The replies stay attached while the comment is in the trash
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"id":        17,
+			"parent_id": 15,
+		}, false)
 	})
 	t.Run("nonexisting comment", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
@@ -253,6 +341,26 @@ func TestTaskComment_ReadAll(t *testing.T) {
 		}
 		assert.True(t, foundComment)
 	})
+	t.Run("replies", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{TaskID: 35}
+		u := &user.User{ID: 1}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, -1)
+		assert.NoError(t, err)
+		for _, comment := range result.([]*TaskComment) {
+			switch comment.ID {
+			case 15:
+				assert.Equal(t, int64(0), comment.ParentID)
+				assert.Equal(t, int64(1), comment.ReplyCount)
+			case 17:
+				assert.Equal(t, int64(15), comment.ParentID)
+				assert.Equal(t, int64(0), comment.ReplyCount)
+			}
+		}
+	})
 	t.Run("normal", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -0,0 +1,345 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// dependencyRelationKinds are the relation kinds which describe an order between two tasks.
+// Because every relation is stored in both directions, these two are enough to get every edge
+// from the task which needs to be done first to the task which depends on it.
+var dependencyRelationKinds = []RelationKind{RelationKindBlocking, RelationKindPreceeds}
+
+// dependencyEdge returns the direction of a dependency relation as the task which needs to be done first
+// and the task depending on it. ok is false for relation kinds which don't describe a dependency.
+func (rel *TaskRelation) dependencyEdge() (from, to int64, ok bool) {
+	switch rel.RelationKind {
+	case RelationKindBlocking, RelationKindPreceeds:
+		return rel.TaskID, rel.OtherTaskID, true
+	case RelationKindBlocked, RelationKindFollows:
+		return rel.OtherTaskID, rel.TaskID, true
+	}
+	return 0, 0, false
+}
+
+// checkRelationDoesNotCreateCycle makes sure a new blocking or precedes relation does not result in
+// a task (indirectly) depending on itself.
+func checkRelationDoesNotCreateCycle(s *xorm.Session, rel *TaskRelation) error {
+	from, to, ok := rel.dependencyEdge()
+	if !ok {
+		return nil
+	}
+
+	// The new edge creates a cycle if the task which should be done first already depends on the other one.
+	visited := map[int64]bool{to: true}
+	frontier := []int64{to}
+	for len(frontier) > 0 {
+		next := []int64{}
+		err := s.
+			Table("task_relations").
+			Cols("other_task_id").
+			In("task_id", frontier).
+			In("relation_kind", dependencyRelationKinds).
+			Find(&next)
+		if err != nil {
+			return err
+		}
+
+		frontier = frontier[:0]
+		for _, id := range next {
+			if id == from {
+				return ErrRelationCreatesCycle{
+					TaskID:      rel.TaskID,
+					OtherTaskID: rel.OtherTaskID,
+					Kind:        rel.RelationKind,
+				}
+			}
+			if !visited[id] {
+				visited[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkTaskIsNotBlocked returns an error if the project of the task enforces blocking relations
+// and the task is still blocked by tasks which are not done.
+func checkTaskIsNotBlocked(s *xorm.Session, task *Task) error {
+	project, err := GetProjectSimpleByID(s, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	if !project.EnforceBlockingRelations {
+		return nil
+	}
+
+	blockerIDs := []int64{}
+	err = s.
+		Table("task_relations").
+		Join("INNER", "tasks", "tasks.id = task_relations.other_task_id").
+		Where(builder.And(
+			builder.Eq{"task_relations.task_id": task.ID},
+			builder.Eq{"task_relations.relation_kind": RelationKindBlocked},
+			builder.Eq{"tasks.done": false},
+			builder.IsNull{"tasks.deleted"},
+		)).
+		Cols("task_relations.other_task_id").
+		OrderBy("task_relations.other_task_id asc").
+		Find(&blockerIDs)
+	if err != nil {
+		return err
+	}
+
+	if len(blockerIDs) > 0 {
+		return ErrTaskIsBlocked{TaskID: task.ID, BlockerIDs: blockerIDs}
+	}
+
+	return nil
+}
+
+// DependencyGraphNode is a task in a dependency graph
+type DependencyGraphNode struct {
+	// The id of the task
+	ID int64 `json:"id"`
+	// The title of the task
+	Title string `json:"title"`
+	// The index of the task in its project
+	Index int64 `json:"index"`
+	// Whether the task is done
+	Done bool `json:"done"`
+	// The due date of the task
+	DueDate time.Time `json:"due_date"`
+	// True if the task is on the critical path
+	Critical bool `json:"critical"`
+}
+
+// DependencyGraphEdge is a dependency between two tasks. The task with the id from needs to be done before the task with the id to.
+type DependencyGraphEdge struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	// Either blocking or precedes
+	Kind RelationKind `json:"kind"`
+	// True if the edge is part of the critical path
+	Critical bool `json:"critical"`
+}
+
+// ProjectDependencyGraph holds all blocking and precedes relations between the tasks of a project
+type ProjectDependencyGraph struct {
+	ProjectID int64 `json:"-" param:"project"`
+
+	// All tasks of the project which have a blocking or precedes relation to another task of the project
+	Nodes []*DependencyGraphNode `json:"nodes"`
+	// All dependencies between those tasks
+	Edges []*DependencyGraphEdge `json:"edges"`
+	// The ids of the tasks on the longest chain of undone dependent tasks, in the order they need to be done.
+	CriticalPath []int64 `json:"critical_path"`
+	// The same graph in the graphviz DOT format, with the critical path highlighted.
+	DOT string `json:"dot"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// CanRead checks if a user can see the dependency graph of a project
+func (g *ProjectDependencyGraph) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	return (&Project{ID: g.ProjectID}).CanRead(s, a)
+}
+
+// ReadOne returns the dependency graph of a project
+// @Summary Get the dependency graph of a project
+// @Description Returns all blocking and precedes relations between the tasks of a project as a graph, in json and the graphviz DOT format. The critical path is the longest chain of undone tasks depending on each other.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param project path int true "Project ID"
+// @Success 200 {object} models.ProjectDependencyGraph "The dependency graph."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{project}/dependency-graph [get]
+func (g *ProjectDependencyGraph) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	project, err := GetProjectSimpleByID(s, g.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	relations := []*TaskRelation{}
+	err = s.
+		Table("task_relations").
+		Join("INNER", []string{"tasks", "t1"}, "t1.id = task_relations.task_id").
+		Join("INNER", []string{"tasks", "t2"}, "t2.id = task_relations.other_task_id").
+		Where(builder.And(
+			builder.Eq{"t1.project_id": g.ProjectID},
+			builder.Eq{"t2.project_id": g.ProjectID},
+			builder.IsNull{"t1.deleted"},
+			builder.IsNull{"t2.deleted"},
+			builder.In("task_relations.relation_kind", dependencyRelationKinds),
+		)).
+		OrderBy("task_relations.id asc").
+		Find(&relations)
+	if err != nil {
+		return err
+	}
+
+	taskIDs := []int64{}
+	seen := map[int64]bool{}
+	g.Edges = make([]*DependencyGraphEdge, 0, len(relations))
+	for _, rel := range relations {
+		from, to, _ := rel.dependencyEdge()
+		g.Edges = append(g.Edges, &DependencyGraphEdge{From: from, To: to, Kind: rel.RelationKind})
+		for _, id := range []int64{from, to} {
+			if !seen[id] {
+				seen[id] = true
+				taskIDs = append(taskIDs, id)
+			}
+		}
+	}
+
+	g.Nodes = []*DependencyGraphNode{}
+	if len(taskIDs) > 0 {
+		err = s.
+			Table("tasks").
+			Cols("id", "title", "index", "done", "due_date").
+			In("id", taskIDs).
+			OrderBy("id asc").
+			Find(&g.Nodes)
+		if err != nil {
+			return err
+		}
+	}
+
+	g.CriticalPath = g.criticalPath()
+	g.DOT = g.toDOT(project.Title)
+	return nil
+}
+
+// criticalPath returns the longest chain of undone tasks and marks its nodes and edges as critical.
+// Tasks which are part of a cycle are ignored.
+func (g *ProjectDependencyGraph) criticalPath() []int64 {
+	nodes := make(map[int64]*DependencyGraphNode, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+
+	outgoing := make(map[int64][]*DependencyGraphEdge)
+	inDegree := make(map[int64]int)
+	for _, e := range g.Edges {
+		outgoing[e.From] = append(outgoing[e.From], e)
+		inDegree[e.To]++
+	}
+
+	weight := func(id int64) int {
+		if n, has := nodes[id]; has && !n.Done {
+			return 1
+		}
+		return 0
+	}
+
+	// Kahn's algorithm, computing the longest path ending at each node along the way
+	queue := []int64{}
+	for _, n := range g.Nodes {
+		if inDegree[n.ID] == 0 {
+			queue = append(queue, n.ID)
+		}
+	}
+
+	length := make(map[int64]int)
+	previous := make(map[int64]*DependencyGraphEdge)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		length[id] += weight(id)
+
+		for _, e := range outgoing[id] {
+			if _, has := previous[e.To]; !has || length[id] > length[e.To] {
+				length[e.To] = length[id]
+				previous[e.To] = e
+			}
+			inDegree[e.To]--
+			if inDegree[e.To] == 0 {
+				queue = append(queue, e.To)
+			}
+		}
+	}
+
+	var end int64
+	longest := 0
+	for _, n := range g.Nodes {
+		// Only undone tasks which are not part of a cycle can end the path
+		if inDegree[n.ID] == 0 && weight(n.ID) > 0 && length[n.ID] > longest {
+			longest = length[n.ID]
+			end = n.ID
+		}
+	}
+	if longest == 0 {
+		return []int64{}
+	}
+
+	path := []int64{end}
+	nodes[end].Critical = true
+	for e, has := previous[end]; has; e, has = previous[e.From] {
+		// Leading done tasks don't add anything to the path
+		if length[e.From] == 0 {
+			break
+		}
+		e.Critical = true
+		nodes[e.From].Critical = true
+		path = append(path, e.From)
+	}
+
+	// The path was built backwards
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func (g *ProjectDependencyGraph) toDOT(title string) string {
+	var b strings.Builder
+	b.WriteString("digraph " + strconv.Quote(title) + " {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + strconv.Quote(n.Title)}
+		if n.Done {
+			attrs = append(attrs, "style=dashed")
+		}
+		if n.Critical {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		b.WriteString("  " + strconv.FormatInt(n.ID, 10) + " [" + strings.Join(attrs, ", ") + "];\n")
+	}
+	for _, e := range g.Edges {
+		attrs := []string{"label=" + strconv.Quote(string(e.Kind))}
+		if e.Critical {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		b.WriteString("  " + strconv.FormatInt(e.From, 10) + " -> " + strconv.FormatInt(e.To, 10) + " [" + strings.Join(attrs, ", ") + "];\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,216 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func createTestRelations(t *testing.T, s *xorm.Session, relations ...*TaskRelation) {
+	for _, rel := range relations {
+		err := rel.Create(s, &user.User{ID: 1})
+		require.NoError(t, err)
+	}
+}
+
+func TestTaskRelation_CreateCycle(t *testing.T) {
+	t.Run("direct", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		createTestRelations(t, s, &TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocking})
+
+		rel := &TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocked}
+		err := rel.Create(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrRelationCreatesCycle(err))
+	})
+	t.Run("indirect across kinds", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		createTestRelations(t, s,
+			&TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocking},
+			&TaskRelation{TaskID: 4, OtherTaskID: 3, RelationKind: RelationKindFollows},
+		)
+
+		rel := &TaskRelation{TaskID: 4, OtherTaskID: 1, RelationKind: RelationKindPreceeds}
+		err := rel.Create(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrRelationCreatesCycle(err))
+	})
+	t.Run("no cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		createTestRelations(t, s,
+			&TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocking},
+			&TaskRelation{TaskID: 3, OtherTaskID: 4, RelationKind: RelationKindBlocking},
+			// Two paths to the same task are fine
+			&TaskRelation{TaskID: 1, OtherTaskID: 4, RelationKind: RelationKindPreceeds},
+			// Other kinds are not checked
+			&TaskRelation{TaskID: 4, OtherTaskID: 1, RelationKind: RelationKindRelated},
+		)
+	})
+}
+
+func TestTask_UpdateBlocked(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	enforce := func(t *testing.T, s *xorm.Session) {
+		_, err := s.ID(1).Cols("enforce_blocking_relations").Update(&Project{EnforceBlockingRelations: true})
+		require.NoError(t, err)
+	}
+
+	t.Run("blocked", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		enforce(t, s)
+		createTestRelations(t, s,
+			&TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocked},
+			// Done blockers don't count
+			&TaskRelation{TaskID: 1, OtherTaskID: 2, RelationKind: RelationKindBlocked},
+		)
+
+		task := &Task{ID: 1, Title: "task #1", Done: true}
+		err := task.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskIsBlocked(err))
+		assert.Equal(t, []int64{3}, err.(ErrTaskIsBlocked).BlockerIDs)
+	})
+	t.Run("blocker done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		enforce(t, s)
+		createTestRelations(t, s, &TaskRelation{TaskID: 1, OtherTaskID: 2, RelationKind: RelationKindBlocked})
+
+		task := &Task{ID: 1, Title: "task #1", Done: true}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+	})
+	t.Run("not enforced", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		createTestRelations(t, s, &TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocked})
+
+		task := &Task{ID: 1, Title: "task #1", Done: true}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+	})
+	t.Run("bulk update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		enforce(t, s)
+		createTestRelations(t, s, &TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocked})
+
+		bt := &BulkTask{IDs: []int64{1}, Task: Task{Done: true}}
+		can, err := bt.CanUpdate(s, u)
+		require.NoError(t, err)
+		require.True(t, can)
+		err = bt.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskIsBlocked(err))
+	})
+}
+
+func TestProjectDependencyGraph_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		createTestRelations(t, s,
+			&TaskRelation{TaskID: 2, OtherTaskID: 1, RelationKind: RelationKindBlocking},
+			&TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocking},
+			&TaskRelation{TaskID: 3, OtherTaskID: 4, RelationKind: RelationKindPreceeds},
+			&TaskRelation{TaskID: 4, OtherTaskID: 5, RelationKind: RelationKindBlocked},
+			// Not a dependency
+			&TaskRelation{TaskID: 6, OtherTaskID: 1, RelationKind: RelationKindRelated},
+		)
+
+		g := &ProjectDependencyGraph{ProjectID: 1}
+		can, _, err := g.CanRead(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+
+		err = g.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Len(t, g.Nodes, 5)
+		assert.Len(t, g.Edges, 4)
+		// Task 2 is done and does not count
+		assert.Equal(t, []int64{1, 3, 4}, g.CriticalPath)
+
+		for _, n := range g.Nodes {
+			assert.Equal(t, n.ID == 1 || n.ID == 3 || n.ID == 4, n.Critical, "task %d", n.ID)
+		}
+		for _, e := range g.Edges {
+			if e.From == 5 {
+				assert.Equal(t, int64(4), e.To)
+				assert.Equal(t, RelationKindBlocking, e.Kind)
+				assert.False(t, e.Critical)
+			}
+		}
+
+		assert.Contains(t, g.DOT, `digraph "Test1" {`)
+		assert.Contains(t, g.DOT, `2 [label="task #2 done", style=dashed];`)
+		assert.Contains(t, g.DOT, `1 -> 3 [label="blocking", color=red, penwidth=2];`)
+		assert.Contains(t, g.DOT, `3 -> 4 [label="precedes", color=red, penwidth=2];`)
+		assert.Contains(t, g.DOT, `5 -> 4 [label="blocking"];`)
+	})
+	t.Run("empty", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		g := &ProjectDependencyGraph{ProjectID: 1}
+		err := g.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Empty(t, g.Nodes)
+		assert.Empty(t, g.Edges)
+		assert.Empty(t, g.CriticalPath)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		g := &ProjectDependencyGraph{ProjectID: 20}
+		can, _, err := g.CanRead(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
@@ -0,0 +1,166 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"math"
+
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// TaskPosition holds the position of a task in a view which does not use the position stored with the task.
+type TaskPosition struct {
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	// The ID of the task this position is for
+	TaskID int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+	// The project view this task is related to
+	ProjectViewID int64 `xorm:"bigint not null index" json:"project_view_id"`
+	// The position of the task - any task project can be sorted as usual by this parameter.
+	// When accessing tasks via views with buckets, this is primarily used to sort them based on a range
+	// We're using a float64 here to make it possible to put any task within any two other tasks (by changing the number).
+	// You would calculate the new position between two tasks with something like task3.position = (task2.position - task1.position) / 2.
+	// A 64-Bit float leaves plenty of room to initially give tasks a position with 2^16 difference to the previous task
+	// which also leaves a lot of room for rearranging and sorting later.
+	Position float64 `xorm:"double not null" json:"position"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for task positions
+func (*TaskPosition) TableName() string {
+	return "task_positions"
+}
+
+// Update sets the position of a task in a view
+// @Summary Updates a task position
+// @Description Updates the position of a task in a project view.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task ID"
+// @Param view body models.TaskPosition true "The task position with updated values you want to change."
+// @Success 200 {object} models.TaskPosition "The updated task position."
+// @Failure 400 {object} web.HTTPError "Invalid task position object provided."
+// @Failure 404 {object} web.HTTPError "The project view does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{id}/position [post]
+func (tp *TaskPosition) Update(s *xorm.Session, _ web.Auth) (err error) {
+	task, err := GetTaskByIDSimple(s, tp.TaskID)
+	if err != nil {
+		return err
+	}
+
+	view, err := getProjectViewByIDAndProject(s, tp.ProjectViewID, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	// Default views use the positions stored with the task
+	if !view.usesOwnTaskData() {
+		if view.ViewKind == ProjectViewKindKanban {
+			_, err = s.ID(task.ID).Cols("kanban_position").Update(&Task{KanbanPosition: tp.Position})
+			if err != nil || tp.Position >= 0.1 {
+				return err
+			}
+			return recalculateTaskKanbanPositions(s, task.BucketID)
+		}
+
+		_, err = s.ID(task.ID).Cols("position").Update(&Task{Position: tp.Position})
+		if err != nil || tp.Position >= 0.1 {
+			return err
+		}
+		return recalculateTaskPositions(s, task.ProjectID)
+	}
+
+	_, err = s.
+		Where("task_id = ? AND project_view_id = ?", tp.TaskID, tp.ProjectViewID).
+		Delete(&TaskPosition{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(tp)
+	if err != nil || tp.Position >= 0.1 {
+		return err
+	}
+
+	return recalculateTaskPositionsForView(s, view.ID)
+}
+
+func recalculateTaskPositionsForView(s *xorm.Session, projectViewID int64) (err error) {
+
+	allPositions := []*TaskPosition{}
+	err = s.
+		Where("project_view_id = ?", projectViewID).
+		OrderBy("position asc").
+		Find(&allPositions)
+	if err != nil {
+		return
+	}
+
+	maxPosition := math.Pow(2, 32)
+
+	for i, position := range allPositions {
+
+		currentPosition := maxPosition / float64(len(allPositions)) * (float64(i + 1))
+
+		_, err = s.Cols("position").
+			Where("id = ?", position.ID).
+			Update(&TaskPosition{Position: currentPosition})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// setTaskPositionsForView puts the positions a view keeps for its tasks into the tasks.
+func setTaskPositionsForView(s *xorm.Session, view *ProjectView, taskMap map[int64]*Task) (err error) {
+	if !view.usesOwnTaskData() || len(taskMap) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, 0, len(taskMap))
+	for id := range taskMap {
+		taskIDs = append(taskIDs, id)
+	}
+
+	positions := []*TaskPosition{}
+	err = s.
+		Where("project_view_id = ?", view.ID).
+		In("task_id", taskIDs).
+		Find(&positions)
+	if err != nil {
+		return err
+	}
+
+	positionMap := make(map[int64]float64, len(positions))
+	for _, p := range positions {
+		positionMap[p.TaskID] = p.Position
+	}
+
+	for _, t := range taskMap {
+		t.Position = positionMap[t.ID]
+		t.KanbanPosition = positionMap[t.ID]
+	}
+
+	return nil
+}
@@ -17,6 +17,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"code.vikunja.io/api/pkg/events"
@@ -77,6 +78,34 @@ func (rk RelationKind) isValid() bool {
 		rk == RelationKindCopiedTo
 }
 
+// inverse returns the kind of the relation which is stored for the other task of a relation.
+func (rk RelationKind) inverse() RelationKind {
+	switch rk {
+	case RelationKindSubtask:
+		return RelationKindParenttask
+	case RelationKindParenttask:
+		return RelationKindSubtask
+	case RelationKindDuplicateOf:
+		return RelationKindDuplicates
+	case RelationKindDuplicates:
+		return RelationKindDuplicateOf
+	case RelationKindBlocking:
+		return RelationKindBlocked
+	case RelationKindBlocked:
+		return RelationKindBlocking
+	case RelationKindPreceeds:
+		return RelationKindFollows
+	case RelationKindFollows:
+		return RelationKindPreceeds
+	case RelationKindCopiedFrom:
+		return RelationKindCopiedTo
+	case RelationKindCopiedTo:
+		return RelationKindCopiedFrom
+	}
+	// Related relations are the same in both directions
+	return rk
+}
+
 // TaskRelation represents a kind of relation between two tasks
 type TaskRelation struct {
 	// The unique, numeric id of this relation.
@@ -108,9 +137,14 @@ func (*TaskRelation) TableName() string {
 // This avoids the need for an extra type TaskWithRelation (or similar).
 type RelatedTaskMap map[RelationKind][]*Task
 
+// activityValue formats a relation for the activity log as <relation kind>:<other task id>
+func (rel *TaskRelation) activityValue() string {
+	return string(rel.RelationKind) + ":" + strconv.FormatInt(rel.OtherTaskID, 10)
+}
+
 // Create creates a new task relation
 // @Summary Create a new relation between two tasks
-// @Description Creates a new relation between two tasks. The user needs to have update rights on the base task and at least read rights on the other task. Both tasks do not need to be on the same project. Take a look at the docs for available task relation kinds.
+// @Description Creates a new relation between two tasks. The user needs to have update rights on the base task and at least read rights on the other task. Both tasks do not need to be on the same project. Take a look at the docs for available task relation kinds. Blocking and precedes relations can't form a cycle.
 // @tags task
 // @Accept json
 // @Produce json
@@ -147,6 +181,11 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 		}
 	}
 
+	err = checkRelationDoesNotCreateCycle(s, rel)
+	if err != nil {
+		return err
+	}
+
 	rel.CreatedBy, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		return err
@@ -155,36 +194,10 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 
 	// Build up the other relation (see the comment above for explanation)
 	otherRelation := &TaskRelation{
-		TaskID:      rel.OtherTaskID,
-		OtherTaskID: rel.TaskID,
-		CreatedByID: rel.CreatedByID,
-	}
-
-	switch rel.RelationKind {
-	case RelationKindSubtask:
-		otherRelation.RelationKind = RelationKindParenttask
-	case RelationKindParenttask:
-		otherRelation.RelationKind = RelationKindSubtask
-	case RelationKindRelated:
-		otherRelation.RelationKind = RelationKindRelated
-	case RelationKindDuplicateOf:
-		otherRelation.RelationKind = RelationKindDuplicates
-	case RelationKindDuplicates:
-		otherRelation.RelationKind = RelationKindDuplicateOf
-	case RelationKindBlocking:
-		otherRelation.RelationKind = RelationKindBlocked
-	case RelationKindBlocked:
-		otherRelation.RelationKind = RelationKindBlocking
-	case RelationKindPreceeds:
-		otherRelation.RelationKind = RelationKindFollows
-	case RelationKindFollows:
-		otherRelation.RelationKind = RelationKindPreceeds
-	case RelationKindCopiedFrom:
-		otherRelation.RelationKind = RelationKindCopiedTo
-	case RelationKindCopiedTo:
-		otherRelation.RelationKind = RelationKindCopiedFrom
-	case RelationKindUnknown:
-		// Nothing to do
+		TaskID:       rel.OtherTaskID,
+		OtherTaskID:  rel.TaskID,
+		RelationKind: rel.RelationKind.inverse(),
+		CreatedByID:  rel.CreatedByID,
 	}
 
 	// Finally insert everything
@@ -196,9 +209,29 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	err = addTaskActivity(s, rel.TaskID, a, TaskActivityKindAdded, "relations", "", rel.activityValue())
+	if err != nil {
+		return err
+	}
+
+	// Relations are part of the caldav representation of both tasks
+	err = recordTaskSyncChangeByTaskID(s, rel.TaskID)
+	if err != nil {
+		return err
+	}
+	err = recordTaskSyncChangeByTaskID(s, rel.OtherTaskID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, rel.TaskID)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	return events.Dispatch(&TaskRelationCreatedEvent{
-		Task:     &Task{ID: rel.TaskID},
+		Task:     &task,
 		Relation: rel,
 		Doer:     doer,
 	})
@@ -230,7 +263,7 @@ func (rel *TaskRelation) Delete(s *xorm.Session, a web.Auth) error {
 		builder.And(
 			builder.Eq{"task_id": rel.OtherTaskID},
 			builder.Eq{"other_task_id": rel.TaskID},
-			builder.Eq{"relation_kind": rel.RelationKind},
+			builder.Eq{"relation_kind": rel.RelationKind.inverse()},
 		),
 	)
 
@@ -256,9 +289,29 @@ func (rel *TaskRelation) Delete(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	err = addTaskActivity(s, rel.TaskID, a, TaskActivityKindRemoved, "relations", rel.activityValue(), "")
+	if err != nil {
+		return err
+	}
+
+	// Relations are part of the caldav representation of both tasks
+	err = recordTaskSyncChangeByTaskID(s, rel.TaskID)
+	if err != nil {
+		return err
+	}
+	err = recordTaskSyncChangeByTaskID(s, rel.OtherTaskID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, rel.TaskID)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	return events.Dispatch(&TaskRelationDeletedEvent{
-		Task:     &Task{ID: rel.TaskID},
+		Task:     &task,
 		Relation: rel,
 		Doer:     doer,
 	})
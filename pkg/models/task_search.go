@@ -0,0 +1,160 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/modules/search"
+
+	"xorm.io/xorm"
+)
+
+// How many tasks are added to the search index at once when rebuilding it
+const searchIndexRebuildBatchSize = 500
+
+type labelTaskWithTitle struct {
+	TaskID int64  `xorm:"task_id"`
+	Title  string `xorm:"title"`
+}
+
+// getSearchDocumentsForTasks returns everything about the tasks the search index needs to know
+func getSearchDocumentsForTasks(s *xorm.Session, tasks []*Task) (docs []*search.Document, err error) {
+	if len(tasks) == 0 {
+		return []*search.Document{}, nil
+	}
+
+	taskIDs := make([]int64, 0, len(tasks))
+	projectIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		taskIDs = append(taskIDs, t.ID)
+		projectIDs = append(projectIDs, t.ProjectID)
+	}
+
+	projects, err := GetProjectsByIDs(s, projectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := []*labelTaskWithTitle{}
+	err = s.
+		Table("label_tasks").
+		Select("label_tasks.task_id, labels.title").
+		Join("INNER", "labels", "labels.id = label_tasks.label_id").
+		In("label_tasks.task_id", taskIDs).
+		Find(&labels)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := []*TaskComment{}
+	err = s.In("task_id", taskIDs).Find(&comments)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := make(map[int64]*search.Document, len(tasks))
+	docs = make([]*search.Document, 0, len(tasks))
+	for _, t := range tasks {
+		doc := &search.Document{
+			TaskID:      t.ID,
+			ProjectID:   t.ProjectID,
+			Index:       t.Index,
+			Title:       t.Title,
+			Description: t.Description,
+			Labels:      []string{},
+			Comments:    []string{},
+		}
+		if p, has := projects[t.ProjectID]; has && p.Identifier != "" {
+			t.setIdentifier(p)
+			doc.Identifier = t.Identifier
+		}
+		docMap[t.ID] = doc
+		docs = append(docs, doc)
+	}
+
+	for _, l := range labels {
+		docMap[l.TaskID].Labels = append(docMap[l.TaskID].Labels, l.Title)
+	}
+
+	for _, c := range comments {
+		docMap[c.TaskID].Comments = append(docMap[c.TaskID].Comments, c.Comment)
+	}
+
+	return docs, nil
+}
+
+func getSearchDocumentForTask(s *xorm.Session, taskID int64) (doc *search.Document, err error) {
+	task, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := getSearchDocumentsForTasks(s, []*Task{&task})
+	if err != nil {
+		return nil, err
+	}
+
+	return docs[0], nil
+}
+
+// RebuildSearchIndex removes all tasks from the search index and adds them again.
+// Public to allow rebuilding the index from the cli.
+func RebuildSearchIndex(s *xorm.Session) (err error) {
+	if !search.KeepsIndex() {
+		log.Infof("The configured search backend does not use an index, nothing to rebuild.")
+		return nil
+	}
+
+	err = search.Clear()
+	if err != nil {
+		return err
+	}
+
+	var indexed int
+	for page := 0; ; page++ {
+		tasks := []*Task{}
+		err = s.
+			OrderBy("id asc").
+			Limit(searchIndexRebuildBatchSize, page*searchIndexRebuildBatchSize).
+			Find(&tasks)
+		if err != nil {
+			return err
+		}
+
+		if len(tasks) == 0 {
+			break
+		}
+
+		docs, err := getSearchDocumentsForTasks(s, tasks)
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range docs {
+			err = search.Index(doc)
+			if err != nil {
+				return err
+			}
+		}
+
+		indexed += len(docs)
+		log.Debugf("Added %d tasks to the search index", indexed)
+	}
+
+	log.Infof("Added %d tasks to the search index", indexed)
+	return nil
+}
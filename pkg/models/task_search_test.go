@@ -0,0 +1,112 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/modules/search"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSearchDocumentForTask(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	doc, err := getSearchDocumentForTask(s, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), doc.TaskID)
+	assert.Equal(t, int64(1), doc.ProjectID)
+	assert.Equal(t, "test1-1", doc.Identifier)
+	assert.Equal(t, "task #1", doc.Title)
+	assert.Equal(t, "Lorem Ipsum", doc.Description)
+	assert.Equal(t, []string{"Label #4 - visible via other task"}, doc.Labels)
+	assert.Contains(t, doc.Comments, "Lorem Ipsum Dolor Sit Amet")
+}
+
+func getTaskIDs(t *testing.T, result interface{}) []int64 {
+	tasks, is := result.([]*Task)
+	require.True(t, is)
+	ids := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func TestSearchRelevance(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err := s.Insert(&Task{ID: 100, Title: "Feed the animals", Description: "<p>Don't forget the unicorns</p>", ProjectID: 1, CreatedByID: 1, Index: 100})
+	require.NoError(t, err)
+	_, err = s.Insert(&Task{ID: 101, Title: "Buy food for the unicorns", ProjectID: 1, CreatedByID: 1, Index: 101})
+	require.NoError(t, err)
+
+	u := &user.User{ID: 1}
+
+	t.Run("database", func(t *testing.T) {
+		tc := &TaskCollection{ProjectID: 1}
+		result, _, _, err := tc.ReadAll(s, u, "unicorns", 0, 50)
+		require.NoError(t, err)
+		// Title matches are more relevant than description matches
+		assert.Equal(t, []int64{101, 100}, getTaskIDs(t, result))
+	})
+	t.Run("embedded", func(t *testing.T) {
+		config.SearchType.Set("embedded")
+		config.SearchEmbeddedPath.Set(t.TempDir())
+		search.InitBackend()
+		defer func() {
+			_ = search.Close()
+			config.SearchType.Set("database")
+			search.InitBackend()
+		}()
+
+		err := RebuildSearchIndex(s)
+		require.NoError(t, err)
+
+		tc := &TaskCollection{ProjectID: 1}
+		result, _, _, err := tc.ReadAll(s, u, "unicorns", 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{101, 100}, getTaskIDs(t, result))
+
+		// Words only need to start with the search term, all words need to match
+		result, _, _, err = tc.ReadAll(s, u, "unic anim", 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{100}, getTaskIDs(t, result))
+
+		// Identifiers
+		result, _, _, err = tc.ReadAll(s, u, "test1-101", 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{101}, getTaskIDs(t, result))
+
+		// Labels and comments
+		result, _, _, err = tc.ReadAll(s, u, "dolor", 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1}, getTaskIDs(t, result))
+		result, _, _, err = tc.ReadAll(s, u, "visible via other task", 0, 50)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 2}, getTaskIDs(t, result))
+	})
+}
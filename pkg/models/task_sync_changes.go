@@ -0,0 +1,196 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskSyncChange records the latest change of a task in a project. Every change of a task gets the next sync
+// sequence number of its project and replaces the previous record of that task.
+// Records of deleted tasks or of tasks moved to another project are kept as tombstones.
+type TaskSyncChange struct {
+	ID        int64     `xorm:"bigint autoincr not null unique pk" json:"-"`
+	ProjectID int64     `xorm:"bigint not null index" json:"-"`
+	TaskID    int64     `xorm:"bigint not null index" json:"-"`
+	TaskUID   string    `xorm:"varchar(250) null" json:"-"`
+	Deleted   bool      `xorm:"bool not null default false" json:"-"`
+	Sequence  int64     `xorm:"bigint not null default 0 index" json:"-"`
+	Created   time.Time `xorm:"created not null" json:"-"`
+}
+
+// TableName returns the table name for task sync changes
+func (*TaskSyncChange) TableName() string {
+	return "task_sync_changes"
+}
+
+// ProjectSyncSequence holds the current sync sequence number of a project.
+type ProjectSyncSequence struct {
+	ProjectID int64 `xorm:"bigint not null pk" json:"-"`
+	Sequence  int64 `xorm:"bigint not null default 0" json:"-"`
+}
+
+// TableName returns the table name for project sync sequences
+func (*ProjectSyncSequence) TableName() string {
+	return "project_sync_sequences"
+}
+
+func createProjectSyncSequence(s *xorm.Session, projectID int64) (err error) {
+	_, err = s.Insert(&ProjectSyncSequence{ProjectID: projectID})
+	return
+}
+
+// nextProjectSyncSequence increments the sync sequence of a project and returns the new value.
+// The sequence row stays locked until the transaction ends, which makes sure changes of a project
+// become visible in the order of their sequence numbers.
+func nextProjectSyncSequence(s *xorm.Session, projectID int64) (sequence int64, err error) {
+	current := &ProjectSyncSequence{}
+	exists, err := s.
+		Where("project_id = ?", projectID).
+		ForUpdate().
+		Get(current)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		// Projects created before sync sequences were introduced
+		err = createProjectSyncSequence(s, projectID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	current.Sequence++
+	_, err = s.
+		Where("project_id = ?", projectID).
+		Cols("sequence").
+		Update(&ProjectSyncSequence{Sequence: current.Sequence})
+	return current.Sequence, err
+}
+
+func addTaskSyncChange(s *xorm.Session, projectID int64, task *Task, deleted bool) (err error) {
+	sequence, err := nextProjectSyncSequence(s, projectID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("project_id = ? AND task_id = ?", projectID, task.ID).
+		Delete(&TaskSyncChange{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(&TaskSyncChange{
+		ProjectID: projectID,
+		TaskID:    task.ID,
+		TaskUID:   task.UID,
+		Deleted:   deleted,
+		Sequence:  sequence,
+	})
+	return err
+}
+
+// recordTaskSyncChange marks a task as changed in its project
+func recordTaskSyncChange(s *xorm.Session, task *Task) error {
+	return addTaskSyncChange(s, task.ProjectID, task, false)
+}
+
+// recordTaskSyncDeletion adds a tombstone for a task which is not part of a project anymore
+func recordTaskSyncDeletion(s *xorm.Session, projectID int64, task *Task) error {
+	return addTaskSyncChange(s, projectID, task, true)
+}
+
+func recordTaskSyncChangeByTaskID(s *xorm.Session, taskID int64) error {
+	task, err := GetTaskByIDSimple(s, taskID)
+	if IsErrTaskDoesNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return recordTaskSyncChange(s, &task)
+}
+
+func deleteTaskSyncChangesOfProject(s *xorm.Session, projectID int64) (err error) {
+	_, err = s.Where("project_id = ?", projectID).Delete(&TaskSyncChange{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("project_id = ?", projectID).Delete(&ProjectSyncSequence{})
+	return
+}
+
+// GetProjectSyncSequence returns the current sync sequence number of a project.
+// It is 0 if no task of that project was changed since change tracking was introduced.
+func GetProjectSyncSequence(s *xorm.Session, projectID int64) (sequence int64, err error) {
+	current := &ProjectSyncSequence{}
+	_, err = s.
+		Where("project_id = ?", projectID).
+		Get(current)
+	return current.Sequence, err
+}
+
+// GetTaskSyncChangesSince returns the latest change of all tasks of a project which were changed after
+// the given sync sequence number, ordered by their sequence.
+func GetTaskSyncChangesSince(s *xorm.Session, projectID int64, sequence int64) (changes []*TaskSyncChange, err error) {
+	changes = []*TaskSyncChange{}
+	err = s.
+		Where(builder.And(
+			builder.Eq{"project_id": projectID},
+			builder.Gt{"sequence": sequence},
+		)).
+		OrderBy("sequence asc").
+		Find(&changes)
+	return
+}
+
+// GetTasksForSyncChanges returns the current version of all tasks which were changed and not deleted
+// in the given changes, with all details needed to build their caldav representation.
+func GetTasksForSyncChanges(s *xorm.Session, changes []*TaskSyncChange, a web.Auth) (tasks []*Task, err error) {
+	taskIDs := []int64{}
+	for _, change := range changes {
+		if !change.Deleted {
+			taskIDs = append(taskIDs, change.TaskID)
+		}
+	}
+
+	tasks = []*Task{}
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	err = s.In("id", taskIDs).OrderBy("id asc").Find(&tasks)
+	if err != nil {
+		return
+	}
+
+	taskMap := make(map[int64]*Task, len(tasks))
+	for _, t := range tasks {
+		taskMap[t.ID] = t
+	}
+
+	err = addMoreInfoToTasks(s, taskMap, a)
+	return
+}
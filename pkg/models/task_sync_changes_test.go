@@ -0,0 +1,201 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProjectSyncSequence(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	sequence, err := GetProjectSyncSequence(s, 36)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), sequence)
+
+	sequence, err = GetProjectSyncSequence(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), sequence)
+}
+
+func TestTaskSyncChanges(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:     "Lorem",
+			ProjectID: 1,
+		}
+		err := task.Create(s, u)
+		assert.NoError(t, err)
+
+		changes, err := GetTaskSyncChangesSince(s, 1, 0)
+		assert.NoError(t, err)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, task.ID, changes[0].TaskID)
+		assert.Equal(t, task.UID, changes[0].TaskUID)
+		assert.False(t, changes[0].Deleted)
+
+		sequence, err := GetProjectSyncSequence(s, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, changes[0].Sequence, sequence)
+	})
+	t.Run("only the latest change of a task is kept", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, Title: "Lorem", ProjectID: 1}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		first, err := GetProjectSyncSequence(s, 1)
+		assert.NoError(t, err)
+
+		task = &Task{ID: 1, Title: "Ipsum", ProjectID: 1}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		second, err := GetProjectSyncSequence(s, 1)
+		assert.NoError(t, err)
+		assert.Greater(t, second, first)
+
+		changes, err := GetTaskSyncChangesSince(s, 1, 0)
+		assert.NoError(t, err)
+		assert.Len(t, changes, 1)
+		assert.Equal(t, second, changes[0].Sequence)
+
+		changes, err = GetTaskSyncChangesSince(s, 1, second)
+		assert.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+	t.Run("delete", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1}
+		err := task.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"project_id": 1,
+			"task_id":    1,
+			"deleted":    true,
+		}, false)
+	})
+	t.Run("move to another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 12, ProjectID: 2}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"project_id": 1,
+			"task_id":    12,
+			"deleted":    true,
+		}, false)
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"project_id": 2,
+			"task_id":    12,
+			"deleted":    false,
+		}, false)
+	})
+	t.Run("bulk move to another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		bt := &BulkTask{
+			IDs:  []int64{10, 11},
+			Task: Task{ProjectID: 10},
+		}
+		can, err := bt.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = bt.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		for _, taskID := range []int64{10, 11} {
+			db.AssertExists(t, "tasks", map[string]interface{}{
+				"id":         taskID,
+				"project_id": 10,
+			}, false)
+			db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+				"project_id": 1,
+				"task_id":    taskID,
+				"deleted":    true,
+			}, false)
+			db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+				"project_id": 10,
+				"task_id":    taskID,
+				"deleted":    false,
+			}, false)
+		}
+	})
+	t.Run("label", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lt := &LabelTask{TaskID: 1, LabelID: 1}
+		err := lt.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"project_id": 1,
+			"task_id":    1,
+			"deleted":    false,
+		}, false)
+	})
+}
+
+func TestGetTasksForSyncChanges(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	changes, err := GetTaskSyncChangesSince(s, 36, 0)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	tasks, err := GetTasksForSyncChanges(s, changes, &user.User{ID: 15})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+	assert.Equal(t, int64(40), tasks[0].ID)
+	assert.NotEmpty(t, tasks[0].Labels)
+}
@@ -0,0 +1,476 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskTimeEntry holds an amount of time a user spent working on a task.
+type TaskTimeEntry struct {
+	// The unique, numeric id of this time entry.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"timeentry"`
+	// The task this time entry belongs to.
+	TaskID int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+
+	UserID int64 `xorm:"bigint not null index" json:"-"`
+	// The user who tracked this time entry.
+	User *user.User `xorm:"-" json:"user"`
+
+	// When the user started working on the task.
+	Start time.Time `xorm:"DATETIME not null 'start_time'" json:"start"`
+	// When the user stopped working on the task. Is null while the timer of this entry is still running.
+	End time.Time `xorm:"DATETIME null index 'end_time'" json:"end"`
+	// The time spent in seconds. When creating a time entry with a start date and duration but without an end date,
+	// the end date will be calculated from the duration. Is 0 while the timer of this entry is still running.
+	Duration int64 `xorm:"bigint not null default 0" json:"duration"`
+	// An optional note about what the user worked on.
+	Note string `xorm:"text null" json:"note"`
+
+	// A timestamp when this time entry was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this time entry was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for time entries
+func (*TaskTimeEntry) TableName() string {
+	return "task_time_entries"
+}
+
+// TaskTimer starts or stops the running timer of the current user on a task.
+type TaskTimer struct {
+	TaskID int64 `xorm:"-" json:"-" param:"task"`
+	// An optional note which will be saved with the time entry.
+	Note string `xorm:"-" json:"note"`
+	// The time entry which was started or stopped.
+	TimeEntry *TaskTimeEntry `xorm:"-" json:"time_entry"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// IsRunning returns true if the timer of this time entry was not stopped yet.
+func (te *TaskTimeEntry) IsRunning() bool {
+	return te.End.IsZero()
+}
+
+// Makes sure start, end and duration of a time entry fit together.
+func (te *TaskTimeEntry) normalize() error {
+	if te.Start.IsZero() {
+		return &ErrInvalidTaskTimeEntry{TimeEntryID: te.ID, Reason: "a start date is required"}
+	}
+
+	te.Start = te.Start.Truncate(time.Second)
+	te.End = te.End.Truncate(time.Second)
+
+	if te.End.IsZero() && te.Duration > 0 {
+		te.End = te.Start.Add(time.Duration(te.Duration) * time.Second)
+	}
+
+	if te.End.IsZero() {
+		te.Duration = 0
+		return nil
+	}
+
+	if te.End.Before(te.Start) {
+		return &ErrInvalidTaskTimeEntry{TimeEntryID: te.ID, Reason: "the end date must be after the start date"}
+	}
+
+	te.Duration = int64(te.End.Sub(te.Start).Seconds())
+	return nil
+}
+
+func getRunningTimeEntryForUser(s *xorm.Session, userID int64) (entry *TaskTimeEntry, err error) {
+	entry = &TaskTimeEntry{}
+	exists, err := s.
+		Where("user_id = ? AND end_time IS NULL", userID).
+		Get(entry)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// Only one timer can run for a user at the same time.
+func checkNoOtherTimerRunning(s *xorm.Session, te *TaskTimeEntry) error {
+	// Locking the row of the user makes parallel requests to start a timer wait for each other until the
+	// transaction of the first one is done, so only one of them can find no running timer.
+	_, err := s.
+		Where("id = ?", te.UserID).
+		Cols("id").
+		ForUpdate().
+		Get(&user.User{})
+	if err != nil {
+		return err
+	}
+
+	running, err := getRunningTimeEntryForUser(s, te.UserID)
+	if err != nil {
+		return err
+	}
+	if running != nil && running.ID != te.ID {
+		return &ErrTimerAlreadyRunning{
+			TimeEntryID: running.ID,
+			TaskID:      running.TaskID,
+		}
+	}
+	return nil
+}
+
+func getTaskTimeEntrySimple(s *xorm.Session, te *TaskTimeEntry) error {
+	exists, err := s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		NoAutoCondition().
+		Get(te)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &ErrTaskTimeEntryDoesNotExist{
+			ID:     te.ID,
+			TaskID: te.TaskID,
+		}
+	}
+	return nil
+}
+
+// Create adds a new time entry to a task
+// @Summary Create a time entry
+// @Description Creates a new time entry for the current user on a task. If no end date and no duration are provided, a running timer is created. Only one timer can run at the same time.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entry body models.TaskTimeEntry true "The time entry"
+// @Success 201 {object} models.TaskTimeEntry "The created time entry."
+// @Failure 400 {object} web.HTTPError "Invalid time entry object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 412 {object} web.HTTPError "There already is a running timer."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries [put]
+func (te *TaskTimeEntry) Create(s *xorm.Session, a web.Auth) (err error) {
+	te.ID = 0
+	te.UserID = a.GetID()
+
+	err = te.normalize()
+	if err != nil {
+		return err
+	}
+
+	if te.IsRunning() {
+		err = checkNoOtherTimerRunning(s, te)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.Insert(te)
+	if err != nil {
+		return err
+	}
+
+	te.User, err = user.GetUserByID(s, te.UserID)
+	return
+}
+
+// ReadOne returns a single time entry
+// @Summary Get one time entry
+// @Description Returns a single time entry of a task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Success 200 {object} models.TaskTimeEntry "The time entry."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries/{entryID} [get]
+func (te *TaskTimeEntry) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	err = getTaskTimeEntrySimple(s, te)
+	if err != nil {
+		return err
+	}
+
+	users, err := user.GetUsersByIDs(s, []int64{te.UserID})
+	if err != nil {
+		return err
+	}
+	te.User = users[te.UserID]
+	return nil
+}
+
+// ReadAll returns all time entries of a task
+// @Summary Get all time entries of a task
+// @Description Returns all time entries of a task, tracked by all users. The most recent entries come first.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search time entries by their note."
+// @Success 200 {array} models.TaskTimeEntry "The time entries"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries [get]
+func (te *TaskTimeEntry) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	t := &Task{ID: te.TaskID}
+	canRead, _, err := t.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.And(builder.Eq{"task_id": te.TaskID})
+	if search != "" {
+		cond = cond.And(db.ILIKE("note", search))
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	query := s.Where(cond).OrderBy("start_time DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	entries := []*TaskTimeEntry{}
+	err = query.Find(&entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	userIDs := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		userIDs = append(userIDs, entry.UserID)
+	}
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, entry := range entries {
+		entry.User = users[entry.UserID]
+	}
+
+	numberOfTotalItems, err = s.Where(cond).Count(&TaskTimeEntry{})
+	return entries, len(entries), numberOfTotalItems, err
+}
+
+// Update changes a time entry
+// @Summary Update a time entry
+// @Description Updates a time entry. Only the user who tracked the time entry can change it. If no start date is provided, the saved one is kept.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Param entry body models.TaskTimeEntry true "The time entry"
+// @Success 200 {object} models.TaskTimeEntry "The updated time entry."
+// @Failure 400 {object} web.HTTPError "Invalid time entry object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 412 {object} web.HTTPError "There already is a running timer."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries/{entryID} [post]
+func (te *TaskTimeEntry) Update(s *xorm.Session, a web.Auth) (err error) {
+	te.UserID = a.GetID()
+
+	// Keep the saved start date if no new one was provided
+	if te.Start.IsZero() {
+		saved := &TaskTimeEntry{ID: te.ID, TaskID: te.TaskID}
+		err = getTaskTimeEntrySimple(s, saved)
+		if err != nil {
+			return err
+		}
+		te.Start = saved.Start
+	}
+
+	err = te.normalize()
+	if err != nil {
+		return err
+	}
+
+	if te.IsRunning() {
+		err = checkNoOtherTimerRunning(s, te)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		Cols("start_time", "end_time", "duration", "note").
+		Update(te)
+	if err != nil {
+		return err
+	}
+
+	return te.ReadOne(s, a)
+}
+
+// Delete removes a time entry
+// @Summary Delete a time entry
+// @Description Deletes a time entry. Only the user who tracked the time entry can delete it.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Success 200 {object} models.Message "The time entry was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries/{entryID} [delete]
+func (te *TaskTimeEntry) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		Delete(&TaskTimeEntry{})
+	return
+}
+
+// Create starts a timer
+// @Summary Start a timer
+// @Description Starts a timer for the current user on a task by creating a running time entry. Only one timer can run at the same time, stop any running timer first.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param timer body models.TaskTimer true "An optional note for the time entry."
+// @Success 201 {object} models.TaskTimer "The started timer."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 412 {object} web.HTTPError "There already is a running timer."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/timer/start [post]
+func (tt *TaskTimer) Create(s *xorm.Session, a web.Auth) (err error) {
+	tt.TimeEntry = &TaskTimeEntry{
+		TaskID: tt.TaskID,
+		Start:  time.Now(),
+		Note:   tt.Note,
+	}
+	return tt.TimeEntry.Create(s, a)
+}
+
+// Update stops a timer
+// @Summary Stop a timer
+// @Description Stops the running timer of the current user on a task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param timer body models.TaskTimer true "An optional note for the time entry. If provided, it will replace the note given when starting the timer."
+// @Success 200 {object} models.TaskTimer "The stopped timer."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "There is no running timer on this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/timer/stop [post]
+func (tt *TaskTimer) Update(s *xorm.Session, a web.Auth) (err error) {
+	running, err := getRunningTimeEntryForUser(s, a.GetID())
+	if err != nil {
+		return err
+	}
+	if running == nil || running.TaskID != tt.TaskID {
+		return &ErrNoRunningTimer{TaskID: tt.TaskID, UserID: a.GetID()}
+	}
+
+	running.End = time.Now()
+	if tt.Note != "" {
+		running.Note = tt.Note
+	}
+
+	err = running.Update(s, a)
+	tt.TimeEntry = running
+	return
+}
+
+// Returns the total time in seconds tracked with finished time entries, grouped by task id.
+func getTimeSpentForTasks(s *xorm.Session, taskIDs []int64) (timeSpent map[int64]int64, err error) {
+	timeSpent = make(map[int64]int64, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	type taskTimeSpent struct {
+		TaskID    int64
+		TimeSpent int64
+	}
+	sums := []*taskTimeSpent{}
+	err = s.
+		Table("task_time_entries").
+		Select("task_id, SUM(duration) AS time_spent").
+		In("task_id", taskIDs).
+		GroupBy("task_id").
+		Find(&sums)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sum := range sums {
+		timeSpent[sum.TaskID] = sum.TimeSpent
+	}
+	return
+}
+
+// Returns the total time in seconds tracked with finished time entries on all tasks of a project, grouped by project id.
+func getTimeSpentForProjects(s *xorm.Session, projectIDs []int64) (timeSpent map[int64]int64, err error) {
+	timeSpent = make(map[int64]int64, len(projectIDs))
+	if len(projectIDs) == 0 {
+		return
+	}
+
+	type projectTimeSpent struct {
+		ProjectID int64
+		TimeSpent int64
+	}
+	sums := []*projectTimeSpent{}
+	err = s.
+		Table("task_time_entries").
+		Select("tasks.project_id, SUM(task_time_entries.duration) AS time_spent").
+		Join("INNER", "tasks", "tasks.id = task_time_entries.task_id").
+		In("tasks.project_id", projectIDs).
+		And("tasks.deleted IS NULL").
+		GroupBy("tasks.project_id").
+		Find(&sums)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sum := range sums {
+		timeSpent[sum.ProjectID] = sum.TimeSpent
+	}
+	return
+}
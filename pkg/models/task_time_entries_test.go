@@ -0,0 +1,373 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskTimeEntry_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+	start := time.Date(2023, 6, 10, 9, 0, 0, 0, time.Local)
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(90 * time.Minute),
+			Note:   "test",
+		}
+		can, err := te.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = te.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5400), te.Duration)
+		assert.Equal(t, int64(1), te.User.ID)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":       te.ID,
+			"task_id":  1,
+			"user_id":  1,
+			"duration": 5400,
+			"note":     "test",
+		}, false)
+	})
+	t.Run("with duration only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID:   1,
+			Start:    start,
+			Duration: 3600,
+		}
+		err := te.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, start.Add(time.Hour), te.End)
+	})
+	t.Run("end before start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(-time.Minute),
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskTimeEntry(err))
+	})
+	t.Run("without start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID:   1,
+			Duration: 3600,
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskTimeEntry(err))
+	})
+	t.Run("second running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  start,
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTimerAlreadyRunning(err))
+	})
+	t.Run("no write access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{TaskID: 1}
+		can, err := te.CanCreate(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{TaskID: 1}
+		can, err := te.CanCreate(s, &LinkSharing{ID: 2, ProjectID: 1, Right: RightWrite})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskTimeEntry_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	te := &TaskTimeEntry{TaskID: 1}
+	res, _, total, err := te.ReadAll(s, &user.User{ID: 1}, "", 0, -1)
+	require.NoError(t, err)
+	entries := res.([]*TaskTimeEntry)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, entries, 3)
+	assert.Equal(t, int64(5), entries[0].ID)
+	assert.Equal(t, int64(2), entries[1].ID)
+	assert.Equal(t, int64(1), entries[2].ID)
+	assert.Equal(t, int64(2), entries[0].User.ID)
+
+	res, _, _, err = te.ReadAll(s, &user.User{ID: 1}, "plan", 0, -1)
+	require.NoError(t, err)
+	entries = res.([]*TaskTimeEntry)
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(1), entries[0].ID)
+}
+
+func TestTaskTimeEntry_ReadOne(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 1, TaskID: 1}
+		can, _, err := te.CanRead(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = te.ReadOne(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, "Planning", te.Note)
+		assert.Equal(t, int64(5400), te.Duration)
+	})
+	t.Run("entry of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 3, TaskID: 1}
+		_, _, err := te.CanRead(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskTimeEntryDoesNotExist(err))
+	})
+}
+
+func TestTaskTimeEntry_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		start := time.Date(2023, 6, 1, 9, 0, 0, 0, time.Local)
+		te := &TaskTimeEntry{
+			ID:     1,
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(2 * time.Hour),
+			Note:   "Planning and more",
+		}
+		can, err := te.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = te.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":       1,
+			"duration": 7200,
+			"note":     "Planning and more",
+		}, false)
+	})
+	t.Run("without start date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			ID:       1,
+			TaskID:   1,
+			Duration: 3600,
+			Note:     "Planning and more",
+		}
+		err := te.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.Equal(t, time.Date(2023, 6, 1, 10, 0, 0, 0, time.Local), te.End.In(time.Local))
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":       1,
+			"duration": 3600,
+			"note":     "Planning and more",
+		}, false)
+	})
+	t.Run("not the own entry", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 5, TaskID: 1}
+		can, err := te.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskTimeEntry_Delete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 1, TaskID: 1}
+		can, err := te.CanDelete(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = te.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "task_time_entries", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 9999, TaskID: 1}
+		_, err := te.CanDelete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskTimeEntryDoesNotExist(err))
+	})
+	t.Run("purged with the task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := purgeTask(s, &Task{ID: 1})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "task_time_entries", map[string]interface{}{
+			"task_id": 1,
+		})
+	})
+}
+
+func TestTaskTimer(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("start while another timer is running", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 1}
+		err := tt.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTimerAlreadyRunning(err))
+	})
+	t.Run("stop and start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 3}
+		err := tt.Update(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), tt.TimeEntry.ID)
+		assert.False(t, tt.TimeEntry.IsRunning())
+		assert.Positive(t, tt.TimeEntry.Duration)
+		assert.Equal(t, "Still working on it", tt.TimeEntry.Note)
+
+		tt = &TaskTimer{TaskID: 1, Note: "Next thing"}
+		err = tt.Create(s, u)
+		require.NoError(t, err)
+		assert.True(t, tt.TimeEntry.IsRunning())
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":      tt.TimeEntry.ID,
+			"task_id": 1,
+			"user_id": 1,
+			"note":    "Next thing",
+		}, false)
+		running, err := getRunningTimeEntryForUser(s, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, tt.TimeEntry.ID, running.ID)
+	})
+	t.Run("stop without running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskID: 1}
+		err := tt.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrNoRunningTimer(err))
+	})
+}
+
+func TestTaskTimeEntry_Totals(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+	u := &user.User{ID: 1}
+
+	task := &Task{ID: 1}
+	err := task.ReadOne(s, u)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8700), task.TimeSpent)
+
+	project := &Project{ID: 1}
+	_, _, err = project.CanRead(s, u)
+	require.NoError(t, err)
+	err = project.ReadOne(s, u)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12300), project.TimeSpent)
+}
@@ -27,7 +27,10 @@ import (
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/modules/search"
+	"code.vikunja.io/api/pkg/rrule"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 
@@ -73,6 +76,8 @@ type Task struct {
 	RepeatAfter int64 `xorm:"bigint INDEX null" json:"repeat_after" valid:"range(0|9223372036854775807)"`
 	// Can have three possible values which will trigger when the task is marked as done: 0 = repeats after the amount specified in repeat_after, 1 = repeats all dates each months (ignoring repeat_after), 3 = repeats from the current date rather than the last set date.
 	RepeatMode TaskRepeatMode `xorm:"not null default 0" json:"repeat_mode"`
+	// An iCalendar recurrence rule (RFC 5545) like `FREQ=WEEKLY;INTERVAL=2;BYDAY=TU`. If this is set, it is used instead of repeat_after and repeat_mode when the task is marked as done. A COUNT in the rule is reduced by one every time the task repeats, the task stays done once it reaches 1 or when the next date would be after UNTIL.
+	RepeatRule string `xorm:"text null" json:"repeat_rule"`
 	// The task priority. Can be anything you want, it is possible to sort by this later.
 	Priority int64 `xorm:"bigint null" json:"priority"`
 	// When this task starts.
@@ -87,6 +92,10 @@ type Task struct {
 	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
 	// Determines how far a task is left from being done
 	PercentDone float64 `xorm:"DOUBLE null" json:"percent_done"`
+	// The estimated effort for this task in story points. Used to calculate the burndown of a project.
+	StoryPoints float64 `xorm:"DOUBLE null" json:"story_points"`
+	// The estimated time in seconds it takes to finish this task. Used to calculate the burndown of a project.
+	TimeEstimate int64 `xorm:"bigint null" json:"time_estimate"`
 
 	// The task identifier, based on the project identifier and the task's index
 	Identifier string `xorm:"-" json:"identifier"`
@@ -108,6 +117,15 @@ type Task struct {
 	// True if a task is a favorite task. Favorite tasks show up in a separate "Important" project. This value depends on the user making the call to the api.
 	IsFavorite bool `xorm:"-" json:"is_favorite"`
 
+	// All custom field values of this task, keyed by the id of their custom field. Use the custom field endpoints to change them.
+	CustomFields map[int64]interface{} `xorm:"-" json:"custom_fields"`
+
+	// The total time in seconds all users tracked on this task. Only contains finished time entries, running timers are not included.
+	TimeSpent int64 `xorm:"-" json:"time_spent"`
+
+	// All reactions on this task, grouped by their value. Use the reaction endpoints to change them.
+	Reactions ReactionMap `xorm:"-" json:"reactions"`
+
 	// The subscription status for the user reading this task. You can only read this property, use the subscription endpoints to modify it.
 	// Will only returned when retrieving one task.
 	Subscription *Subscription `xorm:"-" json:"subscription,omitempty"`
@@ -116,6 +134,8 @@ type Task struct {
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this task was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
+	// A timestamp when this task was moved to the trash. Deleted tasks are only visible in the trash.
+	Deleted time.Time `xorm:"deleted INDEX null" json:"-"`
 
 	// BucketID is the ID of the kanban bucket this task belongs to.
 	BucketID int64 `xorm:"bigint null" json:"bucket_id"`
@@ -140,7 +160,9 @@ type Task struct {
 
 type TaskWithComments struct {
 	Task
-	Comments []*TaskComment `xorm:"-" json:"comments"`
+	Comments    []*TaskComment   `xorm:"-" json:"comments"`
+	TimeEntries []*TaskTimeEntry `xorm:"-" json:"time_entries"`
+	Activity    []*TaskActivity  `xorm:"-" json:"activity"`
 }
 
 // TableName returns the table name for tasks
@@ -176,6 +198,13 @@ type taskOptions struct {
 	filters            []*taskFilter
 	filterConcat       taskFilterConcatinator
 	filterIncludeNulls bool
+	// A parsed filter expression, applied in addition to the other filters
+	filterQuery taskFilterQueryNode
+
+	// The view the tasks are shown in, if any
+	projectView *ProjectView
+	// The filters of the view, applied in addition to the other filters
+	projectViewFilter *taskOptions
 }
 
 // ReadAll is a dummy function to still have that endpoint documented
@@ -187,15 +216,17 @@ type taskOptions struct {
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
 // @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parameters, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parameters, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `story_points`, `time_estimate`, `uid`, `created`, `updated`. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
 // @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
 // @Param filter_value query string false "The value to filter for."
 // @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like` and `in`. `in` expects comma-separated values in `filter_value`. Defaults to `equals`"
 // @Param filter_concat query string false "The concatinator to use for filters. Available values are `and` or `or`. Defaults to `or`."
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
+// @Param filter query string false "A filter expression like `done = false && (priority >= 3 || labels in 4, 5)`. Supports parentheses, `&&`, `||`, `!` for negation and the comparators `=`, `!=`, `>`, `>=`, `<`, `<=`, `like` and `in`. Applied in addition to all other filters."
 // @Security JWTKeyAuth
 // @Success 200 {array} models.Task "The tasks"
+// @Failure 400 {object} web.HTTPError "The filter expression is invalid."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/all [get]
 func (t *Task) ReadAll(_ *xorm.Session, _ web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, totalItems int64, err error) {
@@ -257,6 +288,59 @@ func getFilterCondForSeparateTable(table string, concat taskFilterConcatinator,
 	)
 }
 
+// getTaskSearchCond returns the condition for all tasks matching the search and an order by clause which sorts
+// them by their relevance. If the search backend searches directly in the database, its condition is used as part
+// of the task query so that filters, sorting and pagination are applied to all matching tasks in the database.
+// Otherwise the backend is asked for the ids of all matching tasks.
+func getTaskSearchCond(s *xorm.Session, a web.Auth, query string, projectIDs []int64, hasFavoritesProject bool) (cond builder.Cond, orderby string, orderArgs []interface{}, err error) {
+	cond, relevance, ok, err := search.SearchCond(query)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if ok {
+		orderby, orderArgs, err = builder.ToSQL(relevance)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return cond, orderby + " DESC", orderArgs, nil
+	}
+
+	// Favorite tasks can be in any project the user has access to
+	searchProjectIDs := projectIDs
+	if hasFavoritesProject {
+		searchProjectIDs, err = getAccessibleProjectIDs(s, a)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	// The search backends search all projects when no project is passed
+	searchResultIDs := []int64{}
+	if len(searchProjectIDs) > 0 {
+		searchResultIDs, err = search.Search(s, query, searchProjectIDs)
+		if err != nil {
+			return nil, "", nil, err
+		}
+	}
+
+	if len(searchResultIDs) > 0 {
+		orderby = getSearchRelevanceOrderBy(searchResultIDs)
+	}
+
+	return builder.In("id", searchResultIDs), orderby, nil, nil
+}
+
+// getSearchRelevanceOrderBy returns an order by clause which sorts tasks in the order of the search results
+func getSearchRelevanceOrderBy(taskIDs []int64) string {
+	orderby := strings.Builder{}
+	orderby.WriteString("CASE id")
+	for i, id := range taskIDs {
+		orderby.WriteString(" WHEN " + strconv.FormatInt(id, 10) + " THEN " + strconv.Itoa(i))
+	}
+	orderby.WriteString(" ELSE " + strconv.Itoa(len(taskIDs)) + " END ASC")
+	return orderby.String()
+}
+
 func getTaskIndexFromSearchString(s string) (index int64) {
 	re := regexp.MustCompile("#([0-9]+)")
 	in := re.FindString(s)
@@ -266,6 +350,26 @@ func getTaskIndexFromSearchString(s string) (index int64) {
 	return
 }
 
+// getAccessibleProjectIDs returns the ids of all projects the user has access to, including archived ones.
+func getAccessibleProjectIDs(s *xorm.Session, a web.Auth) (projectIDs []int64, err error) {
+	projects, _, _, err := getRawProjectsForUser(s, &projectOptions{
+		user:        &user.User{ID: a.GetID()},
+		page:        -1,
+		getArchived: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	projectIDs = make([]int64, 0, len(projects))
+	for _, p := range projects {
+		if p.ID > 0 {
+			projectIDs = append(projectIDs, p.ID)
+		}
+	}
+	return
+}
+
 //nolint:gocyclo
 func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, opts *taskOptions) (tasks []*Task, resultCount int, totalItems int64, err error) {
 
@@ -274,11 +378,6 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 		return nil, 0, 0, nil
 	}
 
-	// Set the default concatinator of filter variables to or if none was provided
-	if opts.filterConcat == "" {
-		opts.filterConcat = filterConcatOr
-	}
-
 	// Get all project IDs and get the tasks
 	var projectIDs []int64
 	var hasFavoritesProject bool
@@ -290,6 +389,23 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 		projectIDs = append(projectIDs, l.ID)
 	}
 
+	// Filters and sorting by custom fields need to know the type of their field
+	customFieldProjectIDs := projectIDs
+	if hasFavoritesProject {
+		// Favorite tasks can be in any project the user has access to
+		customFieldProjectIDs, err = getAccessibleProjectIDs(s, a)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	err = opts.resolveCustomFields(s, customFieldProjectIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// Search results are ordered by their relevance if nothing else was requested
+	sortByRelevance := len(opts.sortby) == 0 && opts.search != ""
+
 	// Add the id parameter as the last parameter to sortby by default, but only if it is not already passed as the last parameter.
 	if len(opts.sortby) == 0 ||
 		len(opts.sortby) > 0 && opts.sortby[len(opts.sortby)-1].sortBy != taskPropertyID {
@@ -312,11 +428,22 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 		// Mysql sorts columns with null values before ones without null value.
 		// Because it does not have support for NULLS FIRST or NULLS LAST we work around this by
 		// first sorting for null (or not null) values and then the order we actually want to.
+		sortColumn := "`" + param.sortBy + "`"
+		// Views which keep their own task positions need to get them from a separate table
+		if (param.sortBy == taskPropertyPosition || param.sortBy == taskPropertyKanbanPosition) &&
+			opts.projectView != nil && opts.projectView.usesOwnTaskData() {
+			sortColumn = "(SELECT task_positions.position FROM task_positions WHERE task_positions.task_id = tasks.id AND task_positions.project_view_id = " +
+				strconv.FormatInt(opts.projectView.ID, 10) + ")"
+		}
+		if param.customField != nil {
+			sortColumn = getCustomFieldSortColumn(param.customField)
+		}
+
 		if db.Type() == schemas.MYSQL {
-			orderby += "`" + param.sortBy + "` IS NULL, "
+			orderby += sortColumn + " IS NULL, "
 		}
 
-		orderby += "`" + param.sortBy + "` " + param.orderBy.String()
+		orderby += sortColumn + " " + param.orderBy.String()
 
 		// Postgres and sqlite allow us to control how columns with null values are sorted.
 		// To make that consistent with the sort order we have and other dbms, we're adding a separate clause here.
@@ -329,20 +456,142 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 		}
 	}
 
+	filterCond, err := opts.getFilterCond(opts.projectView)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// The filter of the view is applied in addition to the filters of the request
+	if opts.projectViewFilter != nil {
+		viewFilterCond, err := opts.projectViewFilter.getFilterCond(opts.projectView)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		filterCond = builder.And(filterCond, viewFilterCond)
+	}
+
+	// Then return all tasks for that projects
+	var where builder.Cond
+
+	var relevanceOrderBy string
+	var relevanceArgs []interface{}
+	if opts.search != "" {
+		where, relevanceOrderBy, relevanceArgs, err = getTaskSearchCond(s, a, opts.search, projectIDs, hasFavoritesProject)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		searchIndex := getTaskIndexFromSearchString(opts.search)
+		if searchIndex > 0 {
+			where = builder.Or(where, builder.Eq{"`index`": searchIndex})
+		}
+
+		if !sortByRelevance {
+			relevanceOrderBy = ""
+		}
+	}
+
+	var projectIDCond builder.Cond
+	var favoritesCond builder.Cond
+	if len(projectIDs) > 0 {
+		projectIDCond = builder.In("project_id", projectIDs)
+	}
+
+	if hasFavoritesProject {
+		// All favorite tasks for that user
+		favCond := builder.
+			Select("entity_id").
+			From("favorites").
+			Where(
+				builder.And(
+					builder.Eq{"user_id": a.GetID()},
+					builder.Eq{"kind": FavoriteKindTask},
+				))
+
+		// Favorite tasks of projects in the trash should not show up
+		favoritesCond = builder.And(
+			builder.In("id", favCond),
+			builder.In("project_id", builder.Select("id").From("projects").Where(builder.IsNull{"deleted"})),
+		)
+	}
+
+	limit, start := getLimitFromPageIndex(opts.page, opts.perPage)
+	cond := builder.And(builder.Or(projectIDCond, favoritesCond), where, filterCond)
+
+	query := s.Where(cond)
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	if relevanceOrderBy != "" {
+		query = query.OrderBy(relevanceOrderBy, relevanceArgs...)
+	}
+
+	tasks = []*Task{}
+	err = query.OrderBy(orderby).Find(&tasks)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	queryCount := s.Where(cond)
+	totalItems, err = queryCount.
+		Count(&Task{})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return tasks, len(tasks), totalItems, nil
+}
+
+// getFilterCond returns the db condition for all filters of the options, including a filter expression.
+func (opts *taskOptions) getFilterCond(view *ProjectView) (filterCond builder.Cond, err error) {
+	// Set the default concatinator of filter variables to or if none was provided
+	if opts.filterConcat == "" {
+		opts.filterConcat = filterConcatOr
+	}
+
+	filterCond, err = convertFiltersToDBFilterCond(opts.filters, opts.filterIncludeNulls, opts.filterConcat, view)
+	if err != nil || opts.filterQuery == nil {
+		return filterCond, err
+	}
+
+	queryCond, err := opts.filterQuery.toDBCond(opts.filterIncludeNulls, view)
+	if err != nil {
+		return nil, err
+	}
+
+	return builder.And(filterCond, queryCond), nil
+}
+
+// convertFiltersToDBFilterCond returns the db condition for a set of task filters.
+// If the filters are used with a view which keeps its own kanban buckets, bucket filters are checked against the buckets of that view.
+//
+//nolint:gocyclo
+func convertFiltersToDBFilterCond(rawFilters []*taskFilter, includeNulls bool, concat taskFilterConcatinator, view *ProjectView) (filterCond builder.Cond, err error) {
 	// Some filters need a special treatment since they are in a separate table
 	reminderFilters := []builder.Cond{}
 	assigneeFilters := []builder.Cond{}
 	labelFilters := []builder.Cond{}
 	projectFilters := []builder.Cond{}
+	bucketFilters := []builder.Cond{}
 
-	var filters = make([]builder.Cond, 0, len(opts.filters))
+	var filters = make([]builder.Cond, 0, len(rawFilters))
 	// To still find tasks with nil values, we exclude 0s when comparing with >/< values.
-	for _, f := range opts.filters {
+	for _, f := range rawFilters {
+		if f.customField != nil {
+			filter, err := getCustomFieldFilterCond(f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+
 		if f.field == "reminders" {
 			f.field = "reminder" // This is the name in the db
-			filter, err := getFilterCond(f, opts.filterIncludeNulls)
+			filter, err := getFilterCond(f, includeNulls)
 			if err != nil {
-				return nil, 0, 0, err
+				return nil, err
 			}
 			reminderFilters = append(reminderFilters, filter)
 			continue
@@ -350,12 +599,12 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 
 		if f.field == "assignees" {
 			if f.comparator == taskFilterComparatorLike {
-				return nil, 0, 0, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+				return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
 			}
 			f.field = "username"
-			filter, err := getFilterCond(f, opts.filterIncludeNulls)
+			filter, err := getFilterCond(f, includeNulls)
 			if err != nil {
-				return nil, 0, 0, err
+				return nil, err
 			}
 			assigneeFilters = append(assigneeFilters, filter)
 			continue
@@ -363,9 +612,9 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 
 		if f.field == "labels" || f.field == "label_id" {
 			f.field = "label_id"
-			filter, err := getFilterCond(f, opts.filterIncludeNulls)
+			filter, err := getFilterCond(f, includeNulls)
 			if err != nil {
-				return nil, 0, 0, err
+				return nil, err
 			}
 			labelFilters = append(labelFilters, filter)
 			continue
@@ -373,55 +622,32 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 
 		if f.field == "parent_project" || f.field == "parent_project_id" {
 			f.field = "parent_project_id"
-			filter, err := getFilterCond(f, opts.filterIncludeNulls)
+			filter, err := getFilterCond(f, includeNulls)
 			if err != nil {
-				return nil, 0, 0, err
+				return nil, err
 			}
 			projectFilters = append(projectFilters, filter)
 			continue
 		}
 
-		filter, err := getFilterCond(f, opts.filterIncludeNulls)
-		if err != nil {
-			return nil, 0, 0, err
+		if f.field == taskPropertyBucketID && view != nil && view.usesOwnTaskData() {
+			filter, err := getFilterCond(f, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			bucketFilters = append(bucketFilters, filter)
+			continue
 		}
-		filters = append(filters, filter)
-	}
 
-	// Then return all tasks for that projects
-	var where builder.Cond
-
-	if opts.search != "" {
-		where = db.ILIKE("title", opts.search)
-
-		searchIndex := getTaskIndexFromSearchString(opts.search)
-		if searchIndex > 0 {
-			where = builder.Or(where, builder.Eq{"`index`": searchIndex})
+		filter, err := getFilterCond(f, includeNulls)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	var projectIDCond builder.Cond
-	var favoritesCond builder.Cond
-	if len(projectIDs) > 0 {
-		projectIDCond = builder.In("project_id", projectIDs)
-	}
-
-	if hasFavoritesProject {
-		// All favorite tasks for that user
-		favCond := builder.
-			Select("entity_id").
-			From("favorites").
-			Where(
-				builder.And(
-					builder.Eq{"user_id": a.GetID()},
-					builder.Eq{"kind": FavoriteKindTask},
-				))
-
-		favoritesCond = builder.In("id", favCond)
+		filters = append(filters, filter)
 	}
 
 	if len(reminderFilters) > 0 {
-		filters = append(filters, getFilterCondForSeparateTable("task_reminders", opts.filterConcat, reminderFilters))
+		filters = append(filters, getFilterCondForSeparateTable("task_reminders", concat, reminderFilters))
 	}
 
 	if len(assigneeFilters) > 0 {
@@ -431,19 +657,23 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 					From("users").
 					Where(builder.Or(assigneeFilters...)),
 			)}
-		filters = append(filters, getFilterCondForSeparateTable("task_assignees", opts.filterConcat, assigneeFilter))
+		filters = append(filters, getFilterCondForSeparateTable("task_assignees", concat, assigneeFilter))
 	}
 
 	if len(labelFilters) > 0 {
-		filters = append(filters, getFilterCondForSeparateTable("label_tasks", opts.filterConcat, labelFilters))
+		filters = append(filters, getFilterCondForSeparateTable("label_tasks", concat, labelFilters))
+	}
+
+	if len(bucketFilters) > 0 {
+		filters = append(filters, getFilterCondForSeparateTable("task_buckets", concat, bucketFilters))
 	}
 
 	if len(projectFilters) > 0 {
 		var filtercond builder.Cond
-		if opts.filterConcat == filterConcatOr {
+		if concat == filterConcatOr {
 			filtercond = builder.Or(projectFilters...)
 		}
-		if opts.filterConcat == filterConcatAnd {
+		if concat == filterConcatAnd {
 			filtercond = builder.And(projectFilters...)
 		}
 
@@ -457,38 +687,16 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 		filters = append(filters, cond)
 	}
 
-	var filterCond builder.Cond
 	if len(filters) > 0 {
-		if opts.filterConcat == filterConcatOr {
+		if concat == filterConcatOr {
 			filterCond = builder.Or(filters...)
 		}
-		if opts.filterConcat == filterConcatAnd {
+		if concat == filterConcatAnd {
 			filterCond = builder.And(filters...)
 		}
 	}
 
-	limit, start := getLimitFromPageIndex(opts.page, opts.perPage)
-	cond := builder.And(builder.Or(projectIDCond, favoritesCond), where, filterCond)
-
-	query := s.Where(cond)
-	if limit > 0 {
-		query = query.Limit(limit, start)
-	}
-
-	tasks = []*Task{}
-	err = query.OrderBy(orderby).Find(&tasks)
-	if err != nil {
-		return nil, 0, 0, err
-	}
-
-	queryCount := s.Where(cond)
-	totalItems, err = queryCount.
-		Count(&Task{})
-	if err != nil {
-		return nil, 0, 0, err
-	}
-
-	return tasks, len(tasks), totalItems, nil
+	return filterCond, nil
 }
 
 func getTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, opts *taskOptions) (tasks []*Task, resultCount int, totalItems int64, err error) {
@@ -731,6 +939,16 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 		return
 	}
 
+	err = addCustomFieldValuesToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	err = addReactionsToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
 	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
 	if err != nil {
 		return
@@ -746,6 +964,11 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 		return err
 	}
 
+	timeSpent, err := getTimeSpentForTasks(s, taskIDs)
+	if err != nil {
+		return err
+	}
+
 	// Get all identifiers
 	projects, err := GetProjectsByIDs(s, projectIDs)
 	if err != nil {
@@ -773,6 +996,8 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 		task.setIdentifier(projects[task.ProjectID])
 
 		task.IsFavorite = taskFavorites[task.ID]
+
+		task.TimeSpent = timeSpent[task.ID]
 	}
 
 	// Get all related tasks
@@ -808,11 +1033,17 @@ func checkBucketLimit(s *xorm.Session, t *Task, bucket *Bucket) (err error) {
 }
 
 // Contains all the task logic to figure out what bucket to use for this task.
+// The bucket stored with the task is the one of the default kanban view of the project.
 func setTaskBucket(s *xorm.Session, task *Task, originalTask *Task, doCheckBucketLimit bool) (targetBucket *Bucket, err error) {
+	viewID, err := getDefaultKanbanViewID(s, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Make sure we have a bucket
 	var bucket *Bucket
 	if task.Done && originalTask != nil && !originalTask.Done {
-		bucket, err := getDoneBucketForProject(s, task.ProjectID)
+		bucket, err := getDoneBucketForView(s, viewID)
 		if err != nil {
 			return nil, err
 		}
@@ -827,7 +1058,7 @@ func setTaskBucket(s *xorm.Session, task *Task, originalTask *Task, doCheckBucke
 
 	// Either no bucket was provided or the task was moved between projects
 	if task.BucketID == 0 || (originalTask != nil && task.ProjectID != 0 && originalTask.ProjectID != task.ProjectID) {
-		bucket, err = getDefaultBucket(s, task.ProjectID)
+		bucket, err = getDefaultBucket(s, viewID)
 		if err != nil {
 			return
 		}
@@ -872,7 +1103,9 @@ func calculateDefaultPosition(entityID int64, position float64) float64 {
 
 func getNextTaskIndex(s *xorm.Session, projectID int64) (nextIndex int64, err error) {
 	latestTask := &Task{}
+	// Tasks in the trash keep their index and get it back when they are restored
 	_, err = s.
+		Unscoped().
 		Where("project_id = ?", projectID).
 		OrderBy("`index` desc").
 		Get(latestTask)
@@ -910,6 +1143,14 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		return ErrTaskCannotBeEmpty{}
 	}
 
+	if err := t.normalizeRepeatRule(); err != nil {
+		return err
+	}
+
+	if err := t.validateEstimates(); err != nil {
+		return err
+	}
+
 	// Check if the project exists
 	l, err := GetProjectSimpleByID(s, t.ProjectID)
 	if err != nil {
@@ -948,6 +1189,11 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 
 	t.CreatedBy = createdBy
 
+	err = addTaskToKanbanViewsWithOwnBuckets(s, t)
+	if err != nil {
+		return err
+	}
+
 	// Update the assignees
 	if updateAssignees {
 		if err := t.updateTaskAssignees(s, t.Assignees, a); err != nil {
@@ -968,6 +1214,11 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		}
 	}
 
+	err = recordTaskSyncChange(s, t)
+	if err != nil {
+		return err
+	}
+
 	err = events.Dispatch(&TaskCreatedEvent{
 		Task: t,
 		Doer: createdBy,
@@ -1008,6 +1259,21 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		t.ProjectID = ot.ProjectID
 	}
 
+	if err := t.normalizeRepeatRule(); err != nil {
+		return err
+	}
+
+	if err := t.validateEstimates(); err != nil {
+		return err
+	}
+
+	wasDone := ot.Done
+	movedBetweenProjects := ot.ProjectID != t.ProjectID
+	oldProjectID := ot.ProjectID
+	oldUID := ot.UID
+	oldActivityValues := ot.activityValues()
+	oldBucketID := ot.BucketID
+
 	// Get the stored reminders
 	reminders, err := getRemindersForTasks(s, []int64{t.ID})
 	if err != nil {
@@ -1030,13 +1296,23 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 
 	// If the task was moved into the done bucket and the task has a repeating cycle we should not update
 	// the bucket.
-	if targetBucket.IsDoneBucket && t.RepeatAfter > 0 {
+	if targetBucket.IsDoneBucket && (t.RepeatAfter > 0 || t.RepeatRule != "") {
 		t.Done = true // This will trigger the correct re-scheduling of the task (happening in updateDone later)
 		t.BucketID = ot.BucketID
 	}
 
+	if t.Done && !wasDone {
+		err = checkTaskIsNotBlocked(s, t)
+		if err != nil {
+			return err
+		}
+	}
+
 	// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
-	updateDone(&ot, t)
+	err = updateDone(&ot, t)
+	if err != nil {
+		return err
+	}
 
 	// Update the assignees
 	if err := ot.updateTaskAssignees(s, t.Assignees, a); err != nil {
@@ -1061,10 +1337,13 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		"hex_color",
 		"done_at",
 		"percent_done",
+		"story_points",
+		"time_estimate",
 		"project_id",
 		"bucket_id",
 		"position",
 		"repeat_mode",
+		"repeat_rule",
 		"kanban_position",
 		"cover_image_attachment_id",
 	}
@@ -1175,6 +1454,13 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if t.PercentDone == 0 {
 		ot.PercentDone = 0
 	}
+	// Estimates
+	if t.StoryPoints == 0 {
+		ot.StoryPoints = 0
+	}
+	if t.TimeEstimate == 0 {
+		ot.TimeEstimate = 0
+	}
 	// Position
 	if t.Position == 0 {
 		ot.Position = 0
@@ -1186,6 +1472,10 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if t.RepeatMode == TaskRepeatModeDefault {
 		ot.RepeatMode = TaskRepeatModeDefault
 	}
+	// Repeat rule
+	if t.RepeatRule == "" {
+		ot.RepeatRule = ""
+	}
 	// Is Favorite
 	if !t.IsFavorite {
 		ot.IsFavorite = false
@@ -1203,6 +1493,18 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	err = addTaskActivitiesForChangedFields(s, t.ID, a, oldActivityValues, t.activityValues())
+	if err != nil {
+		return err
+	}
+
+	if oldBucketID != t.BucketID {
+		err = addTaskBucketActivity(s, t.ID, a, oldBucketID, t.BucketID)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Update all positions if the newly saved position is < 0.1
 	if ot.Position < 0.1 {
 		err = recalculateTaskPositions(s, t.ProjectID)
@@ -1228,6 +1530,36 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	t.Position = nt.Position
 	t.KanbanPosition = nt.KanbanPosition
 
+	// Views which keep their own data can't keep it for a task in another project
+	if movedBetweenProjects {
+		err = removeTaskFromProjectViews(s, t.ID)
+		if err != nil {
+			return err
+		}
+		err = addTaskToKanbanViewsWithOwnBuckets(s, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	if t.Done && !wasDone {
+		err = moveTaskToDoneBuckets(s, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	if movedBetweenProjects {
+		err = recordTaskSyncDeletion(s, oldProjectID, &Task{ID: t.ID, UID: oldUID})
+		if err != nil {
+			return err
+		}
+	}
+	err = recordTaskSyncChange(s, t)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	err = events.Dispatch(&TaskUpdatedEvent{
 		Task: t,
@@ -1448,19 +1780,144 @@ func setTaskDatesFromCurrentDateRepeat(oldTask, newTask *Task) {
 	newTask.Done = false
 }
 
+// normalizeRepeatRule removes an optional "RRULE:" prefix from the repeat rule and checks if it is valid.
+func (t *Task) normalizeRepeatRule() error {
+	t.RepeatRule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(t.RepeatRule)), "RRULE:")
+	if t.RepeatRule == "" {
+		return nil
+	}
+
+	_, err := rrule.Parse(t.RepeatRule, config.GetTimeZone())
+	if err != nil {
+		return ErrInvalidRepeatRule{Rule: t.RepeatRule, Message: err.Error()}
+	}
+	return nil
+}
+
+func (t *Task) validateEstimates() error {
+	if t.StoryPoints < 0 || t.TimeEstimate < 0 {
+		return ErrInvalidTaskEstimate{TaskID: t.ID}
+	}
+	return nil
+}
+
+func setTaskDatesRepeatRule(oldTask, newTask *Task) error {
+	rule, err := rrule.Parse(oldTask.RepeatRule, config.GetTimeZone())
+	if err != nil {
+		// Invalid rules are rejected when saving the task, so this should not happen
+		log.Errorf("Could not parse repeat rule %s of task %d: %s", oldTask.RepeatRule, oldTask.ID, err)
+		return nil
+	}
+
+	// The current occurrence is the last one
+	if rule.Count == 1 {
+		return nil
+	}
+
+	// Current time in an extra variable to base all calculations on the same time
+	now := time.Now()
+
+	// The rule starts at the due date, falling back to the start or end date if the task has none.
+	// Tasks without any dates are only set as undone.
+	reference := oldTask.DueDate
+	if reference.IsZero() {
+		reference = oldTask.StartDate
+	}
+	if reference.IsZero() {
+		reference = oldTask.EndDate
+	}
+	if reference.IsZero() {
+		reference = now
+	}
+	reference = reference.In(config.GetTimeZone())
+
+	var next time.Time
+	count := rule.Count
+	if count == 0 {
+		after := now
+		if reference.After(now) {
+			after = reference
+		}
+		next, err = rule.Next(reference, after)
+	} else {
+		next, err = nextCountedOccurrence(rule, reference, now, &count)
+	}
+	if err != nil {
+		return ErrInvalidRepeatRule{Rule: oldTask.RepeatRule, Message: err.Error()}
+	}
+	if next.IsZero() {
+		// No occurrences left before UNTIL
+		return nil
+	}
+
+	// All dates keep their difference to the reference date
+	diff := next.Sub(reference)
+
+	if !oldTask.DueDate.IsZero() {
+		newTask.DueDate = oldTask.DueDate.Add(diff)
+	}
+
+	newTask.Reminders = oldTask.Reminders
+	for in, r := range oldTask.Reminders {
+		newTask.Reminders[in].Reminder = r.Reminder.Add(diff)
+	}
+
+	if !oldTask.StartDate.IsZero() {
+		newTask.StartDate = oldTask.StartDate.Add(diff)
+	}
+
+	if !oldTask.EndDate.IsZero() {
+		newTask.EndDate = oldTask.EndDate.Add(diff)
+	}
+
+	// Only update the count if the rule was not changed in the same update
+	if rule.Count > 0 && newTask.RepeatRule == oldTask.RepeatRule {
+		newTask.RepeatRule = rrule.ReplaceCount(oldTask.RepeatRule, count)
+	}
+
+	newTask.Done = false
+	return nil
+}
+
+// nextCountedOccurrence moves through the occurrences of a rule with a COUNT until one is after now.
+// Every skipped occurrence counts towards COUNT, count is set to the occurrences left afterwards.
+func nextCountedOccurrence(rule *rrule.Rule, reference, now time.Time, count *int) (next time.Time, err error) {
+	it := rule.Iterate(reference)
+	for {
+		next, err = it.Next()
+		if err != nil || next.IsZero() {
+			return next, err
+		}
+		// The reference itself is the occurrence which was just done
+		if !next.After(reference) {
+			continue
+		}
+		*count--
+		if next.After(now) || *count == 1 {
+			return next, nil
+		}
+	}
+}
+
 // This helper function updates the reminders, doneAt, start and end dates of the *old* task
 // and saves the new values in the newTask object.
 // We make a few assumptions here:
 //  1. Everything in oldTask is the truth - we figure out if we update anything at all if oldTask.RepeatAfter has a value > 0
+//     or oldTask.RepeatRule is set
 //  2. Because of 1., this functions should not be used to update values other than Done in the same go
-func updateDone(oldTask *Task, newTask *Task) {
+func updateDone(oldTask *Task, newTask *Task) error {
 	if !oldTask.Done && newTask.Done {
-		switch oldTask.RepeatMode {
-		case TaskRepeatModeMonth:
+		switch {
+		case oldTask.RepeatRule != "":
+			err := setTaskDatesRepeatRule(oldTask, newTask)
+			if err != nil {
+				return err
+			}
+		case oldTask.RepeatMode == TaskRepeatModeMonth:
 			setTaskDatesMonthRepeat(oldTask, newTask)
-		case TaskRepeatModeFromCurrentDate:
+		case oldTask.RepeatMode == TaskRepeatModeFromCurrentDate:
 			setTaskDatesFromCurrentDateRepeat(oldTask, newTask)
-		case TaskRepeatModeDefault:
+		case oldTask.RepeatMode == TaskRepeatModeDefault:
 			setTaskDatesDefault(oldTask, newTask)
 		}
 
@@ -1471,6 +1928,8 @@ func updateDone(oldTask *Task, newTask *Task) {
 	if oldTask.Done && !newTask.Done {
 		newTask.DoneAt = time.Time{}
 	}
+
+	return nil
 }
 
 // Deprecated: will be removed when ReminderDates are removed from Task.
@@ -1603,7 +2062,45 @@ func updateTaskLastUpdated(s *xorm.Session, task *Task) error {
 // @Router /tasks/{ID} [delete]
 func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 
-	if _, err = s.ID(t.ID).Delete(Task{}); err != nil {
+	// The uid and project are needed for the sync tombstone
+	task, err := GetTaskByIDSimple(s, t.ID)
+	if err != nil {
+		return err
+	}
+
+	// The task is only moved to the trash, everything related to it is kept until it is purged.
+	if _, err = s.ID(t.ID).Delete(&Task{}); err != nil {
+		return err
+	}
+
+	// Deleted tasks should not show up in any kanban bucket, they are added back when restoring them.
+	err = removeTaskFromProjectViews(s, t.ID)
+	if err != nil {
+		return
+	}
+
+	err = recordTaskSyncDeletion(s, task.ProjectID, &task)
+	if err != nil {
+		return
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskDeletedEvent{
+		Task: &task,
+		Doer: doer,
+	})
+	if err != nil {
+		return
+	}
+
+	err = updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
+	return
+}
+
+// purgeTask permanently removes a task and everything related to it, including the files of its attachments.
+func purgeTask(s *xorm.Session, t *Task) (err error) {
+
+	if _, err = s.Unscoped().ID(t.ID).Delete(&Task{}); err != nil {
 		return err
 	}
 
@@ -1612,8 +2109,10 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
-	// Delete Favorites
-	err = removeFromFavorite(s, t.ID, a, FavoriteKindTask)
+	// Delete Favorites of all users
+	_, err = s.
+		Where("entity_id = ? AND kind = ?", t.ID, FavoriteKindTask).
+		Delete(&Favorite{})
 	if err != nil {
 		return
 	}
@@ -1624,21 +2123,34 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
-	// Delete task attachments
+	// Delete task attachments and their files
 	attachments, err := getTaskAttachmentsByTaskIDs(s, []int64{t.ID})
 	if err != nil {
 		return err
 	}
 	for _, attachment := range attachments {
-		// Using the attachment delete method here because that takes care of removing all files properly
-		err = attachment.Delete(s, a)
-		if err != nil && !IsErrTaskAttachmentDoesNotExist(err) {
+		_, err = s.ID(attachment.ID).Delete(&TaskAttachment{})
+		if err != nil {
 			return err
 		}
+
+		if attachment.File == nil {
+			continue
+		}
+		err = attachment.File.Delete()
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
+			return err
+		}
+	}
+
+	// Delete all reactions on the task and its comments
+	err = deleteReactionsOfTask(s, t.ID)
+	if err != nil {
+		return
 	}
 
-	// Delete all comments
-	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskComment{})
+	// Delete all comments, including the ones already in the trash
+	_, err = s.Unscoped().Where("task_id = ?", t.ID).Delete(&TaskComment{})
 	if err != nil {
 		return
 	}
@@ -1655,17 +2167,26 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
-	doer, _ := user.GetFromAuth(a)
-	err = events.Dispatch(&TaskDeletedEvent{
-		Task: t,
-		Doer: doer,
-	})
+	// Delete all time entries
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskTimeEntry{})
 	if err != nil {
 		return
 	}
 
-	err = updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
-	return
+	// Delete all custom field values
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return
+	}
+
+	// Delete the activity log
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskActivity{})
+	if err != nil {
+		return
+	}
+
+	// Delete all buckets and positions of views
+	return removeTaskFromProjectViews(s, t.ID)
 }
 
 // ReadOne gets one task by its ID
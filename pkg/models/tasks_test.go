@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
@@ -512,6 +513,80 @@ func TestTask_Update(t *testing.T) {
 		err = s.Commit()
 		assert.NoError(t, err)
 	})
+	t.Run("repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:         1,
+			Title:      "test",
+			ProjectID:  1,
+			RepeatRule: "RRULE:freq=weekly;byday=mo,we",
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          1,
+			"repeat_rule": "FREQ=WEEKLY;BYDAY=MO,WE",
+		}, false)
+	})
+	t.Run("invalid repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:         1,
+			Title:      "test",
+			ProjectID:  1,
+			RepeatRule: "FREQ=DAILY;BYDAY=2TU",
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
+	t.Run("estimates", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:           1,
+			Title:        "test",
+			ProjectID:    1,
+			StoryPoints:  2.5,
+			TimeEstimate: 7200,
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":            1,
+			"story_points":  2.5,
+			"time_estimate": 7200,
+		}, false)
+	})
+	t.Run("negative estimate", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "test",
+			ProjectID:   1,
+			StoryPoints: -1,
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskEstimate(err))
+	})
 }
 
 func TestTask_Delete(t *testing.T) {
@@ -528,8 +603,9 @@ func TestTask_Delete(t *testing.T) {
 		err = s.Commit()
 		assert.NoError(t, err)
 
-		db.AssertMissing(t, "tasks", map[string]interface{}{
-			"id": 1,
+		db.AssertCount(t, "tasks", builder.And(builder.Eq{"id": 1}, builder.NotNull{"deleted"}), 1)
+		db.AssertMissing(t, "task_buckets", map[string]interface{}{
+			"task_id": 1,
 		})
 	})
 }
@@ -542,7 +618,8 @@ func TestUpdateDone(t *testing.T) {
 
 		oldTask := &Task{Done: false}
 		newTask := &Task{Done: true}
-		updateDone(oldTask, newTask)
+		err := updateDone(oldTask, newTask)
+		assert.NoError(t, err)
 		assert.NotEqual(t, time.Time{}, newTask.DoneAt)
 	})
 	t.Run("unmarking a task as done", func(t *testing.T) {
@@ -552,7 +629,8 @@ func TestUpdateDone(t *testing.T) {
 
 		oldTask := &Task{Done: true}
 		newTask := &Task{Done: false}
-		updateDone(oldTask, newTask)
+		err := updateDone(oldTask, newTask)
+		assert.NoError(t, err)
 		assert.Equal(t, time.Time{}, newTask.DoneAt)
 	})
 	t.Run("no interval set, default repeat mode", func(t *testing.T) {
@@ -567,7 +645,8 @@ func TestUpdateDone(t *testing.T) {
 			Done:    true,
 			DueDate: dueDate,
 		}
-		updateDone(oldTask, newTask)
+		err := updateDone(oldTask, newTask)
+		assert.NoError(t, err)
 
 		assert.Equal(t, dueDate.Unix(), newTask.DueDate.Unix())
 		assert.True(t, newTask.Done)
@@ -582,7 +661,8 @@ func TestUpdateDone(t *testing.T) {
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 
 			var expected = time.Unix(1550008600, 0)
 			for time.Since(expected) > 0 {
@@ -602,7 +682,8 @@ func TestUpdateDone(t *testing.T) {
 				Done:    true,
 				DueDate: time.Unix(1543626724, 0),
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 			assert.Equal(t, time.Unix(1543626724, 0), newTask.DueDate)
 			assert.False(t, newTask.Done)
 		})
@@ -622,7 +703,8 @@ func TestUpdateDone(t *testing.T) {
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 
 			var expected1 = time.Unix(1550008600, 0)
 			var expected2 = time.Unix(1555008600, 0)
@@ -647,7 +729,8 @@ func TestUpdateDone(t *testing.T) {
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 
 			var expected = time.Unix(1550008600, 0)
 			for time.Since(expected) > 0 {
@@ -666,7 +749,8 @@ func TestUpdateDone(t *testing.T) {
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 
 			var expected = time.Unix(1550008600, 0)
 			for time.Since(expected) > 0 {
@@ -685,7 +769,8 @@ func TestUpdateDone(t *testing.T) {
 			newTask := &Task{
 				Done: true,
 			}
-			updateDone(oldTask, newTask)
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
 			expected := oldTask.DueDate.Add(time.Duration(oldTask.RepeatAfter) * time.Second)
 			assert.Equal(t, expected, newTask.DueDate)
 			assert.False(t, newTask.Done)
@@ -701,7 +786,8 @@ func TestUpdateDone(t *testing.T) {
 				newTask := &Task{
 					Done: true,
 				}
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
 				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.DueDate.Unix())
@@ -723,7 +809,8 @@ func TestUpdateDone(t *testing.T) {
 				newTask := &Task{
 					Done: true,
 				}
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				diff := oldTask.Reminders[1].Reminder.Sub(oldTask.Reminders[0].Reminder)
 
@@ -743,7 +830,8 @@ func TestUpdateDone(t *testing.T) {
 				newTask := &Task{
 					Done: true,
 				}
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
 				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.StartDate.Unix())
@@ -759,7 +847,8 @@ func TestUpdateDone(t *testing.T) {
 				newTask := &Task{
 					Done: true,
 				}
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
 				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.EndDate.Unix())
@@ -776,7 +865,8 @@ func TestUpdateDone(t *testing.T) {
 				newTask := &Task{
 					Done: true,
 				}
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				diff := oldTask.EndDate.Sub(oldTask.StartDate)
 
@@ -798,7 +888,8 @@ func TestUpdateDone(t *testing.T) {
 				}
 				oldDueDate := oldTask.DueDate
 
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				assert.True(t, newTask.DueDate.After(oldDueDate))
 				assert.NotEqual(t, oldDueDate.Month(), newTask.DueDate.Month())
@@ -824,7 +915,8 @@ func TestUpdateDone(t *testing.T) {
 					oldReminders[i] = r.Reminder
 				}
 
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				assert.Len(t, newTask.Reminders, len(oldReminders))
 				for i, r := range newTask.Reminders {
@@ -844,7 +936,8 @@ func TestUpdateDone(t *testing.T) {
 				}
 				oldStartDate := oldTask.StartDate
 
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				assert.True(t, newTask.StartDate.After(oldStartDate))
 				assert.NotEqual(t, oldStartDate.Month(), newTask.StartDate.Month())
@@ -861,7 +954,8 @@ func TestUpdateDone(t *testing.T) {
 				}
 				oldEndDate := oldTask.EndDate
 
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				assert.True(t, newTask.EndDate.After(oldEndDate))
 				assert.NotEqual(t, oldEndDate.Month(), newTask.EndDate.Month())
@@ -881,7 +975,8 @@ func TestUpdateDone(t *testing.T) {
 				oldEndDate := oldTask.EndDate
 				oldDiff := oldTask.EndDate.Sub(oldTask.StartDate)
 
-				updateDone(oldTask, newTask)
+				err := updateDone(oldTask, newTask)
+				assert.NoError(t, err)
 
 				assert.True(t, newTask.StartDate.After(oldStartDate))
 				assert.NotEqual(t, oldStartDate.Month(), newTask.StartDate.Month())
@@ -892,6 +987,180 @@ func TestUpdateDone(t *testing.T) {
 			})
 		})
 	})
+	t.Run("repeat rule", func(t *testing.T) {
+		// 2100-06-01 is a tuesday
+		dueDate := time.Date(2100, 6, 1, 10, 0, 0, 0, config.GetTimeZone())
+
+		t.Run("every second week", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+				DueDate:    dueDate,
+				StartDate:  dueDate.Add(-time.Hour),
+				Reminders: []*TaskReminder{
+					{
+						Reminder: dueDate.Add(-24 * time.Hour),
+					},
+				},
+			}
+			newTask := &Task{
+				Done:       true,
+				RepeatRule: oldTask.RepeatRule,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.Equal(t, dueDate.AddDate(0, 0, 14), newTask.DueDate)
+			assert.Equal(t, dueDate.AddDate(0, 0, 14).Add(-time.Hour), newTask.StartDate)
+			assert.Equal(t, dueDate.AddDate(0, 0, 13), newTask.Reminders[0].Reminder)
+			assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", newTask.RepeatRule)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("weekdays only", func(t *testing.T) {
+			// 2100-06-04 is a friday
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+				DueDate:    dueDate.AddDate(0, 0, 3),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.Equal(t, dueDate.AddDate(0, 0, 6), newTask.DueDate)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("count", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;COUNT=3",
+				DueDate:    dueDate,
+			}
+			newTask := &Task{
+				Done:       true,
+				RepeatRule: oldTask.RepeatRule,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.Equal(t, dueDate.AddDate(0, 0, 1), newTask.DueDate)
+			assert.Equal(t, "FREQ=DAILY;COUNT=2", newTask.RepeatRule)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("last occurrence", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;COUNT=1",
+				DueDate:    dueDate,
+			}
+			newTask := &Task{
+				Done:       true,
+				RepeatRule: oldTask.RepeatRule,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.True(t, newTask.DueDate.IsZero())
+			assert.True(t, newTask.Done)
+		})
+		t.Run("until", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=WEEKLY;UNTIL=21000605T000000Z",
+				DueDate:    dueDate,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.True(t, newTask.DueDate.IsZero())
+			assert.True(t, newTask.Done)
+		})
+		t.Run("catch up with past due dates", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;COUNT=5",
+				DueDate:    time.Now().AddDate(0, 0, -2),
+			}
+			newTask := &Task{
+				Done:       true,
+				RepeatRule: oldTask.RepeatRule,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.True(t, newTask.DueDate.After(time.Now()))
+			assert.Equal(t, "FREQ=DAILY;COUNT=2", newTask.RepeatRule)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("catch up with a stale minutely rule", func(t *testing.T) {
+			due := time.Now().AddDate(-1, 0, 0).Truncate(time.Second)
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=MINUTELY;INTERVAL=15",
+				DueDate:    due,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.True(t, newTask.DueDate.After(time.Now()))
+			assert.True(t, newTask.DueDate.Before(time.Now().Add(15*time.Minute)))
+			assert.Zero(t, newTask.DueDate.Sub(due)%(15*time.Minute))
+			assert.False(t, newTask.Done)
+		})
+		t.Run("catch up with a stale hourly rule", func(t *testing.T) {
+			due := time.Now().AddDate(-20, 0, 0).Truncate(time.Second)
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=HOURLY",
+				DueDate:    due,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.True(t, newTask.DueDate.After(time.Now()))
+			assert.True(t, newTask.DueDate.Before(time.Now().Add(time.Hour)))
+			assert.False(t, newTask.Done)
+		})
+		t.Run("never matching", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+				DueDate:    dueDate,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.Error(t, err)
+			assert.True(t, IsErrInvalidRepeatRule(err))
+		})
+		t.Run("takes precedence over repeat after", func(t *testing.T) {
+			oldTask := &Task{
+				Done:        false,
+				RepeatAfter: 3600,
+				RepeatRule:  "FREQ=MONTHLY",
+				DueDate:     dueDate,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			err := updateDone(oldTask, newTask)
+			assert.NoError(t, err)
+
+			assert.Equal(t, dueDate.AddDate(0, 1, 0), newTask.DueDate)
+		})
+	})
 }
 
 func TestTask_ReadOne(t *testing.T) {
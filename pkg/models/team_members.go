@@ -35,6 +35,7 @@ import (
 // @Success 201 {object} models.TeamMember "The newly created member object"
 // @Failure 400 {object} web.HTTPError "Invalid member object provided."
 // @Failure 403 {object} web.HTTPError "The user does not have access to the team"
+// @Failure 412 {object} web.HTTPError "The members of the team are managed by an external auth provider."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /teams/{id}/members [put]
 func (tm *TeamMember) Create(s *xorm.Session, a web.Auth) (err error) {
@@ -44,6 +45,9 @@ func (tm *TeamMember) Create(s *xorm.Session, a web.Auth) (err error) {
 	if err != nil {
 		return err
 	}
+	if team.ExternalID != "" {
+		return ErrExternalTeamMembersCannotBeChanged{TeamID: team.ID}
+	}
 
 	// Check if the user exists
 	member, err := user2.GetUserByUsername(s, tm.Username)
@@ -86,10 +90,23 @@ func (tm *TeamMember) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Param id path int true "Team ID"
 // @Param userID path int true "User ID"
 // @Success 200 {object} models.Message "The user was successfully removed from the team."
+// @Failure 412 {object} web.HTTPError "The members of the team are managed by an external auth provider."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /teams/{id}/members/{userID} [delete]
 func (tm *TeamMember) Delete(s *xorm.Session, _ web.Auth) (err error) {
 
+	team := &Team{}
+	exists, err := s.ID(tm.TeamID).Cols("id", "external_id").Get(team)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return ErrTeamDoesNotExist{tm.TeamID}
+	}
+	if team.ExternalID != "" {
+		return ErrExternalTeamMembersCannotBeChanged{TeamID: team.ID}
+	}
+
 	total, err := s.Where("team_id = ?", tm.TeamID).Count(&TeamMember{})
 	if err != nil {
 		return
@@ -89,6 +89,22 @@ func TestTeamMember_Create(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, IsErrTeamDoesNotExist(err))
 	})
+	t.Run("external team", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(1).Cols("external_id", "issuer").Update(&Team{ExternalID: "developers", Issuer: "https://some.issuer"})
+		assert.NoError(t, err)
+
+		tm := &TeamMember{
+			TeamID:   1,
+			Username: "user3",
+		}
+		err = tm.Create(s, doer)
+		assert.Error(t, err)
+		assert.True(t, IsErrExternalTeamMembersCannotBeChanged(err))
+	})
 }
 
 func TestTeamMember_Delete(t *testing.T) {
@@ -111,6 +127,22 @@ func TestTeamMember_Delete(t *testing.T) {
 			"user_id": 1,
 		})
 	})
+	t.Run("external team", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(1).Cols("external_id", "issuer").Update(&Team{ExternalID: "developers", Issuer: "https://some.issuer"})
+		assert.NoError(t, err)
+
+		tm := &TeamMember{
+			TeamID:   1,
+			Username: "user1",
+		}
+		err = tm.Delete(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrExternalTeamMembersCannotBeChanged(err))
+	})
 }
 
 func TestTeamMember_Update(t *testing.T) {
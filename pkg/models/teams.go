@@ -38,6 +38,10 @@ type Team struct {
 	// The team's description.
 	Description string `xorm:"longtext null" json:"description"`
 	CreatedByID int64  `xorm:"bigint not null INDEX" json:"-"`
+	// The id of this team in an external auth provider like LDAP. Teams with an external id are synced when their members log in.
+	ExternalID string `xorm:"varchar(250) null INDEX" json:"external_id"`
+	// The auth provider which manages this team.
+	Issuer string `xorm:"varchar(250) null" json:"-"`
 
 	// The user who created this team.
 	CreatedBy *user.User `xorm:"-" json:"created_by"`
@@ -264,6 +268,9 @@ func (t *Team) Create(s *xorm.Session, a web.Auth) (err error) {
 
 	t.CreatedByID = doer.ID
 	t.CreatedBy = doer
+	// Only teams created through a sync can be managed externally
+	t.ExternalID = ""
+	t.Issuer = ""
 
 	_, err = s.Insert(t)
 	if err != nil {
@@ -344,7 +351,7 @@ func (t *Team) Update(s *xorm.Session, _ web.Auth) (err error) {
 		return
 	}
 
-	_, err = s.ID(t.ID).Update(t)
+	_, err = s.ID(t.ID).Omit("external_id", "issuer").Update(t)
 	if err != nil {
 		return
 	}
@@ -357,3 +364,89 @@ func (t *Team) Update(s *xorm.Session, _ web.Auth) (err error) {
 
 	return
 }
+
+// SyncExternalTeamsForUser makes the user a member of exactly the teams an external auth provider reported for them.
+// Teams are matched by the issuer and their external id and created if they don't exist yet.
+// Existing teams are renamed to the name the provider reported, so renames in the provider show up in Vikunja.
+// Memberships in teams created in Vikunja or by another issuer are not touched.
+func SyncExternalTeamsForUser(s *xorm.Session, u *user.User, issuer string, externalTeams []*Team) (err error) {
+	teamIDs := make([]int64, 0, len(externalTeams))
+	for _, et := range externalTeams {
+		team := &Team{}
+		exists, err := s.
+			Where("issuer = ? AND external_id = ?", issuer, et.ExternalID).
+			Get(team)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			team = &Team{
+				Name:        et.Name,
+				Description: et.Description,
+				ExternalID:  et.ExternalID,
+				Issuer:      issuer,
+				CreatedByID: u.ID,
+			}
+			_, err = s.Insert(team)
+			if err != nil {
+				return err
+			}
+
+			err = events.Dispatch(&TeamCreatedEvent{
+				Team: team,
+				Doer: u,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if exists && et.Name != "" && team.Name != et.Name {
+			team.Name = et.Name
+			_, err = s.ID(team.ID).Cols("name").Update(team)
+			if err != nil {
+				return err
+			}
+		}
+
+		teamIDs = append(teamIDs, team.ID)
+
+		isMember, err := s.
+			Where("team_id = ? AND user_id = ?", team.ID, u.ID).
+			Exist(&TeamMember{})
+		if err != nil {
+			return err
+		}
+		if isMember {
+			continue
+		}
+
+		_, err = s.Insert(&TeamMember{TeamID: team.ID, UserID: u.ID})
+		if err != nil {
+			return err
+		}
+
+		err = events.Dispatch(&TeamMemberAddedEvent{
+			Team:   team,
+			Member: u,
+			Doer:   u,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the user from all teams of the issuer they are not a member of anymore
+	var staleTeamsCond builder.Cond = builder.Eq{"issuer": issuer}
+	if len(teamIDs) > 0 {
+		staleTeamsCond = builder.And(staleTeamsCond, builder.NotIn("id", teamIDs))
+	}
+	_, err = s.
+		Where(builder.And(
+			builder.Eq{"user_id": u.ID},
+			builder.In("team_id", builder.Select("id").From("teams").Where(staleTeamsCond)),
+		)).
+		Delete(&TeamMember{})
+	return err
+}
@@ -23,6 +23,8 @@ import (
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/user"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
 )
 
 func TestTeam_Create(t *testing.T) {
@@ -196,6 +198,93 @@ func TestTeam_Delete(t *testing.T) {
 	})
 }
 
+func TestSyncExternalTeamsForUser(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("new teams", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := SyncExternalTeamsForUser(s, u, "ldap", []*Team{
+			{Name: "Developers", ExternalID: "cn=developers,dc=example,dc=com"},
+		})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		team := &Team{}
+		has, err := s.Where("external_id = ?", "cn=developers,dc=example,dc=com").Get(team)
+		require.NoError(t, err)
+		require.True(t, has)
+		assert.Equal(t, "Developers", team.Name)
+		assert.Equal(t, "ldap", team.Issuer)
+		db.AssertExists(t, "team_members", map[string]interface{}{
+			"team_id": team.ID,
+			"user_id": 1,
+		}, false)
+		// Teams created in Vikunja are not touched
+		db.AssertExists(t, "team_members", map[string]interface{}{
+			"team_id": 1,
+			"user_id": 1,
+		}, false)
+	})
+	t.Run("existing team", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := SyncExternalTeamsForUser(s, u, "ldap", []*Team{
+			{Name: "Developers", ExternalID: "cn=developers,dc=example,dc=com"},
+		})
+		require.NoError(t, err)
+		err = SyncExternalTeamsForUser(s, &user.User{ID: 2}, "ldap", []*Team{
+			{Name: "Renamed Developers", ExternalID: "cn=developers,dc=example,dc=com"},
+		})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		teams := []*Team{}
+		err = s.Where("external_id = ?", "cn=developers,dc=example,dc=com").Find(&teams)
+		require.NoError(t, err)
+		require.Len(t, teams, 1)
+		assert.Equal(t, "Renamed Developers", teams[0].Name)
+		db.AssertCount(t, "team_members", builder.Eq{"team_id": teams[0].ID}, 2)
+	})
+	t.Run("left group", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := SyncExternalTeamsForUser(s, u, "ldap", []*Team{
+			{Name: "Developers", ExternalID: "cn=developers,dc=example,dc=com"},
+		})
+		require.NoError(t, err)
+		err = SyncExternalTeamsForUser(s, u, "https://some.issuer", []*Team{
+			{Name: "Other", ExternalID: "other"},
+		})
+		require.NoError(t, err)
+		err = SyncExternalTeamsForUser(s, u, "ldap", []*Team{})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertCount(t, "team_members", builder.And(
+			builder.Eq{"user_id": 1},
+			builder.In("team_id", builder.Select("id").From("teams").Where(builder.Eq{"issuer": "ldap"})),
+		), 0)
+		db.AssertCount(t, "team_members", builder.And(
+			builder.Eq{"user_id": 1},
+			builder.In("team_id", builder.Select("id").From("teams").Where(builder.Eq{"issuer": "https://some.issuer"})),
+		), 1)
+		// The team itself stays
+		db.AssertExists(t, "teams", map[string]interface{}{
+			"external_id": "cn=developers,dc=example,dc=com",
+		}, false)
+	})
+}
+
 func TestIsErrInvalidRight(t *testing.T) {
 	assert.NoError(t, RightAdmin.isValid())
 	assert.NoError(t, RightRead.isValid())
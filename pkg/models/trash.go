@@ -0,0 +1,629 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+const (
+	TrashItemKindProject = "project"
+	TrashItemKindTask    = "task"
+	TrashItemKindComment = "comment"
+	TrashItemKindBucket  = "bucket"
+)
+
+// TrashItem represents a deleted project, task, comment or bucket which can be restored until it is purged.
+type TrashItem struct {
+	// The kind of the deleted item. Can be `project`, `task`, `comment` or `bucket`.
+	Kind string `json:"kind" param:"kind"`
+	// The id of the deleted item.
+	ID int64 `json:"id" param:"id"`
+	// The title of the deleted item. For comments this is the comment text.
+	Title string `json:"title"`
+	// The project the deleted item belongs to. For projects, this is the id of the project itself.
+	ProjectID int64 `json:"project_id"`
+	// The task a deleted comment belongs to.
+	TaskID int64 `json:"task_id,omitempty"`
+	// A timestamp when this item was moved to the trash.
+	Deleted time.Time `json:"deleted"`
+	// A timestamp after which this item will be removed permanently.
+	PurgeAt time.Time `json:"purge_at"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+func getTrashPurgeDate(deleted time.Time) time.Time {
+	return deleted.AddDate(0, 0, config.ServiceTrashRetentionDays.GetInt())
+}
+
+func newTrashItem(kind string, id int64, title string, projectID int64, deleted time.Time) *TrashItem {
+	return &TrashItem{
+		Kind:      kind,
+		ID:        id,
+		Title:     title,
+		ProjectID: projectID,
+		Deleted:   deleted,
+		PurgeAt:   getTrashPurgeDate(deleted),
+	}
+}
+
+// getTrashedProjectByID returns a project regardless of whether it is in the trash or not.
+func getTrashedProjectByID(s *xorm.Session, projectID int64) (project *Project, err error) {
+	project = &Project{}
+	exists, err := s.Unscoped().Where("id = ?", projectID).Get(project)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProjectDoesNotExist{ID: projectID}
+	}
+	return
+}
+
+// isAdminOfTrashedProject checks if the user has admin rights on a project, even if it is in the trash.
+func isAdminOfTrashedProject(s *xorm.Session, project *Project, a web.Auth) (bool, error) {
+	if project.isOwner(&user.User{ID: a.GetID()}) {
+		return true, nil
+	}
+
+	is, _, err := project.checkRight(s, a, RightAdmin)
+	if IsErrProjectDoesNotExist(err) {
+		// One of the parent projects is in the trash, only the owner can see this project then
+		return false, nil
+	}
+	return is, err
+}
+
+// getAdminProjectIDs returns the ids of all projects not in the trash the user has admin rights on.
+func getAdminProjectIDs(s *xorm.Session, u *user.User) (projectIDs []int64, err error) {
+	projects, _, _, err := getRawProjectsForUser(s, &projectOptions{
+		user:        u,
+		page:        -1,
+		getArchived: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	projectIDs = []int64{}
+	for _, p := range projects {
+		if p.ID < 0 {
+			continue
+		}
+
+		is, err := isAdminOfTrashedProject(s, p, u)
+		if err != nil {
+			return nil, err
+		}
+		if is {
+			projectIDs = append(projectIDs, p.ID)
+		}
+	}
+
+	return
+}
+
+func getTrashedProjectsForUser(s *xorm.Session, u *user.User, adminProjectIDs []int64) (items []*TrashItem, err error) {
+	var parentCond builder.Cond
+	if len(adminProjectIDs) > 0 {
+		parentCond = builder.In("parent_project_id", adminProjectIDs)
+	}
+
+	projects := []*Project{}
+	err = s.
+		Unscoped().
+		Where(builder.And(
+			builder.NotNull{"deleted"},
+			builder.Or(
+				builder.Eq{"owner_id": u.ID},
+				builder.In("id", builder.Select("project_id").From("users_projects").Where(builder.Eq{"user_id": u.ID})),
+				builder.In("id", builder.
+					Select("tl.project_id").
+					From("team_projects", "tl").
+					Join("INNER", "team_members tm", "tm.team_id = tl.team_id").
+					Where(builder.Eq{"tm.user_id": u.ID})),
+				parentCond,
+			),
+			// Child projects which were deleted together with their parent are restored with it
+			builder.Or(
+				builder.IsNull{"parent_project_id"},
+				builder.NotIn("parent_project_id", builder.
+					Select("id").
+					From("projects").
+					Where(builder.NotNull{"deleted"})),
+			),
+		)).
+		Find(&projects)
+	if err != nil {
+		return nil, err
+	}
+
+	items = []*TrashItem{}
+	for _, p := range projects {
+		is, err := isAdminOfTrashedProject(s, p, u)
+		if err != nil {
+			return nil, err
+		}
+		if is {
+			items = append(items, newTrashItem(TrashItemKindProject, p.ID, p.Title, p.ID, p.Deleted))
+		}
+	}
+
+	return
+}
+
+func getTrashedTasks(s *xorm.Session, projectIDs []int64) (items []*TrashItem, err error) {
+	tasks := []*Task{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL").
+		In("project_id", projectIDs).
+		Find(&tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	items = make([]*TrashItem, 0, len(tasks))
+	for _, t := range tasks {
+		items = append(items, newTrashItem(TrashItemKindTask, t.ID, t.Title, t.ProjectID, t.Deleted))
+	}
+	return
+}
+
+func getTrashedComments(s *xorm.Session, projectIDs []int64) (items []*TrashItem, err error) {
+	// Comments of deleted tasks are restored with their task
+	type commentWithProject struct {
+		TaskComment `xorm:"extends"`
+		ProjectID   int64
+	}
+	comments := []*commentWithProject{}
+	err = s.
+		Unscoped().
+		Select("task_comments.*, tasks.project_id").
+		Table("task_comments").
+		Join("INNER", "tasks", "tasks.id = task_comments.task_id").
+		Where(builder.And(
+			builder.NotNull{"task_comments.deleted"},
+			builder.IsNull{"tasks.deleted"},
+			builder.In("tasks.project_id", projectIDs),
+		)).
+		Find(&comments)
+	if err != nil {
+		return nil, err
+	}
+
+	items = make([]*TrashItem, 0, len(comments))
+	for _, c := range comments {
+		item := newTrashItem(TrashItemKindComment, c.ID, c.Comment, c.ProjectID, c.TaskComment.Deleted)
+		item.TaskID = c.TaskID
+		items = append(items, item)
+	}
+	return
+}
+
+func getTrashedBuckets(s *xorm.Session, projectIDs []int64) (items []*TrashItem, err error) {
+	type bucketWithProject struct {
+		Bucket    `xorm:"extends"`
+		ProjectID int64
+	}
+	buckets := []*bucketWithProject{}
+	err = s.
+		Unscoped().
+		Select("buckets.*, project_views.project_id").
+		Table("buckets").
+		Join("INNER", "project_views", "project_views.id = buckets.project_view_id").
+		Where(builder.And(
+			builder.NotNull{"buckets.deleted"},
+			builder.In("project_views.project_id", projectIDs),
+		)).
+		Find(&buckets)
+	if err != nil {
+		return nil, err
+	}
+
+	items = make([]*TrashItem, 0, len(buckets))
+	for _, b := range buckets {
+		items = append(items, newTrashItem(TrashItemKindBucket, b.Bucket.ID, b.Title, b.ProjectID, b.Bucket.Deleted))
+	}
+	return
+}
+
+// ReadAll returns all deleted items the user has admin rights on
+// @Summary Get all items in the trash
+// @Description Returns all deleted projects, tasks, comments and buckets the user has admin rights on, most recently deleted first. Items are removed permanently after the configured retention period.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} models.TrashItem "The deleted items"
+// @Failure 403 {object} web.HTTPError "Link shares cannot access the trash."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash [get]
+func (ti *TrashItem) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	u, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	projectIDs, err := getAdminProjectIDs(s, u)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	items, err := getTrashedProjectsForUser(s, u, projectIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if len(projectIDs) > 0 {
+		for _, getItems := range []func(*xorm.Session, []int64) ([]*TrashItem, error){
+			getTrashedTasks,
+			getTrashedComments,
+			getTrashedBuckets,
+		} {
+			kindItems, err := getItems(s, projectIDs)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			items = append(items, kindItems...)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Deleted.After(items[j].Deleted)
+	})
+
+	return items, len(items), int64(len(items)), nil
+}
+
+// getTrashItem loads a deleted item and the project it belongs to. It returns the project
+// even if that project is in the trash as well.
+func getTrashItem(s *xorm.Session, kind string, id int64) (item *TrashItem, project *Project, err error) {
+	item = &TrashItem{Kind: kind, ID: id}
+	notFound := &ErrTrashItemDoesNotExist{Kind: kind, ID: id}
+
+	switch kind {
+	case TrashItemKindProject:
+		p := &Project{}
+		exists, err := s.Unscoped().Where("id = ? AND deleted IS NOT NULL", id).Get(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return nil, nil, notFound
+		}
+		item = newTrashItem(kind, p.ID, p.Title, p.ID, p.Deleted)
+		return item, p, nil
+	case TrashItemKindTask:
+		t := &Task{}
+		exists, err := s.Unscoped().Where("id = ? AND deleted IS NOT NULL", id).Get(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return nil, nil, notFound
+		}
+		item = newTrashItem(kind, t.ID, t.Title, t.ProjectID, t.Deleted)
+	case TrashItemKindComment:
+		c := &TaskComment{}
+		exists, err := s.Unscoped().Where("id = ? AND deleted IS NOT NULL", id).Get(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return nil, nil, notFound
+		}
+		t := &Task{}
+		exists, err = s.Unscoped().Where("id = ?", c.TaskID).Get(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return nil, nil, notFound
+		}
+		item = newTrashItem(kind, c.ID, c.Comment, t.ProjectID, c.Deleted)
+		item.TaskID = c.TaskID
+	case TrashItemKindBucket:
+		b := &Bucket{}
+		exists, err := s.Unscoped().Where("id = ? AND deleted IS NOT NULL", id).Get(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return nil, nil, notFound
+		}
+		view, err := getProjectViewByID(s, b.ProjectViewID)
+		if err != nil {
+			return nil, nil, err
+		}
+		item = newTrashItem(kind, b.ID, b.Title, view.ProjectID, b.Deleted)
+	default:
+		return nil, nil, &ErrInvalidTrashItemKind{Kind: kind}
+	}
+
+	project, err = getTrashedProjectByID(s, item.ProjectID)
+	return item, project, err
+}
+
+func restoreFromTrash(s *xorm.Session, table string, id int64) (err error) {
+	_, err = s.
+		Unscoped().
+		Table(table).
+		Where("id = ?", id).
+		Update(map[string]interface{}{"deleted": nil})
+	return
+}
+
+// restoreProject restores a project and all of its child projects which were moved to the trash together with it.
+func restoreProject(s *xorm.Session, project *Project) (err error) {
+	projectIDs := []int64{project.ID}
+	parentIDs := []int64{project.ID}
+
+	for len(parentIDs) > 0 {
+		children := []*Project{}
+		err = s.
+			Unscoped().
+			Where("deleted IS NOT NULL").
+			In("parent_project_id", parentIDs).
+			Find(&children)
+		if err != nil {
+			return err
+		}
+
+		parentIDs = []int64{}
+		for _, child := range children {
+			if !child.Deleted.Equal(project.Deleted) {
+				continue
+			}
+			projectIDs = append(projectIDs, child.ID)
+			parentIDs = append(parentIDs, child.ID)
+		}
+	}
+
+	_, err = s.
+		Unscoped().
+		Table("projects").
+		In("id", projectIDs).
+		Update(map[string]interface{}{"deleted": nil})
+	return
+}
+
+func restoreTask(s *xorm.Session, taskID int64, a web.Auth) (err error) {
+	err = restoreFromTrash(s, "tasks", taskID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		return err
+	}
+
+	// The bucket of the task might have been deleted in the meantime
+	_, err = getBucketByID(s, task.BucketID)
+	if err != nil && !IsErrBucketDoesNotExist(err) {
+		return err
+	}
+	if err != nil {
+		task.BucketID = 0
+		_, err = setTaskBucket(s, &task, nil, false)
+		if err != nil {
+			return err
+		}
+		_, err = s.ID(task.ID).Cols("bucket_id").NoAutoTime().Update(&task)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = addTaskToKanbanViewsWithOwnBuckets(s, &task)
+	if err != nil {
+		return err
+	}
+
+	err = recordTaskSyncChange(s, &task)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskRestoredEvent{
+		Task: &task,
+		Doer: doer,
+	})
+	if err != nil {
+		return err
+	}
+
+	return updateProjectLastUpdated(s, &Project{ID: task.ProjectID})
+}
+
+// Create restores an item from the trash
+// @Summary Restore an item from the trash
+// @Description Restores a deleted project, task, comment or bucket. Items whose project or task is in the trash as well can only be restored after restoring that first. Child projects deleted together with a project are restored with it. Replies of a deleted comment stay with the comment they were moved to when it was deleted. Tasks of a deleted bucket stay in the default bucket when the bucket is restored.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Param kind path string true "The kind of the item. Can be `project`, `task`, `comment` or `bucket`."
+// @Param id path int true "The id of the item"
+// @Success 201 {object} models.TrashItem "The restored item."
+// @Failure 400 {object} web.HTTPError "Invalid trash item kind."
+// @Failure 403 {object} web.HTTPError "The user does not have admin rights on the project of this item."
+// @Failure 404 {object} web.HTTPError "The item is not in the trash."
+// @Failure 412 {object} web.HTTPError "The project or task of this item is in the trash as well."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash/{kind}/{id}/restore [post]
+func (ti *TrashItem) Create(s *xorm.Session, a web.Auth) (err error) {
+	item, project, err := getTrashItem(s, ti.Kind, ti.ID)
+	if err != nil {
+		return err
+	}
+
+	switch ti.Kind {
+	case TrashItemKindProject:
+		if project.ParentProjectID > 0 {
+			parent, err := getTrashedProjectByID(s, project.ParentProjectID)
+			if err != nil && !IsErrProjectDoesNotExist(err) {
+				return err
+			}
+			if err == nil && !parent.Deleted.IsZero() {
+				return &ErrTrashItemParentIsDeleted{Kind: ti.Kind, ID: ti.ID}
+			}
+		}
+		err = restoreProject(s, project)
+	case TrashItemKindTask:
+		if !project.Deleted.IsZero() {
+			return &ErrTrashItemParentIsDeleted{Kind: ti.Kind, ID: ti.ID}
+		}
+		err = restoreTask(s, ti.ID, a)
+	case TrashItemKindComment:
+		if !project.Deleted.IsZero() {
+			return &ErrTrashItemParentIsDeleted{Kind: ti.Kind, ID: ti.ID}
+		}
+		_, err = GetTaskByIDSimple(s, item.TaskID)
+		if IsErrTaskDoesNotExist(err) {
+			return &ErrTrashItemParentIsDeleted{Kind: ti.Kind, ID: ti.ID}
+		}
+		if err != nil {
+			return err
+		}
+		err = restoreFromTrash(s, "task_comments", ti.ID)
+	case TrashItemKindBucket:
+		if !project.Deleted.IsZero() {
+			return &ErrTrashItemParentIsDeleted{Kind: ti.Kind, ID: ti.ID}
+		}
+		err = restoreFromTrash(s, "buckets", ti.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	*ti = *item
+	ti.Deleted = time.Time{}
+	ti.PurgeAt = time.Time{}
+	return nil
+}
+
+// purgeTrash permanently removes all items which were moved to the trash before the given date.
+func purgeTrash(s *xorm.Session, before time.Time) (err error) {
+	deletedBefore := builder.And(
+		builder.NotNull{"deleted"},
+		builder.Lt{"deleted": before.Format(dbTimeFormat)},
+	)
+
+	projects := []*Project{}
+	err = s.Unscoped().Where(deletedBefore).Find(&projects)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		err = purgeProject(s, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	tasks := []*Task{}
+	err = s.Unscoped().Where(deletedBefore).Find(&tasks)
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		err = purgeTask(s, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.
+		Where(builder.And(
+			builder.Eq{"entity_kind": ReactionKindComment},
+			builder.In("entity_id", builder.Select("id").From("task_comments").Where(deletedBefore)),
+		)).
+		Delete(&Reaction{})
+	if err != nil {
+		return err
+	}
+
+	err = reparentRepliesOfPurgedComments(s, deletedBefore)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Unscoped().Where(deletedBefore).Delete(&TaskComment{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Unscoped().Where(deletedBefore).Delete(&Bucket{})
+	return err
+}
+
+// RegisterTrashPurgeCron registers a cron function which removes items from the trash
+// once they are older than the configured retention period.
+func RegisterTrashPurgeCron() {
+	const logPrefix = "[Trash Purge Cron] "
+
+	err := cron.Schedule("0 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		retention := config.ServiceTrashRetentionDays.GetInt()
+		before := time.Now().AddDate(0, 0, -retention)
+
+		err := s.Begin()
+		if err != nil {
+			log.Errorf(logPrefix+"Could not start transaction: %s", err)
+			return
+		}
+
+		err = purgeTrash(s, before)
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf(logPrefix+"Could not purge the trash: %s", err)
+			return
+		}
+
+		err = s.Commit()
+		if err != nil {
+			log.Errorf(logPrefix+"Could not commit purging the trash: %s", err)
+			return
+		}
+
+		log.Debugf(logPrefix+"Purged all items deleted before %s", before)
+	})
+	if err != nil {
+		log.Fatalf("Could not register trash purge cron: %s", err)
+	}
+}
@@ -0,0 +1,320 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+func moveToTrash(t *testing.T, s *xorm.Session, u *user.User) {
+	require.NoError(t, (&Task{ID: 2, ProjectID: 1}).Delete(s, u))
+	require.NoError(t, (&TaskComment{ID: 1, TaskID: 1}).Delete(s, u))
+	require.NoError(t, (&Bucket{ID: 2, ProjectID: 1}).Delete(s, u))
+	require.NoError(t, (&Project{ID: 35}).Delete(s, &user.User{ID: 6}))
+}
+
+func TestTrashItem_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		ti := &TrashItem{}
+		res, count, _, err := ti.ReadAll(s, u, "", 0, 0)
+		require.NoError(t, err)
+		items := res.([]*TrashItem)
+		assert.Equal(t, 3, count)
+
+		kinds := map[string]int64{}
+		for _, item := range items {
+			kinds[item.Kind] = item.ID
+			assert.Equal(t, int64(1), item.ProjectID)
+			assert.False(t, item.Deleted.IsZero())
+			assert.True(t, item.PurgeAt.After(item.Deleted))
+		}
+		assert.Equal(t, map[string]int64{
+			TrashItemKindTask:    2,
+			TrashItemKindComment: 1,
+			TrashItemKindBucket:  2,
+		}, kinds)
+	})
+	t.Run("deleted project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		ti := &TrashItem{}
+		res, _, _, err := ti.ReadAll(s, &user.User{ID: 6}, "", 0, 0)
+		require.NoError(t, err)
+		items := res.([]*TrashItem)
+		require.Len(t, items, 1)
+		assert.Equal(t, TrashItemKindProject, items[0].Kind)
+		assert.Equal(t, int64(35), items[0].ID)
+	})
+	t.Run("child projects of a deleted project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		require.NoError(t, (&Project{ID: 12}).Delete(s, &user.User{ID: 6}))
+
+		ti := &TrashItem{}
+		res, _, _, err := ti.ReadAll(s, &user.User{ID: 6}, "", 0, 0)
+		require.NoError(t, err)
+		items := res.([]*TrashItem)
+		require.Len(t, items, 1)
+		assert.Equal(t, TrashItemKindProject, items[0].Kind)
+		assert.Equal(t, int64(12), items[0].ID)
+	})
+	t.Run("no admin rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		ti := &TrashItem{}
+		res, count, _, err := ti.ReadAll(s, &user.User{ID: 2}, "", 0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+		assert.Empty(t, res)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TrashItem{}
+		_, _, _, err := ti.ReadAll(s, &LinkSharing{ID: 1, ProjectID: 1}, "", 0, 0)
+		require.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTrashItem_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("restore task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 2}
+		can, err := ti.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = ti.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.True(t, ti.Deleted.IsZero())
+		db.AssertCount(t, "tasks", builder.And(builder.Eq{"id": 2}, builder.IsNull{"deleted"}), 1)
+	})
+	t.Run("restore task with a new task in the project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		newTask := &Task{Title: "new task", ProjectID: 1}
+		err := newTask.Create(s, u)
+		require.NoError(t, err)
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 2}
+		err = ti.Create(s, u)
+		require.NoError(t, err)
+
+		restored, err := GetTaskByIDSimple(s, 2)
+		require.NoError(t, err)
+		assert.NotEqual(t, restored.Index, newTask.Index)
+	})
+	t.Run("restore comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		ti := &TrashItem{Kind: TrashItemKindComment, ID: 1}
+		err := ti.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), ti.TaskID)
+		db.AssertCount(t, "task_comments", builder.And(builder.Eq{"id": 1}, builder.IsNull{"deleted"}), 1)
+	})
+	t.Run("restore project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		ti := &TrashItem{Kind: TrashItemKindProject, ID: 35}
+		err := ti.Create(s, &user.User{ID: 6})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertCount(t, "projects", builder.And(builder.Eq{"id": 35}, builder.IsNull{"deleted"}), 1)
+	})
+	t.Run("restore project with child projects", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		require.NoError(t, (&Project{ID: 12}).Delete(s, &user.User{ID: 6}))
+		trashed, err := s.Unscoped().Where(builder.And(builder.In("id", 12, 25, 26), builder.NotNull{"deleted"})).Count(&Project{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), trashed)
+
+		ti := &TrashItem{Kind: TrashItemKindProject, ID: 12}
+		err = ti.Create(s, &user.User{ID: 6})
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertCount(t, "projects", builder.And(builder.In("id", 12, 25, 26), builder.IsNull{"deleted"}), 3)
+	})
+	t.Run("task of deleted project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		require.NoError(t, (&Task{ID: 2, ProjectID: 1}).Delete(s, u))
+		require.NoError(t, (&Project{ID: 1}).Delete(s, u))
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 2}
+		can, err := ti.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = ti.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTrashItemParentIsDeleted(err))
+	})
+	t.Run("not in trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 2}
+		_, err := ti.CanCreate(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTrashItemDoesNotExist(err))
+	})
+	t.Run("invalid kind", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TrashItem{Kind: "label", ID: 1}
+		_, err := ti.CanCreate(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidTrashItemKind(err))
+	})
+	t.Run("no admin rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		ti := &TrashItem{Kind: TrashItemKindTask, ID: 2}
+		can, err := ti.CanCreate(s, &user.User{ID: 2})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("purges old items", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		err := purgeTrash(s, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "tasks", map[string]interface{}{"id": 2})
+		db.AssertMissing(t, "task_comments", map[string]interface{}{"id": 1})
+		db.AssertMissing(t, "reactions", map[string]interface{}{"id": 3})
+		db.AssertMissing(t, "buckets", map[string]interface{}{"id": 2})
+		db.AssertMissing(t, "projects", map[string]interface{}{"id": 35})
+		db.AssertExists(t, "tasks", map[string]interface{}{"id": 1}, false)
+	})
+	t.Run("moves replies of purged comments to their parent", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		require.NoError(t, (&TaskComment{ID: 15, TaskID: 35}).Delete(s, u))
+
+		err := purgeTrash(s, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "task_comments", map[string]interface{}{"id": 15})
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"id":        17,
+			"parent_id": 0,
+		}, false)
+	})
+	t.Run("keeps recent items", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		moveToTrash(t, s, u)
+
+		err := purgeTrash(s, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{"id": 2}, false)
+		db.AssertExists(t, "task_comments", map[string]interface{}{"id": 1}, false)
+		db.AssertExists(t, "buckets", map[string]interface{}{"id": 2}, false)
+		db.AssertExists(t, "projects", map[string]interface{}{"id": 35}, false)
+	})
+}
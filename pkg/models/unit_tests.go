@@ -59,11 +59,27 @@ func SetupTests() {
 		"teams",
 		"users",
 		"user_tokens",
+		"sessions",
 		"users_projects",
 		"buckets",
 		"saved_filters",
 		"subscriptions",
 		"favorites",
+		"webhooks",
+		"webhook_retries",
+		"api_tokens",
+		"project_views",
+		"task_buckets",
+		"task_positions",
+		"task_time_entries",
+		"project_custom_fields",
+		"task_custom_field_values",
+		"task_activities",
+		"reactions",
+		"project_templates",
+		"calendar_feeds",
+		"task_sync_changes",
+		"project_sync_sequences",
 	)
 	if err != nil {
 		log.Fatal(err)
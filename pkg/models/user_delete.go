@@ -136,13 +136,39 @@ func DeleteUser(s *xorm.Session, u *user.User) (err error) {
 		return err
 	}
 
+	// Projects of the user which are already in the trash are removed as well
+	trashedProjects := []*Project{}
+	err = s.
+		Unscoped().
+		Where("owner_id = ? AND deleted IS NOT NULL", u.ID).
+		Find(&trashedProjects)
+	if err != nil {
+		return err
+	}
+	projectsToDelete = append(projectsToDelete, trashedProjects...)
+
 	for _, l := range projectsToDelete {
-		err = l.Delete(s, u)
+		err = purgeProject(s, l)
 		if err != nil {
 			return err
 		}
 	}
 
+	_, err = s.Where("owner_id = ?", u.ID).Delete(&APIToken{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&CalendarFeed{})
+	if err != nil {
+		return err
+	}
+
+	err = user.DeleteAllSessionsForUser(s, u.ID)
+	if err != nil {
+		return err
+	}
+
 	_, err = s.Where("id = ?", u.ID).Delete(&user.User{})
 	if err != nil {
 		return err
@@ -0,0 +1,482 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/version"
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// WebhookSignatureHeader is the header which contains the hmac signature of the payload if the webhook has a secret.
+const WebhookSignatureHeader = "X-Vikunja-Signature"
+
+// Webhook represents a webhook which will receive events of a project
+type Webhook struct {
+	// The generated ID of this webhook target
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"webhook"`
+	// The target URL where the POST request with the webhook payload will be made
+	TargetURL string `xorm:"text not null" valid:"required" minLength:"1" json:"target_url"`
+	// The webhook events which should fire this webhook target
+	Events []string `xorm:"JSON not null" valid:"-" json:"events"`
+	// The project ID of the project this webhook target belongs to
+	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// If provided, webhook requests will be signed using HMAC. Check out the docs about how to use this.
+	// You can only set it, not retrieve it after the webhook has been created.
+	Secret string `xorm:"text null" json:"secret"`
+
+	// The HTTP status code the target returned on the last delivery attempt. 0 if the target could not be reached at all.
+	LastDeliveryStatus int `xorm:"int null" json:"last_delivery_status"`
+	// The error message of the last delivery attempt, if it failed.
+	LastDeliveryError string `xorm:"text null" json:"last_delivery_error"`
+	// A timestamp when this webhook was last delivered.
+	LastDeliveredAt time.Time `xorm:"DATETIME null" json:"last_delivered_at"`
+
+	// The user who initially created the webhook target.
+	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
+	CreatedByID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this webhook target was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this webhook target was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for webhooks
+func (w *Webhook) TableName() string {
+	return "webhooks"
+}
+
+var availableWebhookEvents map[string]bool
+var availableWebhookEventsLock *sync.Mutex
+
+func init() {
+	availableWebhookEvents = make(map[string]bool)
+	availableWebhookEventsLock = &sync.Mutex{}
+}
+
+// RegisterEventForWebhook makes an event available to webhooks and registers the listener which will deliver it.
+func RegisterEventForWebhook(event events.Event) {
+	availableWebhookEventsLock.Lock()
+	defer availableWebhookEventsLock.Unlock()
+
+	if availableWebhookEvents[event.Name()] {
+		return
+	}
+
+	availableWebhookEvents[event.Name()] = true
+	events.RegisterListener(event.Name(), &WebhookListener{
+		EventName: event.Name(),
+	})
+}
+
+// GetAvailableWebhookEvents returns a sorted list of all events which can be used with webhooks.
+func GetAvailableWebhookEvents() []string {
+	availableWebhookEventsLock.Lock()
+	defer availableWebhookEventsLock.Unlock()
+
+	evts := make([]string, 0, len(availableWebhookEvents))
+	for e := range availableWebhookEvents {
+		evts = append(evts, e)
+	}
+	sort.Strings(evts)
+
+	return evts
+}
+
+func isAvailableWebhookEvent(event string) bool {
+	availableWebhookEventsLock.Lock()
+	defer availableWebhookEventsLock.Unlock()
+	return availableWebhookEvents[event]
+}
+
+func (w *Webhook) validate() error {
+	if !strings.HasPrefix(w.TargetURL, "http://") && !strings.HasPrefix(w.TargetURL, "https://") {
+		return &ErrInvalidWebhookTargetURL{TargetURL: w.TargetURL}
+	}
+
+	if len(w.Events) == 0 {
+		return &ErrInvalidWebhookEvent{}
+	}
+
+	for _, event := range w.Events {
+		if !isAvailableWebhookEvent(event) {
+			return &ErrInvalidWebhookEvent{EventName: event}
+		}
+	}
+
+	return nil
+}
+
+func getWebhookByID(s *xorm.Session, id int64) (w *Webhook, err error) {
+	w = &Webhook{}
+	exists, err := s.Where("id = ?", id).Get(w)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrWebhookDoesNotExist{WebhookID: id}
+	}
+	return
+}
+
+// Create creates a webhook target
+// @Summary Create a webhook target
+// @Description Create a webhook target which receives POST requests about specified events from a project.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param webhook body models.Webhook true "The webhook target object with required fields"
+// @Success 200 {object} models.Webhook "The created webhook target."
+// @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks [put]
+func (w *Webhook) Create(s *xorm.Session, a web.Auth) (err error) {
+	err = w.validate()
+	if err != nil {
+		return err
+	}
+
+	w.ID = 0
+	w.LastDeliveryStatus = 0
+	w.LastDeliveryError = ""
+	w.LastDeliveredAt = time.Time{}
+	w.CreatedByID = a.GetID()
+	_, err = s.Insert(w)
+	if err != nil {
+		return err
+	}
+
+	w.CreatedBy, err = user.GetUserByID(s, a.GetID())
+	return
+}
+
+// ReadAll returns all webhook targets for a project
+// @Summary Get all api webhook targets for the specified project
+// @Description Get all api webhook targets for the specified project. The secrets are not included.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per bucket per page. This parameter is limited by the configured maximum of items per page."
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Success 200 {array} models.Webhook "The list of all webhook targets"
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /projects/{id}/webhooks [get]
+func (w *Webhook) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	p := &Project{ID: w.ProjectID}
+	can, err := p.IsAdmin(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	query := s.Where("project_id = ?", w.ProjectID).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	ws := []*Webhook{}
+	err = query.Find(&ws)
+	if err != nil {
+		return
+	}
+
+	userIDs := make([]int64, 0, len(ws))
+	for _, webhook := range ws {
+		userIDs = append(userIDs, webhook.CreatedByID)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return
+	}
+
+	for _, webhook := range ws {
+		webhook.Secret = ""
+		webhook.CreatedBy = users[webhook.CreatedByID]
+	}
+
+	numberOfTotalItems, err = s.Where("project_id = ?", w.ProjectID).Count(&Webhook{})
+	return ws, len(ws), numberOfTotalItems, err
+}
+
+// Update updates a project webhook
+// @Summary Change a webhook target's events and target url.
+// @Description Change a webhook target's events and target url. You cannot change the secret of a webhook.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param webhookID path int true "Webhook ID"
+// @Success 200 {object} models.Webhook "Updated webhook target"
+// @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
+// @Failure 404 {object} web.HTTPError "The webhook target does not exist"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks/{webhookID} [post]
+func (w *Webhook) Update(s *xorm.Session, _ web.Auth) (err error) {
+	err = w.validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ?", w.ID).
+		Cols("events", "target_url").
+		Update(w)
+	if err != nil {
+		return err
+	}
+
+	updated, err := getWebhookByID(s, w.ID)
+	if err != nil {
+		return err
+	}
+
+	*w = *updated
+	w.Secret = ""
+	return
+}
+
+// Delete deletes a project webhook
+// @Summary Deletes an existing webhook target
+// @Description Delete any of the project's webhook targets.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param webhookID path int true "Webhook ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 404 {object} web.HTTPError "The webhook target does not exist"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks/{webhookID} [delete]
+func (w *Webhook) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("id = ?", w.ID).Delete(&Webhook{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("webhook_id = ?", w.ID).Delete(&WebhookRetry{})
+	return
+}
+
+// WebhookPayload is the body of every webhook request
+type WebhookPayload struct {
+	EventName string      `json:"event_name"`
+	Time      time.Time   `json:"time"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookRetry holds a failed delivery of a webhook payload until it is tried again.
+type WebhookRetry struct {
+	ID        int64           `xorm:"bigint autoincr not null unique pk" json:"-"`
+	WebhookID int64           `xorm:"bigint not null index" json:"-"`
+	Payload   *WebhookPayload `xorm:"JSON not null" json:"-"`
+	// The number of deliveries of this payload which already failed.
+	Attempt int       `xorm:"int not null" json:"-"`
+	RetryAt time.Time `xorm:"DATETIME not null index" json:"-"`
+	Created time.Time `xorm:"created not null" json:"-"`
+}
+
+// TableName returns the table name for webhook retries
+func (*WebhookRetry) TableName() string {
+	return "webhook_retries"
+}
+
+var webhookClient *http.Client
+
+func getWebhookHTTPClient() *http.Client {
+	if webhookClient != nil {
+		return webhookClient
+	}
+
+	webhookClient = &http.Client{
+		Timeout: time.Duration(config.WebhooksTimeoutSeconds.GetInt()) * time.Second,
+	}
+	return webhookClient
+}
+
+// getWebhookSignature returns the hex encoded hmac sha256 signature of a payload
+func getWebhookSignature(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	// Writing to a hash never returns an error
+	_, _ = mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhookPayload sends a payload to the webhook target and returns the http status code of the response.
+func (w *Webhook) sendWebhookPayload(payload []byte) (status int, err error) {
+	req, err := http.NewRequest(http.MethodPost, w.TargetURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+
+	if w.Secret != "" {
+		req.Header.Add(WebhookSignatureHeader, getWebhookSignature(w.Secret, payload))
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("User-Agent", "Vikunja/"+version.Version)
+
+	res, err := getWebhookHTTPClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return res.StatusCode, &ErrWebhookDeliveryFailed{WebhookID: w.ID, StatusCode: res.StatusCode}
+	}
+
+	return res.StatusCode, nil
+}
+
+// deliver sends a payload to the webhook target and saves the outcome with the webhook. Failed
+// deliveries are stored to be retried with an exponential backoff by the webhook retry cron,
+// attempt counts the deliveries of this payload that already failed.
+func (w *Webhook) deliver(s *xorm.Session, p *WebhookPayload, attempt int) (err error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	status, deliveryErr := w.sendWebhookPayload(payload)
+
+	w.LastDeliveryStatus = status
+	w.LastDeliveryError = ""
+	w.LastDeliveredAt = time.Now()
+	if deliveryErr != nil {
+		log.Errorf("Webhook %d: Could not deliver event %s: %s", w.ID, p.EventName, deliveryErr)
+		w.LastDeliveryError = deliveryErr.Error()
+	} else {
+		log.Debugf("Webhook %d: Delivered event %s, target responded with status %d", w.ID, p.EventName, status)
+	}
+
+	_, err = s.
+		Where("id = ?", w.ID).
+		Cols("last_delivery_status", "last_delivery_error", "last_delivered_at").
+		NoAutoTime().
+		Update(w)
+	if err != nil {
+		return err
+	}
+
+	if deliveryErr == nil || attempt >= config.WebhooksMaxRetries.GetInt() {
+		return nil
+	}
+
+	backoff := time.Minute << attempt
+	log.Debugf("Webhook %d: Retrying delivery of event %s in %s, attempt %d", w.ID, p.EventName, backoff, attempt+1)
+	_, err = s.Insert(&WebhookRetry{
+		WebhookID: w.ID,
+		Payload:   p,
+		Attempt:   attempt + 1,
+		RetryAt:   time.Now().Add(backoff),
+	})
+	return err
+}
+
+// retryWebhookDeliveries delivers all stored webhook payloads which are due again.
+func retryWebhookDeliveries(s *xorm.Session, now time.Time) (err error) {
+	retries := []*WebhookRetry{}
+	err = s.
+		Where("retry_at <= ?", now.Format(dbTimeFormat)).
+		OrderBy("id asc").
+		Find(&retries)
+	if err != nil {
+		return err
+	}
+
+	for _, retry := range retries {
+		// Claiming the retry by deleting it makes sure only one instance delivers it
+		deleted, err := s.Where("id = ?", retry.ID).Delete(&WebhookRetry{})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			continue
+		}
+
+		w, err := getWebhookByID(s, retry.WebhookID)
+		if IsErrWebhookDoesNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = w.deliver(s, retry.Payload, retry.Attempt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RegisterWebhookRetryCron registers a cron function which retries failed webhook deliveries once they are due.
+func RegisterWebhookRetryCron() {
+	if !config.WebhooksEnabled.GetBool() {
+		return
+	}
+
+	const logPrefix = "[Webhook Retry Cron] "
+
+	err := cron.Schedule("* * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		err := retryWebhookDeliveries(s, time.Now())
+		if err != nil {
+			log.Errorf(logPrefix+"Could not retry webhook deliveries: %s", err)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register webhook retry cron: %s", err)
+	}
+}
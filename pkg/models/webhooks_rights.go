@@ -0,0 +1,64 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read a webhook target
+func (w *Webhook) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	can, err := w.canDoWebhook(s, a)
+	return can, int(RightAdmin), err
+}
+
+// CanCreate checks if a user can create a webhook target
+func (w *Webhook) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoWebhook(s, a)
+}
+
+// CanUpdate checks if a user can update a webhook target
+func (w *Webhook) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoWebhook(s, a)
+}
+
+// CanDelete checks if a user can delete a webhook target
+func (w *Webhook) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoWebhook(s, a)
+}
+
+func (w *Webhook) canDoWebhook(s *xorm.Session, a web.Auth) (bool, error) {
+	// Link shares can't manage webhooks
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	// Make sure the webhook actually belongs to the project in the url
+	if w.ID > 0 {
+		existing, err := getWebhookByID(s, w.ID)
+		if err != nil {
+			return false, err
+		}
+		if existing.ProjectID != w.ProjectID {
+			return false, nil
+		}
+	}
+
+	p := &Project{ID: w.ProjectID}
+	return p.IsAdmin(s, a)
+}
@@ -0,0 +1,348 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
+)
+
+func TestWebhook_Create(t *testing.T) {
+	RegisterEventForWebhook(&TaskCreatedEvent{})
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL: "https://example.com/hook",
+			Events:    []string{"task.created"},
+			ProjectID: 1,
+		}
+		err := w.Create(s, u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, w.ID)
+		assert.Equal(t, int64(1), w.CreatedBy.ID)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":            w.ID,
+			"project_id":    1,
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("invalid event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL: "https://example.com/hook",
+			Events:    []string{"task.nonexistent"},
+			ProjectID: 1,
+		}
+		err := w.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebhookEvent(err))
+	})
+	t.Run("no events", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL: "https://example.com/hook",
+			ProjectID: 1,
+		}
+		err := w.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebhookEvent(err))
+	})
+	t.Run("invalid target url", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL: "ftp://example.com/hook",
+			Events:    []string{"task.created"},
+			ProjectID: 1,
+		}
+		err := w.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebhookTargetURL(err))
+	})
+}
+
+func TestWebhook_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ProjectID: 1}
+		result, count, total, err := w.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, int64(1), total)
+		ws := result.([]*Webhook)
+		assert.Empty(t, ws[0].Secret)
+		assert.Equal(t, int64(1), ws[0].CreatedBy.ID)
+	})
+	t.Run("no admin rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// User 1 only has read access to project 3
+		w := &Webhook{ProjectID: 3}
+		_, _, _, err := w.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestWebhook_CanDoWebhook(t *testing.T) {
+	t.Run("admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ID: 1, ProjectID: 1}
+		can, err := w.CanUpdate(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("not admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ProjectID: 3}
+		can, err := w.CanCreate(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("webhook of a different project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// User 1 is admin of project 11, but webhook 1 belongs to project 1
+		w := &Webhook{ID: 1, ProjectID: 11}
+		can, err := w.CanDelete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ProjectID: 1}
+		can, err := w.CanCreate(s, &LinkSharing{ID: 1, ProjectID: 1, Right: RightAdmin})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestWebhook_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	w := &Webhook{ID: 1, ProjectID: 1}
+	err := w.Delete(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	db.AssertMissing(t, "webhooks", map[string]interface{}{
+		"id": 1,
+	})
+}
+
+func TestWebhook_deliver(t *testing.T) {
+	t.Run("signed payload", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		var body []byte
+		var signature string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			signature = r.Header.Get(WebhookSignatureHeader)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		w, err := getWebhookByID(s, 1)
+		assert.NoError(t, err)
+		w.TargetURL = server.URL
+
+		err = w.deliver(s, &WebhookPayload{EventName: "task.created"}, 0)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, body)
+		assert.Equal(t, getWebhookSignature("supersecret", body), signature)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":                   1,
+			"last_delivery_status": http.StatusOK,
+		}, false)
+	})
+	t.Run("failed delivery", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		w, err := getWebhookByID(s, 1)
+		assert.NoError(t, err)
+		w.TargetURL = server.URL
+
+		err = w.deliver(s, &WebhookPayload{EventName: "task.created"}, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, requests)
+		assert.Equal(t, http.StatusInternalServerError, w.LastDeliveryStatus)
+		assert.NotEmpty(t, w.LastDeliveryError)
+		db.AssertExists(t, "webhook_retries", map[string]interface{}{
+			"webhook_id": 1,
+			"attempt":    1,
+		}, false)
+		db.AssertCount(t, "webhook_retries", builder.Eq{"webhook_id": 1}, 2)
+	})
+}
+
+func TestRetryWebhookDeliveries(t *testing.T) {
+	t.Run("due retry", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		_, err := s.Where("id = ?", 1).Cols("target_url").Update(&Webhook{TargetURL: server.URL})
+		assert.NoError(t, err)
+
+		err = retryWebhookDeliveries(s, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, requests)
+		db.AssertMissing(t, "webhook_retries", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("not due yet", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := retryWebhookDeliveries(s, time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC))
+		assert.NoError(t, err)
+		db.AssertExists(t, "webhook_retries", map[string]interface{}{
+			"id": 1,
+		}, false)
+	})
+}
+
+func TestWebhookListener_Handle(t *testing.T) {
+	registerWebhookEvents()
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := &WebhookPayload{}
+		_ = json.NewDecoder(r.Body).Decode(payload)
+		received = append(received, payload.EventName)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err := s.
+		Where("id = ?", 1).
+		Cols("target_url", "events").
+		Update(&Webhook{TargetURL: server.URL, Events: GetAvailableWebhookEvents()})
+	assert.NoError(t, err)
+
+	for _, eventName := range GetAvailableWebhookEvents() {
+		t.Run(eventName, func(t *testing.T) {
+			received = nil
+
+			// Events which only contain the id of their task should be delivered as well
+			event := map[string]interface{}{
+				"Task": map[string]interface{}{"id": 1},
+			}
+			if strings.HasPrefix(eventName, "project.") {
+				event = map[string]interface{}{
+					"Project": map[string]interface{}{"id": 1},
+				}
+			}
+			content, err := json.Marshal(event)
+			assert.NoError(t, err)
+
+			wl := &WebhookListener{EventName: eventName}
+			err = wl.Handle(message.NewMessage(watermill.NewUUID(), content))
+			assert.NoError(t, err)
+			assert.Equal(t, []string{eventName}, received)
+		})
+	}
+}
+
+func TestGetParentProjectIDsFromEvent(t *testing.T) {
+	assert.Equal(t, []int64{2, 1}, getParentProjectIDsFromEvent(map[string]interface{}{
+		"Project":            map[string]interface{}{"id": float64(3)},
+		"parent_project_ids": []interface{}{float64(2), float64(1)},
+	}))
+	assert.Empty(t, getParentProjectIDsFromEvent(map[string]interface{}{
+		"Project": map[string]interface{}{"id": float64(3)},
+	}))
+}
+
+func TestGetProjectIDFromAnyEvent(t *testing.T) {
+	assert.Equal(t, int64(3), getProjectIDFromAnyEvent(map[string]interface{}{
+		"Task": map[string]interface{}{"id": float64(1), "project_id": float64(3)},
+	}))
+	assert.Equal(t, int64(5), getProjectIDFromAnyEvent(map[string]interface{}{
+		"Project": map[string]interface{}{"id": float64(5)},
+	}))
+	assert.Equal(t, int64(0), getProjectIDFromAnyEvent(map[string]interface{}{
+		"Team": map[string]interface{}{"id": float64(5)},
+	}))
+}
@@ -0,0 +1,305 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ldap
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+
+	petname "github.com/dustinkirkland/golang-petname"
+	"github.com/go-ldap/ldap/v3"
+	"xorm.io/xorm"
+)
+
+// directoryUser holds the attributes of a user entry in the ldap directory
+type directoryUser struct {
+	DN string
+	// A unique id of the entry which does not change when the user is renamed or moved
+	ID       string
+	Username string
+	Email    string
+	Name     string
+}
+
+func init() {
+	petname.NonDeterministicMode()
+}
+
+func getLDAPURL() string {
+	scheme := "ldap"
+	if config.AuthLdapUseTLS.GetBool() {
+		scheme = "ldaps"
+	}
+	return scheme + "://" + config.AuthLdapHost.GetString() + ":" + strconv.Itoa(config.AuthLdapPort.GetInt())
+}
+
+func connectToLDAP() (l *ldap.Conn, err error) {
+	l, err = ldap.DialURL(getLDAPURL(), ldap.DialWithTLSConfig(&tls.Config{
+		ServerName:         config.AuthLdapHost.GetString(),
+		InsecureSkipVerify: !config.AuthLdapVerifyTLS.GetBool(),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to ldap server: %w", err)
+	}
+
+	err = bindServiceAccount(l)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// bindServiceAccount binds with the configured bind dn. If none is configured, the connection stays anonymous.
+func bindServiceAccount(l *ldap.Conn) error {
+	if config.AuthLdapBindDN.GetString() == "" {
+		return nil
+	}
+
+	err := l.Bind(config.AuthLdapBindDN.GetString(), config.AuthLdapBindPassword.GetString())
+	if err != nil {
+		return fmt.Errorf("could not bind to ldap server with the configured bind dn: %w", err)
+	}
+	return nil
+}
+
+// getEntryID returns the value of the configured id attribute of an entry or its DN if it does not have one.
+// Binary ids like the objectGUID of Active Directory are hex encoded.
+func getEntryID(entry *ldap.Entry) string {
+	id := entry.GetRawAttributeValue(config.AuthLdapAttributeID.GetString())
+	if len(id) == 0 {
+		return entry.DN
+	}
+	if !utf8.Valid(id) {
+		return hex.EncodeToString(id)
+	}
+	return string(id)
+}
+
+func findUser(l *ldap.Conn, username string) (du *directoryUser, err error) {
+	attrUsername := config.AuthLdapAttributeUsername.GetString()
+	attrEmail := config.AuthLdapAttributeEmail.GetString()
+	attrName := config.AuthLdapAttributeDisplayname.GetString()
+
+	result, err := l.Search(ldap.NewSearchRequest(
+		config.AuthLdapBaseDN.GetString(),
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		fmt.Sprintf(config.AuthLdapUserFilter.GetString(), ldap.EscapeFilter(username)),
+		[]string{"dn", config.AuthLdapAttributeID.GetString(), attrUsername, attrEmail, attrName},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("could not search for user in ldap: %w", err)
+	}
+
+	// Multiple matches would make it impossible to know which account is meant
+	if len(result.Entries) != 1 {
+		log.Debugf("[LDAP] Found %d entries for username %s", len(result.Entries), username)
+		return nil, user.ErrWrongUsernameOrPassword{}
+	}
+
+	entry := result.Entries[0]
+	return &directoryUser{
+		DN:       entry.DN,
+		ID:       getEntryID(entry),
+		Username: entry.GetAttributeValue(attrUsername),
+		Email:    entry.GetAttributeValue(attrEmail),
+		Name:     entry.GetAttributeValue(attrName),
+	}, nil
+}
+
+func findGroupsOfUser(l *ldap.Conn, du *directoryUser) (teams []*models.Team, err error) {
+	attrGroupName := config.AuthLdapAttributeGroupName.GetString()
+
+	result, err := l.Search(ldap.NewSearchRequest(
+		config.AuthLdapBaseDN.GetString(),
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		fmt.Sprintf(config.AuthLdapGroupFilter.GetString(), ldap.EscapeFilter(du.DN)),
+		[]string{"dn", attrGroupName, "description"},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("could not search for groups in ldap: %w", err)
+	}
+
+	teams = make([]*models.Team, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		name := entry.GetAttributeValue(attrGroupName)
+		if name == "" {
+			name = entry.DN
+		}
+		teams = append(teams, &models.Team{
+			Name:        name,
+			Description: entry.GetAttributeValue("description"),
+			ExternalID:  entry.DN,
+		})
+	}
+
+	return
+}
+
+// AuthenticateUserInLDAP checks a username and password against the configured ldap server.
+// The Vikunja user for that ldap user is created on their first login.
+// If group sync is enabled, the teams of the user are synced with their ldap groups.
+func AuthenticateUserInLDAP(s *xorm.Session, username, password string) (u *user.User, err error) {
+	if username == "" || password == "" {
+		return nil, user.ErrNoUsernamePassword{}
+	}
+
+	l, err := connectToLDAP()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+
+	du, err := findUser(l, username)
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.Bind(du.DN, password)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			handleFailedPassword(s, du)
+			return nil, user.ErrWrongUsernameOrPassword{}
+		}
+		return nil, fmt.Errorf("could not bind to ldap server as user %s: %w", du.DN, err)
+	}
+
+	u, err = getOrCreateUser(s, du)
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.AuthLdapGroupSyncEnabled.GetBool() {
+		return u, nil
+	}
+
+	// Groups are searched with the service account because users may not be allowed to read them
+	err = bindServiceAccount(l)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := findGroupsOfUser(l, du)
+	if err != nil {
+		return nil, err
+	}
+
+	err = models.SyncExternalTeamsForUser(s, u, user.IssuerLDAP, teams)
+	return u, err
+}
+
+// handleFailedPassword counts a failed login against the Vikunja account of an ldap user, if they have one already.
+func handleFailedPassword(s *xorm.Session, du *directoryUser) {
+	u, err := user.GetUserWithEmail(s, &user.User{
+		Issuer:  user.IssuerLDAP,
+		Subject: du.ID,
+	})
+	if err != nil {
+		if !user.IsErrUserDoesNotExist(err) {
+			log.Errorf("[LDAP] Could not get user for %s to count the failed login: %s", du.DN, err)
+		}
+		return
+	}
+
+	user.HandleFailedPassword(u)
+}
+
+func getOrCreateUser(s *xorm.Session, du *directoryUser) (u *user.User, err error) {
+	if du.Email == "" {
+		return nil, &user.ErrNoLDAPEmailProvided{Username: du.Username}
+	}
+
+	// Check if the user exists for that ldap entry
+	u, err = user.GetUserWithEmail(s, &user.User{
+		Issuer:  user.IssuerLDAP,
+		Subject: du.ID,
+	})
+	if err != nil && !user.IsErrUserDoesNotExist(err) {
+		return nil, err
+	}
+
+	// If no user exists, create one with the ldap username if it is not already taken
+	if user.IsErrUserDoesNotExist(err) {
+		uu := &user.User{
+			Username: du.Username,
+			Email:    du.Email,
+			Name:     du.Name,
+			Status:   user.StatusActive,
+			Issuer:   user.IssuerLDAP,
+			Subject:  du.ID,
+		}
+
+		u, err = user.CreateUser(s, uu)
+		if err != nil && !user.IsErrUsernameExists(err) && !user.IsErrUsernameMustNotContainSpaces(err) {
+			return nil, err
+		}
+
+		// If their username is already taken or not valid in Vikunja, create a random one
+		if user.IsErrUsernameExists(err) || user.IsErrUsernameMustNotContainSpaces(err) {
+			uu.Username = petname.Generate(3, "-")
+			u, err = user.CreateUser(s, uu)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// And create their project
+		err = models.CreateNewProjectForUser(s, u)
+		if err != nil {
+			return nil, err
+		}
+
+		return
+	}
+
+	// If it exists, check if the email address or name changed and update them
+	if du.Email != u.Email || du.Name != u.Name {
+		u.Email = du.Email
+		u.Name = du.Name
+		u, err = user.UpdateUser(s, &user.User{
+			ID:      u.ID,
+			Email:   u.Email,
+			Name:    u.Name,
+			Issuer:  user.IssuerLDAP,
+			Subject: du.ID,
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
@@ -0,0 +1,220 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ldap
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrCreateUser(t *testing.T) {
+	t.Run("new user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		du := &directoryUser{
+			DN:       "uid=ldapuser1,ou=people,dc=example,dc=org",
+			ID:       "b5a1d9ee-4a2b-103e-9c4d-3f1b7d2c6a10",
+			Username: "ldapuser1",
+			Email:    "ldapuser1@example.org",
+			Name:     "LDAP User 1",
+		}
+		u, err := getOrCreateUser(s, du)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":       u.ID,
+			"username": "ldapuser1",
+			"email":    "ldapuser1@example.org",
+			"name":     "LDAP User 1",
+			"issuer":   user.IssuerLDAP,
+			"subject":  "b5a1d9ee-4a2b-103e-9c4d-3f1b7d2c6a10",
+		}, false)
+	})
+	t.Run("new user, username taken", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		du := &directoryUser{
+			ID:       "uid=user1,ou=people,dc=example,dc=org",
+			Username: "user1",
+			Email:    "user1@example.org",
+		}
+		u, err := getOrCreateUser(s, du)
+		require.NoError(t, err)
+		assert.NotEqual(t, "user1", u.Username)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":      u.ID,
+			"issuer":  user.IssuerLDAP,
+			"subject": "uid=user1,ou=people,dc=example,dc=org",
+		}, false)
+	})
+	t.Run("new user, no email address", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := getOrCreateUser(s, &directoryUser{ID: "ldapuser2", Username: "ldapuser2"})
+		require.Error(t, err)
+		assert.True(t, user.IsErrNoLDAPEmailProvided(err))
+	})
+	t.Run("existing user, different email address", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		du := &directoryUser{
+			ID:       "b5a1d9ee-4a2b-103e-9c4d-3f1b7d2c6a10",
+			Username: "ldapuser1",
+			Email:    "ldapuser1@example.org",
+		}
+		created, err := getOrCreateUser(s, du)
+		require.NoError(t, err)
+
+		du.Email = "other-email-address@example.org"
+		u, err := getOrCreateUser(s, du)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, u.ID)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":    u.ID,
+			"email": "other-email-address@example.org",
+		}, false)
+	})
+	t.Run("existing user, renamed", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		du := &directoryUser{
+			ID:       "b5a1d9ee-4a2b-103e-9c4d-3f1b7d2c6a10",
+			Username: "ldapuser1",
+			Email:    "ldapuser1@example.org",
+		}
+		created, err := getOrCreateUser(s, du)
+		require.NoError(t, err)
+
+		du.Username = "renamed"
+		u, err := getOrCreateUser(s, du)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, u.ID)
+	})
+}
+
+func TestGetEntryID(t *testing.T) {
+	t.Run("id attribute", func(t *testing.T) {
+		entry := ldap.NewEntry("uid=ldapuser1,ou=people,dc=example,dc=org", map[string][]string{
+			"entryUUID": {"b5a1d9ee-4a2b-103e-9c4d-3f1b7d2c6a10"},
+		})
+		assert.Equal(t, "b5a1d9ee-4a2b-103e-9c4d-3f1b7d2c6a10", getEntryID(entry))
+	})
+	t.Run("binary id attribute", func(t *testing.T) {
+		config.AuthLdapAttributeID.Set("objectGUID")
+		defer config.AuthLdapAttributeID.Set("entryUUID")
+
+		entry := ldap.NewEntry("CN=User,DC=example,DC=org", map[string][]string{
+			"objectGUID": {string([]byte{0xff, 0x01, 0xfe})},
+		})
+		assert.Equal(t, "ff01fe", getEntryID(entry))
+	})
+	t.Run("no id attribute", func(t *testing.T) {
+		entry := ldap.NewEntry("uid=ldapuser1,ou=people,dc=example,dc=org", nil)
+		assert.Equal(t, "uid=ldapuser1,ou=people,dc=example,dc=org", getEntryID(entry))
+	})
+}
+
+// TestAuthenticateUserInLDAP needs a local ldap test server with the entries from testdata/users.ldif.
+func TestAuthenticateUserInLDAP(t *testing.T) {
+	if config.AuthLdapHost.GetString() == "" {
+		t.Skip("No ldap test server configured, set VIKUNJA_AUTH_LDAP_HOST to run these tests")
+	}
+
+	config.AuthLdapBaseDN.Set("dc=example,dc=org")
+	config.AuthLdapBindDN.Set("cn=admin,dc=example,dc=org")
+	config.AuthLdapBindPassword.Set("admin")
+	config.AuthLdapGroupSyncEnabled.Set(true)
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u, err := AuthenticateUserInLDAP(s, "ldapuser1", "12345678")
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.Equal(t, "ldapuser1", u.Username)
+		assert.Equal(t, "LDAP User 1", u.Name)
+		db.AssertExists(t, "teams", map[string]interface{}{
+			"name":        "developers",
+			"external_id": "cn=developers,ou=groups,dc=example,dc=org",
+			"issuer":      user.IssuerLDAP,
+		}, false)
+	})
+	t.Run("wrong password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := AuthenticateUserInLDAP(s, "ldapuser1", "wrong")
+		require.Error(t, err)
+		assert.True(t, user.IsErrWrongUsernameOrPassword(err))
+	})
+	t.Run("empty password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := AuthenticateUserInLDAP(s, "ldapuser1", "")
+		require.Error(t, err)
+	})
+	t.Run("nonexisting user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := AuthenticateUserInLDAP(s, "doesnotexist", "12345678")
+		require.Error(t, err)
+		assert.True(t, user.IsErrWrongUsernameOrPassword(err))
+	})
+	t.Run("filter injection", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := AuthenticateUserInLDAP(s, "*", "12345678")
+		require.Error(t, err)
+		assert.True(t, user.IsErrWrongUsernameOrPassword(err))
+	})
+}
@@ -52,6 +52,8 @@ type Provider struct {
 	LogoutURL       string `json:"logout_url"`
 	ClientID        string `json:"client_id"`
 	ClientSecret    string `json:"-"`
+	Scope           string `json:"scope"`
+	GroupsClaim     string `json:"-"`
 	openIDProvider  *oidc.Provider
 	Oauth2Config    *oauth2.Config `json:"-"`
 }
@@ -194,6 +196,25 @@ func HandleCallback(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
+	if provider.GroupsClaim != "" {
+		teams, exists, err := getTeamsFromProvider(provider, idToken, oauth2Token)
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf("Error getting groups claim for provider %s: %v", provider.Name, err)
+			return handler.HandleHTTPError(err, c)
+		}
+
+		// A missing claim does not mean the user is in no groups, the provider might just not send it
+		if exists {
+			err = models.SyncExternalTeamsForUser(s, u, idToken.Issuer, teams)
+			if err != nil {
+				_ = s.Rollback()
+				log.Errorf("Error syncing teams for provider %s: %v", provider.Name, err)
+				return handler.HandleHTTPError(err, c)
+			}
+		}
+	}
+
 	err = s.Commit()
 	if err != nil {
 		return handler.HandleHTTPError(err, c)
@@ -203,6 +224,63 @@ func HandleCallback(c echo.Context) error {
 	return auth.NewUserAuthTokenResponse(u, c, false)
 }
 
+func getTeamsFromProvider(provider *Provider, idToken *oidc.IDToken, oauth2Token *oauth2.Token) (teams []*models.Team, exists bool, err error) {
+	rawClaims := map[string]interface{}{}
+	err = idToken.Claims(&rawClaims)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Some providers only put the groups into the userinfo
+	if _, has := rawClaims[provider.GroupsClaim]; !has {
+		info, err := provider.openIDProvider.UserInfo(context.Background(), provider.Oauth2Config.TokenSource(context.Background(), oauth2Token))
+		if err != nil {
+			return nil, false, err
+		}
+
+		err = info.Claims(&rawClaims)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	claim, exists := rawClaims[provider.GroupsClaim]
+	if !exists {
+		return nil, false, nil
+	}
+
+	return getTeamsFromGroupsClaim(claim), true, nil
+}
+
+// getTeamsFromGroupsClaim returns one team for every group in a groups claim.
+// The claim is either a list of groups or a single one. The group is used as id and name of the team.
+func getTeamsFromGroupsClaim(claim interface{}) (teams []*models.Team) {
+	groups := []interface{}{}
+	switch c := claim.(type) {
+	case []interface{}:
+		groups = c
+	case string:
+		groups = append(groups, c)
+	}
+
+	teams = make([]*models.Team, 0, len(groups))
+	seen := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		group, is := g.(string)
+		if !is || group == "" || seen[group] {
+			continue
+		}
+		seen[group] = true
+
+		teams = append(teams, &models.Team{
+			Name:       group,
+			ExternalID: group,
+		})
+	}
+
+	return
+}
+
 func getOrCreateUser(s *xorm.Session, cl *claims, issuer, subject string) (u *user.User, err error) {
 	// Check if the user exists for that issuer and subject
 	u, err = user.GetUserWithEmail(s, &user.User{
@@ -94,3 +94,22 @@ func TestGetOrCreateUser(t *testing.T) {
 		}, false)
 	})
 }
+
+func TestGetTeamsFromGroupsClaim(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		teams := getTeamsFromGroupsClaim([]interface{}{"developers", "admins", "developers", ""})
+		assert.Len(t, teams, 2)
+		assert.Equal(t, "developers", teams[0].ExternalID)
+		assert.Equal(t, "developers", teams[0].Name)
+		assert.Equal(t, "admins", teams[1].ExternalID)
+	})
+	t.Run("single group", func(t *testing.T) {
+		teams := getTeamsFromGroupsClaim("developers")
+		assert.Len(t, teams, 1)
+		assert.Equal(t, "developers", teams[0].ExternalID)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		teams := getTeamsFromGroupsClaim(map[string]interface{}{"id": "developers"})
+		assert.Empty(t, teams)
+	})
+}
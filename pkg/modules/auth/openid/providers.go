@@ -125,6 +125,16 @@ func getProviderFromMap(pi map[string]interface{}) (provider *Provider, err erro
 		logoutURL = ""
 	}
 
+	scope, ok := pi["scope"].(string)
+	if !ok || scope == "" {
+		scope = strings.Join([]string{oidc.ScopeOpenID, "profile", "email"}, " ")
+	}
+
+	groupsClaim, ok := pi["groupsclaim"].(string)
+	if !ok {
+		groupsClaim = ""
+	}
+
 	provider = &Provider{
 		Name:            pi["name"].(string),
 		Key:             k,
@@ -132,6 +142,8 @@ func getProviderFromMap(pi map[string]interface{}) (provider *Provider, err erro
 		OriginalAuthURL: pi["authurl"].(string),
 		ClientSecret:    pi["clientsecret"].(string),
 		LogoutURL:       logoutURL,
+		Scope:           scope,
+		GroupsClaim:     groupsClaim,
 	}
 
 	cl, is := pi["clientid"].(int)
@@ -154,7 +166,7 @@ func getProviderFromMap(pi map[string]interface{}) (provider *Provider, err erro
 		Endpoint: provider.openIDProvider.Endpoint(),
 
 		// "openid" is a required scope for OpenID Connect flows.
-		Scopes: []string{oidc.ScopeOpenID, "profile", "email"},
+		Scopes: strings.Fields(provider.Scope),
 	}
 
 	provider.AuthURL = provider.Oauth2Config.Endpoint.AuthURL
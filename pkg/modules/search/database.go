@@ -0,0 +1,158 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/db"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// The weights of matches in the different parts of a task, used to rank search results
+const (
+	weightTitle       = 5
+	weightIdentifier  = 10
+	weightLabel       = 3
+	weightDescription = 2
+	weightComment     = 1
+)
+
+// DatabaseBackend searches tasks directly in the database.
+// It does not need an index but is slower on large instances.
+type DatabaseBackend struct {
+}
+
+// SearchCond returns a condition matching all tasks which contain the query in their title, description,
+// comments or labels and an expression with the relevance of a task, based on where the query was found.
+func (d *DatabaseBackend) SearchCond(query string) (cond, relevance builder.Cond, err error) {
+	if strings.TrimSpace(query) == "" {
+		return builder.Expr("1 = 0"), builder.Expr("0"), nil
+	}
+
+	weighted := []struct {
+		cond   builder.Cond
+		weight int
+	}{
+		{db.ILIKE("title", query), weightTitle},
+		{db.ILIKE("description", query), weightDescription},
+		{builder.In("id",
+			builder.
+				Select("label_tasks.task_id").
+				From("label_tasks").
+				InnerJoin("labels", "labels.id = label_tasks.label_id").
+				Where(db.ILIKE("labels.title", query)),
+		), weightLabel},
+		{builder.In("id",
+			builder.
+				Select("task_id").
+				From("task_comments").
+				Where(builder.And(
+					db.ILIKE("comment", query),
+					builder.IsNull{"deleted"},
+				)),
+		), weightComment},
+	}
+
+	conds := make([]builder.Cond, 0, len(weighted))
+	scores := make([]string, 0, len(weighted))
+	args := []interface{}{}
+	for _, w := range weighted {
+		sql, condArgs, err := builder.ToSQL(w.cond)
+		if err != nil {
+			return nil, nil, err
+		}
+		conds = append(conds, w.cond)
+		scores = append(scores, "CASE WHEN "+sql+" THEN "+strconv.Itoa(w.weight)+" ELSE 0 END")
+		args = append(args, condArgs...)
+	}
+
+	return builder.Or(conds...), builder.Expr("("+strings.Join(scores, " + ")+")", args...), nil
+}
+
+// Search searches the title, description, comments and labels of tasks
+func (d *DatabaseBackend) Search(s *xorm.Session, query string, projectIDs []int64) (taskIDs []int64, err error) {
+	cond, relevance, err := d.SearchCond(query)
+	if err != nil {
+		return nil, err
+	}
+	relevanceSQL, relevanceArgs, err := builder.ToSQL(relevance)
+	if err != nil {
+		return nil, err
+	}
+
+	var projectCond builder.Cond
+	if len(projectIDs) > 0 {
+		projectCond = builder.In("project_id", projectIDs)
+	}
+
+	taskIDs = []int64{}
+	err = s.
+		Table("tasks").
+		Cols("id").
+		Where(builder.And(
+			projectCond,
+			builder.IsNull{"deleted"},
+			cond,
+		)).
+		OrderBy(relevanceSQL+" DESC", relevanceArgs...).
+		OrderBy("id ASC").
+		Find(&taskIDs)
+	return
+}
+
+// sortByScore returns the task ids ordered by their score, the highest first.
+// Tasks with the same score are ordered by their id.
+func sortByScore(scores map[int64]float64) (taskIDs []int64) {
+	taskIDs = make([]int64, 0, len(scores))
+	for id := range scores {
+		taskIDs = append(taskIDs, id)
+	}
+
+	sort.Slice(taskIDs, func(i, j int) bool {
+		if scores[taskIDs[i]] != scores[taskIDs[j]] {
+			return scores[taskIDs[i]] > scores[taskIDs[j]]
+		}
+		return taskIDs[i] < taskIDs[j]
+	})
+
+	return
+}
+
+// KeepsIndex returns false, the database is always up to date
+func (d *DatabaseBackend) KeepsIndex() bool {
+	return false
+}
+
+// Index does nothing, the database backend does not need an index
+func (d *DatabaseBackend) Index(_ *Document) error {
+	return nil
+}
+
+// Delete does nothing, the database backend does not need an index
+func (d *DatabaseBackend) Delete(_ int64) error {
+	return nil
+}
+
+// Clear does nothing, the database backend does not need an index
+func (d *DatabaseBackend) Clear() error {
+	return nil
+}
@@ -0,0 +1,286 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"xorm.io/xorm"
+)
+
+// The embedded index is stored in a leveldb database with two kinds of keys:
+//
+//	t/<term>\x00<task id> => the weight of the term in the task and the project id of the task
+//	d/<task id>           => all terms of the task, used to remove the task from the index
+const (
+	embeddedTermPrefix     = "t/"
+	embeddedDocumentPrefix = "d/"
+
+	// Words which only start with a search term are ranked lower than exact matches
+	embeddedPrefixMatchFactor = 0.5
+	// Longer words are most likely not something anyone will search for
+	embeddedMaxTermLength = 64
+)
+
+var identifierRegex = regexp.MustCompile(`^(#[0-9]+|[\p{L}0-9]+-[0-9]+)$`)
+
+// EmbeddedBackend keeps an inverted index of all tasks in a directory on disk.
+type EmbeddedBackend struct {
+	db *leveldb.DB
+}
+
+// NewEmbeddedBackend opens or creates the index at path
+func NewEmbeddedBackend(path string) (*EmbeddedBackend, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedBackend{db: db}, nil
+}
+
+// Close closes the index
+func (e *EmbeddedBackend) Close() error {
+	return e.db.Close()
+}
+
+func getEmbeddedTermKey(term string, taskID int64) []byte {
+	key := make([]byte, 0, len(embeddedTermPrefix)+len(term)+9)
+	key = append(key, embeddedTermPrefix...)
+	key = append(key, term...)
+	key = append(key, 0)
+	return binary.BigEndian.AppendUint64(key, uint64(taskID))
+}
+
+func getEmbeddedDocumentKey(taskID int64) []byte {
+	return []byte(embeddedDocumentPrefix + strconv.FormatInt(taskID, 10))
+}
+
+// getTermWeights returns all terms of a document with the sum of their weights
+func getTermWeights(doc *Document) map[string]float64 {
+	weights := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, token := range tokenize(text) {
+			if len(token) > embeddedMaxTermLength {
+				continue
+			}
+			weights[token] += weight
+		}
+	}
+
+	add(doc.Title, weightTitle)
+	add(stripHTML(doc.Description), weightDescription)
+	for _, label := range doc.Labels {
+		add(label, weightLabel)
+	}
+	for _, comment := range doc.Comments {
+		add(stripHTML(comment), weightComment)
+	}
+
+	// Identifiers are only matched as a whole
+	if doc.Index > 0 {
+		weights["#"+strconv.FormatInt(doc.Index, 10)] += weightIdentifier
+	}
+	if doc.Identifier != "" {
+		weights[strings.ToLower(doc.Identifier)] += weightIdentifier
+	}
+
+	return weights
+}
+
+// Index adds a task to the index or replaces it
+func (e *EmbeddedBackend) Index(doc *Document) (err error) {
+	batch := new(leveldb.Batch)
+	err = e.deleteInBatch(batch, doc.TaskID)
+	if err != nil {
+		return err
+	}
+
+	weights := getTermWeights(doc)
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		value := make([]byte, 16)
+		binary.BigEndian.PutUint64(value[:8], math.Float64bits(weight))
+		binary.BigEndian.PutUint64(value[8:], uint64(doc.ProjectID))
+		batch.Put(getEmbeddedTermKey(term, doc.TaskID), value)
+		terms = append(terms, term)
+	}
+
+	rawTerms, err := json.Marshal(terms)
+	if err != nil {
+		return err
+	}
+	batch.Put(getEmbeddedDocumentKey(doc.TaskID), rawTerms)
+
+	return e.db.Write(batch, nil)
+}
+
+func (e *EmbeddedBackend) deleteInBatch(batch *leveldb.Batch, taskID int64) error {
+	rawTerms, err := e.db.Get(getEmbeddedDocumentKey(taskID), nil)
+	if err == leveldb.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	terms := []string{}
+	err = json.Unmarshal(rawTerms, &terms)
+	if err != nil {
+		return err
+	}
+
+	for _, term := range terms {
+		batch.Delete(getEmbeddedTermKey(term, taskID))
+	}
+	batch.Delete(getEmbeddedDocumentKey(taskID))
+	return nil
+}
+
+// Delete removes a task from the index
+func (e *EmbeddedBackend) Delete(taskID int64) (err error) {
+	batch := new(leveldb.Batch)
+	err = e.deleteInBatch(batch, taskID)
+	if err != nil {
+		return err
+	}
+	return e.db.Write(batch, nil)
+}
+
+// Clear removes all tasks from the index
+func (e *EmbeddedBackend) Clear() (err error) {
+	batch := new(leveldb.Batch)
+	iter := e.db.NewIterator(nil, nil)
+	for iter.Next() {
+		batch.Delete(append([]byte{}, iter.Key()...))
+	}
+	iter.Release()
+	err = iter.Error()
+	if err != nil {
+		return err
+	}
+	return e.db.Write(batch, nil)
+}
+
+// KeepsIndex returns true, the index needs to be updated whenever a task changes
+func (e *EmbeddedBackend) KeepsIndex() bool {
+	return true
+}
+
+// findTerm returns the weights of all tasks containing a term.
+// If exact is false, words starting with the term are found as well.
+func (e *EmbeddedBackend) findTerm(term string, exact bool, projects map[int64]bool) (scores map[int64]float64, err error) {
+	scores = make(map[int64]float64)
+
+	prefix := []byte(embeddedTermPrefix + term)
+	if exact {
+		prefix = append(prefix, 0)
+	}
+
+	iter := e.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		value := iter.Value()
+		if len(key) < 9 || len(value) != 16 {
+			continue
+		}
+
+		projectID := int64(binary.BigEndian.Uint64(value[8:]))
+		if len(projects) > 0 && !projects[projectID] {
+			continue
+		}
+
+		taskID := int64(binary.BigEndian.Uint64(key[len(key)-8:]))
+		weight := math.Float64frombits(binary.BigEndian.Uint64(value[:8]))
+		foundTerm := key[len(embeddedTermPrefix) : len(key)-9]
+		if !bytes.Equal(foundTerm, []byte(term)) {
+			weight *= embeddedPrefixMatchFactor
+		}
+
+		scores[taskID] += weight
+	}
+
+	return scores, iter.Error()
+}
+
+// Search returns all tasks which contain all words of the query, or whose identifier is part of the query.
+func (e *EmbeddedBackend) Search(_ *xorm.Session, query string, projectIDs []int64) (taskIDs []int64, err error) {
+	projects := make(map[int64]bool, len(projectIDs))
+	for _, id := range projectIDs {
+		projects[id] = true
+	}
+
+	identifiers := []string{}
+	words := []string{}
+	for _, part := range strings.Fields(strings.ToLower(query)) {
+		if identifierRegex.MatchString(part) {
+			identifiers = append(identifiers, part)
+		}
+		for _, token := range tokenize(part) {
+			if len(token) <= embeddedMaxTermLength {
+				words = append(words, token)
+			}
+		}
+	}
+
+	var scores map[int64]float64
+	for _, word := range words {
+		wordScores, err := e.findTerm(word, false, projects)
+		if err != nil {
+			return nil, err
+		}
+
+		if scores == nil {
+			scores = wordScores
+			continue
+		}
+
+		// Only tasks containing all words are found
+		for id, score := range scores {
+			wordScore, has := wordScores[id]
+			if !has {
+				delete(scores, id)
+				continue
+			}
+			scores[id] = score + wordScore
+		}
+	}
+
+	if scores == nil {
+		scores = make(map[int64]float64)
+	}
+
+	for _, identifier := range identifiers {
+		identifierScores, err := e.findTerm(identifier, true, projects)
+		if err != nil {
+			return nil, err
+		}
+		for id, score := range identifierScores {
+			scores[id] += score
+		}
+	}
+
+	return sortByScore(scores), nil
+}
@@ -0,0 +1,93 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedBackend(t *testing.T) {
+	e, err := NewEmbeddedBackend(t.TempDir())
+	require.NoError(t, err)
+	defer e.Close()
+
+	docs := []*Document{
+		{TaskID: 1, ProjectID: 1, Index: 1, Identifier: "PROJ-1", Title: "Water the plants", Labels: []string{"Garden"}},
+		{TaskID: 2, ProjectID: 1, Index: 2, Identifier: "PROJ-2", Title: "Buy plant food", Description: "<p>For the <b>garden</b></p>"},
+		{TaskID: 3, ProjectID: 2, Index: 1, Title: "Repot the plants", Comments: []string{"The garden plants too?"}},
+	}
+	for _, doc := range docs {
+		require.NoError(t, e.Index(doc))
+	}
+
+	t.Run("ranked results", func(t *testing.T) {
+		ids, err := e.Search(nil, "garden", nil)
+		require.NoError(t, err)
+		// Label > description > comment
+		assert.Equal(t, []int64{1, 2, 3}, ids)
+	})
+	t.Run("all words need to match", func(t *testing.T) {
+		ids, err := e.Search(nil, "plants garden", nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{1, 3}, ids)
+	})
+	t.Run("prefix", func(t *testing.T) {
+		ids, err := e.Search(nil, "plant", nil)
+		require.NoError(t, err)
+		// The exact match comes first
+		assert.Equal(t, int64(2), ids[0])
+		assert.Len(t, ids, 3)
+	})
+	t.Run("projects", func(t *testing.T) {
+		ids, err := e.Search(nil, "plants", []int64{2})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3}, ids)
+	})
+	t.Run("identifier", func(t *testing.T) {
+		ids, err := e.Search(nil, "proj-2", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2}, ids)
+
+		ids, err = e.Search(nil, "#1", nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []int64{1, 3}, ids)
+	})
+	t.Run("update", func(t *testing.T) {
+		require.NoError(t, e.Index(&Document{TaskID: 1, ProjectID: 1, Title: "Mow the lawn"}))
+		ids, err := e.Search(nil, "water", nil)
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+		ids, err = e.Search(nil, "lawn", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, e.Delete(2))
+		ids, err := e.Search(nil, "food", nil)
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+	t.Run("clear", func(t *testing.T) {
+		require.NoError(t, e.Clear())
+		ids, err := e.Search(nil, "plants", nil)
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+}
@@ -0,0 +1,151 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// Document holds everything about a task which can be searched for
+type Document struct {
+	TaskID    int64
+	ProjectID int64
+	// The identifier of the task, like PROJ-12 or #12
+	Identifier  string
+	Index       int64
+	Title       string
+	Description string
+	Labels      []string
+	Comments    []string
+}
+
+// Backend defines an interface for searching tasks
+type Backend interface {
+	// Search returns the ids of all tasks in one of the projects matching the query, the most relevant first.
+	// If no project ids are passed, the tasks of all projects are searched.
+	Search(s *xorm.Session, query string, projectIDs []int64) (taskIDs []int64, err error)
+	// KeepsIndex returns true if the backend needs to be updated when tasks change
+	KeepsIndex() bool
+	// Index adds a task to the index or replaces it
+	Index(doc *Document) (err error)
+	// Delete removes a task from the index
+	Delete(taskID int64) (err error)
+	// Clear removes all tasks from the index
+	Clear() (err error)
+}
+
+// QueryBackend is implemented by backends which search directly in the database. Their condition can be combined
+// with the filters, sorting and pagination of the query which gets the tasks.
+type QueryBackend interface {
+	// SearchCond returns a condition matching all tasks containing the query and an expression with the
+	// relevance of a task for the query, higher values are more relevant.
+	SearchCond(query string) (cond, relevance builder.Cond, err error)
+}
+
+var backend Backend
+
+// InitBackend initializes the configured search backend
+func InitBackend() {
+	switch config.SearchType.GetString() {
+	case "embedded":
+		path := config.SearchEmbeddedPath.GetString()
+		b, err := NewEmbeddedBackend(path)
+		if err != nil {
+			log.Fatalf("Could not open search index at %s: %s", path, err)
+		}
+		backend = b
+	case "database":
+		fallthrough
+	default:
+		backend = &DatabaseBackend{}
+	}
+}
+
+func getBackend() Backend {
+	if backend == nil {
+		backend = &DatabaseBackend{}
+	}
+	return backend
+}
+
+// SearchCond returns the condition and relevance expression of the configured backend if it searches directly
+// in the database. ok is false if the backend keeps its own index and Search needs to be used instead.
+func SearchCond(query string) (cond, relevance builder.Cond, ok bool, err error) {
+	qb, is := getBackend().(QueryBackend)
+	if !is {
+		return nil, nil, false, nil
+	}
+
+	cond, relevance, err = qb.SearchCond(query)
+	return cond, relevance, true, err
+}
+
+// Search returns the ids of all tasks in one of the projects matching the query, the most relevant first.
+func Search(s *xorm.Session, query string, projectIDs []int64) (taskIDs []int64, err error) {
+	return getBackend().Search(s, query, projectIDs)
+}
+
+// KeepsIndex returns true if the configured backend needs to be updated when tasks change
+func KeepsIndex() bool {
+	return getBackend().KeepsIndex()
+}
+
+// Index adds a task to the index of the configured backend
+func Index(doc *Document) error {
+	return getBackend().Index(doc)
+}
+
+// Delete removes a task from the index of the configured backend
+func Delete(taskID int64) error {
+	return getBackend().Delete(taskID)
+}
+
+// Clear removes all tasks from the index of the configured backend
+func Clear() error {
+	return getBackend().Clear()
+}
+
+// Close closes the index of the configured backend, if it has one
+func Close() error {
+	if closer, is := getBackend().(io.Closer); is {
+		return closer.Close()
+	}
+	return nil
+}
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// stripHTML removes all html tags from descriptions and comments
+func stripHTML(s string) string {
+	return htmlTagRegex.ReplaceAllString(s, " ")
+}
+
+// tokenize splits a text into lowercase words
+func tokenize(text string) (tokens []string) {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,56 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package routes
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	auth2 "code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+
+	"github.com/labstack/echo/v4"
+)
+
+// checkUserIsInstanceAdmin only lets requests from instance admins through.
+// Api tokens can never be used to access admin routes, even if they belong to an admin.
+func checkUserIsInstanceAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, is := c.Get("api_token").(*models.APIToken); is {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+
+		a, err := auth2.GetAuthFromClaims(c)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+
+		s := db.NewSession()
+		defer s.Close()
+
+		is, err := models.IsInstanceAdmin(s, a)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+		if !is {
+			return handler.HandleHTTPError(&user.ErrUserIsNotAdmin{UserID: a.GetID()}, c)
+		}
+
+		return next(c)
+	}
+}
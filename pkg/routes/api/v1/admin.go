@@ -0,0 +1,117 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminUserPassword holds the new password of a user. Used by admins to reset it.
+type AdminUserPassword struct {
+	// The new password. If empty, the user gets an email with a link to reset their password instead.
+	Password string `json:"password"`
+}
+
+// AdminResetUserPassword is the handler to reset the password of any user
+// @Summary Reset a user's password
+// @Description Sets a new password for a user or, if no password is provided, sends them a password reset email. Only available to instance admins.
+// @tags admin
+// @Accept json
+// @Produce json
+// @Param user path int true "User ID"
+// @Param password body v1.AdminUserPassword true "The new password."
+// @Security JWTKeyAuth
+// @Success 200 {object} models.Message
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 404 {object} web.HTTPError "User does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /admin/users/{user}/password [post]
+func AdminResetUserPassword(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("user"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user id.")
+	}
+
+	var pw AdminUserPassword
+	if err := c.Bind(&pw); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "No password provided.")
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	u, err := user.GetUserWithEmail(s, &user.User{ID: userID})
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	message := "The password was reset successfully."
+	if pw.Password == "" {
+		err = user.RequestUserPasswordResetToken(s, u)
+		message = "The user will receive an email with a link to reset their password."
+	} else {
+		err = user.UpdateUserPassword(s, u, pw.Password)
+	}
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: message})
+}
+
+// GetInstanceStats is the handler to get statistics about the instance
+// @Summary Get instance statistics
+// @Description Returns the number of users, projects, tasks and teams on this instance. Only available to instance admins.
+// @tags admin
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} models.InstanceStats
+// @Failure 403 {object} web.HTTPError "The user is not an instance admin."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /admin/stats [get]
+func GetInstanceStats(c echo.Context) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	stats, err := models.GetInstanceStats(s)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,39 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetAvailableAPITokenScopes returns a list of all scopes which can be used for api tokens
+// @Summary Get all possible api token scopes
+// @Description Returns a list of all scopes which can be used when creating an api token. Each scope is a route group and either "read" or "write".
+// @tags api
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} string "The list of all possible api token scopes"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /tokens/scopes [get]
+func GetAvailableAPITokenScopes(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.GetAvailableAPITokenScopes())
+}
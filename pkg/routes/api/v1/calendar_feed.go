@@ -0,0 +1,69 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package v1
+
+import (
+	"net/http"
+	"strings"
+
+	"code.vikunja.io/api/pkg/caldav"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/web/handler"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetCalendarFeed is the handler to return the tasks of a calendar feed
+// @Summary Get the tasks of a calendar feed
+// @Description Returns the tasks of a calendar feed as iCalendar file. Every task with a start or due date is included as event, feeds created with include_todos contain all tasks as todos as well. This does not need authentication, the token of the feed is the secret. Calendar apps can subscribe to it with the webcal:// scheme.
+// @tags user
+// @Produce text/calendar
+// @Param token path string true "The token of the feed. It may end with .ics."
+// @Success 200 {string} string "The calendar with the tasks of the feed."
+// @Failure 403 {object} web.HTTPError "The user who created the feed no longer has access to its project."
+// @Failure 404 {object} web.HTTPError "The feed does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /feeds/{token} [get]
+func GetCalendarFeed(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	s := db.NewSession()
+	defer s.Close()
+
+	feed, err := models.GetCalendarFeedByToken(s, token)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	name, tasks, err := feed.GetTasks(s)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	feedTasks := make([]*models.TaskWithComments, 0, len(tasks))
+	for _, t := range tasks {
+		feedTasks = append(feedTasks, &models.TaskWithComments{Task: *t})
+	}
+
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(caldav.GetCaldavEventsForTasks(name, feedTasks, feed.IncludeTodos)))
+}
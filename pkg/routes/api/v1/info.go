@@ -49,17 +49,23 @@ type vikunjaInfos struct {
 	EmailRemindersEnabled      bool      `json:"email_reminders_enabled"`
 	UserDeletionEnabled        bool      `json:"user_deletion_enabled"`
 	TaskCommentsEnabled        bool      `json:"task_comments_enabled"`
+	WebhooksEnabled            bool      `json:"webhooks_enabled"`
 }
 
 type authInfo struct {
 	Local         localAuthInfo  `json:"local"`
 	OpenIDConnect openIDAuthInfo `json:"openid_connect"`
+	Ldap          ldapAuthInfo   `json:"ldap"`
 }
 
 type localAuthInfo struct {
 	Enabled bool `json:"enabled"`
 }
 
+type ldapAuthInfo struct {
+	Enabled bool `json:"enabled"`
+}
+
 type openIDAuthInfo struct {
 	Enabled     bool               `json:"enabled"`
 	RedirectURL string             `json:"redirect_url"`
@@ -92,6 +98,7 @@ func Info(c echo.Context) error {
 		EmailRemindersEnabled:  config.ServiceEnableEmailReminders.GetBool(),
 		UserDeletionEnabled:    config.ServiceEnableUserDeletion.GetBool(),
 		TaskCommentsEnabled:    config.ServiceEnableTaskComments.GetBool(),
+		WebhooksEnabled:        config.WebhooksEnabled.GetBool(),
 		AvailableMigrators: []string{
 			(&vikunja_file.FileMigrator{}).Name(),
 			(&ticktick.Migrator{}).Name(),
@@ -108,6 +115,9 @@ func Info(c echo.Context) error {
 				Enabled:     config.AuthOpenIDEnabled.GetBool(),
 				RedirectURL: config.AuthOpenIDRedirectURL.GetString(),
 			},
+			Ldap: ldapAuthInfo{
+				Enabled: config.AuthLdapEnabled.GetBool(),
+			},
 		},
 	}
 
@@ -50,6 +50,7 @@ type LinkShareAuth struct {
 // @Param share path string true "The share hash"
 // @Success 200 {object} auth.Token "The valid jwt auth token."
 // @Failure 400 {object} web.HTTPError "Invalid link share object provided."
+// @Failure 403 {object} web.HTTPError "The link share has expired or reached its usage limit."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /shares/{share}/auth [post]
 func AuthenticateLinkShare(c echo.Context) error {
@@ -64,16 +65,35 @@ func AuthenticateLinkShare(c echo.Context) error {
 
 	share, err := models.GetLinkShareByHash(s, sh.Hash)
 	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = models.CheckLinkShareCanBeUsed(share)
+	if err != nil {
+		_ = s.Rollback()
 		return handler.HandleHTTPError(err, c)
 	}
 
 	if share.SharingType == models.SharingTypeWithPassword {
 		err := models.VerifyLinkSharePassword(share, sh.Password)
 		if err != nil {
+			_ = s.Rollback()
 			return handler.HandleHTTPError(err, c)
 		}
 	}
 
+	err = models.RecordLinkShareUsage(s, share, c.RealIP())
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
 	t, err := auth.NewLinkShareJWTAuthtoken(share)
 	if err != nil {
 		return handler.HandleHTTPError(err, c)
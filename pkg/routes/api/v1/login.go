@@ -21,9 +21,12 @@ import (
 
 	"code.vikunja.io/api/pkg/modules/keyvalue"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
 	"code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/api/pkg/modules/auth/ldap"
 	user2 "code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web/handler"
 
@@ -33,7 +36,7 @@ import (
 
 // Login is the login handler
 // @Summary Login
-// @Description Logs a user in. Returns a JWT-Token to authenticate further requests.
+// @Description Logs a user in. Returns a short-lived JWT-Token to authenticate further requests and a refresh token to get new ones.
 // @tags user
 // @Accept json
 // @Produce json
@@ -52,11 +55,34 @@ func Login(c echo.Context) error {
 	s := db.NewSession()
 	defer s.Close()
 
-	// Check user
-	user, err := user2.CheckUserCredentials(s, &u)
-	if err != nil {
-		_ = s.Rollback()
-		return handler.HandleHTTPError(err, c)
+	var user *user2.User
+	var err error
+	var ldapErr error
+
+	// LDAP users log in with the same form, local users are only checked if the ldap server does not know them
+	// or can't be reached.
+	if config.AuthLdapEnabled.GetBool() {
+		user, ldapErr = ldap.AuthenticateUserInLDAP(s, u.Username, u.Password)
+		if ldapErr != nil && !config.AuthLocalEnabled.GetBool() {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(ldapErr, c)
+		}
+		if ldapErr != nil && !user2.IsErrWrongUsernameOrPassword(ldapErr) {
+			log.Errorf("Could not authenticate user in LDAP, trying local login instead: %s", ldapErr)
+		}
+	}
+
+	if user == nil {
+		// Check user
+		user, err = user2.CheckUserCredentials(s, &u)
+		// The user exists, but only in ldap. Their ldap login failed, so that's the error to show.
+		if user2.IsErrAccountIsNotLocal(err) && ldapErr != nil {
+			err = ldapErr
+		}
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
 	}
 
 	if user.Status == user2.StatusDisabled {
@@ -120,7 +146,11 @@ func RenewToken(c echo.Context) (err error) {
 	s := db.NewSession()
 	defer s.Close()
 
-	jwtinf := c.Get("user").(*jwt.Token)
+	// Api tokens can't be renewed
+	jwtinf, is := c.Get("user").(*jwt.Token)
+	if !is {
+		return c.JSON(http.StatusBadRequest, models.Message{Message: "Only user token are available for renew."})
+	}
 	claims := jwtinf.Claims.(jwt.MapClaims)
 	typ := int(claims["type"].(float64))
 	if typ == auth.AuthTypeLinkShare {
@@ -151,6 +181,33 @@ func RenewToken(c echo.Context) (err error) {
 		return handler.HandleHTTPError(err, c)
 	}
 
+	// Tokens which belong to a session get a new short-lived token for the same session
+	sessionID, has := auth.GetSessionIDFromClaims(claims)
+	if has {
+		session, err := user2.GetActiveSession(s, user.ID, sessionID)
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
+
+		err = user2.TouchSession(s, session, c.RealIP())
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
+
+		if err := s.Commit(); err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
+
+		t, err := auth.NewUserJWTAuthtokenForSession(user, session)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+		return c.JSON(http.StatusOK, auth.Token{Token: t})
+	}
+
 	if err := s.Commit(); err != nil {
 		_ = s.Rollback()
 		return handler.HandleHTTPError(err, c)
@@ -165,3 +222,69 @@ func RenewToken(c echo.Context) (err error) {
 	// Create token
 	return auth.NewUserAuthTokenResponse(user, c, long)
 }
+
+// RefreshTokenRequest holds the refresh token a client wants to exchange for a new token pair
+type RefreshTokenRequest struct {
+	// The refresh token returned by the login or the last refresh.
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken exchanges a refresh token for a new access and refresh token
+// @Summary Refresh a user session
+// @Description Returns a new short-lived access token and a new refresh token for the session the refresh token belongs to. Every refresh token can only be used once.
+// @tags user
+// @Accept json
+// @Produce json
+// @Param token body v1.RefreshTokenRequest true "The refresh token."
+// @Success 200 {object} auth.Token
+// @Failure 400 {object} models.Message "No refresh token provided."
+// @Failure 401 {object} web.HTTPError "The refresh token is invalid or the session expired."
+// @Failure 412 {object} web.HTTPError "The user account is disabled."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/token/refresh [post]
+func RefreshToken(c echo.Context) (err error) {
+	req := &RefreshTokenRequest{}
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Message{Message: "Please provide a refresh token."})
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	session, refreshToken, err := user2.RotateRefreshToken(s, req.RefreshToken, c.RealIP())
+	if user2.IsErrRefreshTokenReused(err) {
+		// The session was revoked, that needs to be saved even though the request fails
+		if err := s.Commit(); err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
+		return handler.HandleHTTPError(err, c)
+	}
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	u, err := user2.GetUserWithEmail(s, &user2.User{ID: session.UserID})
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if u.Status == user2.StatusDisabled {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(&user2.ErrAccountDisabled{UserID: u.ID}, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	t, err := auth.NewUserJWTAuthtokenForSession(u, session)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, auth.Token{Token: t, RefreshToken: refreshToken})
+}
@@ -0,0 +1,136 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func getCurrentSessionID(c echo.Context) string {
+	jwtinf, is := c.Get("user").(*jwt.Token)
+	if !is {
+		return ""
+	}
+	sessionID, _ := auth.GetSessionIDFromClaims(jwtinf.Claims.(jwt.MapClaims))
+	return sessionID
+}
+
+// GetUserSessions returns all active sessions of the current user
+// @Summary Get all sessions of the current user
+// @Description Returns all active sessions of the current user with the device, ip address and time they were last used.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} user.Session
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/sessions [get]
+func GetUserSessions(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	sessions, err := user.GetSessionsForUser(s, u.ID, getCurrentSessionID(c))
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// DeleteUserSession revokes one session of the current user
+// @Summary Revoke a session
+// @Description Revokes a session of the current user. Its refresh token and all access tokens issued for it stop working.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Param session path string true "The session id."
+// @Success 200 {object} models.Message "Successfully revoked."
+// @Failure 404 {object} web.HTTPError "The session does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/sessions/{session} [delete]
+func DeleteUserSession(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	err = user.DeleteSession(s, u.ID, c.Param("session"))
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "The session was revoked successfully."})
+}
+
+// DeleteAllUserSessions revokes all sessions of the current user
+// @Summary Revoke all sessions
+// @Description Revokes all sessions of the current user, including the one the request was made with.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} models.Message "Successfully revoked."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/sessions [delete]
+func DeleteAllUserSessions(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	err = user.DeleteAllSessionsForUser(s, u.ID)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "All sessions were revoked successfully."})
+}
@@ -36,6 +36,7 @@ type userWithSettings struct {
 	Settings            *UserSettings `json:"settings"`
 	DeletionScheduledAt time.Time     `json:"deletion_scheduled_at"`
 	IsLocalUser         bool          `json:"is_local_user"`
+	IsInstanceAdmin     bool          `json:"is_admin"`
 }
 
 // UserShow gets all informations about the current user
@@ -79,6 +80,7 @@ func UserShow(c echo.Context) error {
 		},
 		DeletionScheduledAt: u.DeletionScheduledAt,
 		IsLocalUser:         u.Issuer == user.IssuerLocal,
+		IsInstanceAdmin:     u.IsInstanceAdmin(),
 	}
 
 	return c.JSON(http.StatusOK, us)
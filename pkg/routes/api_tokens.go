@@ -0,0 +1,123 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	auth2 "code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+
+	"github.com/golang-jwt/jwt/v5"
+	echojwt "github.com/labstack/echo-jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+func getAPITokenFromRequest(c echo.Context) (token string, is bool) {
+	authHeader := c.Request().Header.Get("Authorization")
+	token = strings.TrimPrefix(authHeader, "Bearer ")
+	return token, token != authHeader && strings.HasPrefix(token, models.APITokenPrefix)
+}
+
+// setupAuthMiddleware makes sure all requests are authenticated with either a jwt or an api token.
+func setupAuthMiddleware(a *echo.Group) {
+	a.Use(echojwt.WithConfig(echojwt.Config{
+		SigningKey: []byte(config.ServiceJWTSecret.GetString()),
+		Skipper: func(c echo.Context) bool {
+			_, is := getAPITokenFromRequest(c)
+			return is
+		},
+	}))
+	a.Use(checkAPITokenAndPutItInContext)
+	a.Use(checkUserSessionIsActive)
+}
+
+// checkUserSessionIsActive rejects user jwts whose session was revoked or expired.
+// Tokens without a session are passed through unchanged, they are only valid until they expire.
+func checkUserSessionIsActive(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		jwtinf, is := c.Get("user").(*jwt.Token)
+		if !is {
+			return next(c)
+		}
+
+		claims := jwtinf.Claims.(jwt.MapClaims)
+		sessionID, has := auth2.GetSessionIDFromClaims(claims)
+		if !has {
+			return next(c)
+		}
+
+		u, err := user.GetUserFromClaims(claims)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err = user.GetActiveSession(s, u.ID, sessionID)
+		if user.IsErrSessionDoesNotExist(err) {
+			log.Debugf("Session %s of user %d is not active anymore", sessionID, u.ID)
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+
+		return next(c)
+	}
+}
+
+// checkAPITokenAndPutItInContext checks the api token of a request against the scopes of the route and puts the owner
+// of the token into the context. Requests without an api token are passed through unchanged.
+func checkAPITokenAndPutItInContext(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		tokenString, is := getAPITokenFromRequest(c)
+		if !is {
+			return next(c)
+		}
+
+		s := db.NewSession()
+		defer s.Close()
+
+		token, err := models.GetTokenFromTokenString(s, tokenString)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+
+		if !token.CanAccessRoute(c.Request().Method, c.Path()) {
+			log.Debugf("API token %d is not allowed to access %s %s", token.ID, c.Request().Method, c.Path())
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+
+		u, err := user.GetUserByID(s, token.OwnerID)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+
+		c.Set("api_token", token)
+		c.Set("api_user", u)
+
+		return next(c)
+	}
+}
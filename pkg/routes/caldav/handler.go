@@ -18,13 +18,16 @@ package caldav
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
 
 	caldav2 "code.vikunja.io/api/pkg/caldav"
+	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
 	"code.vikunja.io/api/pkg/user"
@@ -77,10 +80,37 @@ func ProjectHandler(c echo.Context) error {
 	log.Debugf("[CALDAV] Request Body: %v\n", string(body))
 	log.Debugf("[CALDAV] Request Headers: %v\n", c.Request().Header)
 
+	// caldav-go does not support sync-collection reports, we handle them ourselves
+	if projectID != 0 && c.Request().Method == "REPORT" {
+		if request, is := parseSyncCollectionRequest(body); is {
+			if projectID < 0 {
+				return syncPreconditionFailed(c, "supported-report")
+			}
+			return handleSyncCollection(c, projectID, u, request)
+		}
+	}
+
 	caldav.SetupStorage(storage)
 	caldav.SetupUser("dav/projects")
 	caldav.SetupSupportedComponents([]string{lib.VCALENDAR, lib.VTODO})
 	response := caldav.HandleRequest(c.Request())
+
+	if projectID != 0 && c.Request().Method == "PROPFIND" && response.Status == http.StatusMultiStatus {
+		s := db.NewSession()
+		sequence, err := getProjectSyncSequence(s, storage.project)
+		s.Close()
+		if err != nil {
+			log.Error(err)
+			return echo.ErrInternalServerError
+		}
+		response.Body = addSyncPropsToPropfind(response.Body, body, projectID, sequence)
+	}
+
+	// The sync sequences of all projects were already collected while listing them
+	if projectID == 0 && c.Request().Method == "PROPFIND" && response.Status == http.StatusMultiStatus {
+		response.Body = addSyncPropsToProjectListing(response.Body, body, storage.syncSequences)
+	}
+
 	response.Write(c.Response())
 	return nil
 }
@@ -109,6 +139,16 @@ func TaskHandler(c echo.Context) error {
 
 	caldav.SetupStorage(storage)
 	response := caldav.HandleRequest(c.Request())
+
+	if errors.Is(response.Error, errCannotCreateInPseudoProject) {
+		// Creating a task needs the bind privilege on the collection, which saved filters and the favorites don't grant.
+		condition := `<D:need-privileges><D:resource>` +
+			`<D:href>` + ProjectBasePath + `/` + strconv.FormatInt(projectID, 10) + `/</D:href>` +
+			`<D:privilege><D:bind/></D:privilege>` +
+			`</D:resource></D:need-privileges>`
+		return c.Blob(http.StatusForbidden, mimeXML, []byte(davError(condition)))
+	}
+
 	response.Write(c.Response())
 	return nil
 }
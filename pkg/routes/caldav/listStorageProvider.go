@@ -17,6 +17,9 @@
 package caldav
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -38,6 +41,10 @@ const DavBasePath = `/dav/`
 // ProjectBasePath is the base path for all projects resources
 const ProjectBasePath = DavBasePath + `projects`
 
+// errCannotCreateInPseudoProject is returned when creating a task in a saved filter or the favorites pseudo project
+// while the user has no default project the task could be created in instead.
+var errCannotCreateInPseudoProject = errors.New("tasks can't be created in a saved filter or the favorites")
+
 // VikunjaCaldavProjectStorage represents a project storage
 type VikunjaCaldavProjectStorage struct {
 	// Used when handling a project
@@ -48,6 +55,8 @@ type VikunjaCaldavProjectStorage struct {
 	user        *user2.User
 	isPrincipal bool
 	isEntry     bool // Entry level handling should only return a link to the principal url
+	// The sync sequences of all projects returned when listing them
+	syncSequences map[int64]int64
 }
 
 // GetResources returns either all projects, links to the principal, or only one project, depending on the request
@@ -103,24 +112,60 @@ func (vcls *VikunjaCaldavProjectStorage) GetResources(rpath string, _ bool) ([]d
 		_ = s.Rollback()
 		return nil, err
 	}
-	if err := s.Commit(); err != nil {
+	projects := []*models.Project{}
+	for _, p := range theprojects.([]*models.Project) {
+		// The saved filters pseudo project only groups the filters and does not hold any tasks itself
+		if p.ID == models.SavedFiltersPseudoProject.ID {
+			continue
+		}
+		projects = append(projects, p)
+	}
+
+	// Saved filters are available as their own calendars
+	filters, err := models.GetSavedFiltersAsProjects(s, vcls.user)
+	if err != nil {
+		_ = s.Rollback()
 		return nil, err
 	}
-	projects := theprojects.([]*models.Project)
+	projects = append(projects, filters...)
 
 	var resources []data.Resource
+	vcls.syncSequences = make(map[int64]int64, len(projects))
 	for _, l := range projects {
+		project := &models.ProjectWithTasksAndBuckets{
+			Project: *l,
+		}
+
+		// The ctag of saved filters and favorites is derived from their tasks, it needs to be the same
+		// as the one returned when requesting the calendar directly.
+		if l.ID < 0 {
+			project.Tasks, err = getProjectTasks(s, l.ID, vcls.user)
+			if err != nil {
+				_ = s.Rollback()
+				return nil, err
+			}
+		}
+
+		syncSequence, err := getProjectSyncSequence(s, project)
+		if err != nil {
+			_ = s.Rollback()
+			return nil, err
+		}
+		vcls.syncSequences[l.ID] = syncSequence
 		rr := VikunjaProjectResourceAdapter{
-			project: &models.ProjectWithTasksAndBuckets{
-				Project: *l,
-			},
+			project:      project,
 			isCollection: true,
+			syncSequence: syncSequence,
 		}
 		r := data.NewResource(ProjectBasePath+"/"+strconv.FormatInt(l.ID, 10), &rr)
 		r.Name = l.Title
 		resources = append(resources, r)
 	}
 
+	if err := s.Commit(); err != nil {
+		return nil, err
+	}
+
 	return resources, nil
 }
 
@@ -157,7 +202,7 @@ func (vcls *VikunjaCaldavProjectStorage) GetResourcesByList(rpaths []string) ([]
 		rr := VikunjaProjectResourceAdapter{
 			task: t,
 		}
-		r := data.NewResource(getTaskURL(t), &rr)
+		r := data.NewResource(vcls.getTaskURL(t), &rr)
 		r.Name = t.Title
 		resources = append(resources, r)
 	}
@@ -179,7 +224,7 @@ func (vcls *VikunjaCaldavProjectStorage) GetResourcesByFilters(rpath string, _ *
 				task:         &t.Task,
 				isCollection: false,
 			}
-			r := data.NewResource(getTaskURL(&t.Task), &rr)
+			r := data.NewResource(vcls.getTaskURL(&t.Task), &rr)
 			r.Name = t.Title
 			resources = append(resources, r)
 		}
@@ -202,6 +247,15 @@ func getTaskURL(task *models.Task) string {
 	return ProjectBasePath + "/" + strconv.FormatInt(task.ProjectID, 10) + `/` + task.UID + `.ics`
 }
 
+// getTaskURL returns the url of a task inside the requested collection. Tasks shown in a saved filter or the
+// favorites pseudo project need to be inside that collection as well and not the one of their real project.
+func (vcls *VikunjaCaldavProjectStorage) getTaskURL(task *models.Task) string {
+	if vcls.project != nil && vcls.project.ID < 0 {
+		return ProjectBasePath + "/" + strconv.FormatInt(vcls.project.ID, 10) + `/` + task.UID + `.ics`
+	}
+	return getTaskURL(task)
+}
+
 // GetResource fetches a single resource
 func (vcls *VikunjaCaldavProjectStorage) GetResource(rpath string) (*data.Resource, bool, error) {
 
@@ -271,6 +325,16 @@ func (vcls *VikunjaCaldavProjectStorage) CreateResource(rpath, content string) (
 
 	vTask.ProjectID = vcls.project.ID
 
+	// Saved filters and the favorites pseudo project can't hold tasks of their own,
+	// new tasks created in them are put into the default project of the user.
+	if vcls.project.ID < 0 {
+		if vcls.user.DefaultProjectID == 0 {
+			return nil, errCannotCreateInPseudoProject
+		}
+		vTask.ProjectID = vcls.user.DefaultProjectID
+		vTask.IsFavorite = vcls.project.ID == models.FavoritesPseudoProject.ID
+	}
+
 	// Check the rights
 	canCreate, err := vTask.CanCreate(s, vcls.user)
 	if err != nil {
@@ -294,6 +358,18 @@ func (vcls *VikunjaCaldavProjectStorage) CreateResource(rpath, content string) (
 		return nil, err
 	}
 
+	err = persistRelations(s, vcls.user, vcls.task, vTask.RelatedTasks)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	err = persistAttachments(s, vcls.user, vcls.task, vTask.Attachments)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
 	if err := s.Commit(); err != nil {
 		return nil, err
 	}
@@ -345,6 +421,18 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 		return nil, err
 	}
 
+	err = persistRelations(s, vcls.user, vcls.task, vTask.RelatedTasks)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	err = persistAttachments(s, vcls.user, vcls.task, vTask.Attachments)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
 	if err := s.Commit(); err != nil {
 		return nil, err
 	}
@@ -432,11 +520,151 @@ func persistLabels(s *xorm.Session, a web.Auth, task *models.Task, labels []*mod
 	return task.UpdateTaskLabels(s, a, labels)
 }
 
+// persistRelations syncs the relations of a task with the tasks referenced by uid in the RELATED-TO properties of a caldav task.
+// Referenced tasks which don't exist or which the user does not have access to are ignored.
+func persistRelations(s *xorm.Session, a web.Auth, task *models.Task, relatedTasks models.RelatedTaskMap) error {
+	err := removeStaleRelations(s, a, task, relatedTasks)
+	if err != nil {
+		return err
+	}
+
+	for kind, related := range relatedTasks {
+		uids := make([]string, 0, len(related))
+		for _, t := range related {
+			uids = append(uids, t.UID)
+		}
+
+		otherTasks, err := models.GetTasksByUIDs(s, uids, a)
+		if err != nil {
+			return err
+		}
+
+		for _, otherTask := range otherTasks {
+			relation := &models.TaskRelation{
+				TaskID:       task.ID,
+				OtherTaskID:  otherTask.ID,
+				RelationKind: kind,
+			}
+
+			can, err := relation.CanCreate(s, a)
+			if err != nil {
+				return err
+			}
+			if !can {
+				log.Debugf("[CALDAV] User %d can't relate task %d to task %d, ignoring the relation", a.GetID(), task.ID, otherTask.ID)
+				continue
+			}
+
+			err = relation.Create(s, a)
+			if models.IsErrRelationAlreadyExists(err) || models.IsErrRelationTasksCannotBeTheSame(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeStaleRelations deletes the parent, subtask and related relations of a task which are not part of the
+// RELATED-TO properties of its caldav task anymore. Other relation kinds can't be represented in caldav and are kept.
+func removeStaleRelations(s *xorm.Session, a web.Auth, task *models.Task, relatedTasks models.RelatedTaskMap) error {
+	existing := &models.Task{ID: task.ID}
+	err := existing.ReadOne(s, a)
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range []models.RelationKind{models.RelationKindParenttask, models.RelationKindSubtask, models.RelationKindRelated} {
+		uids := make(map[string]bool, len(relatedTasks[kind]))
+		for _, t := range relatedTasks[kind] {
+			uids[t.UID] = true
+		}
+
+		for _, otherTask := range existing.RelatedTasks[kind] {
+			// Tasks without a uid are not part of the caldav task, there is no way to tell if the relation was removed
+			if otherTask.UID == "" || uids[otherTask.UID] {
+				continue
+			}
+
+			relation := &models.TaskRelation{
+				TaskID:       task.ID,
+				OtherTaskID:  otherTask.ID,
+				RelationKind: kind,
+			}
+
+			can, err := relation.CanDelete(s, a)
+			if err != nil {
+				return err
+			}
+			if !can {
+				log.Debugf("[CALDAV] User %d can't remove the relation of task %d to task %d, keeping it", a.GetID(), task.ID, otherTask.ID)
+				continue
+			}
+
+			err = relation.Delete(s, a)
+			if models.IsErrRelationDoesNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// persistAttachments adds the inline attachments of a caldav task to it.
+// Clients send all attachments again with every update, that's why attachments with the same name and size
+// as an existing one are skipped.
+func persistAttachments(s *xorm.Session, a web.Auth, task *models.Task, attachments []*models.TaskAttachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	ta := &models.TaskAttachment{TaskID: task.ID}
+	result, _, _, err := ta.ReadAll(s, a, "", -1, 0)
+	if err != nil {
+		return err
+	}
+	existingAttachments, _ := result.([]*models.TaskAttachment)
+
+	for _, attachment := range attachments {
+		exists := false
+		for _, existing := range existingAttachments {
+			if existing.File != nil && existing.File.Name == attachment.File.Name && existing.File.Size == attachment.File.Size {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		attachment.TaskID = task.ID
+		err = attachment.NewAttachment(s, io.NopCloser(bytes.NewReader(attachment.File.FileContent)), attachment.File.Name, attachment.File.Size, a)
+		if models.IsErrTaskAttachmentIsTooLarge(err) {
+			log.Warningf("[CALDAV] Attachment %s of task %d is too large, ignoring it", attachment.File.Name, task.ID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // VikunjaProjectResourceAdapter holds the actual resource
 type VikunjaProjectResourceAdapter struct {
 	project      *models.ProjectWithTasksAndBuckets
 	projectTasks []*models.TaskWithComments
 	task         *models.Task
+	// The sync sequence of the project, used as its ctag
+	syncSequence int64
 
 	isPrincipal  bool
 	isCollection bool
@@ -468,10 +696,9 @@ func (vlra *VikunjaProjectResourceAdapter) CalculateEtag() string {
 		return ""
 	}
 
-	// This also returns the etag of the project, and not of the task,
-	// which becomes problematic because the client uses this etag (= the one from the project) to make
-	// Requests to update a task. These do not match and thus updating a task fails.
-	return `"` + strconv.FormatInt(vlra.project.ID, 10) + `-` + strconv.FormatInt(vlra.project.Updated.Unix(), 10) + `"`
+	// The etag of a project is also used as its ctag. It is derived from the same sequence as the sync token
+	// and therefore changes whenever a task of the project is created, changed or deleted.
+	return makeCtag(vlra.project.ID, vlra.syncSequence)
 }
 
 // GetContent returns the content string of a resource (a task in our case)
@@ -506,6 +733,26 @@ func (vlra *VikunjaProjectResourceAdapter) GetModTime() time.Time {
 	return time.Time{}
 }
 
+// getProjectTasks returns the tasks of a project, a saved filter or the favorites pseudo project.
+func getProjectTasks(s *xorm.Session, projectID int64, u *user2.User) (projectTasks []*models.TaskWithComments, err error) {
+	tk := models.TaskCollection{
+		ProjectID: projectID,
+	}
+	iface, _, _, err := tk.ReadAll(s, u, "", 1, 1000)
+	if err != nil {
+		return nil, err
+	}
+	tasks, ok := iface.([]*models.Task)
+	if !ok {
+		panic("Tasks returned from TaskCollection.ReadAll are not []*models.Task!")
+	}
+
+	for _, t := range tasks {
+		projectTasks = append(projectTasks, &models.TaskWithComments{Task: *t})
+	}
+	return
+}
+
 func (vcls *VikunjaCaldavProjectStorage) getProjectRessource(isCollection bool) (rr VikunjaProjectResourceAdapter, err error) {
 	s := db.NewSession()
 	defer s.Close()
@@ -532,25 +779,20 @@ func (vcls *VikunjaCaldavProjectStorage) getProjectRessource(isCollection bool)
 
 	projectTasks := vcls.project.Tasks
 	if projectTasks == nil {
-		tk := models.TaskCollection{
-			ProjectID: vcls.project.ID,
-		}
-		iface, _, _, err := tk.ReadAll(s, vcls.user, "", 1, 1000)
+		projectTasks, err = getProjectTasks(s, vcls.project.ID, vcls.user)
 		if err != nil {
 			_ = s.Rollback()
 			return rr, err
 		}
-		tasks, ok := iface.([]*models.Task)
-		if !ok {
-			panic("Tasks returned from TaskCollection.ReadAll are not []*models.Task!")
-		}
-
-		for _, t := range tasks {
-			projectTasks = append(projectTasks, &models.TaskWithComments{Task: *t})
-		}
 		vcls.project.Tasks = projectTasks
 	}
 
+	syncSequence, err := getProjectSyncSequence(s, vcls.project)
+	if err != nil {
+		_ = s.Rollback()
+		return rr, err
+	}
+
 	if err := s.Commit(); err != nil {
 		return rr, err
 	}
@@ -559,6 +801,7 @@ func (vcls *VikunjaCaldavProjectStorage) getProjectRessource(isCollection bool)
 		project:      vcls.project,
 		projectTasks: projectTasks,
 		isCollection: isCollection,
+		syncSequence: syncSequence,
 	}
 
 	return
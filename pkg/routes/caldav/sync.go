@@ -0,0 +1,326 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"encoding/xml"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samedi/caldav-go/data"
+	"github.com/samedi/caldav-go/ixml"
+	"xorm.io/xorm"
+)
+
+// Sync tokens need to be a valid uri (RFC 6578, section 3.2), the project id and sequence are appended to this.
+const syncTokenPrefix = `http://vikunja.io/ns/sync/`
+
+const mimeXML = `application/xml; charset=utf-8`
+
+var (
+	syncCollectionTag     = xml.Name{Space: ixml.DAV_NS, Local: "sync-collection"}
+	syncTokenTag          = xml.Name{Space: ixml.DAV_NS, Local: "sync-token"}
+	supportedReportSetTag = xml.Name{Space: ixml.DAV_NS, Local: "supported-report-set"}
+)
+
+type syncCollectionRequest struct {
+	XMLName   xml.Name
+	SyncToken string `xml:"DAV: sync-token"`
+	Prop      struct {
+		Tags []xml.Name `xml:",any"`
+	} `xml:"DAV: prop"`
+}
+
+func makeSyncToken(projectID int64, sequence int64) string {
+	return syncTokenPrefix + strconv.FormatInt(projectID, 10) + "-" + strconv.FormatInt(sequence, 10)
+}
+
+// getProjectSyncSequence returns the sync sequence of a project. Saved filters and the favorites pseudo project
+// have no change history of their own, their sequence is a checksum of the tasks they currently contain instead.
+// It can only be used as their ctag, not as a sync token.
+func getProjectSyncSequence(s *xorm.Session, project *models.ProjectWithTasksAndBuckets) (int64, error) {
+	if project.ID > 0 {
+		return models.GetProjectSyncSequence(s, project.ID)
+	}
+
+	h := fnv.New64a()
+	for _, t := range project.Tasks {
+		_, _ = h.Write([]byte(strconv.FormatInt(t.ID, 10) + "-" + strconv.FormatInt(t.Updated.Unix(), 10) + ";"))
+	}
+	return int64(h.Sum64() >> 1), nil
+}
+
+// makeCtag returns the ctag of a project, which is also its etag
+func makeCtag(projectID int64, sequence int64) string {
+	return `"` + strconv.FormatInt(projectID, 10) + `-` + strconv.FormatInt(sequence, 10) + `"`
+}
+
+// parseSyncToken returns the sequence of a sync token. A token is only valid for the project it was issued for.
+func parseSyncToken(token string, projectID int64) (sequence int64, valid bool) {
+	if !strings.HasPrefix(token, syncTokenPrefix) {
+		return 0, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, syncTokenPrefix), "-")
+	if len(parts) != 2 || parts[0] != strconv.FormatInt(projectID, 10) {
+		return 0, false
+	}
+
+	sequence, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || sequence < 0 {
+		return 0, false
+	}
+
+	return sequence, true
+}
+
+func parseSyncCollectionRequest(body []byte) (request *syncCollectionRequest, is bool) {
+	request = &syncCollectionRequest{}
+	if err := xml.Unmarshal(body, request); err != nil {
+		return nil, false
+	}
+
+	return request, request.XMLName == syncCollectionTag
+}
+
+func davError(condition string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<D:error xmlns:D="DAV:">` + condition + `</D:error>`
+}
+
+func syncPreconditionFailed(c echo.Context, precondition string) error {
+	body := davError(ixml.Tag(xml.Name{Space: ixml.DAV_NS, Local: precondition}, ""))
+	return c.Blob(http.StatusForbidden, mimeXML, []byte(body))
+}
+
+// handleSyncCollection answers a sync-collection REPORT (RFC 6578) for a project. Without a sync token, all tasks
+// of the project are returned. With a token, only the tasks changed since that token are returned, deleted tasks
+// or tasks moved to another project are returned with a 404 status.
+func handleSyncCollection(c echo.Context, projectID int64, u *user.User, request *syncCollectionRequest) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	project := &models.Project{ID: projectID}
+	can, _, err := project.CanRead(s, u)
+	if err != nil {
+		_ = s.Rollback()
+		if models.IsErrProjectDoesNotExist(err) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		log.Errorf("[CALDAV] Could not check access to project %d: %s", projectID, err)
+		return echo.ErrInternalServerError
+	}
+	if !can {
+		_ = s.Rollback()
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	currentSequence, err := models.GetProjectSyncSequence(s, projectID)
+	if err != nil {
+		_ = s.Rollback()
+		log.Errorf("[CALDAV] Could not get the sync sequence of project %d: %s", projectID, err)
+		return echo.ErrInternalServerError
+	}
+
+	var tasks []*models.Task
+	var deleted []*models.TaskSyncChange
+
+	if request.SyncToken == "" {
+		// An initial sync gets everything
+		tc := &models.TaskCollection{ProjectID: projectID}
+		all, _, _, err := tc.ReadAll(s, u, "", -1, 0)
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf("[CALDAV] Could not get tasks of project %d: %s", projectID, err)
+			return echo.ErrInternalServerError
+		}
+		tasks = all.([]*models.Task)
+	} else {
+		sequence, valid := parseSyncToken(request.SyncToken, projectID)
+		if !valid || sequence > currentSequence {
+			_ = s.Rollback()
+			return syncPreconditionFailed(c, "valid-sync-token")
+		}
+
+		changes, err := models.GetTaskSyncChangesSince(s, projectID, sequence)
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf("[CALDAV] Could not get sync changes of project %d: %s", projectID, err)
+			return echo.ErrInternalServerError
+		}
+		for _, change := range changes {
+			if change.Deleted {
+				deleted = append(deleted, change)
+			}
+		}
+
+		tasks, err = models.GetTasksForSyncChanges(s, changes, u)
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf("[CALDAV] Could not get changed tasks of project %d: %s", projectID, err)
+			return echo.ErrInternalServerError
+		}
+	}
+
+	if err := s.Commit(); err != nil {
+		return err
+	}
+
+	var bf strings.Builder
+	bf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	bf.WriteString(`<D:multistatus ` + ixml.Namespaces() + `>`)
+	for _, t := range tasks {
+		resource := data.NewResource(getTaskURL(t), &VikunjaProjectResourceAdapter{task: t})
+		bf.WriteString("<D:response>")
+		bf.WriteString(ixml.HrefTag(resource.Path))
+		bf.WriteString(syncPropstats(&resource, request.Prop.Tags))
+		bf.WriteString("</D:response>")
+	}
+	for _, change := range deleted {
+		bf.WriteString("<D:response>")
+		bf.WriteString(ixml.HrefTag(getTaskURL(&models.Task{ProjectID: projectID, UID: change.TaskUID})))
+		bf.WriteString(ixml.StatusTag(http.StatusNotFound))
+		bf.WriteString("</D:response>")
+	}
+	bf.WriteString(ixml.Tag(syncTokenTag, makeSyncToken(projectID, currentSequence)))
+	bf.WriteString("</D:multistatus>")
+
+	return c.Blob(http.StatusMultiStatus, mimeXML, []byte(bf.String()))
+}
+
+func syncPropstats(resource *data.Resource, tags []xml.Name) string {
+	var found, notFound strings.Builder
+	for _, tag := range tags {
+		var content string
+		var ok bool
+		switch tag {
+		case ixml.GET_ETAG_TG:
+			content, ok = resource.GetEtag()
+		case ixml.CALENDAR_DATA_TG:
+			content, ok = resource.GetContentData()
+			content = ixml.EscapeText(content)
+		case ixml.GET_CONTENT_TYPE_TG:
+			content, ok = resource.GetContentType()
+		case ixml.GET_CONTENT_LENGTH_TG:
+			content, ok = resource.GetContentLength()
+		case ixml.GET_LAST_MODIFIED_TG:
+			content, ok = resource.GetLastModified(http.TimeFormat)
+		}
+
+		if ok {
+			found.WriteString(ixml.Tag(tag, content))
+			continue
+		}
+		notFound.WriteString(ixml.Tag(tag, ""))
+	}
+
+	propstats := ""
+	if found.String() != "" {
+		propstats += "<D:propstat><D:prop>" + found.String() + "</D:prop>" + ixml.StatusTag(http.StatusOK) + "</D:propstat>"
+	}
+	if notFound.String() != "" {
+		propstats += "<D:propstat><D:prop>" + notFound.String() + "</D:prop>" + ixml.StatusTag(http.StatusNotFound) + "</D:propstat>"
+	}
+	return propstats
+}
+
+// addSyncPropsToPropfind adds the getctag, sync-token and supported-report-set properties to the response of a
+// PROPFIND request for a project if the client asked for them, since caldav-go does not return them for projects.
+func addSyncPropsToPropfind(responseBody string, requestBody []byte, projectID int64, sequence int64) string {
+	request := &syncCollectionRequest{}
+	if err := xml.Unmarshal(requestBody, request); err != nil {
+		return responseBody
+	}
+
+	// The project itself is always the first response
+	project, rest, _ := strings.Cut(responseBody, "</D:response>")
+	return addSyncPropsToResponse(project+"</D:response>", request, projectID, sequence) + rest
+}
+
+// addSyncPropsToProjectListing does the same as addSyncPropsToPropfind for every project in the response of a
+// PROPFIND request listing all projects. sequences holds the sync sequence of every listed project.
+func addSyncPropsToProjectListing(responseBody string, requestBody []byte, sequences map[int64]int64) string {
+	request := &syncCollectionRequest{}
+	if err := xml.Unmarshal(requestBody, request); err != nil {
+		return responseBody
+	}
+
+	responses := strings.SplitAfter(responseBody, "</D:response>")
+	for i, response := range responses {
+		for projectID, sequence := range sequences {
+			href := ProjectBasePath + "/" + strconv.FormatInt(projectID, 10)
+			if strings.Contains(response, ixml.Tag(ixml.HREF_TG, href)) {
+				responses[i] = addSyncPropsToResponse(response, request, projectID, sequence)
+				break
+			}
+		}
+	}
+
+	return strings.Join(responses, "")
+}
+
+// addSyncPropsToResponse adds the sync properties of one project to its response in a multistatus body.
+func addSyncPropsToResponse(response string, request *syncCollectionRequest, projectID int64, sequence int64) string {
+	// Only real projects keep track of their changes and can be synced with a sync token
+	supportsSync := projectID > 0
+
+	var props strings.Builder
+	for _, tag := range request.Prop.Tags {
+		switch tag {
+		case syncTokenTag:
+			if !supportsSync {
+				continue
+			}
+			props.WriteString(ixml.Tag(syncTokenTag, makeSyncToken(projectID, sequence)))
+		case ixml.GET_CTAG_TG:
+			// caldav-go only returns the etag of resources which are not collections
+			props.WriteString(ixml.Tag(ixml.GET_CTAG_TG, makeCtag(projectID, sequence)))
+		case supportedReportSetTag:
+			supported := []xml.Name{ixml.CALENDAR_MULTIGET_TG, ixml.CALENDAR_QUERY_TG}
+			if supportsSync {
+				supported = append(supported, syncCollectionTag)
+			}
+			reports := ""
+			for _, report := range supported {
+				reports += "<D:supported-report><D:report>" + ixml.Tag(report, "") + "</D:report></D:supported-report>"
+			}
+			props.WriteString(ixml.Tag(supportedReportSetTag, reports))
+		default:
+			continue
+		}
+		// caldav-go reports the property as not found, which we need to remove
+		response = strings.Replace(response, ixml.Tag(tag, ""), "", 1)
+	}
+
+	if props.String() == "" {
+		return response
+	}
+
+	emptyNotFound := "<D:propstat><D:prop></D:prop>" + ixml.StatusTag(http.StatusNotFound) + "</D:propstat>"
+	response = strings.Replace(response, emptyNotFound, "", 1)
+
+	propstat := "<D:propstat><D:prop>" + props.String() + "</D:prop>" + ixml.StatusTag(http.StatusOK) + "</D:propstat>"
+	return strings.Replace(response, "</D:response>", propstat+"</D:response>", 1)
+}
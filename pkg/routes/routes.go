@@ -27,6 +27,8 @@
 // @description # Authorization
 // @description **JWT-Auth:** Main authorization method, used for most of the requests. Needs `Authorization: Bearer <jwt-token>`-header to authenticate successfully.
 // @description
+// @description **API Token:** Personal, scoped tokens created via `/tokens`. Use them like a jwt with an `Authorization: Bearer <api-token>`-header. API tokens start with `tk_`.
+// @description
 // @description **BasicAuth:** Only used when requesting tasks via CalDAV.
 // @description <!-- ReDoc-Inject: <security-definitions> -->
 // @BasePath /api/v1
@@ -77,7 +79,6 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	sentryecho "github.com/getsentry/sentry-go/echo"
-	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	elog "github.com/labstack/gommon/log"
@@ -241,9 +242,12 @@ func registerAPIRoutes(a *echo.Group) {
 	rateLimiter := createRateLimiter(rate)
 	ur.Use(RateLimit(rateLimiter, "ip"))
 
+	if config.AuthLocalEnabled.GetBool() || config.AuthLdapEnabled.GetBool() {
+		ur.POST("/login", apiv1.Login)
+	}
+
 	if config.AuthLocalEnabled.GetBool() {
 		// User stuff
-		ur.POST("/login", apiv1.Login)
 		ur.POST("/register", apiv1.RegisterUser)
 		ur.POST("/user/password/token", apiv1.UserRequestResetPasswordToken)
 		ur.POST("/user/password/reset", apiv1.UserResetPassword)
@@ -254,6 +258,9 @@ func registerAPIRoutes(a *echo.Group) {
 		ur.POST("/auth/openid/:provider/callback", openid.HandleCallback)
 	}
 
+	// Session refresh, works the same for all auth providers
+	ur.POST("/user/token/refresh", apiv1.RefreshToken)
+
 	// Testing
 	if config.ServiceTestingtoken.GetString() != "" {
 		n.PATCH("/test/:table", apiv1.HandleTesting)
@@ -270,8 +277,13 @@ func registerAPIRoutes(a *echo.Group) {
 		ur.POST("/shares/:share/auth", apiv1.AuthenticateLinkShare)
 	}
 
+	// Calendar feeds, authenticated by the token in their url
+	if config.ServiceEnableCaldav.GetBool() {
+		n.GET("/feeds/:token", apiv1.GetCalendarFeed)
+	}
+
 	// ===== Routes with Authentication =====
-	a.Use(echojwt.JWT([]byte(config.ServiceJWTSecret.GetString())))
+	setupAuthMiddleware(a)
 
 	// Rate limit
 	setupRateLimit(a, config.RateLimitKind.GetString())
@@ -288,6 +300,9 @@ func registerAPIRoutes(a *echo.Group) {
 	u.POST("/password", apiv1.UserChangePassword)
 	u.GET("s", apiv1.UserList)
 	u.POST("/token", apiv1.RenewToken)
+	u.GET("/sessions", apiv1.GetUserSessions)
+	u.DELETE("/sessions", apiv1.DeleteAllUserSessions)
+	u.DELETE("/sessions/:session", apiv1.DeleteUserSession)
 	u.POST("/settings/email", apiv1.UpdateUserEmail)
 	u.GET("/settings/avatar", apiv1.GetUserAvatarProvider)
 	u.POST("/settings/avatar", apiv1.ChangeUserAvatarProvider)
@@ -300,6 +315,17 @@ func registerAPIRoutes(a *echo.Group) {
 	u.GET("/settings/token/caldav", apiv1.GetCaldavTokens)
 	u.DELETE("/settings/token/caldav/:id", apiv1.DeleteCaldavToken)
 
+	if config.ServiceEnableCaldav.GetBool() {
+		calendarFeedHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.CalendarFeed{}
+			},
+		}
+		u.GET("/settings/token/feeds", calendarFeedHandler.ReadAllWeb)
+		u.PUT("/settings/token/feeds", calendarFeedHandler.CreateWeb)
+		u.DELETE("/settings/token/feeds/:feed", calendarFeedHandler.DeleteWeb)
+	}
+
 	if config.ServiceEnableTotp.GetBool() {
 		u.GET("/settings/totp", apiv1.UserTOTP)
 		u.POST("/settings/totp/enroll", apiv1.UserTOTPEnroll)
@@ -356,6 +382,48 @@ func registerAPIRoutes(a *echo.Group) {
 	a.POST("/projects/:project/buckets/:bucket", kanbanBucketHandler.UpdateWeb)
 	a.DELETE("/projects/:project/buckets/:bucket", kanbanBucketHandler.DeleteWeb)
 
+	projectViewHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectView{}
+		},
+	}
+	a.GET("/projects/:project/views", projectViewHandler.ReadAllWeb)
+	a.GET("/projects/:project/views/:view", projectViewHandler.ReadOneWeb)
+	a.PUT("/projects/:project/views", projectViewHandler.CreateWeb)
+	a.DELETE("/projects/:project/views/:view", projectViewHandler.DeleteWeb)
+	a.POST("/projects/:project/views/:view", projectViewHandler.UpdateWeb)
+
+	projectCustomFieldHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectCustomField{}
+		},
+	}
+	a.GET("/projects/:project/custom-fields", projectCustomFieldHandler.ReadAllWeb)
+	a.PUT("/projects/:project/custom-fields", projectCustomFieldHandler.CreateWeb)
+	a.GET("/projects/:project/custom-fields/:customfield", projectCustomFieldHandler.ReadOneWeb)
+	a.DELETE("/projects/:project/custom-fields/:customfield", projectCustomFieldHandler.DeleteWeb)
+	a.POST("/projects/:project/custom-fields/:customfield", projectCustomFieldHandler.UpdateWeb)
+
+	taskCustomFieldValueHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskCustomFieldValue{}
+		},
+	}
+	a.POST("/tasks/:task/custom-fields/:customfield", taskCustomFieldValueHandler.UpdateWeb)
+	a.DELETE("/tasks/:task/custom-fields/:customfield", taskCustomFieldValueHandler.DeleteWeb)
+	a.GET("/projects/:project/views/:view/tasks", taskCollectionHandler.ReadAllWeb)
+	a.GET("/projects/:project/views/:view/buckets", kanbanBucketHandler.ReadAllWeb)
+	a.PUT("/projects/:project/views/:view/buckets", kanbanBucketHandler.CreateWeb)
+	a.POST("/projects/:project/views/:view/buckets/:bucket", kanbanBucketHandler.UpdateWeb)
+	a.DELETE("/projects/:project/views/:view/buckets/:bucket", kanbanBucketHandler.DeleteWeb)
+
+	taskBucketHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskBucket{}
+		},
+	}
+	a.POST("/projects/:project/views/:view/buckets/:bucket/tasks", taskBucketHandler.UpdateWeb)
+
 	projectDuplicateHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.ProjectDuplicate{}
@@ -363,6 +431,59 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.PUT("/projects/:projectid/duplicate", projectDuplicateHandler.CreateWeb)
 
+	projectDependencyGraphHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectDependencyGraph{}
+		},
+	}
+	a.GET("/projects/:project/dependency-graph", projectDependencyGraphHandler.ReadOneWeb)
+
+	projectBurndownHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectBurndown{}
+		},
+	}
+	a.GET("/projects/:project/burndown", projectBurndownHandler.ReadOneWeb)
+
+	projectStatsHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectStats{}
+		},
+	}
+	a.GET("/projects/:project/stats", projectStatsHandler.ReadOneWeb)
+
+	projectTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectTemplate{}
+		},
+	}
+	a.GET("/projects/:projectid/template", projectTemplateHandler.ReadOneWeb)
+	a.PUT("/projects/:projectid/template", projectTemplateHandler.CreateWeb)
+	a.POST("/projects/:projectid/template", projectTemplateHandler.UpdateWeb)
+	a.DELETE("/projects/:projectid/template", projectTemplateHandler.DeleteWeb)
+
+	projectTemplateInstantiationHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectTemplateInstantiation{}
+		},
+	}
+	a.PUT("/projects/:projectid/template/instantiate", projectTemplateInstantiationHandler.CreateWeb)
+
+	projectSaveAsTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectSaveAsTemplate{}
+		},
+	}
+	a.PUT("/projects/:projectid/save-as-template", projectSaveAsTemplateHandler.CreateWeb)
+
+	trashHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TrashItem{}
+		},
+	}
+	a.GET("/trash", trashHandler.ReadAllWeb)
+	a.POST("/trash/:kind/:id/restore", trashHandler.CreateWeb)
+
 	taskHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Task{}
@@ -374,6 +495,13 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/tasks/:projecttask", taskHandler.DeleteWeb)
 	a.POST("/tasks/:projecttask", taskHandler.UpdateWeb)
 
+	taskPositionHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskPosition{}
+		},
+	}
+	a.POST("/tasks/:task/position", taskPositionHandler.UpdateWeb)
+
 	bulkTaskHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.BulkTask{}
@@ -446,6 +574,44 @@ func registerAPIRoutes(a *echo.Group) {
 		a.GET("/tasks/:task/comments/:commentid", taskCommentHandler.ReadOneWeb)
 	}
 
+	taskTimeEntryHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTimeEntry{}
+		},
+	}
+	a.GET("/tasks/:task/time-entries", taskTimeEntryHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/time-entries", taskTimeEntryHandler.CreateWeb)
+	a.GET("/tasks/:task/time-entries/:timeentry", taskTimeEntryHandler.ReadOneWeb)
+	a.POST("/tasks/:task/time-entries/:timeentry", taskTimeEntryHandler.UpdateWeb)
+	a.DELETE("/tasks/:task/time-entries/:timeentry", taskTimeEntryHandler.DeleteWeb)
+
+	taskTimerHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTimer{}
+		},
+	}
+	a.POST("/tasks/:task/timer/start", taskTimerHandler.CreateWeb)
+	a.POST("/tasks/:task/timer/stop", taskTimerHandler.UpdateWeb)
+
+	taskActivityHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskActivity{}
+		},
+	}
+	a.GET("/tasks/:task/activity", taskActivityHandler.ReadAllWeb)
+
+	reactionHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.Reaction{}
+		},
+	}
+	a.GET("/tasks/:task/reactions", reactionHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/reactions", reactionHandler.CreateWeb)
+	a.POST("/tasks/:task/reactions/delete", reactionHandler.DeleteWeb)
+	a.GET("/tasks/:task/comments/:commentid/reactions", reactionHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/comments/:commentid/reactions", reactionHandler.CreateWeb)
+	a.POST("/tasks/:task/comments/:commentid/reactions/delete", reactionHandler.DeleteWeb)
+
 	labelHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Label{}
@@ -525,10 +691,39 @@ func registerAPIRoutes(a *echo.Group) {
 	a.GET("/notifications", notificationHandler.ReadAllWeb)
 	a.POST("/notifications/:notificationid", notificationHandler.UpdateWeb)
 
+	// Webhooks
+	if config.WebhooksEnabled.GetBool() {
+		webhookHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.Webhook{}
+			},
+		}
+		a.GET("/projects/:project/webhooks", webhookHandler.ReadAllWeb)
+		a.PUT("/projects/:project/webhooks", webhookHandler.CreateWeb)
+		a.DELETE("/projects/:project/webhooks/:webhook", webhookHandler.DeleteWeb)
+		a.POST("/projects/:project/webhooks/:webhook", webhookHandler.UpdateWeb)
+		a.GET("/webhooks/events", apiv1.GetAvailableWebhookEvents)
+	}
+
+	// API Tokens
+	apiTokenHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.APIToken{}
+		},
+	}
+	a.GET("/tokens", apiTokenHandler.ReadAllWeb)
+	a.PUT("/tokens", apiTokenHandler.CreateWeb)
+	a.DELETE("/tokens/:token", apiTokenHandler.DeleteWeb)
+	a.GET("/tokens/scopes", apiv1.GetAvailableAPITokenScopes)
+
 	// Migrations
 	m := a.Group("/migration")
 	registerMigrations(m)
 
+	// Instance administration
+	ad := a.Group("/admin")
+	registerAdminRoutes(ad)
+
 	// Project Backgrounds
 	if config.BackgroundsEnabled.GetBool() {
 		a.GET("/projects/:project/background", backgroundHandler.GetProjectBackground)
@@ -555,6 +750,38 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 }
 
+func registerAdminRoutes(ad *echo.Group) {
+	ad.Use(checkUserIsInstanceAdmin)
+
+	adminUserHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.AdminUser{}
+		},
+	}
+	ad.GET("/users", adminUserHandler.ReadAllWeb)
+	ad.PUT("/users", adminUserHandler.CreateWeb)
+	ad.GET("/users/:user", adminUserHandler.ReadOneWeb)
+	ad.POST("/users/:user", adminUserHandler.UpdateWeb)
+	ad.DELETE("/users/:user", adminUserHandler.DeleteWeb)
+	ad.POST("/users/:user/password", apiv1.AdminResetUserPassword)
+
+	adminProjectHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.AdminProject{}
+		},
+	}
+	ad.GET("/projects", adminProjectHandler.ReadAllWeb)
+
+	adminTeamHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.AdminTeam{}
+		},
+	}
+	ad.GET("/teams", adminTeamHandler.ReadAllWeb)
+
+	ad.GET("/stats", apiv1.GetInstanceStats)
+}
+
 func registerMigrations(m *echo.Group) {
 	// Todoist
 	if config.MigrationTodoistEnable.GetBool() {
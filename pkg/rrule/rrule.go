@@ -0,0 +1,591 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package rrule implements parsing and evaluation of iCalendar recurrence rules as defined in RFC 5545.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ part of a recurrence rule
+type Frequency int
+
+// All frequencies defined by RFC 5545
+const (
+	FrequencySecondly Frequency = iota
+	FrequencyMinutely
+	FrequencyHourly
+	FrequencyDaily
+	FrequencyWeekly
+	FrequencyMonthly
+	FrequencyYearly
+)
+
+var frequencyNames = map[string]Frequency{
+	"SECONDLY": FrequencySecondly,
+	"MINUTELY": FrequencyMinutely,
+	"HOURLY":   FrequencyHourly,
+	"DAILY":    FrequencyDaily,
+	"WEEKLY":   FrequencyWeekly,
+	"MONTHLY":  FrequencyMonthly,
+	"YEARLY":   FrequencyYearly,
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// The maximum amount of periods without an occurrence looked at when searching for the next occurrence.
+// This prevents endless loops for rules which can never match, like FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30.
+// For daily and longer frequencies it covers 400 years, after which the gregorian calendar repeats itself.
+var maxIterations = map[Frequency]int{
+	FrequencySecondly: 100000,
+	FrequencyMinutely: 100000,
+	FrequencyHourly:   100000,
+	FrequencyDaily:    146097,
+	FrequencyWeekly:   20871,
+	FrequencyMonthly:  4800,
+	FrequencyYearly:   400,
+}
+
+const untilFormat = `20060102T150405Z`
+
+// ErrNoOccurrenceFound is returned when no occurrence of a rule could be found within the maximum
+// amount of periods looked at, which usually means the rule can never match.
+var ErrNoOccurrenceFound = errors.New("the rule has no next occurrence")
+
+// WeekdayNum is a weekday in a BYDAY rule part, optionally with an ordinal.
+// 2TU is the second tuesday, -1FR the last friday of a month or year.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule is a parsed recurrence rule.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count is the total number of occurrences, including the first one. 0 means unlimited.
+	Count int
+	// Until is the last possible occurrence. The zero time means unlimited.
+	Until time.Time
+
+	ByMonth    []int
+	ByYearDay  []int
+	ByMonthDay []int
+	ByDay      []WeekdayNum
+	ByHour     []int
+	ByMinute   []int
+	BySecond   []int
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+// Parse parses a recurrence rule like FREQ=WEEKLY;INTERVAL=2;BYDAY=TU. The rule may be prefixed with "RRULE:".
+// Date-times in UNTIL without a timezone are interpreted in loc.
+func Parse(rule string, loc *time.Location) (r *Rule, err error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	if rule == "" {
+		return nil, errors.New("the rule is empty")
+	}
+
+	r = &Rule{
+		Interval:  1,
+		WeekStart: time.Monday,
+	}
+	seen := make(map[string]bool)
+	hasFreq := false
+
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return nil, fmt.Errorf("invalid rule part %s", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is set more than once", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			freq, exists := frequencyNames[value]
+			if !exists {
+				return nil, fmt.Errorf("invalid frequency %s", value)
+			}
+			r.Freq = freq
+			hasFreq = true
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return nil, fmt.Errorf("invalid interval %s", value)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return nil, fmt.Errorf("invalid count %s", value)
+			}
+		case "UNTIL":
+			r.Until, err = parseUntil(value, loc)
+			if err != nil {
+				return nil, err
+			}
+		case "BYMONTH":
+			r.ByMonth, err = parseIntList(name, value, 1, 12, false)
+		case "BYYEARDAY":
+			r.ByYearDay, err = parseIntList(name, value, 1, 366, true)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(name, value, 1, 31, true)
+		case "BYHOUR":
+			r.ByHour, err = parseIntList(name, value, 0, 23, false)
+		case "BYMINUTE":
+			r.ByMinute, err = parseIntList(name, value, 0, 59, false)
+		case "BYSECOND":
+			r.BySecond, err = parseIntList(name, value, 0, 59, false)
+		case "BYSETPOS":
+			r.BySetPos, err = parseIntList(name, value, 1, 366, true)
+		case "BYDAY":
+			r.ByDay, err = parseWeekdayList(value)
+		case "WKST":
+			wkst, exists := weekdayNames[value]
+			if !exists {
+				return nil, fmt.Errorf("invalid week start %s", value)
+			}
+			r.WeekStart = wkst
+		default:
+			return nil, fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !hasFreq {
+		return nil, errors.New("FREQ is required")
+	}
+
+	return r, r.validate()
+}
+
+func (r *Rule) validate() error {
+	if r.Count > 0 && !r.Until.IsZero() {
+		return errors.New("COUNT and UNTIL can't be used together")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == FrequencyWeekly {
+		return errors.New("BYMONTHDAY can't be used with a weekly frequency")
+	}
+	if len(r.ByYearDay) > 0 && (r.Freq == FrequencyDaily || r.Freq == FrequencyWeekly || r.Freq == FrequencyMonthly) {
+		return errors.New("BYYEARDAY can only be used with a yearly or sub-daily frequency")
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != FrequencyMonthly && r.Freq != FrequencyYearly {
+			return errors.New("BYDAY can only have an ordinal with a monthly or yearly frequency")
+		}
+	}
+	if len(r.BySetPos) > 0 &&
+		len(r.ByMonth) == 0 && len(r.ByYearDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 &&
+		len(r.ByHour) == 0 && len(r.ByMinute) == 0 && len(r.BySecond) == 0 {
+		return errors.New("BYSETPOS needs another BYxxx rule part")
+	}
+	return nil
+}
+
+func parseUntil(value string, loc *time.Location) (until time.Time, err error) {
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		until, err = time.Parse(untilFormat, value)
+	case len(value) == 8:
+		// A date without a time includes the whole day
+		until, err = time.ParseInLocation(`20060102`, value, loc)
+		until = until.AddDate(0, 0, 1).Add(-time.Second)
+	default:
+		until, err = time.ParseInLocation(`20060102T150405`, value, loc)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid until date %s", value)
+	}
+	return until, nil
+}
+
+func parseIntList(name, value string, min, max int, allowNegative bool) (list []int, err error) {
+	for _, v := range strings.Split(value, ",") {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s in %s", v, name)
+		}
+		abs := i
+		if allowNegative && i < 0 {
+			abs = -i
+		}
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("value %s in %s is out of range", v, name)
+		}
+		list = append(list, i)
+	}
+	return
+}
+
+func parseWeekdayList(value string) (list []WeekdayNum, err error) {
+	for _, v := range strings.Split(value, ",") {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("invalid weekday %s", v)
+		}
+		weekday, exists := weekdayNames[v[len(v)-2:]]
+		if !exists {
+			return nil, fmt.Errorf("invalid weekday %s", v)
+		}
+		wn := WeekdayNum{Weekday: weekday}
+		if len(v) > 2 {
+			wn.N, err = strconv.Atoi(v[:len(v)-2])
+			if err != nil || wn.N == 0 || wn.N < -53 || wn.N > 53 {
+				return nil, fmt.Errorf("invalid weekday %s", v)
+			}
+		}
+		list = append(list, wn)
+	}
+	return
+}
+
+// ReplaceCount returns the rule with its COUNT part set to count, keeping all other parts as they are.
+func ReplaceCount(rule string, count int) string {
+	parts := strings.Split(rule, ";")
+	for i, part := range parts {
+		if strings.HasPrefix(strings.ToUpper(part), "COUNT=") {
+			parts[i] = "COUNT=" + strconv.Itoa(count)
+			return strings.Join(parts, ";")
+		}
+	}
+	return rule + ";COUNT=" + strconv.Itoa(count)
+}
+
+// Next returns the first occurrence of the rule starting at dtstart which is after the given time.
+// The dtstart itself is only returned if it matches the rule. Count is not taken into account here,
+// Until is. If there is no such occurrence before Until, the zero time is returned.
+// Periods before the one containing after are skipped without looking at them, so this is fast even if
+// after is a long time after dtstart.
+func (r *Rule) Next(dtstart, after time.Time) (time.Time, error) {
+	it := &Iterator{
+		rule:    r,
+		dtstart: dtstart,
+		period:  r.periodBefore(dtstart, after),
+	}
+	for {
+		next, err := it.Next()
+		if err != nil || next.IsZero() || next.After(after) {
+			return next, err
+		}
+	}
+}
+
+// Iterator returns all occurrences of a rule one after another.
+type Iterator struct {
+	rule    *Rule
+	dtstart time.Time
+	// The next period to look at
+	period int
+	// Occurrences of the last period which were not returned yet
+	pending []time.Time
+}
+
+// Iterate returns an iterator over all occurrences of the rule starting at dtstart.
+func (r *Rule) Iterate(dtstart time.Time) *Iterator {
+	return &Iterator{
+		rule:    r,
+		dtstart: dtstart,
+	}
+}
+
+// Next returns the next occurrence, continuing after the one it returned last. Count is not taken into
+// account here, Until is. If there are no occurrences left before Until, the zero time is returned.
+func (it *Iterator) Next() (time.Time, error) {
+	for empty := 0; ; empty++ {
+		for len(it.pending) > 0 {
+			c := it.pending[0]
+			it.pending = it.pending[1:]
+			if c.Before(it.dtstart) {
+				continue
+			}
+			if !it.rule.Until.IsZero() && c.After(it.rule.Until) {
+				return time.Time{}, nil
+			}
+			return c, nil
+		}
+
+		if empty*it.rule.Interval >= maxIterations[it.rule.Freq] {
+			return time.Time{}, ErrNoOccurrenceFound
+		}
+
+		it.pending = it.rule.occurrencesInPeriod(it.dtstart, it.period)
+		it.period++
+	}
+}
+
+// periodBefore returns the number of a period which starts before t, as late as possible.
+func (r *Rule) periodBefore(dtstart, t time.Time) int {
+	if !t.After(dtstart) {
+		return 0
+	}
+
+	t = t.In(dtstart.Location())
+	start := r.periodStart(dtstart, 0)
+
+	// Days are counted in UTC so a change to or from daylight saving time does not change their number
+	days := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).
+		Sub(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+
+	var steps int
+	switch r.Freq {
+	case FrequencyYearly:
+		steps = t.Year() - start.Year()
+	case FrequencyMonthly:
+		steps = (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	case FrequencyWeekly:
+		steps = days / 7
+	case FrequencyDaily:
+		steps = days
+	case FrequencyHourly:
+		steps = int(t.Sub(start) / time.Hour)
+	case FrequencyMinutely:
+		steps = int(t.Sub(start) / time.Minute)
+	default:
+		steps = int(t.Sub(start) / time.Second)
+	}
+
+	// One period less to be safe from rounding
+	n := steps/r.Interval - 1
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// periodStart returns the start of the nth period after the one containing dtstart.
+func (r *Rule) periodStart(dtstart time.Time, n int) time.Time {
+	loc := dtstart.Location()
+	y, m, d := dtstart.Date()
+	steps := n * r.Interval
+
+	switch r.Freq {
+	case FrequencyYearly:
+		return time.Date(y+steps, 1, 1, 0, 0, 0, 0, loc)
+	case FrequencyMonthly:
+		return time.Date(y, m+time.Month(steps), 1, 0, 0, 0, 0, loc)
+	case FrequencyWeekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		return time.Date(y, m, d-offset+steps*7, 0, 0, 0, 0, loc)
+	case FrequencyDaily:
+		return time.Date(y, m, d+steps, 0, 0, 0, 0, loc)
+	case FrequencyHourly:
+		return dtstart.Truncate(time.Second).Add(time.Duration(steps) * time.Hour)
+	case FrequencyMinutely:
+		return dtstart.Truncate(time.Second).Add(time.Duration(steps) * time.Minute)
+	default:
+		return dtstart.Truncate(time.Second).Add(time.Duration(steps) * time.Second)
+	}
+}
+
+// occurrencesInPeriod returns all occurrences of the nth period, sorted ascending.
+func (r *Rule) occurrencesInPeriod(dtstart time.Time, n int) (occurrences []time.Time) {
+	start := r.periodStart(dtstart, n)
+	loc := dtstart.Location()
+
+	var days []time.Time
+	switch r.Freq {
+	case FrequencyYearly:
+		for d := start; d.Year() == start.Year(); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	case FrequencyMonthly:
+		for d := start; d.Month() == start.Month(); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	case FrequencyWeekly:
+		for i := 0; i < 7; i++ {
+			days = append(days, start.AddDate(0, 0, i))
+		}
+	default:
+		days = []time.Time{start}
+	}
+
+	hours := timeParts(r.ByHour, dtstart.Hour(), start.Hour(), r.Freq <= FrequencyHourly)
+	minutes := timeParts(r.ByMinute, dtstart.Minute(), start.Minute(), r.Freq <= FrequencyMinutely)
+	seconds := timeParts(r.BySecond, dtstart.Second(), start.Second(), r.Freq == FrequencySecondly)
+
+	for _, day := range days {
+		if !r.dayMatches(day, dtstart) {
+			continue
+		}
+		for _, h := range hours {
+			for _, m := range minutes {
+				for _, s := range seconds {
+					occurrences = append(occurrences, localTime(day, h, m, s, loc))
+				}
+			}
+		}
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].Before(occurrences[j])
+	})
+
+	if len(r.BySetPos) == 0 {
+		return occurrences
+	}
+
+	selected := []time.Time{}
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(occurrences) + pos
+		}
+		if i >= 0 && i < len(occurrences) {
+			selected = append(selected, occurrences[i])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Before(selected[j])
+	})
+	return selected
+}
+
+// localTime returns the time of the day in loc. If that time does not exist because daylight saving time starts,
+// it is interpreted with the utc offset before the gap as RFC 5545 requires, which moves it forward by the length of the gap.
+func localTime(day time.Time, hour, minute, second int, loc *time.Location) time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, loc)
+	if t.Hour() == hour && t.Minute() == minute && t.Second() == second {
+		return t
+	}
+
+	// A day before, the old offset was still in use
+	_, offset := t.Add(-24 * time.Hour).Zone()
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, time.UTC).
+		Add(-time.Duration(offset) * time.Second).
+		In(loc)
+}
+
+// timeParts returns the hours, minutes or seconds of all occurrences in a period.
+// If the frequency is at least as fine as the part, the part is fixed by the period and the BYxxx values
+// only limit it. Otherwise they expand the period, falling back to the value from dtstart.
+func timeParts(by []int, fromDtstart, fromPeriod int, limit bool) []int {
+	if limit {
+		if len(by) > 0 && !containsInt(by, fromPeriod) {
+			return nil
+		}
+		return []int{fromPeriod}
+	}
+	if len(by) > 0 {
+		return by
+	}
+	return []int{fromDtstart}
+}
+
+func (r *Rule) dayMatches(day, dtstart time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(day.Month())) {
+		return false
+	}
+
+	daysInYear := time.Date(day.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	if len(r.ByYearDay) > 0 && !matchesPosition(r.ByYearDay, day.YearDay(), daysInYear) {
+		return false
+	}
+
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if len(r.ByMonthDay) > 0 && !matchesPosition(r.ByMonthDay, day.Day(), daysInMonth) {
+		return false
+	}
+
+	if len(r.ByDay) > 0 {
+		matched := false
+		for _, wd := range r.ByDay {
+			if wd.Weekday != day.Weekday() {
+				continue
+			}
+			if wd.N == 0 {
+				matched = true
+				break
+			}
+			// Ordinals are relative to the month, unless it's a yearly rule without BYMONTH
+			pos, total := day.Day(), daysInMonth
+			if r.Freq == FrequencyYearly && len(r.ByMonth) == 0 {
+				pos, total = day.YearDay(), daysInYear
+			}
+			if wd.N == (pos-1)/7+1 || wd.N == -((total-pos)/7+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	// Rules without any day specific parts repeat on the day of dtstart
+	hasDayParts := len(r.ByYearDay) > 0 || len(r.ByMonthDay) > 0 || len(r.ByDay) > 0
+	switch r.Freq {
+	case FrequencyYearly:
+		if !hasDayParts {
+			if len(r.ByMonth) == 0 && day.Month() != dtstart.Month() {
+				return false
+			}
+			return day.Day() == dtstart.Day()
+		}
+	case FrequencyMonthly:
+		if !hasDayParts {
+			return day.Day() == dtstart.Day()
+		}
+	case FrequencyWeekly:
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == dtstart.Weekday()
+		}
+	}
+
+	return true
+}
+
+// matchesPosition checks if pos (starting at 1) matches one of the values in list, where negative values count
+// backwards from total.
+func matchesPosition(list []int, pos, total int) bool {
+	for _, v := range list {
+		if v == pos || (v < 0 && total+v+1 == pos) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, i int) bool {
+	for _, v := range list {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
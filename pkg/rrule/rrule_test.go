@@ -0,0 +1,527 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rrule
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // The tests use the time zone of the RFC 5545 examples
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+// occurrences returns the first n occurrences of a rule, starting with dtstart if it matches.
+func occurrences(t *testing.T, rule string, dtstart time.Time, n int) (result []time.Time) {
+	r, err := Parse(rule, time.UTC)
+	require.NoError(t, err)
+
+	after := dtstart.Add(-time.Second)
+	for i := 0; i < n; i++ {
+		next, err := r.Next(dtstart, after)
+		require.NoError(t, err)
+		if next.IsZero() {
+			break
+		}
+		result = append(result, next)
+		after = next
+	}
+	return
+}
+
+func TestParse(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		r, err := Parse("RRULE:FREQ=MONTHLY;INTERVAL=2;BYDAY=TU,-1FR;WKST=SU", time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, FrequencyMonthly, r.Freq)
+		assert.Equal(t, 2, r.Interval)
+		assert.Equal(t, []WeekdayNum{{Weekday: time.Tuesday}, {Weekday: time.Friday, N: -1}}, r.ByDay)
+		assert.Equal(t, time.Sunday, r.WeekStart)
+	})
+	t.Run("until", func(t *testing.T) {
+		r, err := Parse("FREQ=DAILY;UNTIL=20230701T100000Z", time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, date(2023, 7, 1, 10, 0), r.Until)
+
+		r, err = Parse("FREQ=DAILY;UNTIL=20230701", time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2023, 7, 1, 23, 59, 59, 0, time.UTC), r.Until)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, rule := range []string{
+			"",
+			"INTERVAL=2",
+			"FREQ=FORTNIGHTLY",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;COUNT=2;UNTIL=20230701T100000Z",
+			"FREQ=DAILY;BYDAY=2TU",
+			"FREQ=WEEKLY;BYMONTHDAY=1",
+			"FREQ=MONTHLY;BYMONTH=13",
+			"FREQ=MONTHLY;BYSETPOS=1",
+			"FREQ=YEARLY;BYWEEKNO=20",
+			"FREQ=DAILY;FREQ=WEEKLY",
+		} {
+			_, err := Parse(rule, time.UTC)
+			assert.Error(t, err, rule)
+		}
+	})
+}
+
+func TestReplaceCount(t *testing.T) {
+	assert.Equal(t, "FREQ=DAILY;COUNT=4;INTERVAL=2", ReplaceCount("FREQ=DAILY;COUNT=5;INTERVAL=2", 4))
+	assert.Equal(t, "FREQ=DAILY;COUNT=4", ReplaceCount("FREQ=DAILY", 4))
+}
+
+func TestRule_Next(t *testing.T) {
+	t.Run("daily", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 20, 10, 0),
+			date(2023, 6, 23, 10, 0),
+			date(2023, 6, 26, 10, 0),
+		}, occurrences(t, "FREQ=DAILY;INTERVAL=3", date(2023, 6, 20, 10, 0), 3))
+	})
+	t.Run("every second tuesday", func(t *testing.T) {
+		// 2023-06-20 is a tuesday
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 20, 10, 0),
+			date(2023, 7, 4, 10, 0),
+			date(2023, 7, 18, 10, 0),
+		}, occurrences(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", date(2023, 6, 20, 10, 0), 3))
+	})
+	t.Run("weekdays only", func(t *testing.T) {
+		// 2023-06-23 is a friday
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 23, 9, 0),
+			date(2023, 6, 26, 9, 0),
+			date(2023, 6, 27, 9, 0),
+		}, occurrences(t, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", date(2023, 6, 23, 9, 0), 3))
+	})
+	t.Run("last friday of the month", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 30, 12, 0),
+			date(2023, 7, 28, 12, 0),
+			date(2023, 8, 25, 12, 0),
+		}, occurrences(t, "FREQ=MONTHLY;BYDAY=-1FR", date(2023, 6, 20, 12, 0), 3))
+	})
+	t.Run("last workday of the month", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 30, 12, 0),
+			date(2023, 7, 31, 12, 0),
+			date(2023, 8, 31, 12, 0),
+			date(2023, 9, 29, 12, 0),
+		}, occurrences(t, "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", date(2023, 6, 20, 12, 0), 4))
+	})
+	t.Run("monthly skips months without the day", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2023, 1, 31, 8, 0),
+			date(2023, 3, 31, 8, 0),
+			date(2023, 5, 31, 8, 0),
+		}, occurrences(t, "FREQ=MONTHLY", date(2023, 1, 31, 8, 0), 3))
+	})
+	t.Run("yearly on a leap day", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2024, 2, 29, 8, 0),
+			date(2028, 2, 29, 8, 0),
+		}, occurrences(t, "FREQ=YEARLY", date(2024, 2, 29, 8, 0), 2))
+	})
+	t.Run("yearly in multiple months", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 1, 8, 0),
+			date(2023, 12, 1, 8, 0),
+			date(2024, 6, 1, 8, 0),
+		}, occurrences(t, "FREQ=YEARLY;BYMONTH=6,12", date(2023, 6, 1, 8, 0), 3))
+	})
+	t.Run("hourly limited to working hours", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 20, 16, 30),
+			date(2023, 6, 21, 9, 30),
+		}, occurrences(t, "FREQ=HOURLY;BYHOUR=9,10,11,12,13,14,15,16", date(2023, 6, 20, 16, 30), 2))
+	})
+	t.Run("daily at multiple times", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 20, 8, 15),
+			date(2023, 6, 20, 18, 15),
+			date(2023, 6, 21, 8, 15),
+		}, occurrences(t, "FREQ=DAILY;BYHOUR=8,18", date(2023, 6, 20, 8, 15), 3))
+	})
+	t.Run("until", func(t *testing.T) {
+		assert.Equal(t, []time.Time{
+			date(2023, 6, 20, 10, 0),
+			date(2023, 6, 21, 10, 0),
+		}, occurrences(t, "FREQ=DAILY;UNTIL=20230621T100000Z", date(2023, 6, 20, 10, 0), 5))
+	})
+	t.Run("never matching", func(t *testing.T) {
+		r, err := Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", time.UTC)
+		require.NoError(t, err)
+		next, err := r.Next(date(2023, 1, 1, 0, 0), date(2023, 1, 1, 0, 0))
+		assert.ErrorIs(t, err, ErrNoOccurrenceFound)
+		assert.True(t, next.IsZero())
+	})
+	t.Run("minutely long after dtstart", func(t *testing.T) {
+		r, err := Parse("FREQ=MINUTELY;INTERVAL=7", time.UTC)
+		require.NoError(t, err)
+		next, err := r.Next(date(2000, 1, 1, 0, 3), date(2023, 6, 20, 10, 0))
+		require.NoError(t, err)
+		assert.Equal(t, date(2023, 6, 20, 10, 4), next)
+	})
+	t.Run("hourly with by rules long after dtstart", func(t *testing.T) {
+		r, err := Parse("FREQ=HOURLY;BYHOUR=9,17", time.UTC)
+		require.NoError(t, err)
+		next, err := r.Next(date(2000, 1, 1, 9, 30), date(2023, 6, 20, 10, 0))
+		require.NoError(t, err)
+		assert.Equal(t, date(2023, 6, 20, 17, 30), next)
+	})
+	t.Run("monthly long after dtstart", func(t *testing.T) {
+		r, err := Parse("FREQ=MONTHLY;INTERVAL=5", time.UTC)
+		require.NoError(t, err)
+		next, err := r.Next(date(2000, 1, 31, 8, 0), date(2023, 6, 20, 10, 0))
+		require.NoError(t, err)
+		// 2023-10 is 285 months after 2000-01
+		assert.Equal(t, date(2023, 10, 31, 8, 0), next)
+	})
+}
+
+func TestIterator_Next(t *testing.T) {
+	r, err := Parse("FREQ=WEEKLY;BYDAY=MO,FR", time.UTC)
+	require.NoError(t, err)
+
+	// 2023-06-20 is a tuesday
+	it := r.Iterate(date(2023, 6, 20, 10, 0))
+	for _, expected := range []time.Time{
+		date(2023, 6, 23, 10, 0),
+		date(2023, 6, 26, 10, 0),
+		date(2023, 6, 30, 10, 0),
+	} {
+		next, err := it.Next()
+		require.NoError(t, err)
+		assert.Equal(t, expected, next)
+	}
+}
+
+// expand returns all occurrences of a rule like a calendar client would, taking count into account.
+// Rules without count or until are cut off after limit occurrences.
+func expand(t *testing.T, rule string, dtstart time.Time, limit int) (result []time.Time) {
+	r, err := Parse(rule, dtstart.Location())
+	require.NoError(t, err)
+
+	switch {
+	case r.Count > 0:
+		limit = r.Count
+	case limit == 0:
+		limit = 1000
+	}
+
+	it := r.Iterate(dtstart)
+	for len(result) < limit {
+		next, err := it.Next()
+		require.NoError(t, err)
+		if next.IsZero() {
+			break
+		}
+		result = append(result, next)
+	}
+	return
+}
+
+// The examples from https://datatracker.ietf.org/doc/html/rfc5545#section-3.8.5.3
+func TestRFC5545Examples(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, ny)
+	}
+	// days returns the given days of a month at 09:00
+	days := func(year int, month time.Month, days ...int) (result []time.Time) {
+		for _, d := range days {
+			result = append(result, at(year, month, d, 9, 0))
+		}
+		return
+	}
+	join := func(lists ...[]time.Time) (result []time.Time) {
+		for _, l := range lists {
+			result = append(result, l...)
+		}
+		return
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		limit   int
+		want    []time.Time
+	}{
+		{
+			name:    "daily for 10 occurrences",
+			rule:    "FREQ=DAILY;COUNT=10",
+			dtstart: at(1997, 9, 2, 9, 0),
+			want:    days(1997, 9, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11),
+		},
+		{
+			name:    "every 10 days, 5 occurrences",
+			rule:    "FREQ=DAILY;INTERVAL=10;COUNT=5",
+			dtstart: at(1997, 9, 2, 9, 0),
+			want:    join(days(1997, 9, 2, 12, 22), days(1997, 10, 2, 12)),
+		},
+		{
+			name:    "weekly for 10 occurrences, across the end of daylight saving time",
+			rule:    "FREQ=WEEKLY;COUNT=10",
+			dtstart: at(1997, 9, 2, 9, 0),
+			want:    join(days(1997, 9, 2, 9, 16, 23, 30), days(1997, 10, 7, 14, 21, 28), days(1997, 11, 4)),
+		},
+		{
+			name:    "weekly on tuesday and thursday for five weeks",
+			rule:    "FREQ=WEEKLY;UNTIL=19971007T000000Z;WKST=SU;BYDAY=TU,TH",
+			dtstart: at(1997, 9, 2, 9, 0),
+			want:    join(days(1997, 9, 2, 4, 9, 11, 16, 18, 23, 25, 30), days(1997, 10, 2)),
+		},
+		{
+			name:    "every other week on monday, wednesday and friday until december 24, 1997",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;UNTIL=19971224T000000Z;WKST=SU;BYDAY=MO,WE,FR",
+			dtstart: at(1997, 9, 1, 9, 0),
+			want: join(
+				days(1997, 9, 1, 3, 5, 15, 17, 19, 29),
+				days(1997, 10, 1, 3, 13, 15, 17, 27, 29, 31),
+				days(1997, 11, 10, 12, 14, 24, 26, 28),
+				days(1997, 12, 8, 10, 12, 22),
+			),
+		},
+		{
+			name:    "week start changes the weeks of an interval, monday",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			dtstart: at(1997, 8, 5, 9, 0),
+			want:    days(1997, 8, 5, 10, 19, 24),
+		},
+		{
+			name:    "week start changes the weeks of an interval, sunday",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			dtstart: at(1997, 8, 5, 9, 0),
+			want:    days(1997, 8, 5, 17, 19, 31),
+		},
+		{
+			name:    "monthly on the first friday for 10 occurrences",
+			rule:    "FREQ=MONTHLY;COUNT=10;BYDAY=1FR",
+			dtstart: at(1997, 9, 5, 9, 0),
+			want: join(
+				days(1997, 9, 5), days(1997, 10, 3), days(1997, 11, 7), days(1997, 12, 5),
+				days(1998, 1, 2), days(1998, 2, 6), days(1998, 3, 6), days(1998, 4, 3), days(1998, 5, 1), days(1998, 6, 5),
+			),
+		},
+		{
+			name:    "every other month on the first and last sunday for 10 occurrences",
+			rule:    "FREQ=MONTHLY;INTERVAL=2;COUNT=10;BYDAY=1SU,-1SU",
+			dtstart: at(1997, 9, 7, 9, 0),
+			want: join(
+				days(1997, 9, 7, 28), days(1997, 11, 2, 30),
+				days(1998, 1, 4, 25), days(1998, 3, 1, 29), days(1998, 5, 3, 31),
+			),
+		},
+		{
+			name:    "monthly on the second-to-last monday for 6 months",
+			rule:    "FREQ=MONTHLY;COUNT=6;BYDAY=-2MO",
+			dtstart: at(1997, 9, 22, 9, 0),
+			want: join(
+				days(1997, 9, 22), days(1997, 10, 20), days(1997, 11, 17), days(1997, 12, 22),
+				days(1998, 1, 19), days(1998, 2, 16),
+			),
+		},
+		{
+			name:    "monthly on the third-to-the-last day of the month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-3",
+			dtstart: at(1997, 9, 28, 9, 0),
+			limit:   6,
+			want: join(
+				days(1997, 9, 28), days(1997, 10, 29), days(1997, 11, 28), days(1997, 12, 29),
+				days(1998, 1, 29), days(1998, 2, 26),
+			),
+		},
+		{
+			name:    "monthly on the 15th and 30th, skipping february 30",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=15,30;COUNT=5",
+			dtstart: at(2007, 1, 15, 9, 0),
+			want:    join(days(2007, 1, 15, 30), days(2007, 2, 15), days(2007, 3, 15, 30)),
+		},
+		{
+			name:    "yearly in june and july for 10 occurrences",
+			rule:    "FREQ=YEARLY;COUNT=10;BYMONTH=6,7",
+			dtstart: at(1997, 6, 10, 9, 0),
+			want: join(
+				days(1997, 6, 10), days(1997, 7, 10), days(1998, 6, 10), days(1998, 7, 10), days(1999, 6, 10),
+				days(1999, 7, 10), days(2000, 6, 10), days(2000, 7, 10), days(2001, 6, 10), days(2001, 7, 10),
+			),
+		},
+		{
+			name:    "every day in january, for 3 years",
+			rule:    "FREQ=YEARLY;UNTIL=20000131T140000Z;BYMONTH=1;BYDAY=SU,MO,TU,WE,TH,FR,SA",
+			dtstart: at(1998, 1, 1, 9, 0),
+			limit:   100,
+			want: join(
+				days(1998, 1, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31),
+				days(1999, 1, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31),
+				days(2000, 1, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31),
+			),
+		},
+		{
+			name:    "every 20th monday of the year",
+			rule:    "FREQ=YEARLY;BYDAY=20MO",
+			dtstart: at(1997, 5, 19, 9, 0),
+			limit:   3,
+			want:    join(days(1997, 5, 19), days(1998, 5, 18), days(1999, 5, 17)),
+		},
+		{
+			name:    "every thursday in march",
+			rule:    "FREQ=YEARLY;BYMONTH=3;BYDAY=TH",
+			dtstart: at(1997, 3, 13, 9, 0),
+			limit:   11,
+			want:    join(days(1997, 3, 13, 20, 27), days(1998, 3, 5, 12, 19, 26), days(1999, 3, 4, 11, 18, 25)),
+		},
+		{
+			name:    "every friday the 13th",
+			rule:    "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			dtstart: at(1997, 9, 2, 9, 0),
+			limit:   5,
+			want:    join(days(1998, 2, 13), days(1998, 3, 13), days(1998, 11, 13), days(1999, 8, 13), days(2000, 10, 13)),
+		},
+		{
+			name:    "the first saturday that follows the first sunday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=SA;BYMONTHDAY=7,8,9,10,11,12,13",
+			dtstart: at(1997, 9, 13, 9, 0),
+			limit:   10,
+			want: join(
+				days(1997, 9, 13), days(1997, 10, 11), days(1997, 11, 8), days(1997, 12, 13), days(1998, 1, 10),
+				days(1998, 2, 7), days(1998, 3, 7), days(1998, 4, 11), days(1998, 5, 9), days(1998, 6, 13),
+			),
+		},
+		{
+			name:    "every 4 years, the first tuesday after a monday in november",
+			rule:    "FREQ=YEARLY;INTERVAL=4;BYMONTH=11;BYDAY=TU;BYMONTHDAY=2,3,4,5,6,7,8",
+			dtstart: at(1996, 11, 5, 9, 0),
+			limit:   3,
+			want:    join(days(1996, 11, 5), days(2000, 11, 7), days(2004, 11, 2)),
+		},
+		{
+			name:    "the third instance of tuesday, wednesday or thursday for the next 3 months",
+			rule:    "FREQ=MONTHLY;COUNT=3;BYDAY=TU,WE,TH;BYSETPOS=3",
+			dtstart: at(1997, 9, 4, 9, 0),
+			want:    join(days(1997, 9, 4), days(1997, 10, 7), days(1997, 11, 6)),
+		},
+		{
+			name:    "the second-to-last weekday of the month",
+			rule:    "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-2",
+			dtstart: at(1997, 9, 29, 9, 0),
+			limit:   7,
+			want: join(
+				days(1997, 9, 29), days(1997, 10, 30), days(1997, 11, 27), days(1997, 12, 30),
+				days(1998, 1, 29), days(1998, 2, 26), days(1998, 3, 30),
+			),
+		},
+		{
+			name:    "every 15 minutes for 6 occurrences",
+			rule:    "FREQ=MINUTELY;INTERVAL=15;COUNT=6",
+			dtstart: at(1997, 9, 2, 9, 0),
+			want: []time.Time{
+				at(1997, 9, 2, 9, 0), at(1997, 9, 2, 9, 15), at(1997, 9, 2, 9, 30),
+				at(1997, 9, 2, 9, 45), at(1997, 9, 2, 10, 0), at(1997, 9, 2, 10, 15),
+			},
+		},
+		{
+			name:    "every hour and a half for 4 occurrences",
+			rule:    "FREQ=MINUTELY;INTERVAL=90;COUNT=4",
+			dtstart: at(1997, 9, 2, 9, 0),
+			want:    []time.Time{at(1997, 9, 2, 9, 0), at(1997, 9, 2, 10, 30), at(1997, 9, 2, 12, 0), at(1997, 9, 2, 13, 30)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expand(t, tt.rule, tt.dtstart, tt.limit)
+			require.Len(t, got, len(tt.want))
+			for i := range tt.want {
+				assert.True(t, tt.want[i].Equal(got[i]), "occurrence %d: want %s, got %s", i, tt.want[i], got[i])
+			}
+		})
+	}
+
+	t.Run("every 20 minutes from 9:00 to 16:40 every day", func(t *testing.T) {
+		got := expand(t, "FREQ=DAILY;BYHOUR=9,10,11,12,13,14,15,16;BYMINUTE=0,20,40", at(1997, 9, 2, 9, 0), 25)
+		require.Len(t, got, 25)
+		for i, occurrence := range got[:24] {
+			assert.Equal(t, at(1997, 9, 2, 9+i/3, i%3*20), occurrence)
+		}
+		assert.Equal(t, at(1997, 9, 3, 9, 0), got[24])
+	})
+	t.Run("daily until december 24, across the end of daylight saving time", func(t *testing.T) {
+		got := expand(t, "FREQ=DAILY;UNTIL=19971224T000000Z", at(1997, 9, 2, 9, 0), 200)
+		require.Len(t, got, 113)
+		assert.Equal(t, at(1997, 9, 2, 9, 0), got[0])
+		assert.Equal(t, at(1997, 12, 23, 9, 0), got[112])
+		// The local time stays the same, even though the utc offset changes on october 26
+		for _, occurrence := range got {
+			assert.Equal(t, 9, occurrence.Hour())
+		}
+		assert.Equal(t, 13, got[0].UTC().Hour())
+		assert.Equal(t, 14, got[112].UTC().Hour())
+	})
+}
+
+func TestRule_Next_DaylightSavingTime(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	t.Run("daily keeps the local time when daylight saving time starts", func(t *testing.T) {
+		// Daylight saving time started on 2023-03-12 in new york
+		got := expand(t, "FREQ=DAILY;COUNT=3", time.Date(2023, 3, 11, 9, 0, 0, 0, ny), 0)
+		assert.Equal(t, []time.Time{
+			time.Date(2023, 3, 11, 9, 0, 0, 0, ny),
+			time.Date(2023, 3, 12, 9, 0, 0, 0, ny),
+			time.Date(2023, 3, 13, 9, 0, 0, 0, ny),
+		}, got)
+		assert.Equal(t, 23*time.Hour, got[1].Sub(got[0]))
+	})
+	t.Run("hourly counts real hours when daylight saving time starts", func(t *testing.T) {
+		got := expand(t, "FREQ=HOURLY;COUNT=4", time.Date(2023, 3, 12, 0, 0, 0, 0, ny), 0)
+		require.Len(t, got, 4)
+		for i, hour := range []int{0, 1, 3, 4} {
+			assert.Equal(t, hour, got[i].Hour())
+		}
+	})
+	t.Run("a local time which does not exist is moved after the gap", func(t *testing.T) {
+		// 02:30 does not exist on 2023-03-12 in new york
+		got := expand(t, "FREQ=DAILY;COUNT=3", time.Date(2023, 3, 11, 2, 30, 0, 0, ny), 0)
+		require.Len(t, got, 3)
+		assert.Equal(t, time.Date(2023, 3, 12, 7, 30, 0, 0, time.UTC), got[1].UTC())
+		assert.Equal(t, 2, got[2].Hour())
+	})
+	t.Run("a local time which exists twice uses the first one", func(t *testing.T) {
+		// 01:30 exists twice on 2023-11-05 in new york
+		got := expand(t, "FREQ=DAILY;COUNT=2", time.Date(2023, 11, 4, 1, 30, 0, 0, ny), 0)
+		require.Len(t, got, 2)
+		assert.Equal(t, time.Date(2023, 11, 5, 5, 30, 0, 0, time.UTC), got[1].UTC())
+	})
+	t.Run("next occurrence long after dtstart across daylight saving time", func(t *testing.T) {
+		r, err := Parse("FREQ=WEEKLY;BYDAY=MO", ny)
+		require.NoError(t, err)
+		next, err := r.Next(time.Date(2023, 1, 2, 9, 0, 0, 0, ny), time.Date(2023, 7, 1, 0, 0, 0, 0, ny))
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2023, 7, 3, 9, 0, 0, 0, ny), next)
+	})
+}
@@ -37,5 +37,6 @@ func GetTables() []interface{} {
 		&User{},
 		&TOTP{},
 		&Token{},
+		&Session{},
 	}
 }
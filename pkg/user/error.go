@@ -506,3 +506,163 @@ func (err *ErrUsernameMustNotContainSpaces) HTTPError() web.HTTPError {
 		Message:  "The username must not contain spaces.",
 	}
 }
+
+// ErrNoLDAPEmailProvided represents a "NoLDAPEmailProvided" kind of error.
+type ErrNoLDAPEmailProvided struct {
+	Username string
+}
+
+// IsErrNoLDAPEmailProvided checks if an error is a ErrNoLDAPEmailProvided.
+func IsErrNoLDAPEmailProvided(err error) bool {
+	_, ok := err.(*ErrNoLDAPEmailProvided)
+	return ok
+}
+
+func (err *ErrNoLDAPEmailProvided) Error() string {
+	return "No email provided by ldap [Username: " + err.Username + "]"
+}
+
+// ErrCodeNoLDAPEmailProvided holds the unique world-error code of this error
+const ErrCodeNoLDAPEmailProvided = 1023
+
+// HTTPError holds the http error description
+func (err *ErrNoLDAPEmailProvided) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeNoLDAPEmailProvided,
+		Message:  "No email address available. Please make sure your account in the LDAP directory has an email address.",
+	}
+}
+
+// ErrInvalidRefreshToken represents a "InvalidRefreshToken" kind of error.
+type ErrInvalidRefreshToken struct{}
+
+// IsErrInvalidRefreshToken checks if an error is a ErrInvalidRefreshToken.
+func IsErrInvalidRefreshToken(err error) bool {
+	_, ok := err.(*ErrInvalidRefreshToken)
+	return ok
+}
+
+func (err *ErrInvalidRefreshToken) Error() string {
+	return "Invalid refresh token"
+}
+
+// ErrCodeInvalidRefreshToken holds the unique world-error code of this error
+const ErrCodeInvalidRefreshToken = 1024
+
+// HTTPError holds the http error description
+func (err *ErrInvalidRefreshToken) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusUnauthorized,
+		Code:     ErrCodeInvalidRefreshToken,
+		Message:  "The refresh token is invalid or expired. Please log in again.",
+	}
+}
+
+// ErrSessionDoesNotExist represents a "SessionDoesNotExist" kind of error.
+type ErrSessionDoesNotExist struct {
+	SessionID string
+}
+
+// IsErrSessionDoesNotExist checks if an error is a ErrSessionDoesNotExist.
+func IsErrSessionDoesNotExist(err error) bool {
+	_, ok := err.(*ErrSessionDoesNotExist)
+	return ok
+}
+
+func (err *ErrSessionDoesNotExist) Error() string {
+	return "Session does not exist [SessionID: " + err.SessionID + "]"
+}
+
+// ErrCodeSessionDoesNotExist holds the unique world-error code of this error
+const ErrCodeSessionDoesNotExist = 1025
+
+// HTTPError holds the http error description
+func (err *ErrSessionDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeSessionDoesNotExist,
+		Message:  "This session does not exist or has expired.",
+	}
+}
+
+// ErrUserIsNotAdmin represents a "UserIsNotAdmin" kind of error.
+type ErrUserIsNotAdmin struct {
+	UserID int64
+}
+
+// IsErrUserIsNotAdmin checks if an error is a ErrUserIsNotAdmin.
+func IsErrUserIsNotAdmin(err error) bool {
+	_, ok := err.(*ErrUserIsNotAdmin)
+	return ok
+}
+
+func (err *ErrUserIsNotAdmin) Error() string {
+	return fmt.Sprintf("User is not an instance admin [UserID: %d]", err.UserID)
+}
+
+// ErrCodeUserIsNotAdmin holds the unique world-error code of this error
+const ErrCodeUserIsNotAdmin = 1026
+
+// HTTPError holds the http error description
+func (err *ErrUserIsNotAdmin) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeUserIsNotAdmin,
+		Message:  "Only instance admins are allowed to do this.",
+	}
+}
+
+// ErrAdminCannotChangeOwnAccount represents a "AdminCannotChangeOwnAccount" kind of error.
+type ErrAdminCannotChangeOwnAccount struct {
+	UserID int64
+}
+
+// IsErrAdminCannotChangeOwnAccount checks if an error is a ErrAdminCannotChangeOwnAccount.
+func IsErrAdminCannotChangeOwnAccount(err error) bool {
+	_, ok := err.(*ErrAdminCannotChangeOwnAccount)
+	return ok
+}
+
+func (err *ErrAdminCannotChangeOwnAccount) Error() string {
+	return fmt.Sprintf("Admin cannot disable, demote or delete their own account [UserID: %d]", err.UserID)
+}
+
+// ErrCodeAdminCannotChangeOwnAccount holds the unique world-error code of this error
+const ErrCodeAdminCannotChangeOwnAccount = 1027
+
+// HTTPError holds the http error description
+func (err *ErrAdminCannotChangeOwnAccount) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeAdminCannotChangeOwnAccount,
+		Message:  "You cannot disable, demote or delete your own account through the admin api.",
+	}
+}
+
+// ErrRefreshTokenReused represents a "RefreshTokenReused" kind of error.
+type ErrRefreshTokenReused struct {
+	SessionID string
+}
+
+// IsErrRefreshTokenReused checks if an error is a ErrRefreshTokenReused.
+func IsErrRefreshTokenReused(err error) bool {
+	_, ok := err.(*ErrRefreshTokenReused)
+	return ok
+}
+
+func (err *ErrRefreshTokenReused) Error() string {
+	return "Refresh token was already used [SessionID: " + err.SessionID + "]"
+}
+
+// ErrCodeRefreshTokenReused holds the unique world-error code of this error
+const ErrCodeRefreshTokenReused = 1028
+
+// HTTPError holds the http error description
+func (err *ErrRefreshTokenReused) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusUnauthorized,
+		Code:     ErrCodeRefreshTokenReused,
+		Message:  "This refresh token was already used. The session was revoked, please log in again.",
+	}
+}
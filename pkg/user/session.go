@@ -0,0 +1,269 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/utils"
+
+	"github.com/google/uuid"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+const refreshTokenSize = 64
+
+// Session is a login of a user on one device. Every session has a refresh token which can be used to get new access tokens.
+type Session struct {
+	// The unique id of this session.
+	ID string `xorm:"varchar(36) not null unique pk" json:"id" param:"session"`
+	// The user agent of the device which created this session.
+	DeviceInfo string `xorm:"text null" json:"device_info"`
+	// The ip address this session was last used from.
+	IPAddress string `xorm:"varchar(50) null" json:"ip_address"`
+	// Whether this is the session the request was made with.
+	IsCurrent bool `xorm:"-" json:"is_current"`
+	// When this session was last used to get a new access token.
+	LastActive time.Time `xorm:"not null" json:"last_active"`
+	// A timestamp when this session was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	UserID           int64  `xorm:"bigint not null INDEX" json:"-"`
+	RefreshTokenHash string `xorm:"varchar(64) not null unique" json:"-"`
+	IsLongSession    bool   `xorm:"bool not null default false" json:"-"`
+	// The hash of the refresh token this session had before the last rotation, used to detect reused tokens.
+	PreviousRefreshTokenHash string `xorm:"varchar(64) null INDEX" json:"-"`
+}
+
+// TableName returns the real table name for sessions
+func (*Session) TableName() string {
+	return "sessions"
+}
+
+func hashRefreshToken(refreshToken string) string {
+	h := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(h[:])
+}
+
+// getTTL returns how long a session can be unused before it expires.
+func (session *Session) getTTL() time.Duration {
+	if session.IsLongSession {
+		return time.Duration(config.ServiceJWTTTLLong.GetInt64()) * time.Second
+	}
+	return time.Duration(config.ServiceJWTTTL.GetInt64()) * time.Second
+}
+
+func (session *Session) isExpired() bool {
+	return time.Since(session.LastActive) > session.getTTL()
+}
+
+// CreateSession creates a new session for a user and returns it with its refresh token.
+// The refresh token is only stored hashed and can't be retrieved again.
+func CreateSession(s *xorm.Session, userID int64, deviceInfo, ipAddress string, long bool) (session *Session, refreshToken string, err error) {
+	refreshToken = utils.MakeRandomString(refreshTokenSize)
+	session = &Session{
+		ID:               uuid.New().String(),
+		UserID:           userID,
+		DeviceInfo:       deviceInfo,
+		IPAddress:        ipAddress,
+		LastActive:       time.Now(),
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		IsLongSession:    long,
+	}
+
+	_, err = s.Insert(session)
+	return
+}
+
+// GetActiveSession returns a session if it exists and did not expire yet.
+func GetActiveSession(s *xorm.Session, userID int64, sessionID string) (session *Session, err error) {
+	session = &Session{}
+	exists, err := s.
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		Get(session)
+	if err != nil {
+		return nil, err
+	}
+	if !exists || session.isExpired() {
+		return nil, &ErrSessionDoesNotExist{SessionID: sessionID}
+	}
+
+	return session, nil
+}
+
+// TouchSession marks a session as used from an ip address.
+func TouchSession(s *xorm.Session, session *Session, ipAddress string) (err error) {
+	session.LastActive = time.Now()
+	session.IPAddress = ipAddress
+	_, err = s.
+		ID(session.ID).
+		Cols("last_active", "ip_address").
+		Update(session)
+	return
+}
+
+// RotateRefreshToken checks a refresh token and replaces it with a new one.
+// Every refresh token can only be used once. If a token which was already rotated is used again,
+// it was most likely stolen and the session is revoked.
+func RotateRefreshToken(s *xorm.Session, refreshToken, ipAddress string) (session *Session, newRefreshToken string, err error) {
+	if refreshToken == "" {
+		return nil, "", &ErrInvalidRefreshToken{}
+	}
+
+	oldHash := hashRefreshToken(refreshToken)
+	session = &Session{}
+	exists, err := s.
+		Where("refresh_token_hash = ?", oldHash).
+		Get(session)
+	if err != nil {
+		return nil, "", err
+	}
+	if !exists {
+		return nil, "", revokeSessionOfReusedRefreshToken(s, oldHash)
+	}
+	if session.isExpired() {
+		return nil, "", &ErrInvalidRefreshToken{}
+	}
+
+	newRefreshToken = utils.MakeRandomString(refreshTokenSize)
+	session.PreviousRefreshTokenHash = oldHash
+	session.RefreshTokenHash = hashRefreshToken(newRefreshToken)
+	session.LastActive = time.Now()
+	session.IPAddress = ipAddress
+	updated, err := s.
+		Where("id = ? AND refresh_token_hash = ?", session.ID, oldHash).
+		Cols("refresh_token_hash", "previous_refresh_token_hash", "last_active", "ip_address").
+		Update(session)
+	if err != nil {
+		return nil, "", err
+	}
+	if updated == 0 {
+		// Someone else rotated the same token at the same time
+		_, err = s.Where("id = ?", session.ID).Delete(&Session{})
+		if err != nil {
+			return nil, "", err
+		}
+		return nil, "", &ErrRefreshTokenReused{SessionID: session.ID}
+	}
+
+	return session, newRefreshToken, nil
+}
+
+// revokeSessionOfReusedRefreshToken deletes the session which had a refresh token before its last rotation.
+// It returns ErrRefreshTokenReused if there was such a session and ErrInvalidRefreshToken otherwise.
+func revokeSessionOfReusedRefreshToken(s *xorm.Session, hash string) error {
+	session := &Session{}
+	exists, err := s.
+		Where("previous_refresh_token_hash = ?", hash).
+		Get(session)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &ErrInvalidRefreshToken{}
+	}
+
+	_, err = s.Where("id = ?", session.ID).Delete(&Session{})
+	if err != nil {
+		return err
+	}
+
+	return &ErrRefreshTokenReused{SessionID: session.ID}
+}
+
+// GetSessionsForUser returns all active sessions of a user, the most recently used first.
+func GetSessionsForUser(s *xorm.Session, userID int64, currentSessionID string) (sessions []*Session, err error) {
+	all := []*Session{}
+	err = s.
+		Where("user_id = ?", userID).
+		OrderBy("last_active DESC").
+		Find(&all)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions = make([]*Session, 0, len(all))
+	for _, session := range all {
+		if session.isExpired() {
+			continue
+		}
+		session.IsCurrent = session.ID == currentSessionID
+		sessions = append(sessions, session)
+	}
+
+	return
+}
+
+// DeleteSession revokes one session of a user.
+func DeleteSession(s *xorm.Session, userID int64, sessionID string) (err error) {
+	deleted, err := s.
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		Delete(&Session{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return &ErrSessionDoesNotExist{SessionID: sessionID}
+	}
+	return nil
+}
+
+// DeleteAllSessionsForUser revokes all sessions of a user.
+func DeleteAllSessionsForUser(s *xorm.Session, userID int64) (err error) {
+	_, err = s.
+		Where("user_id = ?", userID).
+		Delete(&Session{})
+	return
+}
+
+// RegisterSessionCleanupCron registers a cron function to remove all expired sessions
+func RegisterSessionCleanupCron() {
+	const logPrefix = "[Session Cleanup Cron] "
+
+	err := cron.Schedule("0 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		now := time.Now()
+		deleted, err := s.
+			Where(builder.Or(
+				builder.And(
+					builder.Eq{"is_long_session": false},
+					builder.Lt{"last_active": now.Add(-time.Duration(config.ServiceJWTTTL.GetInt64()) * time.Second)},
+				),
+				builder.Lt{"last_active": now.Add(-time.Duration(config.ServiceJWTTTLLong.GetInt64()) * time.Second)},
+			)).
+			Delete(&Session{})
+		if err != nil {
+			log.Errorf(logPrefix+"Error removing expired sessions: %s", err)
+			return
+		}
+		if deleted > 0 {
+			log.Debugf(logPrefix+"Deleted %d expired sessions", deleted)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register session cleanup cron: %s", err)
+	}
+}
@@ -0,0 +1,189 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package user
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSession(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	session, refreshToken, err := CreateSession(s, 1, "Firefox", "127.0.0.1", false)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, session.ID)
+	assert.Len(t, refreshToken, refreshTokenSize)
+	assert.NotEqual(t, refreshToken, session.RefreshTokenHash)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertExists(t, "sessions", map[string]interface{}{
+		"id":                 session.ID,
+		"user_id":            1,
+		"device_info":        "Firefox",
+		"refresh_token_hash": hashRefreshToken(refreshToken),
+	}, false)
+}
+
+func TestGetActiveSession(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		session, err := GetActiveSession(s, 1, "a1b2c3d4-0000-4000-8000-000000000001")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), session.UserID)
+	})
+	t.Run("expired", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetActiveSession(s, 1, "a1b2c3d4-0000-4000-8000-000000000002")
+		assert.Error(t, err)
+		assert.True(t, IsErrSessionDoesNotExist(err))
+	})
+	t.Run("other user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetActiveSession(s, 1, "a1b2c3d4-0000-4000-8000-000000000003")
+		assert.Error(t, err)
+		assert.True(t, IsErrSessionDoesNotExist(err))
+	})
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		session, newToken, err := RotateRefreshToken(s, "sessionrefreshtoken1", "127.0.0.5")
+		assert.NoError(t, err)
+		assert.Equal(t, "a1b2c3d4-0000-4000-8000-000000000001", session.ID)
+		assert.NotEqual(t, "sessionrefreshtoken1", newToken)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "sessions", map[string]interface{}{
+			"id":                 session.ID,
+			"ip_address":         "127.0.0.5",
+			"refresh_token_hash": hashRefreshToken(newToken),
+		}, false)
+	})
+	t.Run("used twice", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, newToken, err := RotateRefreshToken(s, "sessionrefreshtoken1", "127.0.0.1")
+		assert.NoError(t, err)
+		_, _, err = RotateRefreshToken(s, "sessionrefreshtoken1", "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrRefreshTokenReused(err))
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		// Reusing a rotated token revokes the whole session, including the new token
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"id": "a1b2c3d4-0000-4000-8000-000000000001",
+		})
+		_, _, err = RotateRefreshToken(s, newToken, "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRefreshToken(err))
+	})
+	t.Run("expired session", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, _, err := RotateRefreshToken(s, "sessionrefreshtoken2", "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRefreshToken(err))
+	})
+	t.Run("empty token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, _, err := RotateRefreshToken(s, "", "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRefreshToken(err))
+	})
+}
+
+func TestGetSessionsForUser(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	sessions, err := GetSessionsForUser(s, 1, "a1b2c3d4-0000-4000-8000-000000000001")
+	assert.NoError(t, err)
+	// The expired session is not returned
+	assert.Len(t, sessions, 1)
+	assert.True(t, sessions[0].IsCurrent)
+}
+
+func TestDeleteSession(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := DeleteSession(s, 1, "a1b2c3d4-0000-4000-8000-000000000001")
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"id": "a1b2c3d4-0000-4000-8000-000000000001",
+		})
+	})
+	t.Run("session of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := DeleteSession(s, 1, "a1b2c3d4-0000-4000-8000-000000000003")
+		assert.Error(t, err)
+		assert.True(t, IsErrSessionDoesNotExist(err))
+	})
+}
+
+func TestStatusChangeRevokesSessions(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &User{ID: 2}
+	err := u.SetStatus(s, StatusDisabled)
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "sessions", map[string]interface{}{
+		"user_id": 2,
+	})
+}
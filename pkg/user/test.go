@@ -35,7 +35,7 @@ func InitTests() {
 		log.Fatal(err)
 	}
 
-	err = db.InitTestFixtures("users", "user_tokens")
+	err = db.InitTestFixtures("users", "user_tokens", "sessions")
 	if err != nil {
 		log.Fatal(err)
 	}
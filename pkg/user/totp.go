@@ -103,7 +103,7 @@ func EnrollTOTP(s *xorm.Session, user *User) (t *TOTP, err error) {
 	return
 }
 
-// EnableTOTP enables totp for a user. The provided passcode is used to verify the user has a working totp setup.
+// EnableTOTP enables totp for a user and revokes all of their sessions. The provided passcode is used to verify the user has a working totp setup.
 func EnableTOTP(s *xorm.Session, passcode *TOTPPasscode) (err error) {
 	t, err := ValidateTOTPPasscode(s, passcode)
 	if err != nil {
@@ -114,15 +114,23 @@ func EnableTOTP(s *xorm.Session, passcode *TOTPPasscode) (err error) {
 		Where("id = ?", t.ID).
 		Cols("enabled").
 		Update(&TOTP{Enabled: true})
-	return
+	if err != nil {
+		return err
+	}
+
+	return DeleteAllSessionsForUser(s, passcode.User.ID)
 }
 
-// DisableTOTP removes all totp settings for a user.
+// DisableTOTP removes all totp settings for a user and revokes all of their sessions.
 func DisableTOTP(s *xorm.Session, user *User) (err error) {
 	_, err = s.
 		Where("user_id = ?", user.ID).
 		Delete(&TOTP{})
-	return
+	if err != nil {
+		return err
+	}
+
+	return DeleteAllSessionsForUser(s, user.ID)
 }
 
 // ValidateTOTPPasscode validated totp codes of users.
@@ -82,6 +82,8 @@ type User struct {
 	Email string `xorm:"varchar(250) null" json:"email,omitempty" valid:"email,length(0|250)" maxLength:"250"`
 
 	Status Status `xorm:"default 0" json:"-"`
+	// Whether this user is an instance admin. Use IsInstanceAdmin to check, it also considers the admins from the config.
+	IsAdmin bool `xorm:"bool not null default false" json:"-"`
 
 	AvatarProvider string `xorm:"varchar(255) null" json:"-"`
 	AvatarFileID   int64  `xorm:"null" json:"-"`
@@ -331,7 +333,7 @@ func CheckUserCredentials(s *xorm.Session, u *Login) (*User, error) {
 	err = CheckUserPassword(user, u.Password)
 	if err != nil {
 		if IsErrWrongUsernameOrPassword(err) {
-			handleFailedPassword(user)
+			HandleFailedPassword(user)
 		}
 		return user, err
 	}
@@ -339,7 +341,8 @@ func CheckUserCredentials(s *xorm.Session, u *Login) (*User, error) {
 	return user, nil
 }
 
-func handleFailedPassword(user *User) {
+// HandleFailedPassword counts a failed login with a wrong password and notifies the user after three of them.
+func HandleFailedPassword(user *User) {
 	key := user.GetFailedPasswordAttemptsKey()
 	err := keyvalue.IncrBy(key, 1)
 	if err != nil {
@@ -395,8 +398,12 @@ func GetCurrentUserFromDB(s *xorm.Session, c echo.Context) (user *User, err erro
 	return GetUserByID(s, u.ID)
 }
 
-// GetCurrentUser returns the current user based on its jwt token
+// GetCurrentUser returns the current user based on its jwt token or api token
 func GetCurrentUser(c echo.Context) (user *User, err error) {
+	if u, is := c.Get("api_user").(*User); is {
+		return u, nil
+	}
+
 	jwtinf := c.Get("user").(*jwt.Token)
 	claims := jwtinf.Claims.(jwt.MapClaims)
 	return GetUserFromClaims(claims)
@@ -544,15 +551,46 @@ func UpdateUserPassword(s *xorm.Session, user *User, newPassword string) (err er
 		return err
 	}
 
-	return err
+	// Everyone who knew the old password has to log in again
+	return DeleteAllSessionsForUser(s, user.ID)
 }
 
-// SetStatus sets a users status in the database
+// SetStatus sets a users status in the database and revokes all of their sessions
 func (u *User) SetStatus(s *xorm.Session, status Status) (err error) {
 	u.Status = status
 	_, err = s.
 		Where("id = ?", u.ID).
 		Cols("status").
 		Update(u)
+	if err != nil {
+		return err
+	}
+
+	return DeleteAllSessionsForUser(s, u.ID)
+}
+
+// IsInstanceAdmin checks if a user is allowed to administrate the whole instance,
+// either because they were made admin or because they are listed as admin in the config.
+func (u *User) IsInstanceAdmin() bool {
+	if u.IsAdmin {
+		return true
+	}
+
+	for _, username := range config.ServiceAdmins.GetStringSlice() {
+		if username == u.Username {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetIsAdmin grants or revokes the instance admin flag of a user
+func (u *User) SetIsAdmin(s *xorm.Session, isAdmin bool) (err error) {
+	u.IsAdmin = isAdmin
+	_, err = s.
+		Where("id = ?", u.ID).
+		Cols("is_admin").
+		Update(u)
 	return
 }
@@ -28,6 +28,9 @@ import (
 
 const IssuerLocal = `local`
 
+// IssuerLDAP is the issuer of all users who authenticated through LDAP
+const IssuerLDAP = `ldap`
+
 // CreateUser creates a new user and inserts it into the database
 func CreateUser(s *xorm.Session, user *User) (newUser *User, err error) {
 
@@ -346,6 +346,9 @@ func TestUpdateUserPassword(t *testing.T) {
 			ID: 1,
 		}, "12345")
 		assert.NoError(t, err)
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"user_id": 1,
+		})
 	})
 	t.Run("nonexistant user", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)